@@ -2,6 +2,7 @@ package azure
 
 import (
 	"errors"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 )
@@ -17,3 +18,11 @@ func IsParentResourceNotFound(err error) bool {
 
 	return errors.As(err, &rerr) && rerr.ErrorCode == ParentResourceNotFoundErrorCode
 }
+
+// IsNotFound parses the error to check if the requested resource, or the
+// resource group it is looked up in, doesn't exist.
+func IsNotFound(err error) bool {
+	rerr := &azcore.ResponseError{}
+
+	return errors.As(err, &rerr) && rerr.StatusCode == http.StatusNotFound
+}
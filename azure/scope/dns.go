@@ -3,9 +3,14 @@ package scope
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
 
 	"github.com/giantswarm/microerror"
 
@@ -17,8 +22,282 @@ const (
 	clientSecretKeyName = "clientSecret"
 
 	AnnotationWildcardCNAMETarget = "network.giantswarm.io/wildcard-cname-target"
+
+	// AnnotationIngressServiceNamespaces and AnnotationIngressServiceSelectors
+	// are the annotations on the Cluster object that override the operator-wide
+	// ingress service discovery settings for a single cluster. Namespaces are
+	// separated by commas, label selectors by semicolons as selectors may
+	// contain commas themselves.
+	AnnotationIngressServiceNamespaces = "dns-operator-azure.giantswarm.io/ingress-service-namespaces"
+	AnnotationIngressServiceSelectors  = "dns-operator-azure.giantswarm.io/ingress-service-selectors"
+
+	// AnnotationAPIServerHostnameMode is the annotation on the Cluster object
+	// that overrides the operator-wide APIServerHostnameMode for a single
+	// cluster.
+	AnnotationAPIServerHostnameMode = "dns-operator-azure.giantswarm.io/api-server-hostname-mode"
+
+	// APIServerHostnameModeCNAME publishes the api and apiserver records of
+	// clusters with a hostname control plane endpoint as CNAME records pointing
+	// to that hostname. APIServerHostnameModeResolve resolves the hostname and
+	// publishes its IPv4 addresses as A records instead.
+	APIServerHostnameModeCNAME   = "cname"
+	APIServerHostnameModeResolve = "resolve"
+
+	// AnnotationAPIServerRecordMode is the annotation on the Cluster object
+	// that overrides the operator-wide APIServerRecordMode for a single
+	// cluster.
+	AnnotationAPIServerRecordMode = "dns-operator-azure.giantswarm.io/api-server-record-mode"
+
+	// APIServerRecordModeAddress publishes the addresses of the API server
+	// public IPs of CAPZ clusters in the api and apiserver A records.
+	// APIServerRecordModeAlias publishes them as alias record sets pointing at
+	// the public IP resource instead, which Azure keeps up to date.
+	APIServerRecordModeAddress = "address"
+	APIServerRecordModeAlias   = "alias"
+
+	// AnnotationAdoptionPolicy is the annotation on the Cluster object that
+	// overrides the operator-wide AdoptionPolicy for a single cluster.
+	AnnotationAdoptionPolicy = "dns-operator-azure.giantswarm.io/adoption-policy"
+
+	// AdoptionPolicyMatching only adopts existing records in the cluster zone
+	// that already point to the desired target and leaves conflicting records
+	// alone. AdoptionPolicyTakeover also overwrites conflicting records.
+	AdoptionPolicyMatching = "matching"
+	AdoptionPolicyTakeover = "takeover"
+
+	// AnnotationPrivateRecordsMode is the annotation on the Cluster object
+	// that overrides the operator-wide PrivateRecordsMode for a single
+	// cluster.
+	AnnotationPrivateRecordsMode = "dns-operator-azure.giantswarm.io/private-records-mode"
+
+	// PrivateRecordsModePublic publishes all records, including private API
+	// server and load balancer IPs, in the public cluster zone.
+	// PrivateRecordsModeSplitHorizon publishes records with private IPs only in
+	// an Azure private DNS zone of the same name, linked to the split-horizon
+	// virtual networks, and keeps them out of the public cluster zone.
+	PrivateRecordsModePublic       = "public"
+	PrivateRecordsModeSplitHorizon = "split-horizon"
+
+	// AnnotationSplitHorizonVirtualNetworks is the annotation on the Cluster
+	// object listing further virtual network IDs, separated by commas, the
+	// private zone of a split-horizon cluster is linked to.
+	AnnotationSplitHorizonVirtualNetworks = "dns-operator-azure.giantswarm.io/split-horizon-virtual-networks"
+
+	// AnnotationSplitHorizonPublicIP is the annotation on the Cluster object
+	// holding the public IPv4 address the api and apiserver records of a
+	// split-horizon cluster point to in the public zone, e.g. the one of a VPN
+	// gateway or reverse proxy in front of the private API server.
+	AnnotationSplitHorizonPublicIP = "dns-operator-azure.giantswarm.io/split-horizon-public-ip"
+
+	// AnnotationIntermediateZone is the annotation on the Cluster object
+	// holding the label of the intermediate zone the cluster zone is delegated
+	// from, e.g. "acme" for <cluster>.acme.<base domain>. It overrides the
+	// operator-wide IntermediateZoneMode, an empty value places the cluster
+	// zone right below the base zone.
+	AnnotationIntermediateZone = "dns-operator-azure.giantswarm.io/intermediate-zone"
+
+	// AnnotationClusterZone is the annotation on the Cluster object recording
+	// the name of the cluster zone. It is set when the operator first manages
+	// the cluster, so that later changes of the intermediate zone
+	// configuration don't move the zone of existing clusters.
+	AnnotationClusterZone = "dns-operator-azure.giantswarm.io/cluster-zone"
+
+	// IntermediateZoneModeNone delegates cluster zones right from the base
+	// zone. IntermediateZoneModeOrganization delegates them from a zone named
+	// after the organization of the cluster, given by the
+	// giantswarm.io/organization label or the org-<name> namespace, and
+	// IntermediateZoneModeRegion from a zone named after the Azure location of
+	// CAPZ clusters.
+	IntermediateZoneModeNone         = "none"
+	IntermediateZoneModeOrganization = "organization"
+	IntermediateZoneModeRegion       = "region"
+
+	// LabelOrganization is the label on the Cluster object naming the
+	// organization owning it.
+	LabelOrganization = "giantswarm.io/organization"
+
+	DefaultIngressServiceNamespace = "kube-system"
+	DefaultIngressServiceSelector  = "app.kubernetes.io/name in (ingress-nginx,nginx-ingress-controller)"
+
+	// DefaultAPIRecordTTL, DefaultIngressRecordTTL and DefaultNSRecordTTL are
+	// the TTLs in seconds of the records RecordTTLs leaves unset.
+	DefaultAPIRecordTTL     = 300
+	DefaultIngressRecordTTL = 300
+	DefaultNSRecordTTL      = 3600
 )
 
+// RecordTTLs are the TTLs in seconds of the records the operator writes.
+// Unset TTLs default to DefaultAPIRecordTTL, DefaultIngressRecordTTL and
+// DefaultNSRecordTTL.
+type RecordTTLs struct {
+	// API is the TTL of the api and apiserver records.
+	API int64
+	// Ingress is the TTL of the ingress, gateway, service hostname and
+	// wildcard records.
+	Ingress int64
+	// NS is the TTL of the NS delegations of cluster zones and intermediate
+	// zones.
+	NS int64
+}
+
+func (t RecordTTLs) withDefaults() RecordTTLs {
+	if t.API <= 0 {
+		t.API = DefaultAPIRecordTTL
+	}
+	if t.Ingress <= 0 {
+		t.Ingress = DefaultIngressRecordTTL
+	}
+	if t.NS <= 0 {
+		t.NS = DefaultNSRecordTTL
+	}
+	return t
+}
+
+// IngressServiceDiscovery defines where the ingress controller services
+// of a workload cluster are looked up.
+type IngressServiceDiscovery struct {
+	Namespaces []string
+	Selectors  []string
+}
+
+// NewIngressServiceDiscovery builds an IngressServiceDiscovery from a comma
+// separated list of namespaces and a semicolon separated list of selectors.
+func NewIngressServiceDiscovery(namespaces, selectors string) IngressServiceDiscovery {
+	return IngressServiceDiscovery{
+		Namespaces: splitList(namespaces, ","),
+		Selectors:  splitList(selectors, ";"),
+	}
+}
+
+// Zone references an existing DNS zone, reachable with the base zone
+// credentials, that records may be written to.
+type Zone struct {
+	Name          string
+	ResourceGroup string
+}
+
+// ParseZones parses a comma separated list of <zone>=<resource group> pairs.
+func ParseZones(value string) ([]Zone, error) {
+	var zones []Zone
+	for _, item := range splitList(value, ",") {
+		name, resourceGroup, ok := strings.Cut(item, "=")
+		name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+		resourceGroup = strings.TrimSpace(resourceGroup)
+		if !ok || name == "" || resourceGroup == "" {
+			return nil, microerror.Maskf(errors.InvalidConfigError, "zone %q must be given as <zone>=<resource group>", item)
+		}
+		zones = append(zones, Zone{Name: name, ResourceGroup: resourceGroup})
+	}
+	return zones, nil
+}
+
+// ValidateAPIServerHostnameMode returns an InvalidConfigError if mode is not a
+// known APIServerHostnameMode.
+func ValidateAPIServerHostnameMode(mode string) error {
+	switch mode {
+	case APIServerHostnameModeCNAME, APIServerHostnameModeResolve:
+		return nil
+	}
+	return microerror.Maskf(errors.InvalidConfigError, "api server hostname mode must be %q or %q, got %q", APIServerHostnameModeCNAME, APIServerHostnameModeResolve, mode)
+}
+
+// ValidateAPIServerRecordMode returns an InvalidConfigError if mode is not a
+// known APIServerRecordMode.
+func ValidateAPIServerRecordMode(mode string) error {
+	switch mode {
+	case APIServerRecordModeAddress, APIServerRecordModeAlias:
+		return nil
+	}
+	return microerror.Maskf(errors.InvalidConfigError, "api server record mode must be %q or %q, got %q", APIServerRecordModeAddress, APIServerRecordModeAlias, mode)
+}
+
+// ValidatePrivateRecordsMode returns an InvalidConfigError if mode is not a
+// known PrivateRecordsMode.
+func ValidatePrivateRecordsMode(mode string) error {
+	switch mode {
+	case PrivateRecordsModePublic, PrivateRecordsModeSplitHorizon:
+		return nil
+	}
+	return microerror.Maskf(errors.InvalidConfigError, "private records mode must be %q or %q, got %q", PrivateRecordsModePublic, PrivateRecordsModeSplitHorizon, mode)
+}
+
+// ValidateIntermediateZoneMode returns an InvalidConfigError if mode is not a
+// known IntermediateZoneMode.
+func ValidateIntermediateZoneMode(mode string) error {
+	switch mode {
+	case IntermediateZoneModeNone, IntermediateZoneModeOrganization, IntermediateZoneModeRegion:
+		return nil
+	}
+	return microerror.Maskf(errors.InvalidConfigError, "intermediate zone mode must be %q, %q or %q, got %q", IntermediateZoneModeNone, IntermediateZoneModeOrganization, IntermediateZoneModeRegion, mode)
+}
+
+// ClusterZoneName returns the name of the zone of cluster below baseDomain:
+// the zone recorded in the AnnotationClusterZone annotation if it is below
+// baseDomain, otherwise <cluster>.<intermediate label>.<baseDomain> with the
+// intermediate label given by the AnnotationIntermediateZone annotation or
+// intermediateZoneMode. Clusters without a valid intermediate label get
+// <cluster>.<baseDomain>. spec is nil for clusters other than CAPZ clusters.
+func ClusterZoneName(cluster *capi.Cluster, spec *infrav1.AzureClusterSpec, baseDomain, intermediateZoneMode string) string {
+	if zone := cluster.GetAnnotations()[AnnotationClusterZone]; strings.HasPrefix(zone, cluster.Name+".") && strings.HasSuffix(zone, "."+baseDomain) {
+		return zone
+	}
+	if label := intermediateZoneLabel(cluster, spec, intermediateZoneMode); label != "" {
+		return fmt.Sprintf("%s.%s.%s", cluster.Name, label, baseDomain)
+	}
+	return fmt.Sprintf("%s.%s", cluster.Name, baseDomain)
+}
+
+// intermediateZoneLabel returns the label of the intermediate zone of
+// cluster, empty if it has none or the label isn't a valid DNS label.
+func intermediateZoneLabel(cluster *capi.Cluster, spec *infrav1.AzureClusterSpec, mode string) string {
+	label, ok := cluster.GetAnnotations()[AnnotationIntermediateZone]
+	if !ok {
+		switch mode {
+		case IntermediateZoneModeOrganization:
+			label = cluster.GetLabels()[LabelOrganization]
+			if label == "" {
+				label = strings.TrimPrefix(cluster.Namespace, "org-")
+				if label == cluster.Namespace {
+					label = ""
+				}
+			}
+		case IntermediateZoneModeRegion:
+			if spec != nil {
+				label = spec.Location
+			}
+		}
+	}
+
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || len(validation.IsDNS1123Label(label)) > 0 {
+		return ""
+	}
+	return label
+}
+
+// ParseVirtualNetworkIDs parses a comma separated list of virtual network
+// resource IDs.
+func ParseVirtualNetworkIDs(value string) ([]string, error) {
+	var ids []string
+	for _, id := range splitList(value, ",") {
+		if !isVirtualNetworkID(id) {
+			return nil, microerror.Maskf(errors.InvalidConfigError, "%q is not a virtual network resource ID", id)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ValidateAdoptionPolicy returns an InvalidConfigError if policy is not a
+// known AdoptionPolicy.
+func ValidateAdoptionPolicy(policy string) error {
+	switch policy {
+	case AdoptionPolicyMatching, AdoptionPolicyTakeover:
+		return nil
+	}
+	return microerror.Maskf(errors.InvalidConfigError, "adoption policy must be %q or %q, got %q", AdoptionPolicyMatching, AdoptionPolicyTakeover, policy)
+}
+
 type BaseZoneCredentials struct {
 	ClientID       string
 	ClientSecret   string
@@ -33,6 +312,7 @@ type DNSScopeParams struct {
 	BaseDomain              string
 	BaseDomainResourceGroup string
 	BaseZoneCredentials     BaseZoneCredentials
+	AdditionalZones         []Zone
 
 	AzureClusterIdentity               infrav1.AzureClusterIdentity
 	AzureClusterServicePrincipalSecret corev1.Secret
@@ -42,7 +322,28 @@ type DNSScopeParams struct {
 
 	ManagementClusterSpec infrav1.AzureClusterSpec
 
+	IngressServiceDiscovery IngressServiceDiscovery
+	APIServerHostnameMode   string
+	APIServerRecordMode     string
+	AdoptionPolicy          string
+	PrivateRecordsMode      string
+	IntermediateZoneMode    string
+	// SplitHorizonVirtualNetworkIDs are the virtual networks the private zones
+	// of all split-horizon clusters are linked to.
+	SplitHorizonVirtualNetworkIDs []string
+
+	// ResourceTags are the tags of the Azure resources created for the
+	// cluster: its zones and, for non-Azure clusters, its resource group.
 	ResourceTags map[string]*string
+	// ManagementLocks places CanNotDelete management locks on the cluster
+	// zone and on the resource group of non-Azure clusters.
+	ManagementLocks bool
+	// OperatorIdentity identifies the operator instance in the ownership
+	// marker of the records, zones and resource groups it creates, see
+	// OperatorIdentity. It defaults to the base domain.
+	OperatorIdentity string
+	// RecordTTLs are the TTLs of the records written for the cluster.
+	RecordTTLs RecordTTLs
 }
 
 // DNSScope defines the basic context for an actuator to operate upon.
@@ -52,13 +353,26 @@ type DNSScope struct {
 	baseDomain              string
 	baseDomainResourceGroup string
 	baseZoneCredentials     BaseZoneCredentials
+	additionalZones         []Zone
 
 	identity                  Identity
 	managementClusterIdentity Identity
 
 	managementClusterSpec infrav1.AzureClusterSpec
 
-	resourceTags map[string]*string
+	ingressServiceDiscovery IngressServiceDiscovery
+	apiServerHostnameMode   string
+	apiServerRecordMode     string
+	adoptionPolicy          string
+	privateRecordsMode      string
+	intermediateZoneMode    string
+
+	splitHorizonVirtualNetworkIDs []string
+
+	resourceTags     map[string]*string
+	managementLocks  bool
+	operatorIdentity string
+	recordTTLs       RecordTTLs
 }
 
 type Identity struct {
@@ -84,6 +398,7 @@ func NewDNSScope(_ context.Context, params DNSScopeParams) (*DNSScope, error) {
 		baseDomain:              params.BaseDomain,
 		baseDomainResourceGroup: params.BaseDomainResourceGroup,
 		baseZoneCredentials:     params.BaseZoneCredentials,
+		additionalZones:         params.AdditionalZones,
 		identity: Identity{
 			clusterIdentity: params.AzureClusterIdentity,
 			secret:          params.AzureClusterServicePrincipalSecret,
@@ -92,8 +407,18 @@ func NewDNSScope(_ context.Context, params DNSScopeParams) (*DNSScope, error) {
 			clusterIdentity: params.ManagementClusterAzureIdentity,
 			secret:          params.ManagementClusterServicePrincipalSecret,
 		},
-		managementClusterSpec: params.ManagementClusterSpec,
-		resourceTags:          params.ResourceTags,
+		managementClusterSpec:         params.ManagementClusterSpec,
+		ingressServiceDiscovery:       params.IngressServiceDiscovery,
+		apiServerHostnameMode:         params.APIServerHostnameMode,
+		apiServerRecordMode:           params.APIServerRecordMode,
+		adoptionPolicy:                params.AdoptionPolicy,
+		privateRecordsMode:            params.PrivateRecordsMode,
+		intermediateZoneMode:          params.IntermediateZoneMode,
+		splitHorizonVirtualNetworkIDs: params.SplitHorizonVirtualNetworkIDs,
+		resourceTags:                  params.ResourceTags,
+		managementLocks:               params.ManagementLocks,
+		operatorIdentity:              OperatorIdentity(params.OperatorIdentity, params.BaseDomain),
+		recordTTLs:                    params.RecordTTLs.withDefaults(),
 	}
 
 	return scope, nil
@@ -107,8 +432,25 @@ func (s *DNSScope) BaseDomain() string {
 	return s.baseDomain
 }
 
+// ClusterDomain returns the name of the cluster zone, see ClusterZoneName.
 func (s *DNSScope) ClusterDomain() string {
-	return fmt.Sprintf("%s.%s", s.Patcher.ClusterName(), s.baseDomain)
+	return ClusterZoneName(s.Cluster, s.AzureClusterSpec(), s.baseDomain, s.intermediateZoneMode)
+}
+
+// ParentDomain returns the name of the zone the cluster zone is delegated
+// from, either the base zone or an intermediate zone.
+func (s *DNSScope) ParentDomain() string {
+	return strings.TrimPrefix(s.ClusterDomain(), s.Cluster.Name+".")
+}
+
+// IntermediateDomain returns the name of the intermediate zone between the
+// base zone and the cluster zone, empty if the cluster zone is delegated from
+// the base zone.
+func (s *DNSScope) IntermediateDomain() string {
+	if parent := s.ParentDomain(); parent != s.baseDomain {
+		return parent
+	}
+	return ""
 }
 
 func (s *DNSScope) ResourceGroup() string {
@@ -123,6 +465,12 @@ func (s *DNSScope) BaseZoneCredentials() BaseZoneCredentials {
 	return s.baseZoneCredentials
 }
 
+// AdditionalZones returns the zones besides the base zone and the cluster
+// zone that service hostnames may be published in.
+func (s *DNSScope) AdditionalZones() []Zone {
+	return s.additionalZones
+}
+
 func (s *DNSScope) AzureClusterIdentity() infrav1.AzureClusterIdentity {
 	return s.identity.clusterIdentity
 }
@@ -135,6 +483,34 @@ func (s *DNSScope) ResourceTags() map[string]*string {
 	return s.resourceTags
 }
 
+// RecordTTLs returns the TTLs of the records written for the cluster.
+func (s *DNSScope) RecordTTLs() RecordTTLs {
+	return s.recordTTLs
+}
+
+// ManagementLocks reports whether the cluster zone and the resource group of
+// non-Azure clusters are protected by CanNotDelete management locks.
+func (s *DNSScope) ManagementLocks() bool {
+	return s.managementLocks
+}
+
+// OperatorIdentity returns the identity of the operator instance the
+// resources of the cluster are marked with.
+func (s *DNSScope) OperatorIdentity() string {
+	return s.operatorIdentity
+}
+
+// OperatorIdentity returns the identity of an operator instance: the name of
+// the management cluster it runs in, or the base domain if it's unknown.
+// Operators sharing a base zone or subscription only ever touch resources
+// marked with their own identity.
+func OperatorIdentity(managementClusterName, baseDomain string) string {
+	if managementClusterName != "" {
+		return managementClusterName
+	}
+	return baseDomain
+}
+
 // WildcardFQDN returns the FQDN for the wildcard CNAME record target.
 // If the annotation is set, it returns "<annotation>.<clusterdomain>"; otherwise "ingress.<clusterdomain>".
 func (s *DNSScope) WildcardFQDN() string {
@@ -144,3 +520,169 @@ func (s *DNSScope) WildcardFQDN() string {
 	}
 	return fmt.Sprintf("%s.%s", target, s.ClusterDomain())
 }
+
+// IngressServiceNamespaces returns the namespaces in the workload cluster to
+// look for ingress controller services in. The Cluster annotation takes
+// precedence over the operator-wide configuration.
+func (s *DNSScope) IngressServiceNamespaces() []string {
+	if namespaces := splitList(s.Cluster.GetAnnotations()[AnnotationIngressServiceNamespaces], ","); len(namespaces) > 0 {
+		return namespaces
+	}
+	if len(s.ingressServiceDiscovery.Namespaces) > 0 {
+		return s.ingressServiceDiscovery.Namespaces
+	}
+	return []string{DefaultIngressServiceNamespace}
+}
+
+// IngressServiceSelectors returns the label selectors used to find ingress
+// controller services in the workload cluster. The Cluster annotation takes
+// precedence over the operator-wide configuration.
+func (s *DNSScope) IngressServiceSelectors() []string {
+	if selectors := splitList(s.Cluster.GetAnnotations()[AnnotationIngressServiceSelectors], ";"); len(selectors) > 0 {
+		return selectors
+	}
+	if len(s.ingressServiceDiscovery.Selectors) > 0 {
+		return s.ingressServiceDiscovery.Selectors
+	}
+	return []string{DefaultIngressServiceSelector}
+}
+
+// APIServerHostname returns the control plane endpoint of non-Azure clusters
+// if it is a hostname rather than an IP, e.g. a kube-vip or external load
+// balancer FQDN. An empty string is returned otherwise.
+func (s *DNSScope) APIServerHostname() string {
+	if s.IsAzureCluster() {
+		return ""
+	}
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s.APIServerEndpoint())), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+	return host
+}
+
+// APIServerHostnameMode returns how the api records of a cluster with a
+// hostname control plane endpoint are published. A valid Cluster annotation
+// takes precedence over the operator-wide configuration.
+func (s *DNSScope) APIServerHostnameMode() string {
+	if mode := s.Cluster.GetAnnotations()[AnnotationAPIServerHostnameMode]; ValidateAPIServerHostnameMode(mode) == nil {
+		return mode
+	}
+	if s.apiServerHostnameMode != "" {
+		return s.apiServerHostnameMode
+	}
+	return APIServerHostnameModeCNAME
+}
+
+// APIServerRecordMode returns how the api records of a CAPZ cluster with a
+// public API server are published. A valid Cluster annotation takes precedence
+// over the operator-wide configuration.
+func (s *DNSScope) APIServerRecordMode() string {
+	if mode := s.Cluster.GetAnnotations()[AnnotationAPIServerRecordMode]; ValidateAPIServerRecordMode(mode) == nil {
+		return mode
+	}
+	if s.apiServerRecordMode != "" {
+		return s.apiServerRecordMode
+	}
+	return APIServerRecordModeAddress
+}
+
+// AdoptionPolicy returns how existing records in the cluster zone are
+// adopted. A valid Cluster annotation takes precedence over the operator-wide
+// configuration.
+func (s *DNSScope) AdoptionPolicy() string {
+	if policy := s.Cluster.GetAnnotations()[AnnotationAdoptionPolicy]; ValidateAdoptionPolicy(policy) == nil {
+		return policy
+	}
+	if s.adoptionPolicy != "" {
+		return s.adoptionPolicy
+	}
+	return AdoptionPolicyMatching
+}
+
+// PrivateRecordsMode returns where records with private IPs are published. A
+// valid Cluster annotation takes precedence over the operator-wide
+// configuration.
+func (s *DNSScope) PrivateRecordsMode() string {
+	if mode := s.Cluster.GetAnnotations()[AnnotationPrivateRecordsMode]; ValidatePrivateRecordsMode(mode) == nil {
+		return mode
+	}
+	if s.privateRecordsMode != "" {
+		return s.privateRecordsMode
+	}
+	return PrivateRecordsModePublic
+}
+
+// IsSplitHorizon reports whether records with private IPs are kept out of the
+// public cluster zone and published in a private zone instead.
+func (s *DNSScope) IsSplitHorizon() bool {
+	return s.PrivateRecordsMode() == PrivateRecordsModeSplitHorizon
+}
+
+// SplitHorizonVirtualNetworkIDs returns the virtual networks the private zone
+// of a split-horizon cluster is linked to: the operator-wide ones, the ones of
+// the Cluster annotation and, for CAPZ clusters, the cluster's own virtual
+// network. Invalid IDs in the annotation are ignored.
+func (s *DNSScope) SplitHorizonVirtualNetworkIDs() []string {
+	ids := append([]string{}, s.splitHorizonVirtualNetworkIDs...)
+	for _, id := range splitList(s.Cluster.GetAnnotations()[AnnotationSplitHorizonVirtualNetworks], ",") {
+		if isVirtualNetworkID(id) {
+			ids = append(ids, id)
+		}
+	}
+	if spec := s.AzureClusterSpec(); spec != nil && spec.NetworkSpec.Vnet.ID != "" {
+		ids = append(ids, spec.NetworkSpec.Vnet.ID)
+	}
+
+	var unique []string
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if !seen[strings.ToLower(id)] {
+			seen[strings.ToLower(id)] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// SplitHorizonPublicIP returns the IPv4 address of the Cluster annotation the
+// api and apiserver records of a split-horizon cluster point to in the public
+// zone. An empty string is returned if it isn't set or not a public IPv4
+// address.
+func (s *DNSScope) SplitHorizonPublicIP() string {
+	ip := net.ParseIP(strings.TrimSpace(s.Cluster.GetAnnotations()[AnnotationSplitHorizonPublicIP])).To4()
+	if ip == nil || IsInternalIP(ip) {
+		return ""
+	}
+	return ip.String()
+}
+
+// IsInternalIP reports whether ip is only reachable from within private
+// networks, i.e. a private, shared (carrier-grade NAT), loopback or link-local
+// address.
+func IsInternalIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the RFC 6598 range used by carrier-grade NAT and many
+// VPNs.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isVirtualNetworkID reports whether id is the resource ID of a virtual
+// network.
+func isVirtualNetworkID(id string) bool {
+	resourceID, err := arm.ParseResourceID(id)
+	return err == nil && strings.EqualFold(resourceID.ResourceType.String(), "Microsoft.Network/virtualNetworks")
+}
+
+// splitList splits value by sep and drops empty items.
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
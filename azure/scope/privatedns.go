@@ -31,6 +31,24 @@ type PrivateDNSScopeParams struct {
 	ClusterServicePrincipalSecretToAttachPrivateDNS corev1.Secret
 
 	ClusterSpecToAttachPrivateDNS infrav1.AzureClusterSpec
+
+	// ResourceTags are the tags of the private zone, e.g. the resource tags
+	// of the cluster.
+	ResourceTags map[string]*string
+
+	// RecordTTLs are the TTLs of the private records, only API and Ingress
+	// are used.
+	RecordTTLs RecordTTLs
+
+	// Events records the events about private DNS changes, e.g. the
+	// infracluster.Scope of the cluster. No events are recorded if nil.
+	Events EventRecorder
+}
+
+// EventRecorder records events about the DNS changes of a cluster.
+type EventRecorder interface {
+	Eventf(reason, messageFmt string, args ...interface{})
+	Warnf(reason, messageFmt string, args ...interface{})
 }
 
 // DNSScope defines the basic context for an actuator to operate upon.
@@ -47,6 +65,11 @@ type PrivateDNSScope struct {
 	managementClusterIdentity identity
 
 	managementClusterSpec infrav1.AzureClusterSpec
+
+	resourceTags map[string]*string
+	recordTTLs   RecordTTLs
+
+	events EventRecorder
 }
 
 type identity struct {
@@ -71,6 +94,9 @@ func NewPrivateDNSScope(_ context.Context, params PrivateDNSScopeParams) (*Priva
 		mcIngressIP:           params.MCIngressIP,
 		wildcardCNAMETarget:   params.WildcardCNAMETarget,
 		virtualNetworkID:      params.VirtualNetworkIDToAttachPrivateDNS,
+		resourceTags:          params.ResourceTags,
+		recordTTLs:            params.RecordTTLs.withDefaults(),
+		events:                params.Events,
 	}
 
 	return scope, nil
@@ -80,6 +106,30 @@ func (s *PrivateDNSScope) ClusterDomain() string {
 	return fmt.Sprintf("%s.%s", s.clusterName, s.baseDomain)
 }
 
+// Eventf records a Normal event about a private DNS change.
+func (s *PrivateDNSScope) Eventf(reason, messageFmt string, args ...interface{}) {
+	if s.events != nil {
+		s.events.Eventf(reason, messageFmt, args...)
+	}
+}
+
+// Warnf records a Warning event, e.g. about a failed private DNS change.
+func (s *PrivateDNSScope) Warnf(reason, messageFmt string, args ...interface{}) {
+	if s.events != nil {
+		s.events.Warnf(reason, messageFmt, args...)
+	}
+}
+
+// RecordTTLs returns the TTLs of the private records.
+func (s *PrivateDNSScope) RecordTTLs() RecordTTLs {
+	return s.recordTTLs
+}
+
+// ResourceTags returns the tags of the private zone.
+func (s *PrivateDNSScope) ResourceTags() map[string]*string {
+	return s.resourceTags
+}
+
 func (s *PrivateDNSScope) ClusterName() string {
 	return s.clusterName
 }
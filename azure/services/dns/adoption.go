@@ -0,0 +1,144 @@
+package dns
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/go-logr/logr"
+	"golang.org/x/exp/slices"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+// recordSetClass is the classification of an existing record set with a name
+// the operator wants to write.
+type recordSetClass string
+
+const (
+	// recordSetOwned record sets carry the ownership metadata of the cluster.
+	recordSetOwned recordSetClass = "owned"
+	// recordSetMatching record sets aren't owned, e.g. because they were
+	// created by CAPZ, Terraform or an earlier operator version, but already
+	// point to the desired target.
+	recordSetMatching recordSetClass = "matching"
+	// recordSetLegacy record sets aren't owned and point somewhere else, but
+	// were written by an operator version that didn't mark record sets with
+	// their owner yet, see isLegacyRecordSet.
+	recordSetLegacy recordSetClass = "legacy"
+	// recordSetConflicting record sets aren't owned and point somewhere else.
+	recordSetConflicting recordSetClass = "conflicting"
+)
+
+// classifyRecordSet classifies the current record set of a name the operator
+// wants to write in zone z. Record sets with names the operator doesn't want
+// are foreign and never looked at.
+func (s *Service) classifyRecordSet(z zone, desiredRecordSet, currentRecordSet *armdns.RecordSet) recordSetClass {
+	switch {
+	case s.isOwnedRecordSet(currentRecordSet):
+		return recordSetOwned
+	case s.recordSetTargetsEqual(desiredRecordSet, currentRecordSet):
+		return recordSetMatching
+	case s.isLegacyRecordSet(z, currentRecordSet):
+		return recordSetLegacy
+	default:
+		return recordSetConflicting
+	}
+}
+
+// isLegacyRecordSet reports whether currentRecordSet of zone z was written by
+// an operator version that didn't mark record sets with their owner yet: it
+// is a record set of the cluster zone without any owner marker, with a name
+// the operator has always written there. These are the api, apiserver and
+// wildcard records and, for non-Azure clusters, the hostnames of the ingress
+// and gateway services, i.e. all but the provider records.
+func (s *Service) isLegacyRecordSet(z zone, currentRecordSet *armdns.RecordSet) bool {
+	if z.name != s.scope.ClusterDomain() || currentRecordSet.Name == nil {
+		return false
+	}
+	if currentRecordSet.Properties != nil && hasOwnerMarker(currentRecordSet.Properties.Metadata) {
+		return false
+	}
+
+	switch name := *currentRecordSet.Name; {
+	case name == apiRecordName || name == apiserverRecordName || name == "*":
+		return true
+	case s.scope.IsAzureCluster():
+		return false
+	default:
+		return !slices.ContainsFunc(s.getProviderRecords(), func(recordSet *armdns.RecordSet) bool {
+			return *recordSet.Name == name
+		})
+	}
+}
+
+// recordSetTargetsEqual reports whether both record sets have the same type
+// and point to the same targets. The TTL is not taken into account. An alias
+// record set and a plain A record set holding the addresses of the alias
+// target point to the same target, so that plain records are migrated.
+func (s *Service) recordSetTargetsEqual(desiredRecordSet, currentRecordSet *armdns.RecordSet) bool {
+	if currentType := recordSetType(currentRecordSet); currentType != "" && currentType != recordSetType(desiredRecordSet) {
+		return false
+	}
+	if currentRecordSet.Properties == nil {
+		return false
+	}
+	if targetResourceID(desiredRecordSet) != "" || targetResourceID(currentRecordSet) != "" {
+		addresses := s.recordSetAddresses(desiredRecordSet)
+		return len(addresses) > 0 && slices.Equal(addresses, s.recordSetAddresses(currentRecordSet))
+	}
+	return aRecordsEqual(desiredRecordSet.Properties.ARecords, currentRecordSet.Properties.ARecords) &&
+		reflect.DeepEqual(desiredRecordSet.Properties.CnameRecord, currentRecordSet.Properties.CnameRecord)
+}
+
+// checkOwnership decides whether the current record set of a name the
+// operator wants to write in zone z may be written. adopt is true if the
+// record set has to be written to take ownership of it, no matter whether its
+// values differ. In the cluster zone matching record sets and the ones
+// earlier operator versions wrote are adopted, while conflicting ones are only
+// taken over if the adoption policy allows it. In shared zones only owned
+// record sets are written.
+func (s *Service) checkOwnership(logger logr.Logger, z zone, desiredRecordSet, currentRecordSet *armdns.RecordSet) (writable bool, adopt bool) {
+	name := *desiredRecordSet.Name
+
+	switch s.classifyRecordSet(z, desiredRecordSet, currentRecordSet) {
+	case recordSetOwned:
+		return true, false
+	case recordSetMatching:
+		if z.shared {
+			break
+		}
+		logger.Info("Adopting matching DNS record", "DNSZone", z.name, "name", name)
+		return true, true
+	case recordSetLegacy:
+		logger.Info("Adopting DNS record written before ownership tracking", "DNSZone", z.name, "name", name)
+		return true, true
+	case recordSetConflicting:
+		if z.shared {
+			break
+		}
+		if s.scope.AdoptionPolicy() == scope.AdoptionPolicyTakeover {
+			logger.Info("Taking over conflicting DNS record", "DNSZone", z.name, "name", name)
+			return true, true
+		}
+		logger.Info("DNS record conflicts with an existing record, skipping", "DNSZone", z.name, "name", name)
+		s.reportConflict(fmt.Sprintf("%s.%s", name, z.name))
+		return false, false
+	}
+
+	logger.Info("DNS record is not owned by this cluster, skipping", "DNSZone", z.name, "name", name)
+	s.scope.Warnf("DNSHostnameRejected", "Hostname %s.%s already exists in zone %s and is not owned by this cluster", name, z.name, z.name)
+	return false, false
+}
+
+// reportConflict records a conflicting record set, see Conflicts.
+func (s *Service) reportConflict(fqdn string) {
+	s.conflicts = append(s.conflicts, fqdn)
+	s.scope.Warnf("DNSRecordConflict", "DNS record %s already exists with a different target and is not adopted, set the %s annotation to %q to take it over", fqdn, scope.AnnotationAdoptionPolicy, scope.AdoptionPolicyTakeover)
+}
+
+// Conflicts returns the FQDNs of the record sets found during the last
+// reconciliation that conflict with desired records and were left alone.
+func (s *Service) Conflicts() []string {
+	return s.conflicts
+}
@@ -0,0 +1,236 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/go-logr/logr"
+	"k8s.io/utils/pointer"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+func TestService_calculateMissingARecords_adoption(t *testing.T) {
+	aRecord := func(name, ip string, metadata map[string]*string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String(ip)}},
+				Metadata: metadata,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		adoptionPolicy    string
+		currentRecordSet  func(owner map[string]*string) *armdns.RecordSet
+		expectedWrite     bool
+		expectedConflicts []string
+	}{
+		{
+			name:             "case0: up to date owned record is left alone",
+			currentRecordSet: func(owner map[string]*string) *armdns.RecordSet { return aRecord("api", "1.2.3.4", owner) },
+		},
+		{
+			name:             "case1: matching record without owner is adopted",
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet { return aRecord("api", "1.2.3.4", nil) },
+			expectedWrite:    true,
+		},
+		{
+			name: "case2: matching record owned by another cluster is adopted",
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet {
+				return aRecord("api", "1.2.3.4", map[string]*string{ownerMetadataKey: pointer.String("default/other-cluster")})
+			},
+			expectedWrite: true,
+		},
+		{
+			name: "case3: conflicting record is reported and left alone",
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet {
+				return aRecord("api", "5.6.7.8", map[string]*string{ownerMetadataKey: pointer.String("default/other-cluster")})
+			},
+			expectedConflicts: []string{"api.test-cluster.basedomain.io"},
+		},
+		{
+			name:           "case4: conflicting record is taken over with the takeover policy",
+			adoptionPolicy: scope.AdoptionPolicyTakeover,
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet {
+				return aRecord("api", "5.6.7.8", map[string]*string{ownerMetadataKey: pointer.String("default/other-cluster")})
+			},
+			expectedWrite: true,
+		},
+		{
+			name:             "case5: record written before ownership tracking is adopted",
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet { return aRecord("api", "5.6.7.8", nil) },
+			expectedWrite:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			svc := newZonesTestService(t, ctx, nil)
+			if tc.adoptionPolicy != "" {
+				svc.scope.Cluster.SetAnnotations(map[string]string{scope.AnnotationAdoptionPolicy: tc.adoptionPolicy})
+			}
+			clusterZone := svc.managedZones()[0]
+
+			desired := aRecord("api", "1.2.3.4", nil)
+			current := []*armdns.RecordSet{
+				aRecord("foreign", "9.9.9.9", nil),
+				tc.currentRecordSet(svc.ownerMetadata()),
+			}
+
+			got := svc.calculateMissingARecords(logr.Discard(), clusterZone, []*armdns.RecordSet{desired}, current)
+
+			var want []*armdns.RecordSet
+			if tc.expectedWrite {
+				want = []*armdns.RecordSet{aRecord("api", "1.2.3.4", svc.ownerMetadata())}
+			}
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("calculateMissingARecords() = %s, want %s", gotJSON, wantJSON)
+			}
+			if !reflect.DeepEqual(svc.Conflicts(), tc.expectedConflicts) {
+				t.Errorf("Conflicts() = %v, want %v", svc.Conflicts(), tc.expectedConflicts)
+			}
+		})
+	}
+}
+
+func TestService_calculateMissingARecords_upgrade(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	clusterZone := svc.managedZones()[0]
+
+	// the api record an earlier operator version wrote for the single
+	// frontend of the cluster, which now has two
+	current := []*armdns.RecordSet{
+		{
+			Name: pointer.String("api"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+			},
+		},
+	}
+	desired := &armdns.RecordSet{
+		Name: pointer.String("api"),
+		Type: pointer.String(RecordSetTypeA),
+		Properties: &armdns.RecordSetProperties{
+			TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+			ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}, {IPv4Address: pointer.String("5.6.7.8")}},
+		},
+	}
+
+	got := svc.calculateMissingARecords(logr.Discard(), clusterZone, []*armdns.RecordSet{desired}, current)
+
+	if len(got) != 1 || len(got[0].Properties.ARecords) != 2 || !svc.isOwnedRecordSet(got[0]) {
+		gotJSON, _ := json.Marshal(got)
+		t.Errorf("calculateMissingARecords() = %s, want the owned api record with both frontends", gotJSON)
+	}
+	if len(svc.Conflicts()) != 0 {
+		t.Errorf("Conflicts() = %v, want none", svc.Conflicts())
+	}
+}
+
+func TestService_calculateMissingARecords_alias(t *testing.T) {
+	const publicIPID = "/subscriptions/123/resourceGroups/test-cluster/providers/Microsoft.Network/publicIPAddresses/pip-test-cluster-apiserver"
+
+	aRecord := func(ip string, metadata map[string]*string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String("api"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String(ip)}},
+				Metadata: metadata,
+			},
+		}
+	}
+	aliasRecord := func(id string, metadata map[string]*string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String("api"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:            pointer.Int64(scope.DefaultAPIRecordTTL),
+				TargetResource: &armdns.SubResource{ID: pointer.String(id)},
+				Metadata:       metadata,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		currentRecordSet  func(owner map[string]*string) *armdns.RecordSet
+		expectedWrite     bool
+		expectedConflicts []string
+	}{
+		{
+			name: "case0: up to date alias record is left alone, regardless of the ID case",
+			currentRecordSet: func(owner map[string]*string) *armdns.RecordSet {
+				return aliasRecord(strings.ToUpper(publicIPID), owner)
+			},
+		},
+		{
+			name:             "case1: owned plain record is migrated",
+			currentRecordSet: func(owner map[string]*string) *armdns.RecordSet { return aRecord("20.1.2.3", owner) },
+			expectedWrite:    true,
+		},
+		{
+			name:             "case2: plain record holding the address of the public IP is adopted",
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet { return aRecord("20.1.2.3", nil) },
+			expectedWrite:    true,
+		},
+		{
+			name: "case3: plain record of another cluster holding another address is reported and left alone",
+			currentRecordSet: func(map[string]*string) *armdns.RecordSet {
+				return aRecord("5.6.7.8", map[string]*string{ownerMetadataKey: pointer.String("default/other-cluster")})
+			},
+			expectedConflicts: []string{"api.test-cluster.basedomain.io"},
+		},
+		{
+			name:             "case4: owned alias record pointing elsewhere is updated",
+			currentRecordSet: func(owner map[string]*string) *armdns.RecordSet { return aliasRecord(publicIPID+"-old", owner) },
+			expectedWrite:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			svc := newZonesTestService(t, ctx, nil)
+			svc.setAliasAddresses(publicIPID, []string{"20.1.2.3"})
+			clusterZone := svc.managedZones()[0]
+
+			desired := aliasRecord(publicIPID, nil)
+			current := []*armdns.RecordSet{tc.currentRecordSet(svc.ownerMetadata())}
+
+			got := svc.calculateMissingARecords(logr.Discard(), clusterZone, []*armdns.RecordSet{desired}, current)
+
+			var want []*armdns.RecordSet
+			if tc.expectedWrite {
+				want = []*armdns.RecordSet{aliasRecord(publicIPID, svc.ownerMetadata())}
+			}
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("calculateMissingARecords() = %s, want %s", gotJSON, wantJSON)
+			}
+			if !reflect.DeepEqual(svc.Conflicts(), tc.expectedConflicts) {
+				t.Errorf("Conflicts() = %v, want %v", svc.Conflicts(), tc.expectedConflicts)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
@@ -16,12 +17,14 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/go-logr/logr"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	capzpublicips "sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	kubeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/giantswarm/microerror"
 
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
 	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
 )
 
@@ -29,13 +32,6 @@ const (
 	apiRecordName       = "api"
 	apiserverRecordName = "apiserver"
 
-	apiRecordTTL     = 300
-	ingressRecordTTL = 300
-	gatewayRecordTTL = 300
-
-	ingressServiceSelector = "app.kubernetes.io/name in (ingress-nginx,nginx-ingress-controller)"
-	ingressAppNamespace    = "kube-system"
-
 	gatewayNamespace              = "envoy-gateway-system"
 	externalDNSManagedAnnotation  = "giantswarm.io/external-dns"
 	externalDNSManagedValue       = "managed"
@@ -47,81 +43,187 @@ func (s *Service) updateARecords(ctx context.Context, currentRecordSets []*armdn
 
 	logger.V(1).Info("update A records", "current record sets", currentRecordSets)
 
-	recordsToCreate, err := s.calculateMissingARecords(ctx, logger, currentRecordSets)
+	desiredRecordSets, step, err := s.desiredARecords(ctx)
 	if err != nil {
-		return err
+		return s.stepFailed(step, err)
+	}
+
+	for _, z := range s.managedZones() {
+		zoneRecordSets := currentRecordSets
+		if z.shared {
+			zoneRecordSets, err = z.client.ListRecordSets(ctx, z.resourceGroup, z.name)
+			if err != nil {
+				return s.stepFailed(StepIngressRecords, microerror.Mask(err))
+			}
+		}
+
+		if err := s.updateZoneARecords(ctx, logger, z, desiredRecordSets[z.name], zoneRecordSets); err != nil {
+			return microerror.Mask(err)
+		}
 	}
 
-	logger.V(1).Info("update A records", "records to create", recordsToCreate)
+	return nil
+}
+
+func (s *Service) updateZoneARecords(ctx context.Context, logger logr.Logger, z zone, desiredRecordSets, currentRecordSets []*armdns.RecordSet) error {
+	recordsToCreate := s.calculateMissingARecords(logger, z, desiredRecordSets, currentRecordSets)
+
+	logger.V(1).Info("update A records", "DNSZone", z.name, "records to create", recordsToCreate)
 
 	if len(recordsToCreate) == 0 {
 		logger.Info(
 			"All DNS A records have already been created",
-			"DNSZone", s.scope.ClusterDomain())
-		return nil
+			"DNSZone", z.name)
 	}
 
-	for _, aRecord := range recordsToCreate {
+	for _, desiredRecordSet := range recordsToCreate {
+		recordType := recordSetType(desiredRecordSet)
+
+		// Azure doesn't allow an A and a CNAME record set with the same name,
+		// so a record switching between both types is removed first.
+		var replacedRecordSet *armdns.RecordSet
+		for _, currentRecordSet := range currentRecordSets {
+			if *currentRecordSet.Name != *desiredRecordSet.Name || !isAddressRecordSet(currentRecordSet) {
+				continue
+			}
+			currentRecordType := recordSetType(currentRecordSet)
+			if currentRecordType == "" {
+				continue
+			}
+			replacedRecordSet = currentRecordSet
+			if currentRecordType == recordType {
+				continue
+			}
+
+			logger.Info(
+				fmt.Sprintf("DNS %s record %s is replaced by a %s record, it will be deleted", currentRecordType, *currentRecordSet.Name, recordType),
+				"DNSZone", z.name)
+
+			err := z.client.DeleteRecordSet(ctx, z.resourceGroup, z.name, currentRecordType, *currentRecordSet.Name)
+			if err != nil {
+				s.recordSetDeleted(z.name, currentRecordSet, err)
+				return s.stepFailed(s.recordStep(z.name, desiredRecordSet), microerror.Mask(err))
+			}
+		}
 
 		logger.Info(
-			fmt.Sprintf("DNS A record %s is missing, it will be created", *aRecord.Name),
-			"DNSZone", s.scope.ClusterDomain(),
-			"FQDN", fmt.Sprintf("%s.%s", *aRecord.Name, s.scope.ClusterDomain()))
+			fmt.Sprintf("DNS %s record %s is missing, it will be created", recordType, *desiredRecordSet.Name),
+			"DNSZone", z.name,
+			"FQDN", fmt.Sprintf("%s.%s", *desiredRecordSet.Name, z.name))
 
 		logger.Info(
-			"Creating DNS A record",
-			"DNSZone", s.scope.ClusterDomain(),
-			"hostname", aRecord.Name,
-			"ipv4", aRecord.Properties.ARecords)
+			fmt.Sprintf("Creating DNS %s record", recordType),
+			"DNSZone", z.name,
+			"hostname", desiredRecordSet.Name,
+			"ipv4", desiredRecordSet.Properties.ARecords,
+			"cname", desiredRecordSet.Properties.CnameRecord)
 
-		createdRecordSet, err := s.azureClient.CreateOrUpdateRecordSet(
+		createdRecordSet, err := z.client.CreateOrUpdateRecordSet(
 			ctx,
-			s.scope.ResourceGroup(),
-			s.scope.ClusterDomain(),
-			armdns.RecordTypeA,
-			*aRecord.Name,
-			*aRecord)
+			z.resourceGroup,
+			z.name,
+			recordType,
+			*desiredRecordSet.Name,
+			*desiredRecordSet)
+		s.recordSetWritten(z.name, recordType, desiredRecordSet, replacedRecordSet, err)
 		if err != nil {
-			return microerror.Mask(err)
+			return s.stepFailed(s.recordStep(z.name, desiredRecordSet), microerror.Mask(err))
 		}
 
 		logger.Info(
-			"Successfully created DNS A record",
-			"DNSZone", s.scope.ClusterDomain(),
-			"hostname", aRecord.Name,
+			fmt.Sprintf("Successfully created DNS %s record", recordType),
+			"DNSZone", z.name,
+			"hostname", desiredRecordSet.Name,
 			"id", createdRecordSet.ID)
 	}
 
-	return nil
-}
+	if !z.shared {
+		return nil
+	}
 
-func (s *Service) calculateMissingARecords(ctx context.Context, logger logr.Logger, currentRecordSets []*armdns.RecordSet) ([]*armdns.RecordSet, error) {
-	desiredRecordSets, err := s.getDesiredARecords(ctx)
-	if err != nil {
-		return nil, err
+	// Records in shared zones don't go away with the cluster zone, so the ones
+	// we own but no longer want are removed here.
+	for _, currentRecordSet := range currentRecordSets {
+		if !isAddressRecordSet(currentRecordSet) || !s.isOwnedRecordSet(currentRecordSet) {
+			continue
+		}
+		if slices.ContainsFunc(desiredRecordSets, func(recordSet *armdns.RecordSet) bool { return *recordSet.Name == *currentRecordSet.Name }) {
+			continue
+		}
+
+		logger.Info("Deleting DNS record that is no longer desired", "DNSZone", z.name, "hostname", *currentRecordSet.Name, "type", recordSetType(currentRecordSet))
+		err := z.client.DeleteRecordSet(ctx, z.resourceGroup, z.name, recordSetType(currentRecordSet), *currentRecordSet.Name)
+		s.recordSetDeleted(z.name, currentRecordSet, err)
+		if err != nil {
+			return s.stepFailed(StepIngressRecords, microerror.Mask(err))
+		}
 	}
 
+	return nil
+}
+
+func (s *Service) calculateMissingARecords(logger logr.Logger, z zone, desiredRecordSets, currentRecordSets []*armdns.RecordSet) []*armdns.RecordSet {
 	var recordsToCreate []*armdns.RecordSet
 
 	for _, desiredRecordSet := range desiredRecordSets {
 
 		logger.V(1).Info(fmt.Sprintf("compare entries individually - %s", *desiredRecordSet.Name))
 
-		currentRecordSetIndex := slices.IndexFunc(currentRecordSets, func(recordSet *armdns.RecordSet) bool { return *recordSet.Name == *desiredRecordSet.Name })
+		if z.shared && isDelegatedName(*desiredRecordSet.Name, currentRecordSets) {
+			logger.Info("DNS A record is below a delegated subdomain, skipping", "DNSZone", z.name, "name", *desiredRecordSet.Name)
+			s.scope.Warnf("DNSHostnameRejected", "Hostname %s.%s is below a delegated subdomain of zone %s", *desiredRecordSet.Name, z.name, z.name)
+			continue
+		}
+
+		// every record written by the operator is marked as owned by the cluster
+		desiredRecordSet.Properties.Metadata = s.ownerMetadata()
+
+		currentRecordSetIndex := slices.IndexFunc(currentRecordSets, func(recordSet *armdns.RecordSet) bool {
+			return *recordSet.Name == *desiredRecordSet.Name && isAddressRecordSet(recordSet)
+		})
 		if currentRecordSetIndex == -1 {
 			recordsToCreate = append(recordsToCreate, desiredRecordSet)
 		} else {
+			writable, adopt := s.checkOwnership(logger, z, desiredRecordSet, currentRecordSets[currentRecordSetIndex])
+			if !writable {
+				continue
+			}
+			if adopt {
+				recordsToCreate = append(recordsToCreate, desiredRecordSet)
+				continue
+			}
+
 			// remove ProvisioningState from currentRecordSet to make further comparison easier
 			currentRecordSets[currentRecordSetIndex].Properties.ProvisioningState = nil
 
+			currentRecordType := recordSetType(currentRecordSets[currentRecordSetIndex])
+
 			switch {
-			// compare ARecords[].IPv4Address
-			case !reflect.DeepEqual(
+			// compare the record type, e.g. a load balancer switching from an IP to a hostname
+			case currentRecordType != "" && currentRecordType != recordSetType(desiredRecordSet):
+				logger.V(1).Info(fmt.Sprintf("Record type for %s changed from %s to %s - force update", *desiredRecordSet.Name, currentRecordType, recordSetType(desiredRecordSet)))
+				recordsToCreate = append(recordsToCreate, desiredRecordSet)
+			// compare ARecords[].IPv4Address, in any order
+			case !aRecordsEqual(
 				desiredRecordSet.Properties.ARecords,
 				currentRecordSets[currentRecordSetIndex].Properties.ARecords,
 			):
 				logger.V(1).Info(fmt.Sprintf("A Records for %s are not equal - force update", *desiredRecordSet.Name))
 				recordsToCreate = append(recordsToCreate, desiredRecordSet)
+			// compare TargetResource.ID, e.g. a plain A record becoming an alias record
+			case !strings.EqualFold(
+				targetResourceID(desiredRecordSet),
+				targetResourceID(currentRecordSets[currentRecordSetIndex]),
+			):
+				logger.V(1).Info(fmt.Sprintf("Alias target for %s is not equal - force update", *desiredRecordSet.Name))
+				recordsToCreate = append(recordsToCreate, desiredRecordSet)
+			// compare CnameRecord.Cname
+			case !reflect.DeepEqual(
+				desiredRecordSet.Properties.CnameRecord,
+				currentRecordSets[currentRecordSetIndex].Properties.CnameRecord,
+			):
+				logger.V(1).Info(fmt.Sprintf("CNAME Record for %s is not equal - force update", *desiredRecordSet.Name))
+				recordsToCreate = append(recordsToCreate, desiredRecordSet)
 			// compare TTL
 			case !reflect.DeepEqual(
 				desiredRecordSet.Properties.TTL,
@@ -135,9 +237,9 @@ func (s *Service) calculateMissingARecords(ctx context.Context, logger logr.Logg
 			for _, ip := range currentRecordSets[currentRecordSetIndex].Properties.ARecords {
 				// dns_operator_azure_record_set_info{controller="dns-operator-azure",fqdn="api.glippy.azuretest.gigantic.io",ip="20.4.101.180",ttl="300"} 1
 				metrics.RecordInfo.WithLabelValues(
-					s.scope.ClusterDomain(), // label: zone
-					metrics.ZoneTypePublic,  // label: type
-					fmt.Sprintf("%s.%s", *currentRecordSets[currentRecordSetIndex].Name, s.scope.ClusterDomain()), // label: fqdn
+					z.name,                 // label: zone
+					metrics.ZoneTypePublic, // label: type
+					fmt.Sprintf("%s.%s", *currentRecordSets[currentRecordSetIndex].Name, z.name), // label: fqdn
 					*ip.IPv4Address, // label: ip
 					fmt.Sprint(*currentRecordSets[currentRecordSetIndex].Properties.TTL), // label: ttl
 				).Set(1)
@@ -145,10 +247,38 @@ func (s *Service) calculateMissingARecords(ctx context.Context, logger logr.Logg
 		}
 	}
 
-	return recordsToCreate, nil
+	return recordsToCreate
+}
+
+// getDesiredARecords returns the desired A records keyed by the name of the
+// zone they belong to.
+func (s *Service) getDesiredARecords(ctx context.Context) (map[string][]*armdns.RecordSet, error) {
+	desiredRecordSets, _, err := s.desiredARecords(ctx)
+	return desiredRecordSets, err
+}
+
+// desiredARecords is getDesiredARecords, additionally returning the step
+// that failed on error. The cluster zone of split-horizon clusters only gets
+// the records safe to expose publicly, see externalRecordSets, the others are
+// kept in internalRecordSets for the private zone.
+func (s *Service) desiredARecords(ctx context.Context) (map[string][]*armdns.RecordSet, Step, error) {
+	desiredRecordSets, step, err := s.desiredInternalARecords(ctx)
+	if err != nil {
+		return nil, step, err
+	}
+
+	if s.scope.IsSplitHorizon() && desiredRecordSets != nil {
+		clusterDomain := s.scope.ClusterDomain()
+		s.internalRecordSets = desiredRecordSets[clusterDomain]
+		desiredRecordSets[clusterDomain] = s.externalRecordSets(desiredRecordSets[clusterDomain])
+	}
+
+	return desiredRecordSets, "", nil
 }
 
-func (s *Service) getDesiredARecords(ctx context.Context) ([]*armdns.RecordSet, error) {
+// desiredInternalARecords returns the desired A records keyed by the name of
+// the zone they belong to, including records with internal addresses.
+func (s *Service) desiredInternalARecords(ctx context.Context) (map[string][]*armdns.RecordSet, Step, error) {
 
 	// AKS (AzureASOManagedCluster) clusters expose their API server through an
 	// Azure-provided FQDN whose TLS certificate only matches that FQDN. Publishing
@@ -156,164 +286,406 @@ func (s *Service) getDesiredARecords(ctx context.Context) ([]*armdns.RecordSet,
 	// mismatch, so we don't manage any A records for them. Ingress records for AKS
 	// clusters are handled by external-dns running inside the cluster.
 	if s.scope.IsASOManagedCluster() {
-		return nil, nil
+		return nil, "", nil
 	}
 
-	var armdnsRecordSet []*armdns.RecordSet
+	armdnsRecordSet, err := s.getAPIServerRecords(ctx)
+	if err != nil {
+		return nil, StepAPIRecords, microerror.Mask(err)
+	}
+	armdnsRecordSet = appendUniqueRecordSets(log.FromContext(ctx), armdnsRecordSet, s.getProviderRecords())
 
-	armdnsRecordSet = append(armdnsRecordSet,
-		// api A-Record
-		&armdns.RecordSet{
-			Name: pointer.String(apiRecordName),
-			Type: pointer.String(string(armdns.RecordTypeA)),
-			Properties: &armdns.RecordSetProperties{
-				TTL: pointer.Int64(apiRecordTTL),
-			},
-		},
-		// apiserver A-Record
-		&armdns.RecordSet{
-			Name: pointer.String(apiserverRecordName),
+	desiredRecordSets := map[string][]*armdns.RecordSet{
+		s.scope.ClusterDomain(): armdnsRecordSet,
+	}
+
+	if !s.scope.IsAzureCluster() {
+		logger := log.FromContext(ctx).WithName("getDesiredARecords")
+
+		// ingress: one A or CNAME record per hostname of the annotated ingress controller services.
+		ingressRecords, err := s.getIngressRecords(ctx)
+		if err != nil {
+			return nil, StepIngressRecords, microerror.Mask(err)
+		}
+
+		// gateway: one A or CNAME record per hostname of the annotated services in envoy-gateway-system.
+		gatewayRecords, err := s.getGatewayRecords(ctx)
+		if err != nil {
+			return nil, StepIngressRecords, microerror.Mask(err)
+		}
+
+		serviceRecords := appendUniqueRecordSets(logger, ingressRecords, gatewayRecords)
+		for zoneName, zoneRecords := range s.placeHostnameRecordSets(ctx, serviceRecords) {
+			desiredRecordSets[zoneName] = appendUniqueRecordSets(logger, desiredRecordSets[zoneName], zoneRecords)
+		}
+	}
+
+	return desiredRecordSets, "", nil
+}
+
+// getAPIServerRecords returns the api and apiserver records of the cluster
+// zone.
+func (s *Service) getAPIServerRecords(ctx context.Context) ([]*armdns.RecordSet, error) {
+	if hostname := s.scope.APIServerHostname(); hostname != "" {
+		return s.getAPIServerHostnameRecords(ctx, hostname)
+	}
+
+	addresses, aliasTarget, err := s.getAPIServerAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var armdnsRecordSet []*armdns.RecordSet
+	for _, recordName := range []string{apiRecordName, apiserverRecordName} {
+		recordSet := &armdns.RecordSet{
+			Name: pointer.String(recordName),
 			Type: pointer.String(string(armdns.RecordTypeA)),
 			Properties: &armdns.RecordSetProperties{
-				TTL: pointer.Int64(apiRecordTTL),
+				TTL: pointer.Int64(s.scope.RecordTTLs().API),
 			},
-		})
+		}
+		if aliasTarget != "" {
+			recordSet.Properties.TargetResource = &armdns.SubResource{ID: pointer.String(aliasTarget)}
+			armdnsRecordSet = append(armdnsRecordSet, recordSet)
+			continue
+		}
+		for _, address := range addresses {
+			recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armdns.ARecord{
+				IPv4Address: pointer.String(address),
+			})
+		}
+		armdnsRecordSet = append(armdnsRecordSet, recordSet)
+	}
 
-	apiIndex := slices.IndexFunc(armdnsRecordSet, func(recordSet *armdns.RecordSet) bool { return *recordSet.Name == apiRecordName })
-	apiserverIndex := slices.IndexFunc(armdnsRecordSet, func(recordSet *armdns.RecordSet) bool { return *recordSet.Name == apiserverRecordName })
+	return armdnsRecordSet, nil
+}
 
-	switch {
-	case s.scope.Patcher.IsAPIServerPrivate():
+// getAPIServerAddresses returns the sorted addresses of all frontend IPs of
+// the API server load balancer. In the alias APIServerRecordMode, aliasTarget
+// is the ID of the public IP resource the api records point at. Alias record
+// sets can only point at a single resource, so API servers with several public
+// IPs, or with IPs that aren't Azure resources, keep plain A records.
+func (s *Service) getAPIServerAddresses(ctx context.Context) (addresses []string, aliasTarget string, err error) {
+	logger := log.FromContext(ctx).WithName("getAPIServerAddresses")
+
+	if s.scope.Patcher.IsAPIServerPrivate() {
+		addresses = s.scope.APIServerPrivateIPs()
+	} else {
+		var resourceIDs []string
+		for _, publicIP := range s.scope.APIServerPublicIPs() {
+			address, resourceID, err := s.getIPAddressForPublicDNS(ctx, publicIP)
+			if err != nil {
+				return nil, "", err
+			}
+			addresses = append(addresses, address)
+			resourceIDs = append(resourceIDs, resourceID)
+		}
 
-		armdnsRecordSet[apiIndex].Properties.ARecords = append(armdnsRecordSet[apiIndex].Properties.ARecords, &armdns.ARecord{
-			IPv4Address: pointer.String(s.scope.Patcher.APIServerPrivateIP()),
-		})
+		if s.scope.APIServerRecordMode() == scope.APIServerRecordModeAlias {
+			if len(resourceIDs) == 1 && resourceIDs[0] != "" {
+				aliasTarget = resourceIDs[0]
+			} else {
+				logger.Info("API server has no single public IP resource to point alias records at, publishing A records", "publicIPs", len(resourceIDs))
+			}
+		}
+	}
 
-		armdnsRecordSet[apiserverIndex].Properties.ARecords = append(armdnsRecordSet[apiserverIndex].Properties.ARecords, &armdns.ARecord{
-			IPv4Address: pointer.String(s.scope.Patcher.APIServerPrivateIP()),
-		})
+	// every frontend IP of the API server load balancer is published, sorted
+	// to keep the record sets stable
+	sort.Strings(addresses)
+	addresses = slices.Compact(addresses)
 
-	case !s.scope.Patcher.IsAPIServerPrivate():
+	if aliasTarget != "" {
+		s.setAliasAddresses(aliasTarget, addresses)
+	}
 
-		publicIP, err := s.getIPAddressForPublicDNS(ctx)
-		if err != nil {
-			return nil, err
+	return addresses, aliasTarget, nil
+}
+
+// getProviderRecords returns the extra A records of the infrastructure
+// provider, e.g. the internal API server load balancer IP of OpenStack
+// clusters, sorted by name. Records with internal addresses are only returned
+// for split-horizon clusters, which publish them in the private zone only, see
+// externalRecordSets.
+func (s *Service) getProviderRecords() []*armdns.RecordSet {
+	extraRecords := s.scope.ExtraRecords()
+
+	var names []string
+	for name, address := range extraRecords {
+		if isInternalAddress(address) && !s.scope.IsSplitHorizon() {
+			continue
 		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-		armdnsRecordSet[apiIndex].Properties.ARecords = append(armdnsRecordSet[apiIndex].Properties.ARecords, &armdns.ARecord{
-			IPv4Address: pointer.String(publicIP),
+	var recordSets []*armdns.RecordSet
+	for _, name := range names {
+		recordSets = append(recordSets, &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String(string(armdns.RecordTypeA)),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(s.scope.RecordTTLs().API),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String(extraRecords[name])}},
+			},
 		})
+	}
+	return recordSets
+}
 
-		armdnsRecordSet[apiserverIndex].Properties.ARecords = append(armdnsRecordSet[apiserverIndex].Properties.ARecords, &armdns.ARecord{
-			IPv4Address: pointer.String(publicIP),
-		})
+// getAPIServerHostnameRecords returns the api and apiserver records for a
+// control plane endpoint that is a hostname. Depending on the configured mode
+// they are CNAME records pointing to the hostname or A records holding the
+// IPv4 addresses it resolves to. Resolved internal addresses, e.g. of an
+// internal load balancer, are only published in the private zone of
+// split-horizon clusters, see externalRecordSets, and dropped otherwise.
+func (s *Service) getAPIServerHostnameRecords(ctx context.Context, hostname string) ([]*armdns.RecordSet, error) {
+	logger := log.FromContext(ctx).WithName("getAPIServerHostnameRecords")
+
+	for _, recordName := range []string{apiRecordName, apiserverRecordName} {
+		if hostname == fmt.Sprintf("%s.%s", recordName, s.scope.ClusterDomain()) {
+			logger.Info("Control plane endpoint points to a record managed by dns-operator-azure, skipping api records", "hostname", hostname)
+			s.scope.Warnf("DNSHostnameRejected", "Control plane endpoint %s can't point to itself", hostname)
+			return nil, nil
+		}
+	}
 
+	newRecordSet := func(name string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String(string(armdns.RecordTypeCNAME)),
+			Properties: &armdns.RecordSetProperties{TTL: pointer.Int64(s.scope.RecordTTLs().API)},
+		}
 	}
 
-	if !s.scope.IsAzureCluster() {
-		// ingress: A record for the nginx ingress controller, name read from external-dns annotation.
-		ingressRecord, err := s.getIngressARecord(ctx)
-		if err != nil {
-			return nil, microerror.Mask(err)
+	if s.scope.APIServerHostnameMode() != scope.APIServerHostnameModeResolve {
+		var recordSets []*armdns.RecordSet
+		for _, recordName := range []string{apiRecordName, apiserverRecordName} {
+			recordSet := newRecordSet(recordName)
+			recordSet.Properties.CnameRecord = &armdns.CnameRecord{Cname: pointer.String(hostname)}
+			recordSets = append(recordSets, recordSet)
 		}
-		if ingressRecord != nil {
-			armdnsRecordSet = append(armdnsRecordSet, ingressRecord)
+		return recordSets, nil
+	}
+
+	addresses, err := s.lookupIPv4(ctx, hostname)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	logger.V(1).Info("resolved control plane endpoint", "hostname", hostname, "addresses", addresses)
+
+	if !s.scope.IsSplitHorizon() {
+		var public, internal []string
+		for _, address := range addresses {
+			if isInternalAddress(address) {
+				internal = append(internal, address)
+			} else {
+				public = append(public, address)
+			}
 		}
+		if len(internal) > 0 {
+			logger.Info("Control plane endpoint resolves to internal addresses, which are not published in the public zone", "hostname", hostname, "addresses", internal)
+			s.scope.Warnf("DNSInternalAddressSkipped", "Control plane endpoint %s resolves to the internal addresses %s, which are not published in the public zone %s", hostname, strings.Join(internal, ","), s.scope.ClusterDomain())
+		}
+		if len(public) == 0 {
+			return nil, nil
+		}
+		addresses = public
+	}
 
-		// gateway: one A record per annotated service in envoy-gateway-system.
-		gatewayRecords, err := s.getGatewayARecords(ctx)
-		if err != nil {
-			return nil, microerror.Mask(err)
+	var recordSets []*armdns.RecordSet
+	for _, recordName := range []string{apiRecordName, apiserverRecordName} {
+		recordSet := newRecordSet(recordName)
+		recordSet.Type = pointer.String(string(armdns.RecordTypeA))
+		for _, address := range addresses {
+			recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(address)})
 		}
-		armdnsRecordSet = append(armdnsRecordSet, gatewayRecords...)
+		recordSets = append(recordSets, recordSet)
+	}
+	return recordSets, nil
+}
+
+// lookupIPv4 returns the sorted IPv4 addresses hostname resolves to.
+func (s *Service) lookupIPv4(ctx context.Context, hostname string) ([]string, error) {
+	var lookup resolver = net.DefaultResolver
+	if s.resolver != nil {
+		lookup = s.resolver
 	}
 
-	return armdnsRecordSet, nil
+	ipAddrs, err := lookup.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return nil, microerror.Maskf(apiServerHostnameNotResolvableError, "%s: %s", hostname, err)
+	}
+
+	var addresses []string
+	for _, ipAddr := range ipAddrs {
+		if ip := ipAddr.IP.To4(); ip != nil && !slices.Contains(addresses, ip.String()) {
+			addresses = append(addresses, ip.String())
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, microerror.Maskf(apiServerHostnameNotResolvableError, "%s has no IPv4 address", hostname)
+	}
+	sort.Strings(addresses)
+
+	return addresses, nil
 }
 
-func (s *Service) getIPAddressForPublicDNS(ctx context.Context) (string, error) {
+// getIPAddressForPublicDNS returns the address of publicIP, looking up the
+// public IP resource unless its name already is an IP. The ID of the resource
+// is returned as well, empty if there was no lookup.
+func (s *Service) getIPAddressForPublicDNS(ctx context.Context, publicIP *infrav1.PublicIPSpec) (string, string, error) {
 	logger := log.FromContext(ctx).WithName("getIPAddressForPublicDNS")
 
-	logger.V(1).Info(fmt.Sprintf("resolve IP for %s/%s", s.scope.Patcher.APIServerPublicIP().Name, s.scope.Patcher.APIServerPublicIP().DNSName))
+	logger.V(1).Info(fmt.Sprintf("resolve IP for %s/%s", publicIP.Name, publicIP.DNSName))
 
-	if net.ParseIP(s.scope.Patcher.APIServerPublicIP().Name) == nil {
+	if net.ParseIP(publicIP.Name) == nil {
 		publicIPIface, err := s.publicIPsService.Get(ctx, &capzpublicips.PublicIPSpec{
-			Name:          s.scope.Patcher.APIServerPublicIP().Name,
+			Name:          publicIP.Name,
 			ResourceGroup: s.scope.ResourceGroup(),
 		})
 		if err != nil {
-			return "", microerror.Mask(err)
+			return "", "", microerror.Mask(err)
 		}
 
-		var ipaddress string
+		var ipaddress, resourceID string
 		switch v := publicIPIface.(type) {
 		case armnetwork.PublicIPAddress:
 			ipaddress = *v.Properties.IPAddress
+			resourceID = pointer.StringDeref(v.ID, "")
 		// Version used and returned by CAPZ.
 		// Can be removed when CAPZ finally upgrades from v4.
 		case armnetworkv4.PublicIPAddress:
 			ipaddress = *v.Properties.IPAddress
+			resourceID = pointer.StringDeref(v.ID, "")
 		default:
-			return "", microerror.Mask(fmt.Errorf("%T is not a armnetwork.PublicIPAddress", v))
+			return "", "", microerror.Mask(fmt.Errorf("%T is not a armnetwork.PublicIPAddress", v))
 		}
 
-		logger.V(1).Info(fmt.Sprintf("got IP %q for %s/%s", ipaddress, s.scope.Patcher.APIServerPublicIP().Name, s.scope.Patcher.APIServerPublicIP().DNSName))
+		logger.V(1).Info(fmt.Sprintf("got IP %q for %s/%s", ipaddress, publicIP.Name, publicIP.DNSName))
 
-		return ipaddress, nil
+		return ipaddress, resourceID, nil
 	}
 
-	return s.scope.Patcher.APIServerPublicIP().Name, nil
+	return publicIP.Name, "", nil
 }
 
-func (s *Service) getIngressARecord(ctx context.Context) (*armdns.RecordSet, error) {
-	k8sClient, err := s.scope.ClusterK8sClient(ctx)
-	if err != nil {
-		return nil, microerror.Mask(err)
-	}
+// getIngressRecords returns one record per hostname of the annotated
+// LoadBalancer services found by the configured ingress service namespaces and
+// selectors, see loadBalancerRecordSet. Record sets are named by hostname,
+// placing them into a zone is up to the caller. When several services claim
+// the same hostname, the oldest service wins, see listIngressServices, and the
+// conflict is reported with a Warning event naming both services.
+func (s *Service) getIngressRecords(ctx context.Context) ([]*armdns.RecordSet, error) {
+	logger := log.FromContext(ctx).WithName("getIngressRecords")
 
-	var icServices corev1.ServiceList
-	err = k8sClient.List(ctx, &icServices,
-		kubeclient.InNamespace(ingressAppNamespace),
-		&kubeclient.ListOptions{Raw: &metav1.ListOptions{LabelSelector: ingressServiceSelector}},
-	)
+	icServices, err := s.listIngressServices(ctx)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	clusterZone := s.scope.ClusterDomain()
+	var recordSets []*armdns.RecordSet
+	claimedBy := map[string]*corev1.Service{}
+	notReady := 0
+
+	for i := range icServices {
+		icService := &icServices[i]
 
-	for _, icService := range icServices.Items {
 		if icService.Annotations[externalDNSManagedAnnotation] != externalDNSManagedValue {
 			continue
 		}
-		hostname, ok := icService.Annotations[externalDNSHostnameAnnotation]
-		if !ok || hostname == "" {
+		hostnames := s.serviceHostnames(logger, icService)
+		if len(hostnames) == 0 {
 			continue
 		}
 		if icService.Spec.Type != corev1.ServiceTypeLoadBalancer {
 			continue
 		}
-		if len(icService.Status.LoadBalancer.Ingress) < 1 || icService.Status.LoadBalancer.Ingress[0].IP == "" {
-			return nil, microerror.Mask(ingressNotReadyError)
+		if !hasLoadBalancerAddress(icService) {
+			logger.V(1).Info("ingress service has no load balancer IP or hostname yet", "service", kubeclient.ObjectKeyFromObject(icService))
+			notReady++
+			continue
 		}
 
-		recordName := strings.TrimSuffix(hostname, "."+clusterZone)
-		return &armdns.RecordSet{
-			Name: pointer.String(recordName),
-			Type: pointer.String(string(armdns.RecordTypeA)),
-			Properties: &armdns.RecordSetProperties{
-				TTL: pointer.Int64(ingressRecordTTL),
-				ARecords: []*armdns.ARecord{
-					{IPv4Address: pointer.String(icService.Status.LoadBalancer.Ingress[0].IP)},
-				},
-			},
-		}, nil
+		for _, hostname := range hostnames {
+			if owner, claimed := claimedBy[hostname]; claimed {
+				logger.Info("Hostname is already claimed by another ingress service, skipping",
+					"hostname", hostname,
+					"service", kubeclient.ObjectKeyFromObject(icService),
+					"claimedBy", kubeclient.ObjectKeyFromObject(owner))
+				s.scope.Warnf("DNSHostnameConflict", "Hostname %s is claimed by the ingress services %s/%s and %s/%s, publishing it for the older service %s/%s",
+					hostname, owner.Namespace, owner.Name, icService.Namespace, icService.Name, owner.Namespace, owner.Name)
+				continue
+			}
+			claimedBy[hostname] = icService
+
+			recordSets = append(recordSets, loadBalancerRecordSet(hostname, icService.Status.LoadBalancer.Ingress[0], s.scope.RecordTTLs().Ingress))
+		}
+	}
+
+	if len(recordSets) == 0 && notReady > 0 {
+		return nil, microerror.Mask(ingressNotReadyError)
 	}
 
-	return nil, nil
+	return recordSets, nil
 }
 
-func (s *Service) getGatewayARecords(ctx context.Context) ([]*armdns.RecordSet, error) {
+// listIngressServices returns all services matching any of the configured
+// ingress selectors in any of the configured namespaces. Every service is
+// returned once, ordered by creation time and then by namespace and name, so
+// that hostname conflicts are always resolved the same way.
+func (s *Service) listIngressServices(ctx context.Context) ([]corev1.Service, error) {
+	k8sClient, err := s.scope.ClusterK8sClient(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	seen := map[kubeclient.ObjectKey]bool{}
+	var services []corev1.Service
+
+	for _, namespace := range s.scope.IngressServiceNamespaces() {
+		for _, selector := range s.scope.IngressServiceSelectors() {
+			var icServices corev1.ServiceList
+			err = k8sClient.List(ctx, &icServices,
+				kubeclient.InNamespace(namespace),
+				&kubeclient.ListOptions{Raw: &metav1.ListOptions{LabelSelector: selector}},
+			)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, icService := range icServices.Items {
+				key := kubeclient.ObjectKeyFromObject(&icService)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				services = append(services, icService)
+			}
+		}
+	}
+
+	sort.SliceStable(services, func(i, j int) bool {
+		if !services[i].CreationTimestamp.Equal(&services[j].CreationTimestamp) {
+			return services[i].CreationTimestamp.Before(&services[j].CreationTimestamp)
+		}
+		if services[i].Namespace != services[j].Namespace {
+			return services[i].Namespace < services[j].Namespace
+		}
+		return services[i].Name < services[j].Name
+	})
+
+	return services, nil
+}
+
+// getGatewayRecords returns one record per hostname of the annotated
+// LoadBalancer services in the gateway namespace, see loadBalancerRecordSet.
+// Record sets are named by hostname, placing them into a zone is up to the
+// caller.
+func (s *Service) getGatewayRecords(ctx context.Context) ([]*armdns.RecordSet, error) {
+	logger := log.FromContext(ctx).WithName("getGatewayRecords")
+
 	k8sClient, err := s.scope.ClusterK8sClient(ctx)
 	if err != nil {
 		return nil, microerror.Mask(err)
@@ -324,36 +696,93 @@ func (s *Service) getGatewayARecords(ctx context.Context) ([]*armdns.RecordSet,
 		return nil, microerror.Mask(err)
 	}
 
-	clusterZone := s.scope.ClusterDomain()
 	var recordSets []*armdns.RecordSet
 
-	for _, svc := range services.Items {
+	for i := range services.Items {
+		svc := &services.Items[i]
+
 		if svc.Annotations[externalDNSManagedAnnotation] != externalDNSManagedValue {
 			continue
 		}
-		hostname, ok := svc.Annotations[externalDNSHostnameAnnotation]
-		if !ok || hostname == "" {
+		hostnames := s.serviceHostnames(logger, svc)
+		if len(hostnames) == 0 {
 			continue
 		}
 		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
 			continue
 		}
-		if len(svc.Status.LoadBalancer.Ingress) < 1 || svc.Status.LoadBalancer.Ingress[0].IP == "" {
+		if !hasLoadBalancerAddress(svc) {
 			continue
 		}
 
-		recordName := strings.TrimSuffix(hostname, "."+clusterZone)
-		recordSets = append(recordSets, &armdns.RecordSet{
-			Name: pointer.String(recordName),
-			Type: pointer.String(string(armdns.RecordTypeA)),
+		for _, hostname := range hostnames {
+			recordSets = append(recordSets, loadBalancerRecordSet(hostname, svc.Status.LoadBalancer.Ingress[0], s.scope.RecordTTLs().Ingress))
+		}
+	}
+
+	return recordSets, nil
+}
+
+// hasLoadBalancerAddress reports whether the load balancer of svc has been
+// assigned an IP or a hostname.
+func hasLoadBalancerAddress(svc *corev1.Service) bool {
+	if len(svc.Status.LoadBalancer.Ingress) < 1 {
+		return false
+	}
+	return svc.Status.LoadBalancer.Ingress[0].IP != "" || svc.Status.LoadBalancer.Ingress[0].Hostname != ""
+}
+
+// loadBalancerRecordSet returns the record set publishing hostname for a load
+// balancer ingress. Load balancers with an IP get an A record, load balancers
+// that only publish a hostname, e.g. AWS NLBs, get a CNAME record pointing to
+// that hostname.
+func loadBalancerRecordSet(hostname string, ingress corev1.LoadBalancerIngress, ttl int64) *armdns.RecordSet {
+	if ingress.IP == "" {
+		return &armdns.RecordSet{
+			Name: pointer.String(hostname),
+			Type: pointer.String(string(armdns.RecordTypeCNAME)),
 			Properties: &armdns.RecordSetProperties{
-				TTL: pointer.Int64(gatewayRecordTTL),
-				ARecords: []*armdns.ARecord{
-					{IPv4Address: pointer.String(svc.Status.LoadBalancer.Ingress[0].IP)},
+				TTL: pointer.Int64(ttl),
+				CnameRecord: &armdns.CnameRecord{
+					Cname: pointer.String(strings.TrimSuffix(strings.ToLower(ingress.Hostname), ".")),
 				},
 			},
-		})
+		}
 	}
 
-	return recordSets, nil
+	return &armdns.RecordSet{
+		Name: pointer.String(hostname),
+		Type: pointer.String(string(armdns.RecordTypeA)),
+		Properties: &armdns.RecordSetProperties{
+			TTL: pointer.Int64(ttl),
+			ARecords: []*armdns.ARecord{
+				{IPv4Address: pointer.String(ingress.IP)},
+			},
+		},
+	}
+}
+
+// serviceHostnames returns the valid hostnames of the external-dns hostname
+// annotation of svc. Invalid hostnames are reported and dropped.
+func (s *Service) serviceHostnames(logger logr.Logger, svc *corev1.Service) []string {
+	hostnames, invalid := parseHostnames(svc.Annotations[externalDNSHostnameAnnotation])
+	for _, hostname := range invalid {
+		logger.Info("Invalid hostname in service annotation, skipping", "hostname", hostname, "service", kubeclient.ObjectKeyFromObject(svc))
+		s.scope.Warnf("DNSHostnameRejected", "Hostname %q of service %s/%s is not a valid DNS name", hostname, svc.Namespace, svc.Name)
+	}
+	return hostnames
+}
+
+// appendUniqueRecordSets appends the candidates to recordSets, skipping any
+// candidate whose name is already taken. Records that were added first, e.g.
+// the api records, therefore always win over service-derived records.
+func appendUniqueRecordSets(logger logr.Logger, recordSets []*armdns.RecordSet, candidates []*armdns.RecordSet) []*armdns.RecordSet {
+	for _, candidate := range candidates {
+		if slices.ContainsFunc(recordSets, func(recordSet *armdns.RecordSet) bool { return *recordSet.Name == *candidate.Name }) {
+			logger.Info("DNS record name is already claimed, skipping", "name", *candidate.Name)
+			continue
+		}
+		recordSets = append(recordSets, candidate)
+	}
+	return recordSets
 }
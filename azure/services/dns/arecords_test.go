@@ -3,10 +3,14 @@ package dns
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v9"
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
@@ -171,7 +175,8 @@ func TestService_calculateMissingARecords(t *testing.T) {
 								IPv4Address: pointer.String("192.168.2.6"),
 							},
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("api"),
 					Type: pointer.String("A"),
@@ -183,7 +188,8 @@ func TestService_calculateMissingARecords(t *testing.T) {
 								IPv4Address: pointer.String("192.168.2.6"),
 							},
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("apiserver"),
 					Type: pointer.String("A"),
@@ -265,16 +271,18 @@ func TestService_calculateMissingARecords(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			// inject empty workload cluster client so getGatewayARecords finds no services
+			// inject empty workload cluster client so getGatewayRecords finds no services
 			dnsService.scope.SetClusterK8sClient(
 				fakeclient.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
 			)
 
-			got, err := dnsService.calculateMissingARecords(tt.args.ctx, tt.args.logger, tt.args.currentRecordSets)
+			desired, err := dnsService.getDesiredARecords(tt.args.ctx)
 			if err != nil {
-				t.Errorf("Service.calculateMissingARecords() error = %v", err)
 				t.Fatal(err)
 			}
+
+			clusterZone := dnsService.managedZones()[0]
+			got := dnsService.calculateMissingARecords(tt.args.logger, clusterZone, desired[clusterZone.name], tt.args.currentRecordSets)
 			if !reflect.DeepEqual(got, tt.want) {
 				gotJSON, err := json.Marshal(got)
 				if err != nil {
@@ -439,7 +447,7 @@ func newGatewayTestService(t *testing.T, ctx context.Context, wcServices []*core
 	return dnsService
 }
 
-func TestService_getGatewayARecords(t *testing.T) {
+func TestService_getGatewayRecords(t *testing.T) {
 	// Cluster domain for the test service: test-cluster.basedomain.io
 	ctx := context.TODO()
 
@@ -571,15 +579,46 @@ func TestService_getGatewayARecords(t *testing.T) {
 			},
 			want: []*armdns.RecordSet{
 				{
-					Name: pointer.String("gw"),
+					Name: pointer.String("gw.test-cluster.basedomain.io"),
 					Type: pointer.String("A"),
 					Properties: &armdns.RecordSetProperties{
-						TTL:      pointer.Int64(gatewayRecordTTL),
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
 						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
 					},
 				},
 			},
 		},
+		{
+			name: "creates CNAME record for gateway service with load balancer hostname",
+			services: []*corev1.Service{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "envoy-gateway",
+						Namespace: gatewayNamespace,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "gw.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{Hostname: "gw.lb.example.com"}},
+						},
+					},
+				},
+			},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("gw.test-cluster.basedomain.io"),
+					Type: pointer.String("CNAME"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+						CnameRecord: &armdns.CnameRecord{Cname: pointer.String("gw.lb.example.com")},
+					},
+				},
+			},
+		},
 		{
 			name: "creates A records for multiple valid gateway services",
 			services: []*corev1.Service{
@@ -618,18 +657,18 @@ func TestService_getGatewayARecords(t *testing.T) {
 			},
 			want: []*armdns.RecordSet{
 				{
-					Name: pointer.String("app1"),
+					Name: pointer.String("app1.test-cluster.basedomain.io"),
 					Type: pointer.String("A"),
 					Properties: &armdns.RecordSetProperties{
-						TTL:      pointer.Int64(gatewayRecordTTL),
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
 						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
 					},
 				},
 				{
-					Name: pointer.String("app2"),
+					Name: pointer.String("app2.test-cluster.basedomain.io"),
 					Type: pointer.String("A"),
 					Properties: &armdns.RecordSetProperties{
-						TTL:      pointer.Int64(gatewayRecordTTL),
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
 						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("5.6.7.8")}},
 					},
 				},
@@ -693,10 +732,10 @@ func TestService_getGatewayARecords(t *testing.T) {
 			},
 			want: []*armdns.RecordSet{
 				{
-					Name: pointer.String("gw"),
+					Name: pointer.String("gw.test-cluster.basedomain.io"),
 					Type: pointer.String("A"),
 					Properties: &armdns.RecordSetProperties{
-						TTL:      pointer.Int64(gatewayRecordTTL),
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
 						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
 					},
 				},
@@ -708,7 +747,7 @@ func TestService_getGatewayARecords(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := newGatewayTestService(t, ctx, tt.services)
 
-			got, err := svc.getGatewayARecords(ctx)
+			got, err := svc.getGatewayRecords(ctx)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -716,24 +755,29 @@ func TestService_getGatewayARecords(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				gotJSON, _ := json.Marshal(got)
 				wantJSON, _ := json.Marshal(tt.want)
-				t.Errorf("getGatewayARecords() = %s, want %s", gotJSON, wantJSON)
+				t.Errorf("getGatewayRecords() = %s, want %s", gotJSON, wantJSON)
 			}
 		})
 	}
 }
 
-func TestService_getIngressARecord(t *testing.T) {
+func TestService_getIngressRecords(t *testing.T) {
 	// Cluster domain for the test service: test-cluster.basedomain.io
 	ctx := context.TODO()
 
-	// ingressLabels satisfies the ingressServiceSelector label selector.
+	// ingressLabels satisfies the default ingress service selector.
 	ingressLabels := map[string]string{"app.kubernetes.io/name": "ingress-nginx"}
 
+	// older and newer are used to order services by creation time.
+	older := v1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	newer := v1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
 	tests := []struct {
-		name        string
-		services    []*corev1.Service
-		want        *armdns.RecordSet
-		wantErrKind string
+		name               string
+		clusterAnnotations map[string]string
+		services           []*corev1.Service
+		want               []*armdns.RecordSet
+		wantErrKind        string
 	}{
 		{
 			name:     "returns nil when no services in namespace",
@@ -746,7 +790,7 @@ func TestService_getIngressARecord(t *testing.T) {
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 					},
 					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
@@ -765,7 +809,7 @@ func TestService_getIngressARecord(t *testing.T) {
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 						Annotations: map[string]string{
 							externalDNSManagedAnnotation:  "not-managed",
@@ -788,7 +832,7 @@ func TestService_getIngressARecord(t *testing.T) {
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 						Annotations: map[string]string{
 							externalDNSManagedAnnotation: externalDNSManagedValue,
@@ -810,7 +854,7 @@ func TestService_getIngressARecord(t *testing.T) {
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 						Annotations: map[string]string{
 							externalDNSManagedAnnotation:  externalDNSManagedValue,
@@ -828,7 +872,7 @@ func TestService_getIngressARecord(t *testing.T) {
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 						Annotations: map[string]string{
 							externalDNSManagedAnnotation:  externalDNSManagedValue,
@@ -842,13 +886,45 @@ func TestService_getIngressARecord(t *testing.T) {
 			want:        nil,
 			wantErrKind: ingressNotReadyError.Kind,
 		},
+		{
+			name: "creates CNAME record for load balancer with hostname only",
+			services: []*corev1.Service{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "ingress-nginx",
+						Namespace: scope.DefaultIngressServiceNamespace,
+						Labels:    ingressLabels,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{Hostname: "Abc123.elb.eu-west-1.amazonaws.com."}},
+						},
+					},
+				},
+			},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("ingress.test-cluster.basedomain.io"),
+					Type: pointer.String("CNAME"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+						CnameRecord: &armdns.CnameRecord{Cname: pointer.String("abc123.elb.eu-west-1.amazonaws.com")},
+					},
+				},
+			},
+		},
 		{
 			name: "creates A record with name from hostname annotation",
 			services: []*corev1.Service{
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 						Annotations: map[string]string{
 							externalDNSManagedAnnotation:  externalDNSManagedValue,
@@ -863,12 +939,14 @@ func TestService_getIngressARecord(t *testing.T) {
 					},
 				},
 			},
-			want: &armdns.RecordSet{
-				Name: pointer.String("ingress"),
-				Type: pointer.String("A"),
-				Properties: &armdns.RecordSetProperties{
-					TTL:      pointer.Int64(ingressRecordTTL),
-					ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("ingress.test-cluster.basedomain.io"),
+					Type: pointer.String("A"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+					},
 				},
 			},
 		},
@@ -878,7 +956,7 @@ func TestService_getIngressARecord(t *testing.T) {
 				{
 					ObjectMeta: v1.ObjectMeta{
 						Name:      "ingress-nginx",
-						Namespace: ingressAppNamespace,
+						Namespace: scope.DefaultIngressServiceNamespace,
 						Labels:    ingressLabels,
 						Annotations: map[string]string{
 							externalDNSManagedAnnotation:  externalDNSManagedValue,
@@ -893,12 +971,194 @@ func TestService_getIngressARecord(t *testing.T) {
 					},
 				},
 			},
-			want: &armdns.RecordSet{
-				Name: pointer.String("my-ingress"),
-				Type: pointer.String("A"),
-				Properties: &armdns.RecordSetProperties{
-					TTL:      pointer.Int64(ingressRecordTTL),
-					ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("5.6.7.8")}},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("my-ingress.test-cluster.basedomain.io"),
+					Type: pointer.String("A"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("5.6.7.8")}},
+					},
+				},
+			},
+		},
+		{
+			name: "creates one A record per ingress controller across configured namespaces",
+			clusterAnnotations: map[string]string{
+				scope.AnnotationIngressServiceNamespaces: "kube-system, ingress-internal",
+				scope.AnnotationIngressServiceSelectors:  "app.kubernetes.io/name in (ingress-nginx,nginx-ingress-controller); app.kubernetes.io/name=ingress-nginx-internal",
+			},
+			services: []*corev1.Service{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "ingress-nginx",
+						Namespace: scope.DefaultIngressServiceNamespace,
+						Labels:    ingressLabels,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+						},
+					},
+				},
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "ingress-nginx-internal",
+						Namespace: "ingress-internal",
+						Labels:    map[string]string{"app.kubernetes.io/name": "ingress-nginx-internal"},
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress-internal.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{IP: "10.0.0.10"}},
+						},
+					},
+				},
+			},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("ingress-internal.test-cluster.basedomain.io"),
+					Type: pointer.String("A"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("10.0.0.10")}},
+					},
+				},
+				{
+					Name: pointer.String("ingress.test-cluster.basedomain.io"),
+					Type: pointer.String("A"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+					},
+				},
+			},
+		},
+		{
+			name: "ignores ingress controllers outside the default namespace without annotation",
+			services: []*corev1.Service{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "ingress-nginx",
+						Namespace: "ingress-internal",
+						Labels:    ingressLabels,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+						},
+					},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "oldest service wins when two services claim the same hostname",
+			services: []*corev1.Service{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:              "a-ingress-nginx-new",
+						Namespace:         scope.DefaultIngressServiceNamespace,
+						Labels:            ingressLabels,
+						CreationTimestamp: newer,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{IP: "5.6.7.8"}},
+						},
+					},
+				},
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:              "b-ingress-nginx-old",
+						Namespace:         scope.DefaultIngressServiceNamespace,
+						Labels:            ingressLabels,
+						CreationTimestamp: older,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+						},
+					},
+				},
+			},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("ingress.test-cluster.basedomain.io"),
+					Type: pointer.String("A"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+					},
+				},
+			},
+		},
+		{
+			name: "skips services that are not ready when another one is",
+			services: []*corev1.Service{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "ingress-nginx",
+						Namespace: scope.DefaultIngressServiceNamespace,
+						Labels:    ingressLabels,
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress.test-cluster.basedomain.io",
+						},
+					},
+					Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{
+						LoadBalancer: corev1.LoadBalancerStatus{
+							Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+						},
+					},
+				},
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "nginx-ingress-controller",
+						Namespace: scope.DefaultIngressServiceNamespace,
+						Labels:    map[string]string{"app.kubernetes.io/name": "nginx-ingress-controller"},
+						Annotations: map[string]string{
+							externalDNSManagedAnnotation:  externalDNSManagedValue,
+							externalDNSHostnameAnnotation: "ingress-2.test-cluster.basedomain.io",
+						},
+					},
+					Spec:   corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+					Status: corev1.ServiceStatus{},
+				},
+			},
+			want: []*armdns.RecordSet{
+				{
+					Name: pointer.String("ingress.test-cluster.basedomain.io"),
+					Type: pointer.String("A"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+						ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+					},
 				},
 			},
 		},
@@ -907,8 +1167,9 @@ func TestService_getIngressARecord(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := newGatewayTestService(t, ctx, tt.services)
+			svc.scope.Cluster.SetAnnotations(tt.clusterAnnotations)
 
-			got, err := svc.getIngressARecord(ctx)
+			got, err := svc.getIngressRecords(ctx)
 			if tt.wantErrKind != "" {
 				if err == nil {
 					t.Fatalf("expected error with kind %q, got nil", tt.wantErrKind)
@@ -925,7 +1186,7 @@ func TestService_getIngressARecord(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				gotJSON, _ := json.Marshal(got)
 				wantJSON, _ := json.Marshal(tt.want)
-				t.Errorf("getIngressARecord() = %s, want %s", gotJSON, wantJSON)
+				t.Errorf("getIngressRecords() = %s, want %s", gotJSON, wantJSON)
 			}
 		})
 	}
@@ -1025,3 +1286,457 @@ func TestService_getDesiredARecords_ASOManagedCluster(t *testing.T) {
 		t.Errorf("getDesiredARecords() for AKS cluster = %s, want no records", gotJSON)
 	}
 }
+
+type fakeResolver map[string][]net.IPAddr
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ipAddrs, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ipAddrs, nil
+}
+
+func TestService_getAPIServerRecords_hostnameEndpoint(t *testing.T) {
+	ctx := context.TODO()
+
+	resolver := fakeResolver{
+		"lb.example.com": {
+			{IP: net.ParseIP("10.0.0.5")},
+			{IP: net.ParseIP("2001:db8::1")},
+			{IP: net.ParseIP("10.0.0.4")},
+		},
+		"ipv6-only.example.com": {
+			{IP: net.ParseIP("2001:db8::1")},
+		},
+		"mixed-lb.example.com": {
+			{IP: net.ParseIP("10.0.0.4")},
+			{IP: net.ParseIP("20.1.2.3")},
+		},
+	}
+
+	cnameRecord := func(name string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String("CNAME"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:         pointer.Int64(scope.DefaultAPIRecordTTL),
+				CnameRecord: &armdns.CnameRecord{Cname: pointer.String("lb.example.com")},
+			},
+		}
+	}
+	aRecord := func(name string, addresses ...string) *armdns.RecordSet {
+		recordSet := &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String("A"),
+			Properties: &armdns.RecordSetProperties{
+				TTL: pointer.Int64(scope.DefaultAPIRecordTTL),
+			},
+		}
+		for _, address := range addresses {
+			recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(address)})
+		}
+		return recordSet
+	}
+
+	tests := []struct {
+		name               string
+		host               string
+		operatorMode       string
+		privateRecordsMode string
+		clusterAnnotations map[string]string
+		want               []*armdns.RecordSet
+		wantErr            func(error) bool
+	}{
+		{
+			name: "publishes CNAME records by default",
+			host: "LB.example.com.",
+			want: []*armdns.RecordSet{cnameRecord(apiRecordName), cnameRecord(apiserverRecordName)},
+		},
+		{
+			name:         "publishes only the public resolved IPv4 addresses in resolve mode",
+			host:         "mixed-lb.example.com",
+			operatorMode: scope.APIServerHostnameModeResolve,
+			want:         []*armdns.RecordSet{aRecord(apiRecordName, "20.1.2.3"), aRecord(apiserverRecordName, "20.1.2.3")},
+		},
+		{
+			name:         "publishes no records for a hostname resolving to internal addresses only",
+			host:         "lb.example.com",
+			operatorMode: scope.APIServerHostnameModeResolve,
+			want:         nil,
+		},
+		{
+			name:               "keeps internal resolved addresses for the private zone of split-horizon clusters",
+			host:               "lb.example.com",
+			operatorMode:       scope.APIServerHostnameModeResolve,
+			privateRecordsMode: scope.PrivateRecordsModeSplitHorizon,
+			want:               []*armdns.RecordSet{aRecord(apiRecordName, "10.0.0.4", "10.0.0.5"), aRecord(apiserverRecordName, "10.0.0.4", "10.0.0.5")},
+		},
+		{
+			name:               "cluster annotation overrides the operator mode",
+			host:               "lb.example.com",
+			operatorMode:       scope.APIServerHostnameModeResolve,
+			clusterAnnotations: map[string]string{scope.AnnotationAPIServerHostnameMode: scope.APIServerHostnameModeCNAME},
+			want:               []*armdns.RecordSet{cnameRecord(apiRecordName), cnameRecord(apiserverRecordName)},
+		},
+		{
+			name:               "invalid cluster annotation falls back to the operator mode",
+			host:               "mixed-lb.example.com",
+			operatorMode:       scope.APIServerHostnameModeResolve,
+			clusterAnnotations: map[string]string{scope.AnnotationAPIServerHostnameMode: "alias"},
+			want:               []*armdns.RecordSet{aRecord(apiRecordName, "20.1.2.3"), aRecord(apiserverRecordName, "20.1.2.3")},
+		},
+		{
+			name:         "fails when the hostname has no IPv4 address",
+			host:         "ipv6-only.example.com",
+			operatorMode: scope.APIServerHostnameModeResolve,
+			wantErr:      IsAPIServerHostnameNotResolvable,
+		},
+		{
+			name:         "fails when the hostname does not resolve",
+			host:         "unknown.example.com",
+			operatorMode: scope.APIServerHostnameModeResolve,
+			wantErr:      IsAPIServerHostnameNotResolvable,
+		},
+		{
+			name: "skips api records pointing to themselves",
+			host: "api.test-cluster.basedomain.io",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newGatewayTestService(t, ctx, nil)
+
+			// turn the cluster into a non-Azure cluster with a hostname endpoint
+			svc.scope.InfraCluster.SetKind("DockerCluster")
+			svc.scope.Cluster.Spec.ControlPlaneEndpoint.Host = tt.host
+			svc.scope.Cluster.SetAnnotations(tt.clusterAnnotations)
+
+			dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+				ClusterScope:            &svc.scope.Scope,
+				BaseDomain:              svc.scope.BaseDomain(),
+				BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+				BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+				APIServerHostnameMode:   tt.operatorMode,
+				PrivateRecordsMode:      tt.privateRecordsMode,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc.scope = *dnsScope
+			svc.resolver = resolver
+
+			got, err := svc.getAPIServerRecords(ctx)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("getAPIServerRecords() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestService_getAPIServerRecords_frontends(t *testing.T) {
+	ctx := context.TODO()
+
+	aRecord := func(name string, addresses ...string) *armdns.RecordSet {
+		recordSet := &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String("A"),
+			Properties: &armdns.RecordSetProperties{TTL: pointer.Int64(scope.DefaultAPIRecordTTL)},
+		}
+		for _, address := range addresses {
+			recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(address)})
+		}
+		return recordSet
+	}
+
+	tests := []struct {
+		name               string
+		apiServerLB        *infrav1.LoadBalancerSpec
+		endpoint           string
+		clusterAnnotations map[string]string
+		want               []*armdns.RecordSet
+	}{
+		{
+			name: "case0: all public frontends of the API server load balancer, sorted",
+			apiServerLB: &infrav1.LoadBalancerSpec{
+				FrontendIPs: []infrav1.FrontendIP{
+					{PublicIP: &infrav1.PublicIPSpec{Name: "5.6.7.8"}},
+					{PublicIP: &infrav1.PublicIPSpec{Name: "1.2.3.4"}},
+					{PublicIP: &infrav1.PublicIPSpec{Name: "5.6.7.8"}},
+				},
+			},
+			want: []*armdns.RecordSet{
+				aRecord(apiRecordName, "1.2.3.4", "5.6.7.8"),
+				aRecord(apiserverRecordName, "1.2.3.4", "5.6.7.8"),
+			},
+		},
+		{
+			name: "case1: all private frontends of an internal API server load balancer",
+			apiServerLB: &infrav1.LoadBalancerSpec{
+				LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{Type: infrav1.Internal},
+				FrontendIPs: []infrav1.FrontendIP{
+					{FrontendIPClass: infrav1.FrontendIPClass{PrivateIPAddress: "192.168.2.7"}},
+					{FrontendIPClass: infrav1.FrontendIPClass{PrivateIPAddress: "192.168.2.6"}},
+				},
+			},
+			want: []*armdns.RecordSet{
+				aRecord(apiRecordName, "192.168.2.6", "192.168.2.7"),
+				aRecord(apiserverRecordName, "192.168.2.6", "192.168.2.7"),
+			},
+		},
+		{
+			name:     "case2: control plane endpoint and further addresses of non-Azure clusters",
+			endpoint: "5.6.7.8",
+			clusterAnnotations: map[string]string{
+				infracluster.AnnotationAPIServerAddresses: "9.9.9.9, 1.2.3.4,not-an-ip",
+			},
+			want: []*armdns.RecordSet{
+				aRecord(apiRecordName, "1.2.3.4", "5.6.7.8", "9.9.9.9"),
+				aRecord(apiserverRecordName, "1.2.3.4", "5.6.7.8", "9.9.9.9"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newZonesTestService(t, ctx, nil)
+
+			if tt.apiServerLB != nil {
+				svc.scope.Patcher.(*capzscope.ClusterScope).AzureCluster.Spec.NetworkSpec.APIServerLB = tt.apiServerLB
+			} else {
+				svc.scope.InfraCluster.SetKind("DockerCluster")
+				svc.scope.Cluster.Spec.ControlPlaneEndpoint.Host = tt.endpoint
+				svc.scope.Cluster.SetAnnotations(tt.clusterAnnotations)
+
+				patcher, err := infracluster.NewCommonPatcher(ctx, infracluster.CommonPatcherParams{
+					Cluster:      svc.scope.Cluster,
+					InfraCluster: svc.scope.InfraCluster,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				svc.scope.Patcher = patcher
+			}
+
+			got, err := svc.getAPIServerRecords(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("getAPIServerRecords() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestService_getProviderRecords(t *testing.T) {
+	ctx := context.TODO()
+
+	aRecord := func(name, address string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String("A"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String(address)}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		privateRecordsMode string
+		want               []*armdns.RecordSet
+	}{
+		{
+			name: "skips records with internal addresses in the public zone",
+			want: []*armdns.RecordSet{
+				aRecord(infracluster.OpenStackFloatingIPRecordName, "20.1.2.3"),
+			},
+		},
+		{
+			name:               "keeps records with internal addresses for the private zone of split-horizon clusters",
+			privateRecordsMode: scope.PrivateRecordsModeSplitHorizon,
+			want: []*armdns.RecordSet{
+				aRecord(infracluster.OpenStackFloatingIPRecordName, "20.1.2.3"),
+				aRecord(infracluster.OpenStackInternalAPIRecordName, "10.6.0.10"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newGatewayTestService(t, ctx, nil)
+
+			// turn the cluster into an OpenStack cluster with a load balancer
+			svc.scope.InfraCluster.SetKind("OpenStackCluster")
+			err := unstructured.SetNestedMap(svc.scope.InfraCluster.Object, map[string]interface{}{
+				"ready":                 true,
+				"apiServerLoadBalancer": map[string]interface{}{"ip": "20.1.2.3", "internalIP": "10.6.0.10"},
+			}, "status")
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc.scope.Provider = infracluster.ProviderFor(svc.scope.InfraCluster.GroupVersionKind().GroupKind())
+
+			dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+				ClusterScope:            &svc.scope.Scope,
+				BaseDomain:              svc.scope.BaseDomain(),
+				BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+				BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+				PrivateRecordsMode:      tt.privateRecordsMode,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc.scope = *dnsScope
+
+			got := svc.getProviderRecords()
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("getProviderRecords() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// fakePublicIPs returns the public IP addresses by name.
+type fakePublicIPs map[string]armnetwork.PublicIPAddress
+
+func (f fakePublicIPs) Get(_ context.Context, spec azure.ResourceSpecGetter) (any, error) {
+	publicIP, ok := f[spec.ResourceName()]
+	if !ok {
+		return nil, microerror.Mask(errors.New("public IP not found"))
+	}
+	return publicIP, nil
+}
+
+func TestService_getAPIServerRecords_alias(t *testing.T) {
+	ctx := context.TODO()
+
+	const (
+		publicIPID      = "/subscriptions/123/resourceGroups/test-cluster/providers/Microsoft.Network/publicIPAddresses/pip-test-cluster-apiserver"
+		extraPublicIPID = "/subscriptions/123/resourceGroups/test-cluster/providers/Microsoft.Network/publicIPAddresses/pip-test-cluster-apiserver-2"
+	)
+
+	publicIPs := fakePublicIPs{
+		"pip-test-cluster-apiserver": {
+			ID:         pointer.String(publicIPID),
+			Properties: &armnetwork.PublicIPAddressPropertiesFormat{IPAddress: pointer.String("20.1.2.3")},
+		},
+		"pip-test-cluster-apiserver-2": {
+			ID:         pointer.String(extraPublicIPID),
+			Properties: &armnetwork.PublicIPAddressPropertiesFormat{IPAddress: pointer.String("20.1.2.4")},
+		},
+	}
+
+	aRecord := func(name string, addresses ...string) *armdns.RecordSet {
+		recordSet := &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String("A"),
+			Properties: &armdns.RecordSetProperties{TTL: pointer.Int64(scope.DefaultAPIRecordTTL)},
+		}
+		for _, address := range addresses {
+			recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(address)})
+		}
+		return recordSet
+	}
+	aliasRecord := func(name string) *armdns.RecordSet {
+		recordSet := aRecord(name)
+		recordSet.Properties.TargetResource = &armdns.SubResource{ID: pointer.String(publicIPID)}
+		return recordSet
+	}
+
+	tests := []struct {
+		name               string
+		publicIPNames      []string
+		recordMode         string
+		clusterAnnotations map[string]string
+		want               []*armdns.RecordSet
+	}{
+		{
+			name:          "case0: address mode publishes the address of the public IP",
+			publicIPNames: []string{"pip-test-cluster-apiserver"},
+			want:          []*armdns.RecordSet{aRecord(apiRecordName, "20.1.2.3"), aRecord(apiserverRecordName, "20.1.2.3")},
+		},
+		{
+			name:          "case1: alias mode points at the public IP resource",
+			publicIPNames: []string{"pip-test-cluster-apiserver"},
+			recordMode:    scope.APIServerRecordModeAlias,
+			want:          []*armdns.RecordSet{aliasRecord(apiRecordName), aliasRecord(apiserverRecordName)},
+		},
+		{
+			name:               "case2: cluster annotation overrides the operator mode",
+			publicIPNames:      []string{"pip-test-cluster-apiserver"},
+			clusterAnnotations: map[string]string{scope.AnnotationAPIServerRecordMode: scope.APIServerRecordModeAlias},
+			want:               []*armdns.RecordSet{aliasRecord(apiRecordName), aliasRecord(apiserverRecordName)},
+		},
+		{
+			name:          "case3: several public IPs keep A records in alias mode",
+			publicIPNames: []string{"pip-test-cluster-apiserver", "pip-test-cluster-apiserver-2"},
+			recordMode:    scope.APIServerRecordModeAlias,
+			want:          []*armdns.RecordSet{aRecord(apiRecordName, "20.1.2.3", "20.1.2.4"), aRecord(apiserverRecordName, "20.1.2.3", "20.1.2.4")},
+		},
+		{
+			name:          "case4: public IPs given as address keep A records in alias mode",
+			publicIPNames: []string{"1.2.3.4"},
+			recordMode:    scope.APIServerRecordModeAlias,
+			want:          []*armdns.RecordSet{aRecord(apiRecordName, "1.2.3.4"), aRecord(apiserverRecordName, "1.2.3.4")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newZonesTestService(t, ctx, nil)
+
+			var frontendIPs []infrav1.FrontendIP
+			for _, name := range tt.publicIPNames {
+				frontendIPs = append(frontendIPs, infrav1.FrontendIP{PublicIP: &infrav1.PublicIPSpec{Name: name}})
+			}
+			svc.scope.Patcher.(*capzscope.ClusterScope).AzureCluster.Spec.NetworkSpec.APIServerLB = &infrav1.LoadBalancerSpec{FrontendIPs: frontendIPs}
+			svc.scope.Cluster.SetAnnotations(tt.clusterAnnotations)
+			svc.publicIPsService = publicIPs
+
+			dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+				ClusterScope:            &svc.scope.Scope,
+				BaseDomain:              svc.scope.BaseDomain(),
+				BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+				BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+				APIServerRecordMode:     tt.recordMode,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc.scope = *dnsScope
+
+			got, err := svc.getAPIServerRecords(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("getAPIServerRecords() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
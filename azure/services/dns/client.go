@@ -2,6 +2,7 @@ package dns
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
@@ -18,12 +19,40 @@ type azureClient struct {
 	zones          *armdns.ZonesClient
 	recordSets     *armdns.RecordSetsClient
 	resourceGroups *armresources.ResourceGroupsClient
+	resources      *armresources.Client
 }
 
 var _ client = (*azureClient)(nil)
 
-func newAzureClient(scope scope.DNSScope) (*azureClient, error) {
+func newAzureClient(scope scope.DNSScope, cred azcore.TokenCredential) (*azureClient, error) {
+	zonesClient, err := newZonesClient(scope.Patcher.SubscriptionID(), cred)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	recordSetsClient, err := newRecordSetsClient(scope.Patcher.SubscriptionID(), cred)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	resourceGroupsClient, err := newResourceGroupClient(scope.Patcher.SubscriptionID(), cred)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	resourcesClient, err := newResourcesClient(scope.Patcher.SubscriptionID(), cred)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
+	return &azureClient{
+		zones:          zonesClient,
+		recordSets:     recordSetsClient,
+		resourceGroups: resourceGroupsClient,
+		resources:      resourcesClient,
+	}, nil
+}
+
+// newClusterCredential returns the credential of the identity of the cluster,
+// which the cluster zone and its resource group are managed with.
+func newClusterCredential(scope scope.DNSScope) (azcore.TokenCredential, error) {
 	clusterIdentity := scope.AzureClusterIdentity()
 
 	var cred azcore.TokenCredential
@@ -56,24 +85,7 @@ func newAzureClient(scope scope.DNSScope) (*azureClient, error) {
 		}
 	}
 
-	zonesClient, err := newZonesClient(scope.Patcher.SubscriptionID(), cred)
-	if err != nil {
-		return nil, microerror.Mask(err)
-	}
-	recordSetsClient, err := newRecordSetsClient(scope.Patcher.SubscriptionID(), cred)
-	if err != nil {
-		return nil, microerror.Mask(err)
-	}
-	resourceGroupsClient, err := newResourceGroupClient(scope.Patcher.SubscriptionID(), cred)
-	if err != nil {
-		return nil, microerror.Mask(err)
-	}
-
-	return &azureClient{
-		zones:          zonesClient,
-		recordSets:     recordSetsClient,
-		resourceGroups: resourceGroupsClient,
-	}, nil
+	return cred, nil
 }
 
 func newBaseZoneClient(credentials scope.BaseZoneCredentials) (*azureClient, error) {
@@ -97,10 +109,16 @@ func newBaseZoneClient(credentials scope.BaseZoneCredentials) (*azureClient, err
 		return nil, microerror.Mask(err)
 	}
 
+	resourcesClient, err := newResourcesClient(credentials.SubscriptionID, cred)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	return &azureClient{
 		zones:          zonesClient,
 		recordSets:     recordSetsClient,
 		resourceGroups: resourceGroupsClient,
+		resources:      resourcesClient,
 	}, nil
 }
 
@@ -116,6 +134,10 @@ func newResourceGroupClient(subscriptionID string, cred azcore.TokenCredential)
 	return armresources.NewResourceGroupsClient(subscriptionID, cred, nil)
 }
 
+func newResourcesClient(subscriptionID string, cred azcore.TokenCredential) (*armresources.Client, error) {
+	return armresources.NewClient(subscriptionID, cred, nil)
+}
+
 func (ac *azureClient) GetZone(ctx context.Context, resourceGroupName string, zoneName string) (armdns.Zone, error) {
 
 	// dns_operator_api_request_total{controller="dns-operator-azure",method="zones.Get"}
@@ -146,6 +168,23 @@ func (ac *azureClient) CreateOrUpdateZone(ctx context.Context, resourceGroupName
 	return zoneResult.Zone, nil
 }
 
+// UpdateZoneTags replaces the tags of the zone with a PATCH request, leaving
+// all other properties of the zone alone.
+func (ac *azureClient) UpdateZoneTags(ctx context.Context, resourceGroupName string, zoneName string, tags map[string]*string) (armdns.Zone, error) {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="zones.Update"}
+	metrics.AzureRequest.WithLabelValues("zones.Update").Inc()
+
+	resp, err := ac.zones.Update(ctx, resourceGroupName, zoneName, armdns.ZoneUpdate{Tags: tags}, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="zones.Update"}
+		metrics.AzureRequestError.WithLabelValues("zones.Update").Inc()
+		return armdns.Zone{}, microerror.Mask(err)
+	}
+
+	return resp.Zone, nil
+}
+
 func (ac *azureClient) DeleteZone(ctx context.Context, resourceGroupName string, zoneName string) error {
 
 	// dns_operator_api_request_total{controller="dns-operator-azure",method="zones.Get"}
@@ -268,3 +307,120 @@ func (ac *azureClient) DeleteResourceGroup(ctx context.Context, resourceGroupNam
 
 	return nil
 }
+
+func (ac *azureClient) ListZones(ctx context.Context) ([]*armdns.Zone, error) {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="zones.NewListPager"}
+	metrics.AzureRequest.WithLabelValues("zones.NewListPager").Inc()
+
+	zonesResultPager := ac.zones.NewListPager(nil)
+	var zones []*armdns.Zone
+	for zonesResultPager.More() {
+		nextPage, err := zonesResultPager.NextPage(ctx)
+		if err != nil {
+			// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="zones.NewListPager"}
+			metrics.AzureRequestError.WithLabelValues("zones.NewListPager").Inc()
+			return nil, microerror.Mask(err)
+		}
+		zones = append(zones, nextPage.Value...)
+	}
+
+	return zones, nil
+}
+
+// ListResourceGroups lists the resource groups of the subscription that carry
+// the tag tagName.
+func (ac *azureClient) ListResourceGroups(ctx context.Context, tagName string) ([]*armresources.ResourceGroup, error) {
+	metrics.AzureRequest.WithLabelValues("resourceGroups.NewListPager").Inc()
+
+	filter := fmt.Sprintf("tagName eq '%s'", tagName)
+	resourceGroupsResultPager := ac.resourceGroups.NewListPager(&armresources.ResourceGroupsClientListOptions{Filter: &filter})
+	var resourceGroups []*armresources.ResourceGroup
+	for resourceGroupsResultPager.More() {
+		nextPage, err := resourceGroupsResultPager.NextPage(ctx)
+		if err != nil {
+			metrics.AzureRequestError.WithLabelValues("resourceGroups.NewListPager").Inc()
+			return nil, microerror.Mask(err)
+		}
+		resourceGroups = append(resourceGroups, nextPage.Value...)
+	}
+
+	return resourceGroups, nil
+}
+
+// ListResourceGroupResources lists the resources in the resource group.
+func (ac *azureClient) ListResourceGroupResources(ctx context.Context, resourceGroupName string) ([]*armresources.GenericResourceExpanded, error) {
+	metrics.AzureRequest.WithLabelValues("resources.NewListByResourceGroupPager").Inc()
+
+	resourcesResultPager := ac.resources.NewListByResourceGroupPager(resourceGroupName, nil)
+	var resources []*armresources.GenericResourceExpanded
+	for resourcesResultPager.More() {
+		nextPage, err := resourcesResultPager.NextPage(ctx)
+		if err != nil {
+			metrics.AzureRequestError.WithLabelValues("resources.NewListByResourceGroupPager").Inc()
+			return nil, microerror.Mask(err)
+		}
+		resources = append(resources, nextPage.Value...)
+	}
+
+	return resources, nil
+}
+
+// GetManagementLock returns the management lock lockName of the resource
+// resourceID.
+func (ac *azureClient) GetManagementLock(ctx context.Context, resourceID string, lockName string) (armresources.GenericResource, error) {
+	metrics.AzureRequest.WithLabelValues("resources.GetByID").Inc()
+
+	resp, err := ac.resources.GetByID(ctx, managementLockID(resourceID, lockName), managementLockAPIVersion, nil)
+	if err != nil {
+		metrics.AzureRequestError.WithLabelValues("resources.GetByID").Inc()
+		return armresources.GenericResource{}, microerror.Mask(err)
+	}
+
+	return resp.GenericResource, nil
+}
+
+// CreateOrUpdateManagementLock places the CanNotDelete management lock
+// lockName on the resource resourceID.
+func (ac *azureClient) CreateOrUpdateManagementLock(ctx context.Context, resourceID string, lockName string, notes string) error {
+	metrics.AzureRequest.WithLabelValues("resources.BeginCreateOrUpdateByID").Inc()
+
+	poller, err := ac.resources.BeginCreateOrUpdateByID(ctx, managementLockID(resourceID, lockName), managementLockAPIVersion, armresources.GenericResource{
+		Properties: map[string]any{
+			"level": managementLockLevelCanNotDelete,
+			"notes": notes,
+		},
+	}, nil)
+	if err != nil {
+		metrics.AzureRequestError.WithLabelValues("resources.BeginCreateOrUpdateByID").Inc()
+		return microerror.Mask(err)
+	}
+
+	_, err = poller.PollUntilDone(ctx, nil)
+	if err != nil {
+		metrics.AzureRequestError.WithLabelValues("poller.PollUntilDone").Inc()
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// DeleteManagementLock removes the management lock lockName from the
+// resource resourceID.
+func (ac *azureClient) DeleteManagementLock(ctx context.Context, resourceID string, lockName string) error {
+	metrics.AzureRequest.WithLabelValues("resources.BeginDeleteByID").Inc()
+
+	poller, err := ac.resources.BeginDeleteByID(ctx, managementLockID(resourceID, lockName), managementLockAPIVersion, nil)
+	if err != nil {
+		metrics.AzureRequestError.WithLabelValues("resources.BeginDeleteByID").Inc()
+		return microerror.Mask(err)
+	}
+
+	_, err = poller.PollUntilDone(ctx, nil)
+	if err != nil {
+		metrics.AzureRequestError.WithLabelValues("poller.PollUntilDone").Inc()
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
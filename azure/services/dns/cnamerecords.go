@@ -12,10 +12,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-const (
-	cnameRecordTTL = 300
-)
-
 func (s *Service) updateCnameRecords(ctx context.Context, currentRecordSets []*armdns.RecordSet) error {
 	logger := log.FromContext(ctx).WithName("cnamerecords")
 
@@ -49,6 +45,7 @@ func (s *Service) updateCnameRecords(ctx context.Context, currentRecordSets []*a
 			armdns.RecordTypeCNAME,
 			*cnameRecord.Name,
 			*cnameRecord)
+		s.recordSetWritten(s.scope.ClusterDomain(), armdns.RecordTypeCNAME, cnameRecord, findRecordSet(currentRecordSets, *cnameRecord.Name), err)
 		if err != nil {
 			return err
 		}
@@ -65,7 +62,7 @@ func (s *Service) updateCnameRecords(ctx context.Context, currentRecordSets []*a
 
 func (s *Service) calculateMissingCnameRecords(logger logr.Logger, currentRecordSets []*armdns.RecordSet) []*armdns.RecordSet {
 
-	desiredRecords := desiredCnameRecords(s.scope.WildcardFQDN())
+	desiredRecords := s.desiredCnameRecords()
 
 	var recordsToCreate []*armdns.RecordSet
 
@@ -73,11 +70,23 @@ func (s *Service) calculateMissingCnameRecords(logger logr.Logger, currentRecord
 
 		logger.V(1).Info(fmt.Sprintf("compare entries individually - %s", *desiredRecordSet.Name))
 
+		desiredRecordSet.Properties.Metadata = s.ownerMetadata()
+
 		currentRecordSetIndex := slices.IndexFunc(currentRecordSets, func(recordSet *armdns.RecordSet) bool { return *recordSet.Name == *desiredRecordSet.Name })
 		if currentRecordSetIndex < 0 {
 			recordsToCreate = append(recordsToCreate, desiredRecordSet)
 		} else {
 			currentRecordSet := currentRecordSets[currentRecordSetIndex]
+
+			writable, adopt := s.checkOwnership(logger, s.clusterZone(), desiredRecordSet, currentRecordSet)
+			if !writable {
+				continue
+			}
+			if adopt {
+				recordsToCreate = append(recordsToCreate, desiredRecordSet)
+				continue
+			}
+
 			switch {
 			case !reflect.DeepEqual(currentRecordSet.Properties.CnameRecord, desiredRecordSet.Properties.CnameRecord):
 				logger.V(1).Info(fmt.Sprintf("A Records for %s are not equal - force update", *desiredRecordSet.Name))
@@ -92,15 +101,15 @@ func (s *Service) calculateMissingCnameRecords(logger logr.Logger, currentRecord
 	return recordsToCreate
 }
 
-func desiredCnameRecords(wildcardTarget string) []*armdns.RecordSet {
+func (s *Service) desiredCnameRecords() []*armdns.RecordSet {
 	return []*armdns.RecordSet{
 		{
 			Name: pointer.String("*"),
 			Type: pointer.String(string(armdns.RecordTypeCNAME)),
 			Properties: &armdns.RecordSetProperties{
-				TTL: pointer.Int64(cnameRecordTTL),
+				TTL: pointer.Int64(s.scope.RecordTTLs().Ingress),
 				CnameRecord: &armdns.CnameRecord{
-					Cname: pointer.String(wildcardTarget),
+					Cname: pointer.String(s.scope.WildcardFQDN()),
 				},
 			},
 		},
@@ -107,7 +107,8 @@ func Test_CnameRecords(t *testing.T) {
 						CnameRecord: &armdns.CnameRecord{
 							Cname: pointer.String("ingress.test-cluster.basedomain.io"),
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("*"),
 					Type: pointer.String("CNAME"),
@@ -188,7 +189,8 @@ func Test_CnameRecords(t *testing.T) {
 						CnameRecord: &armdns.CnameRecord{
 							Cname: pointer.String("ingress.test-cluster.basedomain.io"),
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("*"),
 					Type: pointer.String("CNAME"),
@@ -269,7 +271,8 @@ func Test_CnameRecords(t *testing.T) {
 						CnameRecord: &armdns.CnameRecord{
 							Cname: pointer.String("ingress.test-cluster.basedomain.io"),
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("*"),
 					Type: pointer.String("CNAME"),
@@ -341,7 +344,8 @@ func Test_CnameRecords(t *testing.T) {
 						CnameRecord: &armdns.CnameRecord{
 							Cname: pointer.String("custom.target.test-cluster.basedomain.io"),
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("*"),
 					Type: pointer.String("CNAME"),
@@ -409,7 +413,8 @@ func Test_CnameRecords(t *testing.T) {
 							CnameRecord: &armdns.CnameRecord{
 								Cname: pointer.String("api.test-cluster.basedomain.io"),
 							},
-							TTL: pointer.Int64(600),
+							TTL:      pointer.Int64(600),
+							Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 						},
 						Name: pointer.String("*"),
 						Type: pointer.String("CNAME"),
@@ -422,7 +427,8 @@ func Test_CnameRecords(t *testing.T) {
 						CnameRecord: &armdns.CnameRecord{
 							Cname: pointer.String("ingress.test-cluster.basedomain.io"),
 						},
-						TTL: pointer.Int64(300),
+						TTL:      pointer.Int64(300),
+						Metadata: map[string]*string{ownerMetadataKey: pointer.String("default/test-cluster"), ownerInstanceMetadataKey: pointer.String("basedomain.io")},
 					},
 					Name: pointer.String("*"),
 					Type: pointer.String("CNAME"),
@@ -2,6 +2,7 @@ package dns
 
 import (
 	"context"
+	"net"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
@@ -13,19 +14,33 @@ import (
 
 	"github.com/giantswarm/dns-operator-azure/v3/azure"
 	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
 	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
 )
 
-type client interface {
+// Provider manages the zones and record sets the service plans. Azure DNS is
+// the default provider, rfc2136.Client writes the same records with dynamic
+// updates to other name servers. Record sets and their types are described
+// with the Azure DNS API types for all providers.
+type Provider interface {
 	GetZone(ctx context.Context, resourceGroupName string, zoneName string) (armdns.Zone, error)
 	CreateOrUpdateZone(ctx context.Context, resourceGroupName string, zoneName string, zone armdns.Zone) (armdns.Zone, error)
 	DeleteZone(ctx context.Context, resourceGroupName string, zoneName string) error
 	CreateOrUpdateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, name string, recordSet armdns.RecordSet) (armdns.RecordSet, error)
 	DeleteRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, recordSetName string) error
 	ListRecordSets(ctx context.Context, resourceGroupName string, zoneName string) ([]*armdns.RecordSet, error)
+}
+
+type client interface {
+	Provider
+	UpdateZoneTags(ctx context.Context, resourceGroupName string, zoneName string, tags map[string]*string) (armdns.Zone, error)
 	GetResourceGroup(ctx context.Context, resourceGroupName string) (armresources.ResourceGroup, error)
 	CreateOrUpdateResourceGroup(ctx context.Context, resourceGroupName string, resourceGroup armresources.ResourceGroup) (armresources.ResourceGroup, error)
 	DeleteResourceGroup(ctx context.Context, resourceGroupName string) error
+	ListResourceGroupResources(ctx context.Context, resourceGroupName string) ([]*armresources.GenericResourceExpanded, error)
+	GetManagementLock(ctx context.Context, resourceID string, lockName string) (armresources.GenericResource, error)
+	CreateOrUpdateManagementLock(ctx context.Context, resourceID string, lockName string, notes string) error
+	DeleteManagementLock(ctx context.Context, resourceID string, lockName string) error
 }
 
 const (
@@ -42,14 +57,63 @@ type Service struct {
 	azureClient client
 	// azureBaseZoneClient is used as client for all baseDomain operations
 	azureBaseZoneClient client
+	// privateZones manages the private zone of split-horizon clusters, it is
+	// nil for providers other than Azure DNS.
+	privateZones privateZoneClient
 
 	publicIPsService async.Getter
+
+	// external is set for providers other than Azure DNS, whose zones
+	// aren't kept in resource groups, see NewWithProvider.
+	external bool
+
+	// resolver looks up hostname control plane endpoints, it defaults to
+	// net.DefaultResolver.
+	resolver resolver
+
+	// conflicts holds the FQDNs of conflicting records found during the last
+	// reconciliation.
+	conflicts []string
+	// locksLifted is set once the management locks of the cluster were lifted
+	// for a deletion, liftedLocks holds the ones to place again, see
+	// liftManagementLocks.
+	locksLifted bool
+	liftedLocks []lockedResource
+	// resourceGroupChecked is set once the last reconciliation created or
+	// checked the resource group of a non-Azure cluster, resourceGroupNotOwned
+	// tells why it was left alone, nil if it is owned by the cluster.
+	resourceGroupChecked  bool
+	resourceGroupNotOwned error
+	// resourceGroupKept tells why the resource group of a non-Azure cluster
+	// was kept during the last deletion, nil if it was deleted.
+	resourceGroupKept error
+	// steps holds the outcome of the steps of the last reconciliation.
+	steps map[Step]error
+	// aliasAddresses holds the addresses of the alias record set targets,
+	// keyed by lowercase resource ID.
+	aliasAddresses map[string][]string
+	// internalRecordSets holds the desired A records of the cluster zone of a
+	// split-horizon cluster including the ones with internal addresses.
+	internalRecordSets []*armdns.RecordSet
+}
+
+type resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
 }
 
 // New creates a new dns service.
 func New(scope scope.DNSScope, publicIPsService async.Getter) (*Service, error) {
-	azureClient, err := newAzureClient(scope)
+	cred, err := newClusterCredential(scope)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
+	azureClient, err := newAzureClient(scope, cred)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	privateZones, err := newPrivateZoneClient(scope.Patcher.SubscriptionID(), cred)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
@@ -59,21 +123,62 @@ func New(scope scope.DNSScope, publicIPsService async.Getter) (*Service, error)
 		return nil, microerror.Mask(err)
 	}
 
-	return &Service{
+	s := &Service{
 		scope:               scope,
 		azureClient:         azureClient,
 		azureBaseZoneClient: azureBaseZoneClient,
+		privateZones:        privateZones,
 		publicIPsService:    publicIPsService,
-	}, nil
+	}
+
+	// the management locks also block the deletions in the cluster zone and
+	// in the resource group of the cluster the operator makes itself
+	if scope.ManagementLocks() {
+		s.azureClient = unlockingClient{client: azureClient, service: s}
+		s.privateZones = unlockingPrivateZoneClient{privateZoneClient: privateZones, service: s}
+	}
+
+	return s, nil
+}
+
+// NewWithProvider creates a new dns service writing all zones with provider
+// instead of Azure DNS. The cluster zones must exist on the provider, no
+// resource groups are created for them and deleting a cluster only deletes
+// the records it owns in its zone. Split-horizon clusters aren't supported, as their
+// private zones are Azure private DNS zones.
+func NewWithProvider(scope scope.DNSScope, publicIPsService async.Getter, provider Provider) *Service {
+	providerClient := externalProviderClient{Provider: provider}
+
+	return &Service{
+		scope:               scope,
+		azureClient:         providerClient,
+		azureBaseZoneClient: providerClient,
+		publicIPsService:    publicIPsService,
+		external:            true,
+	}
 }
 
 // Reconcile creates or updates the DNS zone, and creates DNS A and CNAME records.
-func (s *Service) Reconcile(ctx context.Context) error {
+func (s *Service) Reconcile(ctx context.Context) (err error) {
 	log := log.FromContext(ctx).WithName("azure-dns-create")
 
+	// place the management locks lifted for the deletions of the
+	// reconciliation again
+	defer func() {
+		if restoreErr := s.restoreManagementLocks(ctx); restoreErr != nil && err == nil {
+			err = microerror.Mask(restoreErr)
+		}
+	}()
+
 	clusterZoneName := s.scope.ClusterDomain()
 	log.Info("Reconcile DNS", "DNSZone", clusterZoneName)
 
+	s.conflicts = nil
+	s.steps = map[Step]error{}
+	s.internalRecordSets = nil
+	s.resourceGroupChecked = false
+	s.resourceGroupNotOwned = nil
+
 	log.V(1).Info("client information for base Zone",
 		"clientID", s.scope.BaseZoneCredentials().ClientID,
 		"tenantID", s.scope.BaseZoneCredentials().TenantID,
@@ -86,11 +191,12 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	)
 
 	// create resource group for non-Azure clusters
-	if !s.scope.IsAzureCluster() {
+	if !s.scope.IsAzureCluster() && !s.external {
 		_, err := s.createClusterResourceGroup(ctx)
 		if err != nil {
-			return microerror.Mask(err)
+			return s.stepFailed(StepZone, microerror.Mask(err))
 		}
+		s.resourceGroupChecked = true
 	}
 
 	// create info metric
@@ -110,13 +216,13 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="recordSets.NewListByDNSZonePager"}
 		metrics.AzureRequestError.WithLabelValues("recordSets.NewListByDNSZonePager").Inc()
 
-		return microerror.Mask(err)
+		return s.stepFailed(StepZone, microerror.Mask(err))
 	} else if azure.IsParentResourceNotFound(err) {
 		log.V(1).Info("cluster specific DNS zone not found", "error", err.Error())
 		_, err = s.createClusterDNSZone(ctx)
 		if err != nil {
 			log.V(1).Info("zone creation failed", "error", err.Error())
-			return microerror.Mask(err)
+			return s.stepFailed(StepZone, microerror.Mask(err))
 		}
 	}
 
@@ -124,8 +230,25 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	log.V(1).Info("get cluster specific zone information")
 	clusterZone, err := s.azureClient.GetZone(ctx, s.scope.ResourceGroup(), clusterZoneName)
 	if err != nil {
-		return microerror.Mask(err)
+		return s.stepFailed(StepZone, microerror.Mask(err))
+	}
+
+	// update the tags of the zone if the resource tags of the cluster
+	// changed, and tag zones the operator created before ownership tracking,
+	// so that the orphan sweeper finds them once the cluster is gone
+	zoneOwned, err := s.reconcileZoneTags(ctx, &clusterZone, clusterRecordSets)
+	if err != nil {
+		return s.stepFailed(StepZone, microerror.Mask(err))
+	}
+
+	// protect the cluster zone and the resource group of non-Azure clusters
+	// from deletion, recreating locks someone removed
+	if s.scope.ManagementLocks() && !s.external && zoneOwned {
+		if err := s.reconcileManagementLocks(ctx); err != nil {
+			return s.stepFailed(StepZone, microerror.Mask(err))
+		}
 	}
+	s.stepDone(StepZone)
 
 	// dns_operator_zone_records_sum{controller="dns-operator-azure",zone="glippy.azuretest.gigantic.io"} 30
 	metrics.ClusterZoneRecords.WithLabelValues(
@@ -133,30 +256,26 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		metrics.ZoneTypePublic,
 	).Set(float64(*clusterZone.Properties.NumberOfRecordSets))
 
-	// create NS Record in base zone
-	log.V(1).Info("list NS records in basedomain", "resourcegroup", s.scope.BaseDomainResourceGroup(), "dns zone", s.scope.BaseDomain())
-	basedomainRecordSets, err := s.azureBaseZoneClient.ListRecordSets(ctx, s.scope.BaseDomainResourceGroup(), s.scope.BaseDomain())
+	// create the intermediate zone the cluster zone is delegated from
+	if s.scope.IntermediateDomain() != "" {
+		if err := s.reconcileIntermediateZone(ctx); err != nil {
+			return s.stepFailed(StepNSDelegation, microerror.Mask(err))
+		}
+	}
+
+	// create NS Record in base zone or intermediate zone
+	log.V(1).Info("list NS records in basedomain", "resourcegroup", s.scope.BaseDomainResourceGroup(), "dns zone", s.scope.ParentDomain())
+	basedomainRecordSets, err := s.azureBaseZoneClient.ListRecordSets(ctx, s.scope.BaseDomainResourceGroup(), s.scope.ParentDomain())
 	if err != nil {
-		return microerror.Mask(err)
+		return s.stepFailed(StepNSDelegation, microerror.Mask(err))
 	}
 
 	// dns_operator_zone_records_sum{controller="dns-operator-azure",zone="azuretest.gigantic.io"} 7
 	metrics.ClusterZoneRecords.WithLabelValues(
-		s.scope.BaseDomain(),
+		s.scope.ParentDomain(),
 		metrics.ZoneTypePublic,
 	).Set(float64(len(basedomainRecordSets)))
 
-	log.V(1).Info("range over received NS records")
-	clusterNSRecordExists := false
-	for _, basedomainRecordSet := range basedomainRecordSets {
-		log.V(1).Info("basedomainRecordSet", "name", basedomainRecordSet.Name)
-		if basedomainRecordSet.Name == &s.scope.Cluster.Name {
-			if len(basedomainRecordSet.Properties.NsRecords) > 0 {
-				clusterNSRecordExists = true
-			}
-		}
-	}
-
 	log.V(1).Info("range over clusterZone name servers")
 	clusterZoneNameServers := []*armdns.NsRecord{}
 	for _, nameServer := range clusterZone.Properties.NameServers {
@@ -166,44 +285,107 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		})
 	}
 
+	// the delegation is rewritten if it's missing, points to other name
+	// servers or isn't marked as owned yet
+	log.V(1).Info("range over received NS records")
+	clusterNSRecordExists := false
+	for _, basedomainRecordSet := range basedomainRecordSets {
+		log.V(1).Info("basedomainRecordSet", "name", basedomainRecordSet.Name)
+		if basedomainRecordSet.Name != nil && *basedomainRecordSet.Name == s.scope.Patcher.ClusterName() && basedomainRecordSet.Properties != nil {
+			clusterNSRecordExists = s.isOwnedRecordSet(basedomainRecordSet) &&
+				nameServers(basedomainRecordSet.Properties.NsRecords) == nameServers(clusterZoneNameServers)
+		}
+	}
+
 	if !clusterNSRecordExists {
-		log.Info("Creating NS records", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.BaseDomain())
+		log.Info("Creating NS records", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.ParentDomain())
 		if err := s.createClusterNSRecord(ctx, clusterZoneNameServers); err != nil {
-			return microerror.Mask(err)
+			return s.stepFailed(StepNSDelegation, microerror.Mask(err))
 		}
-		log.Info("Successfully created NS records", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.BaseDomain())
+		log.Info("Successfully created NS records", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.ParentDomain())
+	}
+	s.stepDone(StepNSDelegation)
+
+	if !s.scope.ManagesRecords(infracluster.ManagedRecordsRecords) {
+		log.Info("Cluster limits DNS management, skipping A and CNAME records", "annotation", infracluster.AnnotationManagedRecords)
+		log.Info("Successfully reconciled DNS", "DNSZone", clusterZoneName)
+		return nil
 	}
 
 	// Create required A records.
 	if err := s.updateARecords(ctx, clusterRecordSets); err != nil {
 		return microerror.Mask(err)
 	}
+	s.stepDone(StepAPIRecords)
 
 	// Create required CNAME records
 	if err = s.updateCnameRecords(ctx, clusterRecordSets); err != nil {
-		return microerror.Mask(err)
+		return s.stepFailed(StepIngressRecords, microerror.Mask(err))
 	}
+	s.stepDone(StepIngressRecords)
+
+	// Publish the records with internal addresses in the private zone of
+	// split-horizon clusters
+	if err = s.reconcileSplitHorizon(ctx, clusterZone, clusterRecordSets); err != nil {
+		return s.stepFailed(StepSplitHorizon, microerror.Mask(err))
+	}
+	s.stepDone(StepSplitHorizon)
 
 	log.Info("Successfully reconciled DNS", "DNSZone", clusterZoneName)
 	return nil
 }
 
+// IsSplitHorizon reports whether the cluster publishes its records with
+// internal addresses in a private zone instead of the public one.
+func (s *Service) IsSplitHorizon() bool {
+	return s.scope.IsSplitHorizon()
+}
+
 func (s *Service) ReconcileDelete(ctx context.Context) error {
 	log := log.FromContext(ctx).WithName("azure-dns-delete")
 	clusterZoneName := s.scope.ClusterDomain()
 	log.Info("Reconcile DNS deletion", "DNSZone", clusterZoneName)
 
-	log.Info("Deleting NS record", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.BaseDomain())
+	// remove the management locks before anything of the cluster is deleted
+	if s.scope.ManagementLocks() && !s.external {
+		if err := s.deleteManagementLocks(ctx); err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// delete records in zones shared with other clusters, and in the cluster
+	// zone of other providers
+	if s.scope.ManagesRecords(infracluster.ManagedRecordsRecords) {
+		if err := s.deleteOwnedRecordSets(ctx); err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	log.Info("Deleting NS record", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.ParentDomain())
 
 	// delete cluster NS records
 	if err := s.deleteClusterNSRecords(ctx); err != nil {
 		return microerror.Mask(err)
 	}
 
-	log.Info("Successfully deleted NS record", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.BaseDomain())
+	log.Info("Successfully deleted NS record", "NSrecord", s.scope.Patcher.ClusterName(), "DNS zone", s.scope.ParentDomain())
 
-	// delete non-Azure cluster's resource group
-	if !s.scope.IsAzureCluster() {
+	// delete the intermediate zone once its last cluster is gone
+	if err := s.deleteUnusedIntermediateZone(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+
+	// delete the private zone of split-horizon clusters, the resource groups
+	// of CAPZ clusters may outlive them
+	if s.scope.IsSplitHorizon() {
+		if err := s.deletePrivateZone(ctx); err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// delete non-Azure cluster's resource group, the zones of other providers
+	// outlive the cluster, see deleteOwnedRecordSets
+	if !s.scope.IsAzureCluster() && !s.external {
 		err := s.deleteClusterResourceGroup(ctx)
 		if err != nil {
 			return microerror.Mask(err)
@@ -226,12 +408,55 @@ func (s *Service) createClusterDNSZone(ctx context.Context) (armdns.Zone, error)
 	dnsZoneParams := armdns.Zone{
 		Name:     &zoneName,
 		Location: pointer.String(capzazure.Global),
+		Tags:     s.updatedZoneTags(nil),
 	}
 	dnsZone, err := s.azureClient.CreateOrUpdateZone(ctx, s.scope.ResourceGroup(), zoneName, dnsZoneParams)
 	if err != nil {
+		s.scope.Warnf("DNSZoneCreationFailed", "Failed to create DNS zone %s in resource group %s: %s", zoneName, s.scope.ResourceGroup(), err)
 		return armdns.Zone{}, microerror.Mask(err)
 	}
+	s.scope.Eventf("DNSZoneCreated", "Created DNS zone %s in resource group %s", zoneName, s.scope.ResourceGroup())
 	log.Info("Successfully created DNS zone", "zone", zoneName)
 
 	return dnsZone, nil
 }
+
+// externalProviderClient adapts providers other than Azure DNS to client.
+// They have no resource groups, which the service doesn't manage for them.
+type externalProviderClient struct {
+	Provider
+}
+
+// UpdateZoneTags returns the zone, zones of providers other than Azure DNS
+// have no tags, they would be published in the zone.
+func (c externalProviderClient) UpdateZoneTags(ctx context.Context, resourceGroupName string, zoneName string, tags map[string]*string) (armdns.Zone, error) {
+	return c.GetZone(ctx, resourceGroupName, zoneName)
+}
+
+func (externalProviderClient) GetResourceGroup(ctx context.Context, resourceGroupName string) (armresources.ResourceGroup, error) {
+	return armresources.ResourceGroup{}, microerror.Maskf(resourceGroupsNotSupportedError, "resource group %s", resourceGroupName)
+}
+
+func (externalProviderClient) CreateOrUpdateResourceGroup(ctx context.Context, resourceGroupName string, resourceGroup armresources.ResourceGroup) (armresources.ResourceGroup, error) {
+	return armresources.ResourceGroup{}, microerror.Maskf(resourceGroupsNotSupportedError, "resource group %s", resourceGroupName)
+}
+
+func (externalProviderClient) DeleteResourceGroup(ctx context.Context, resourceGroupName string) error {
+	return microerror.Maskf(resourceGroupsNotSupportedError, "resource group %s", resourceGroupName)
+}
+
+func (externalProviderClient) GetManagementLock(ctx context.Context, resourceID string, lockName string) (armresources.GenericResource, error) {
+	return armresources.GenericResource{}, microerror.Maskf(managementLocksNotSupportedError, "management lock %s", lockName)
+}
+
+func (externalProviderClient) CreateOrUpdateManagementLock(ctx context.Context, resourceID string, lockName string, notes string) error {
+	return microerror.Maskf(managementLocksNotSupportedError, "management lock %s", lockName)
+}
+
+func (externalProviderClient) DeleteManagementLock(ctx context.Context, resourceID string, lockName string) error {
+	return microerror.Maskf(managementLocksNotSupportedError, "management lock %s", lockName)
+}
+
+func (externalProviderClient) ListResourceGroupResources(ctx context.Context, resourceGroupName string) ([]*armresources.GenericResourceExpanded, error) {
+	return nil, microerror.Maskf(resourceGroupsNotSupportedError, "resource group %s", resourceGroupName)
+}
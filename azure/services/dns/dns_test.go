@@ -0,0 +1,103 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/utils/pointer"
+)
+
+// recordingProvider serves the record sets of its zones and records the
+// deletions.
+type recordingProvider struct {
+	Provider
+
+	recordSets map[string][]*armdns.RecordSet
+	calls      []string
+}
+
+func (p *recordingProvider) ListRecordSets(_ context.Context, _ string, zoneName string) ([]*armdns.RecordSet, error) {
+	return p.recordSets[zoneName], nil
+}
+
+func (p *recordingProvider) DeleteRecordSet(_ context.Context, _ string, zoneName string, recordType armdns.RecordType, recordSetName string) error {
+	p.calls = append(p.calls, "DeleteRecordSet "+zoneName+" "+string(recordType)+" "+recordSetName)
+	return nil
+}
+
+func (p *recordingProvider) DeleteZone(_ context.Context, _ string, zoneName string) error {
+	p.calls = append(p.calls, "DeleteZone "+zoneName)
+	return nil
+}
+
+// recordingClient is an Azure DNS client recording the tags-only zone updates
+// with the calls of its recordingProvider.
+type recordingClient struct {
+	externalProviderClient
+}
+
+func newRecordingClient(provider *recordingProvider) recordingClient {
+	return recordingClient{externalProviderClient{Provider: provider}}
+}
+
+func (c recordingClient) UpdateZoneTags(_ context.Context, _ string, zoneName string, tags map[string]*string) (armdns.Zone, error) {
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	provider := c.Provider.(*recordingProvider)
+	provider.calls = append(provider.calls, "UpdateZoneTags "+zoneName+" "+strings.Join(keys, ","))
+	return armdns.Zone{Tags: tags}, nil
+}
+
+func TestService_ReconcileDelete_provider(t *testing.T) {
+	ctx := context.TODO()
+
+	provider := &recordingProvider{}
+	svc := NewWithProvider(newZonesTestService(t, ctx, nil).scope, nil, provider)
+
+	provider.recordSets = map[string][]*armdns.RecordSet{
+		"test-cluster.basedomain.io": {
+			{
+				Name: pointer.String("@"),
+				Type: pointer.String(RecordSetTypeNS),
+			},
+			{
+				Name:       pointer.String("api"),
+				Type:       pointer.String(RecordSetTypeA),
+				Properties: &armdns.RecordSetProperties{Metadata: svc.ownerMetadata()},
+			},
+			{
+				Name:       pointer.String("*"),
+				Type:       pointer.String(RecordSetTypeCNAME),
+				Properties: &armdns.RecordSetProperties{Metadata: svc.ownerMetadata()},
+			},
+			{
+				Name:       pointer.String("customer"),
+				Type:       pointer.String(RecordSetTypeA),
+				Properties: &armdns.RecordSetProperties{},
+			},
+		},
+	}
+
+	if err := svc.ReconcileDelete(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the records the cluster owns in its zone are deleted, the zone, the
+	// records of others and resource groups are left alone
+	expected := []string{
+		"DeleteRecordSet test-cluster.basedomain.io A api",
+		"DeleteRecordSet test-cluster.basedomain.io CNAME *",
+		"DeleteRecordSet basedomain.io NS test-cluster",
+	}
+	if !reflect.DeepEqual(provider.calls, expected) {
+		t.Errorf("calls = %#v, want %#v", provider.calls, expected)
+	}
+}
@@ -18,13 +18,13 @@ var ingressNotReadyError = &microerror.Error{
 	Kind: "ingressNotReadyError",
 }
 
-// IsTooManyICServices asserts tooManyICServicesError.
-func IsTooManyICServices(err error) bool {
-	return microerror.Cause(err) == tooManyICServicesError
+// IsAPIServerHostnameNotResolvable asserts apiServerHostnameNotResolvableError.
+func IsAPIServerHostnameNotResolvable(err error) bool {
+	return microerror.Cause(err) == apiServerHostnameNotResolvableError
 }
 
-var tooManyICServicesError = &microerror.Error{
-	Kind: "tooManyICServicesError",
+var apiServerHostnameNotResolvableError = &microerror.Error{
+	Kind: "apiServerHostnameNotResolvableError",
 }
 
 // IsResourceNotFoundError asserts resourceNotFoundError.
@@ -44,3 +44,75 @@ func IsResourceNotFoundError(err error) bool {
 var resourceNotFoundError = &microerror.Error{
 	Kind: "resourceNotFoundError",
 }
+
+// IsNotOwned asserts notOwnedError.
+func IsNotOwned(err error) bool {
+	return microerror.Cause(err) == notOwnedError
+}
+
+var notOwnedError = &microerror.Error{
+	Kind: "notOwnedError",
+}
+
+// IsBaseZoneCheckFailed asserts baseZoneCheckFailedError.
+func IsBaseZoneCheckFailed(err error) bool {
+	return microerror.Cause(err) == baseZoneCheckFailedError
+}
+
+var baseZoneCheckFailedError = &microerror.Error{
+	Kind: "baseZoneCheckFailedError",
+}
+
+// IsResourceGroupsNotSupported asserts resourceGroupsNotSupportedError.
+func IsResourceGroupsNotSupported(err error) bool {
+	return microerror.Cause(err) == resourceGroupsNotSupportedError
+}
+
+var resourceGroupsNotSupportedError = &microerror.Error{
+	Kind: "resourceGroupsNotSupportedError",
+}
+
+// IsManagementLocksNotSupported asserts managementLocksNotSupportedError.
+func IsManagementLocksNotSupported(err error) bool {
+	return microerror.Cause(err) == managementLocksNotSupportedError
+}
+
+var managementLocksNotSupportedError = &microerror.Error{
+	Kind: "managementLocksNotSupportedError",
+}
+
+// IsResourceGroupKept asserts resourceGroupKeptError.
+func IsResourceGroupKept(err error) bool {
+	return microerror.Cause(err) == resourceGroupKeptError
+}
+
+var resourceGroupKeptError = &microerror.Error{
+	Kind: "resourceGroupKeptError",
+}
+
+// IsResourceGroupNotOwned asserts resourceGroupNotOwnedError.
+func IsResourceGroupNotOwned(err error) bool {
+	return microerror.Cause(err) == resourceGroupNotOwnedError
+}
+
+var resourceGroupNotOwnedError = &microerror.Error{
+	Kind: "resourceGroupNotOwnedError",
+}
+
+// IsSplitHorizonNotSupported asserts splitHorizonNotSupportedError.
+func IsSplitHorizonNotSupported(err error) bool {
+	return microerror.Cause(err) == splitHorizonNotSupportedError
+}
+
+var splitHorizonNotSupportedError = &microerror.Error{
+	Kind: "splitHorizonNotSupportedError",
+}
+
+// IsIntermediateZoneConflict asserts intermediateZoneConflictError.
+func IsIntermediateZoneConflict(err error) bool {
+	return microerror.Cause(err) == intermediateZoneConflictError
+}
+
+var intermediateZoneConflictError = &microerror.Error{
+	Kind: "intermediateZoneConflictError",
+}
@@ -0,0 +1,84 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// recordSetWritten records an event about desired being written to the zone
+// zoneName, replacing current if not nil. A failed write, err not nil, is
+// recorded as Warning event.
+func (s *Service) recordSetWritten(zoneName string, recordType armdns.RecordType, desired, current *armdns.RecordSet, err error) {
+	fqdn := recordSetFQDN(*desired.Name, zoneName)
+
+	switch {
+	case err != nil:
+		s.scope.Warnf("DNSRecordUpdateFailed", "Failed to write DNS %s record %s: %s", recordType, fqdn, err)
+	case current == nil:
+		s.scope.Eventf("DNSRecordCreated", "Created DNS %s record %s with value %s", recordType, fqdn, recordSetValue(desired))
+	default:
+		s.scope.Eventf("DNSRecordUpdated", "Updated DNS %s record %s from %s %s to %s", recordType, fqdn, recordSetType(current), recordSetValue(current), recordSetValue(desired))
+	}
+}
+
+// recordSetDeleted records an event about recordSet being deleted from the
+// zone zoneName. A failed deletion, err not nil, is recorded as Warning event.
+func (s *Service) recordSetDeleted(zoneName string, recordSet *armdns.RecordSet, err error) {
+	fqdn := recordSetFQDN(*recordSet.Name, zoneName)
+
+	if err != nil {
+		s.scope.Warnf("DNSRecordDeletionFailed", "Failed to delete DNS %s record %s: %s", recordSetType(recordSet), fqdn, err)
+		return
+	}
+	s.scope.Eventf("DNSRecordDeleted", "Deleted DNS %s record %s with value %s", recordSetType(recordSet), fqdn, recordSetValue(recordSet))
+}
+
+// recordSetValue describes the records of recordSet for events, e.g.
+// "1.2.3.4,5.6.7.8".
+func recordSetValue(recordSet *armdns.RecordSet) string {
+	if recordSet.Properties == nil {
+		return "<none>"
+	}
+
+	var values []string
+	for _, record := range recordSet.Properties.ARecords {
+		if record.IPv4Address != nil {
+			values = append(values, *record.IPv4Address)
+		}
+	}
+	if record := recordSet.Properties.CnameRecord; record != nil && record.Cname != nil {
+		values = append(values, *record.Cname)
+	}
+	if targetResource := targetResourceID(recordSet); targetResource != "" {
+		values = append(values, "alias:"+targetResource)
+	}
+	for _, record := range recordSet.Properties.NsRecords {
+		if record.Nsdname != nil {
+			values = append(values, *record.Nsdname)
+		}
+	}
+
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
+
+// findRecordSet returns the record set called name, nil if there is none.
+func findRecordSet(recordSets []*armdns.RecordSet, name string) *armdns.RecordSet {
+	for _, recordSet := range recordSets {
+		if recordSet.Name != nil && *recordSet.Name == name {
+			return recordSet
+		}
+	}
+	return nil
+}
+
+func recordSetFQDN(name, zoneName string) string {
+	if name == "@" {
+		return zoneName
+	}
+	return fmt.Sprintf("%s.%s", name, zoneName)
+}
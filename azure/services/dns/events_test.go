@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/utils/pointer"
+)
+
+func Test_recordSetValue(t *testing.T) {
+	testCases := []struct {
+		name      string
+		recordSet *armdns.RecordSet
+		expected  string
+	}{
+		{
+			name: "case0: A records",
+			recordSet: &armdns.RecordSet{Properties: &armdns.RecordSetProperties{
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}, {IPv4Address: pointer.String("5.6.7.8")}},
+			}},
+			expected: "1.2.3.4,5.6.7.8",
+		},
+		{
+			name: "case1: CNAME record",
+			recordSet: &armdns.RecordSet{Properties: &armdns.RecordSetProperties{
+				CnameRecord: &armdns.CnameRecord{Cname: pointer.String("ingress.test-cluster.basedomain.io")},
+			}},
+			expected: "ingress.test-cluster.basedomain.io",
+		},
+		{
+			name: "case2: NS records",
+			recordSet: &armdns.RecordSet{Properties: &armdns.RecordSetProperties{
+				NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1-01.azure-dns.com.")}},
+			}},
+			expected: "ns1-01.azure-dns.com.",
+		},
+		{
+			name:      "case3: no records",
+			recordSet: &armdns.RecordSet{},
+			expected:  "<none>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := recordSetValue(tc.recordSet); got != tc.expected {
+				t.Errorf("recordSetValue() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
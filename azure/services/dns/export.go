@@ -0,0 +1,44 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+// ClusterZoneRecordSets lists the record sets of all types in the cluster
+// zone.
+func (s *Service) ClusterZoneRecordSets(ctx context.Context) ([]*armdns.RecordSet, error) {
+	recordSets, err := s.azureClient.ListRecordSets(ctx, s.scope.ResourceGroup(), s.scope.ClusterDomain())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	return recordSets, nil
+}
+
+// BaseZoneRecordSets lists the record sets of all types in the base zone,
+// read with provider if set and from Azure DNS otherwise.
+func BaseZoneRecordSets(ctx context.Context, provider Provider, credentials scope.BaseZoneCredentials, resourceGroup, baseDomain string) ([]*armdns.RecordSet, error) {
+	baseZoneClient := provider
+	if provider == nil {
+		azureClient, err := newBaseZoneClient(credentials)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		baseZoneClient = azureClient
+	}
+
+	recordSets, err := baseZoneClient.ListRecordSets(ctx, resourceGroup, baseDomain)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	return recordSets, nil
+}
+
+// ClusterZoneName returns the name of the cluster zone.
+func (s *Service) ClusterZoneName() string {
+	return s.scope.ClusterDomain()
+}
@@ -0,0 +1,151 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/zonefile"
+)
+
+// ImportReport is the result of importing record sets into the cluster
+// zone. Record sets are listed as "<name> <type>".
+type ImportReport struct {
+	Created   []string
+	Unchanged []string
+	// Refused record sets have names or types the operator manages itself.
+	Refused []string
+	// Conflicts exist in the cluster zone with different values and were
+	// left alone.
+	Conflicts []string
+}
+
+// HasProblems reports whether record sets were refused or conflicted.
+func (r ImportReport) HasProblems() bool {
+	return len(r.Refused) > 0 || len(r.Conflicts) > 0
+}
+
+// ImportRecordSets creates recordSets, e.g. parsed from a zone file with
+// zonefile.Parse, in the cluster zone. Record sets the operator manages
+// itself, the zone apex SOA and NS records and the api, apiserver, ingress
+// and wildcard records, are refused. Existing record sets are never
+// overwritten, differing ones are reported as conflicts.
+func (s *Service) ImportRecordSets(ctx context.Context, recordSets []*armdns.RecordSet) (ImportReport, error) {
+	logger := log.FromContext(ctx).WithName("import")
+	zoneName := s.scope.ClusterDomain()
+
+	var report ImportReport
+
+	currentRecordSets, err := s.azureClient.ListRecordSets(ctx, s.scope.ResourceGroup(), zoneName)
+	if err != nil {
+		return report, microerror.Mask(err)
+	}
+
+	managedNames := s.managedRecordNames(ctx, currentRecordSets)
+
+	for _, recordSet := range recordSets {
+		name := *recordSet.Name
+		recordType := zonefile.RecordType(recordSet)
+		entry := fmt.Sprintf("%s %s", name, recordType)
+
+		if managedNames[name] || recordType == armdns.RecordTypeSOA || (name == "@" && recordType == armdns.RecordTypeNS) {
+			report.Refused = append(report.Refused, entry)
+			continue
+		}
+
+		var current *armdns.RecordSet
+		conflict := false
+		for _, currentRecordSet := range currentRecordSets {
+			if currentRecordSet.Name == nil || !strings.EqualFold(*currentRecordSet.Name, name) {
+				continue
+			}
+			currentType := recordSetType(currentRecordSet)
+			if currentType == recordType {
+				current = currentRecordSet
+			} else if currentType == armdns.RecordTypeCNAME || recordType == armdns.RecordTypeCNAME {
+				// CNAME records can't coexist with other records of the same name
+				conflict = true
+			}
+		}
+
+		switch {
+		case conflict, current != nil && !recordSetDataEqual(current, recordSet):
+			report.Conflicts = append(report.Conflicts, entry)
+			continue
+		case current != nil:
+			report.Unchanged = append(report.Unchanged, entry)
+			continue
+		}
+
+		logger.Info("Importing DNS record set", "DNSZone", zoneName, "name", name, "type", recordType)
+		_, err := s.azureClient.CreateOrUpdateRecordSet(ctx, s.scope.ResourceGroup(), zoneName, recordType, name, armdns.RecordSet{
+			Properties: recordSet.Properties,
+		})
+		if err != nil {
+			return report, microerror.Mask(err)
+		}
+		report.Created = append(report.Created, entry)
+	}
+
+	if len(report.Created) > 0 {
+		s.scope.Eventf("DNSRecordsImported", "Imported %d record sets into zone %s", len(report.Created), zoneName)
+	}
+	if len(report.Refused) > 0 {
+		s.scope.Warnf("DNSImportRefused", "Refused to import record sets managed by the operator into zone %s: %s", zoneName, strings.Join(report.Refused, ", "))
+	}
+	if len(report.Conflicts) > 0 {
+		s.scope.Warnf("DNSImportConflict", "Record sets already exist with different values in zone %s and were not imported: %s", zoneName, strings.Join(report.Conflicts, ", "))
+	}
+
+	return report, nil
+}
+
+// managedRecordNames returns the names of the record sets in the cluster
+// zone the operator manages itself.
+func (s *Service) managedRecordNames(ctx context.Context, currentRecordSets []*armdns.RecordSet) map[string]bool {
+	names := map[string]bool{"api": true, "apiserver": true}
+
+	for _, recordSet := range s.desiredCnameRecords() {
+		names[*recordSet.Name] = true
+	}
+
+	// desired records may not be known yet, e.g. while ingress services
+	// are pending, in that case the owned records still protect them
+	desiredRecordSets, err := s.getDesiredARecords(ctx)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("desired records unknown, only protecting owned records", "error", err.Error())
+	}
+	for _, recordSet := range desiredRecordSets[s.scope.ClusterDomain()] {
+		names[*recordSet.Name] = true
+	}
+
+	for _, recordSet := range currentRecordSets {
+		if recordSet.Name != nil && s.isOwnedRecordSet(recordSet) {
+			names[*recordSet.Name] = true
+		}
+	}
+
+	return names
+}
+
+// recordSetDataEqual reports whether both record sets hold the same records
+// with the same TTL, ignoring read-only fields and metadata.
+func recordSetDataEqual(a, b *armdns.RecordSet) bool {
+	data := func(recordSet *armdns.RecordSet) armdns.RecordSetProperties {
+		if recordSet.Properties == nil {
+			return armdns.RecordSetProperties{}
+		}
+		properties := *recordSet.Properties
+		properties.Fqdn = nil
+		properties.ProvisioningState = nil
+		properties.Metadata = nil
+		properties.TargetResource = nil
+		return properties
+	}
+	return reflect.DeepEqual(data(a), data(b))
+}
@@ -0,0 +1,102 @@
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/utils/pointer"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzscope "sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+)
+
+// recordSetClient serves the record sets of one zone and records writes.
+type recordSetClient struct {
+	client
+
+	recordSets []*armdns.RecordSet
+	written    []string
+}
+
+func (c *recordSetClient) ListRecordSets(context.Context, string, string) ([]*armdns.RecordSet, error) {
+	return c.recordSets, nil
+}
+
+func (c *recordSetClient) CreateOrUpdateRecordSet(_ context.Context, _ string, _ string, recordType armdns.RecordType, name string, recordSet armdns.RecordSet) (armdns.RecordSet, error) {
+	c.written = append(c.written, name+" "+string(recordType))
+	return recordSet, nil
+}
+
+func TestService_ImportRecordSets(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.Patcher.(*capzscope.ClusterScope).AzureCluster.Spec.NetworkSpec.APIServerLB = &infrav1.LoadBalancerSpec{
+		FrontendIPs: []infrav1.FrontendIP{{PublicIP: &infrav1.PublicIPSpec{Name: "1.2.3.4"}}},
+	}
+
+	recordSet := func(name string, recordType armdns.RecordType, properties armdns.RecordSetProperties) *armdns.RecordSet {
+		properties.TTL = pointer.Int64(300)
+		return &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String(string(recordType)),
+			Properties: &properties,
+		}
+	}
+	listed := func(recordSet *armdns.RecordSet) *armdns.RecordSet {
+		recordSet.Type = pointer.String(RecordSetTypePrefix + *recordSet.Type)
+		recordSet.Properties.Fqdn = pointer.String(*recordSet.Name + ".test-cluster.basedomain.io.")
+		return recordSet
+	}
+	cname := func(name, target string) *armdns.RecordSet {
+		return recordSet(name, armdns.RecordTypeCNAME, armdns.RecordSetProperties{CnameRecord: &armdns.CnameRecord{Cname: pointer.String(target)}})
+	}
+	txt := func(name, value string) *armdns.RecordSet {
+		return recordSet(name, armdns.RecordTypeTXT, armdns.RecordSetProperties{TxtRecords: []*armdns.TxtRecord{{Value: []*string{pointer.String(value)}}}})
+	}
+
+	ownedRecordSet := listed(cname("grafana", "ingress.test-cluster.basedomain.io"))
+	ownedRecordSet.Properties.Metadata = svc.ownerMetadata()
+
+	azureClient := &recordSetClient{
+		recordSets: []*armdns.RecordSet{
+			listed(recordSet("@", armdns.RecordTypeNS, armdns.RecordSetProperties{NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1-01.azure-dns.com.")}}})),
+			listed(cname("docs", "docs.example.com")),
+			listed(cname("shop", "shop.example.com")),
+			listed(txt("verify", "token")),
+			ownedRecordSet,
+		},
+	}
+	svc.azureClient = azureClient
+
+	report, err := svc.ImportRecordSets(ctx, []*armdns.RecordSet{
+		recordSet("@", armdns.RecordTypeSOA, armdns.RecordSetProperties{SoaRecord: &armdns.SoaRecord{Host: pointer.String("ns1.legacy.io")}}),
+		recordSet("@", armdns.RecordTypeNS, armdns.RecordSetProperties{NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1.legacy.io")}}}),
+		recordSet("@", armdns.RecordTypeMX, armdns.RecordSetProperties{MxRecords: []*armdns.MxRecord{{Preference: pointer.Int32(10), Exchange: pointer.String("mail.example.com")}}}),
+		cname("api", "legacy.example.com"),
+		cname("*", "legacy.example.com"),
+		cname("grafana", "legacy.example.com"),
+		cname("docs", "docs.example.com"),
+		cname("shop", "legacy.example.com"),
+		txt("verify", "other-token"),
+		txt("docs", "token"),
+		txt("_acme", "token"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ImportReport{
+		Created:   []string{"@ MX", "_acme TXT"},
+		Unchanged: []string{"docs CNAME"},
+		Refused:   []string{"@ SOA", "@ NS", "api CNAME", "* CNAME", "grafana CNAME"},
+		Conflicts: []string{"shop CNAME", "verify TXT", "docs TXT"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("ImportRecordSets() = %+v, want %+v", report, want)
+	}
+	if !reflect.DeepEqual(azureClient.written, want.Created) {
+		t.Errorf("written record sets = %v, want %v", azureClient.written, want.Created)
+	}
+}
@@ -0,0 +1,191 @@
+package dns
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"k8s.io/utils/pointer"
+	capzazure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+)
+
+const (
+	// intermediateZoneTagKey marks the intermediate zones the operator
+	// created, and their NS delegations in the base zone. Intermediate zones
+	// are shared by all clusters below them, so they aren't owned by a
+	// single cluster.
+	intermediateZoneTagKey = "dns_operator_azure_intermediate_zone"
+)
+
+// intermediateZoneTags returns the tags marking an intermediate zone or its
+// delegation as created by the operator.
+func intermediateZoneTags() map[string]*string {
+	return map[string]*string{
+		intermediateZoneTagKey: pointer.String("true"),
+	}
+}
+
+// isIntermediateZoneTags reports whether tags mark an intermediate zone or its
+// delegation as created by the operator.
+func isIntermediateZoneTags(tags map[string]*string) bool {
+	_, ok := tags[intermediateZoneTagKey]
+	return ok
+}
+
+// reconcileIntermediateZone creates the intermediate zone the cluster zone is
+// delegated from if it's missing, and delegates it from the base zone.
+// Existing zones the operator didn't create and NS delegations of the same
+// name pointing elsewhere, e.g. to a zone handed to a customer, are never
+// written to. The zones of other providers are configured by hand and have
+// no tags.
+func (s *Service) reconcileIntermediateZone(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("reconcileIntermediateZone")
+
+	zoneName := s.scope.IntermediateDomain()
+	resourceGroup := s.scope.BaseDomainResourceGroup()
+
+	existingZone, err := s.azureBaseZoneClient.GetZone(ctx, resourceGroup, zoneName)
+	if err == nil && !s.external && !isIntermediateZoneTags(existingZone.Tags) {
+		return microerror.Maskf(intermediateZoneConflictError, "zone %s wasn't created by dns-operator-azure as intermediate zone", zoneName)
+	} else if azure.IsNotFound(err) {
+		logger.Info("Creating intermediate DNS zone", "zone", zoneName)
+		_, err = s.azureBaseZoneClient.CreateOrUpdateZone(ctx, resourceGroup, zoneName, armdns.Zone{
+			Location: pointer.String(capzazure.Global),
+			Tags:     intermediateZoneTags(),
+		})
+		if err != nil {
+			s.scope.Warnf("DNSIntermediateZoneCreationFailed", "Failed to create intermediate DNS zone %s in resource group %s: %s", zoneName, resourceGroup, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("DNSIntermediateZoneCreated", "Created intermediate DNS zone %s in resource group %s", zoneName, resourceGroup)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	intermediateZone, err := s.azureBaseZoneClient.GetZone(ctx, resourceGroup, zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var nameServerRecords []*armdns.NsRecord
+	if intermediateZone.Properties != nil {
+		for _, nameServer := range intermediateZone.Properties.NameServers {
+			nameServerRecords = append(nameServerRecords, &armdns.NsRecord{Nsdname: nameServer})
+		}
+	}
+
+	baseRecordSets, err := s.azureBaseZoneClient.ListRecordSets(ctx, resourceGroup, s.scope.BaseDomain())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	recordName := strings.TrimSuffix(zoneName, "."+s.scope.BaseDomain())
+	for _, recordSet := range baseRecordSets {
+		if recordSet.Name == nil || *recordSet.Name != recordName || recordSetType(recordSet) != armdns.RecordTypeNS || recordSet.Properties == nil {
+			continue
+		}
+
+		sameNameServers := nameServers(recordSet.Properties.NsRecords) == nameServers(nameServerRecords)
+		switch {
+		case sameNameServers && isIntermediateZoneTags(recordSet.Properties.Metadata):
+			return nil
+		case !sameNameServers && !isIntermediateZoneTags(recordSet.Properties.Metadata):
+			return microerror.Maskf(intermediateZoneConflictError, "NS delegation %s in zone %s points to %s, not to intermediate zone %s", recordName, s.scope.BaseDomain(), nameServers(recordSet.Properties.NsRecords), zoneName)
+		}
+	}
+
+	logger.Info("Delegating intermediate DNS zone", "zone", zoneName, "DNS zone", s.scope.BaseDomain())
+	_, err = s.azureBaseZoneClient.CreateOrUpdateRecordSet(ctx, resourceGroup, s.scope.BaseDomain(), armdns.RecordTypeNS, recordName, armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL:       pointer.Int64(s.scope.RecordTTLs().NS),
+			NsRecords: nameServerRecords,
+			Metadata:  intermediateZoneTags(),
+		},
+	})
+	if err != nil {
+		s.scope.Warnf("DNSDelegationUpdateFailed", "Failed to write NS delegation %s to zone %s: %s", zoneName, s.scope.BaseDomain(), err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("DNSDelegationUpdated", "Delegated %s to name servers %s in zone %s", zoneName, nameServers(nameServerRecords), s.scope.BaseDomain())
+
+	return nil
+}
+
+// deleteUnusedIntermediateZone deletes the intermediate zone of the cluster
+// and its delegation in the base zone once no cluster zone is delegated from
+// it anymore. Intermediate zones the operator didn't create, or delegated by
+// an NS record set it didn't write, are left alone.
+// A cluster zone delegated from the zone while it is deleted gets a new
+// intermediate zone on its next reconciliation.
+func (s *Service) deleteUnusedIntermediateZone(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("deleteUnusedIntermediateZone")
+
+	zoneName := s.scope.IntermediateDomain()
+	if zoneName == "" {
+		return nil
+	}
+	resourceGroup := s.scope.BaseDomainResourceGroup()
+
+	intermediateZone, err := s.azureBaseZoneClient.GetZone(ctx, resourceGroup, zoneName)
+	if azure.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+	if !isIntermediateZoneTags(intermediateZone.Tags) {
+		return nil
+	}
+
+	recordSets, err := s.azureBaseZoneClient.ListRecordSets(ctx, resourceGroup, zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for _, recordSet := range recordSets {
+		if recordSet.Name != nil && *recordSet.Name != "@" && recordSetType(recordSet) == armdns.RecordTypeNS {
+			logger.V(1).Info("Intermediate DNS zone still delegates to other zones, keeping it", "zone", zoneName, "delegation", *recordSet.Name)
+			return nil
+		}
+	}
+
+	// a delegation the operator didn't write would point to nothing once the
+	// zone is gone, so both are kept
+	recordName := strings.TrimSuffix(zoneName, "."+s.scope.BaseDomain())
+	baseRecordSets, err := s.azureBaseZoneClient.ListRecordSets(ctx, resourceGroup, s.scope.BaseDomain())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	delegated := false
+	for _, recordSet := range baseRecordSets {
+		if recordSet.Name == nil || *recordSet.Name != recordName || recordSetType(recordSet) != armdns.RecordTypeNS {
+			continue
+		}
+		if recordSet.Properties == nil || !isIntermediateZoneTags(recordSet.Properties.Metadata) {
+			logger.Info("NS delegation of the intermediate DNS zone wasn't written by dns-operator-azure, keeping the zone", "zone", zoneName)
+			return nil
+		}
+		delegated = true
+	}
+
+	logger.Info("Deleting unused intermediate DNS zone", "zone", zoneName)
+	if delegated {
+		err = s.azureBaseZoneClient.DeleteRecordSet(ctx, resourceGroup, s.scope.BaseDomain(), armdns.RecordTypeNS, recordName)
+		if err != nil {
+			s.scope.Warnf("DNSDelegationDeletionFailed", "Failed to delete NS delegation %s: %s", zoneName, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("DNSDelegationDeleted", "Deleted NS delegation %s from zone %s", zoneName, s.scope.BaseDomain())
+	}
+
+	err = s.azureBaseZoneClient.DeleteZone(ctx, resourceGroup, zoneName)
+	if err != nil {
+		s.scope.Warnf("DNSIntermediateZoneDeletionFailed", "Failed to delete intermediate DNS zone %s: %s", zoneName, err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("DNSIntermediateZoneDeleted", "Deleted unused intermediate DNS zone %s", zoneName)
+
+	return nil
+}
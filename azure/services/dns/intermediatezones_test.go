@@ -0,0 +1,401 @@
+package dns
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+// fakeZone is a zone served by zonesClient.
+type fakeZone struct {
+	tags       map[string]*string
+	recordSets []*armdns.RecordSet
+}
+
+// zonesClient serves the zones of one resource group and records the changes.
+// Created zones get the name servers ns1.<zone> and ns2.<zone>.
+type zonesClient struct {
+	client
+
+	zones map[string]*fakeZone
+	calls []string
+}
+
+func (c *zonesClient) GetZone(_ context.Context, _ string, zoneName string) (armdns.Zone, error) {
+	z, ok := c.zones[zoneName]
+	if !ok {
+		return armdns.Zone{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return armdns.Zone{
+		Name: pointer.String(zoneName),
+		Tags: z.tags,
+		Properties: &armdns.ZoneProperties{
+			NameServers: []*string{pointer.String("ns1." + zoneName), pointer.String("ns2." + zoneName)},
+		},
+	}, nil
+}
+
+func (c *zonesClient) CreateOrUpdateZone(_ context.Context, _ string, zoneName string, zone armdns.Zone) (armdns.Zone, error) {
+	c.calls = append(c.calls, "CreateOrUpdateZone "+zoneName)
+	c.zones[zoneName] = &fakeZone{tags: zone.Tags}
+	return zone, nil
+}
+
+func (c *zonesClient) DeleteZone(_ context.Context, _ string, zoneName string) error {
+	c.calls = append(c.calls, "DeleteZone "+zoneName)
+	delete(c.zones, zoneName)
+	return nil
+}
+
+func (c *zonesClient) ListRecordSets(_ context.Context, _ string, zoneName string) ([]*armdns.RecordSet, error) {
+	z, ok := c.zones[zoneName]
+	if !ok {
+		return nil, &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ParentResourceNotFound"}
+	}
+	return z.recordSets, nil
+}
+
+func (c *zonesClient) CreateOrUpdateRecordSet(_ context.Context, _ string, zoneName string, recordType armdns.RecordType, name string, recordSet armdns.RecordSet) (armdns.RecordSet, error) {
+	c.calls = append(c.calls, "CreateOrUpdateRecordSet "+zoneName+" "+string(recordType)+" "+name+" "+nameServers(recordSet.Properties.NsRecords))
+	recordSet.Name = pointer.String(name)
+	recordSet.Type = pointer.String(RecordSetTypePrefix + string(recordType))
+	z := c.zones[zoneName]
+	z.recordSets = append(z.recordSets, &recordSet)
+	return recordSet, nil
+}
+
+func (c *zonesClient) DeleteRecordSet(_ context.Context, _ string, zoneName string, recordType armdns.RecordType, name string) error {
+	c.calls = append(c.calls, "DeleteRecordSet "+zoneName+" "+string(recordType)+" "+name)
+	z, ok := c.zones[zoneName]
+	if !ok {
+		return &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ParentResourceNotFound"}
+	}
+	var recordSets []*armdns.RecordSet
+	for _, recordSet := range z.recordSets {
+		if *recordSet.Name != name || recordSetType(recordSet) != recordType {
+			recordSets = append(recordSets, recordSet)
+		}
+	}
+	z.recordSets = recordSets
+	return nil
+}
+
+// newIntermediateZoneTestService builds a DNS Service for the cluster
+// test-cluster in the namespace default with the given intermediate zone mode,
+// Cluster labels and annotations.
+func newIntermediateZoneTestService(t *testing.T, ctx context.Context, mode string, labels, annotations map[string]string) *Service {
+	t.Helper()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.Cluster.SetLabels(labels)
+	svc.scope.Cluster.SetAnnotations(annotations)
+
+	dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+		ClusterScope:            &svc.scope.Scope,
+		BaseDomain:              svc.scope.BaseDomain(),
+		BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+		BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+		IntermediateZoneMode:    mode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.scope = *dnsScope
+
+	return svc
+}
+
+func TestDNSScope_ClusterDomain(t *testing.T) {
+	ctx := context.TODO()
+
+	tests := []struct {
+		name                   string
+		mode                   string
+		labels                 map[string]string
+		annotations            map[string]string
+		location               string
+		wantClusterDomain      string
+		wantIntermediateDomain string
+	}{
+		{
+			name:              "case0: cluster zones are delegated from the base zone by default",
+			labels:            map[string]string{scope.LabelOrganization: "acme"},
+			wantClusterDomain: "test-cluster.basedomain.io",
+		},
+		{
+			name:                   "case1: organization mode uses the organization label",
+			mode:                   scope.IntermediateZoneModeOrganization,
+			labels:                 map[string]string{scope.LabelOrganization: "acme"},
+			wantClusterDomain:      "test-cluster.acme.basedomain.io",
+			wantIntermediateDomain: "acme.basedomain.io",
+		},
+		{
+			name:              "case2: organization mode without organization places the zone below the base zone",
+			mode:              scope.IntermediateZoneModeOrganization,
+			wantClusterDomain: "test-cluster.basedomain.io",
+		},
+		{
+			name:                   "case3: region mode uses the location of CAPZ clusters",
+			mode:                   scope.IntermediateZoneModeRegion,
+			location:               "westeurope",
+			wantClusterDomain:      "test-cluster.westeurope.basedomain.io",
+			wantIntermediateDomain: "westeurope.basedomain.io",
+		},
+		{
+			name:                   "case4: the annotation takes precedence over the mode",
+			mode:                   scope.IntermediateZoneModeOrganization,
+			labels:                 map[string]string{scope.LabelOrganization: "acme"},
+			annotations:            map[string]string{scope.AnnotationIntermediateZone: "Customer-1"},
+			wantClusterDomain:      "test-cluster.customer-1.basedomain.io",
+			wantIntermediateDomain: "customer-1.basedomain.io",
+		},
+		{
+			name:              "case5: an empty annotation places the zone below the base zone",
+			mode:              scope.IntermediateZoneModeOrganization,
+			labels:            map[string]string{scope.LabelOrganization: "acme"},
+			annotations:       map[string]string{scope.AnnotationIntermediateZone: ""},
+			wantClusterDomain: "test-cluster.basedomain.io",
+		},
+		{
+			name:              "case6: invalid labels are ignored",
+			annotations:       map[string]string{scope.AnnotationIntermediateZone: "a.b"},
+			wantClusterDomain: "test-cluster.basedomain.io",
+		},
+		{
+			name:   "case7: the recorded cluster zone takes precedence",
+			mode:   scope.IntermediateZoneModeOrganization,
+			labels: map[string]string{scope.LabelOrganization: "acme"},
+			annotations: map[string]string{
+				scope.AnnotationClusterZone: "test-cluster.basedomain.io",
+			},
+			wantClusterDomain: "test-cluster.basedomain.io",
+		},
+		{
+			name: "case8: recorded cluster zones outside the base zone are ignored",
+			annotations: map[string]string{
+				scope.AnnotationClusterZone: "test-cluster.example.com",
+			},
+			wantClusterDomain: "test-cluster.basedomain.io",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newIntermediateZoneTestService(t, ctx, tt.mode, tt.labels, tt.annotations)
+			if tt.location != "" {
+				if err := unstructured.SetNestedField(svc.scope.InfraCluster.Object, tt.location, "spec", "location"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := svc.scope.ClusterDomain(); got != tt.wantClusterDomain {
+				t.Errorf("ClusterDomain() = %q, want %q", got, tt.wantClusterDomain)
+			}
+			if got := svc.scope.IntermediateDomain(); got != tt.wantIntermediateDomain {
+				t.Errorf("IntermediateDomain() = %q, want %q", got, tt.wantIntermediateDomain)
+			}
+		})
+	}
+}
+
+func TestService_reconcileIntermediateZone(t *testing.T) {
+	ctx := context.TODO()
+
+	tests := []struct {
+		name             string
+		intermediateZone *fakeZone
+		baseRecordSet    *armdns.RecordSet
+		wantCalls        []string
+		wantConflict     bool
+	}{
+		{
+			name: "case0: the intermediate zone is created and delegated",
+			wantCalls: []string{
+				"CreateOrUpdateZone acme.basedomain.io",
+				"CreateOrUpdateRecordSet basedomain.io NS acme ns1.acme.basedomain.io,ns2.acme.basedomain.io",
+			},
+		},
+		{
+			name: "case1: a delegation pointing to other name servers isn't overwritten",
+			baseRecordSet: &armdns.RecordSet{
+				Name: pointer.String("acme"),
+				Type: pointer.String(RecordSetTypeNS),
+				Properties: &armdns.RecordSetProperties{
+					NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1.customer.example.com")}},
+				},
+			},
+			wantCalls: []string{
+				"CreateOrUpdateZone acme.basedomain.io",
+			},
+			wantConflict: true,
+		},
+		{
+			name: "case2: a delegation pointing to the intermediate zone is adopted",
+			baseRecordSet: &armdns.RecordSet{
+				Name: pointer.String("acme"),
+				Type: pointer.String(RecordSetTypeNS),
+				Properties: &armdns.RecordSetProperties{
+					NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1.acme.basedomain.io")}, {Nsdname: pointer.String("ns2.acme.basedomain.io")}},
+				},
+			},
+			wantCalls: []string{
+				"CreateOrUpdateZone acme.basedomain.io",
+				"CreateOrUpdateRecordSet basedomain.io NS acme ns1.acme.basedomain.io,ns2.acme.basedomain.io",
+			},
+		},
+		{
+			name:             "case3: an existing zone the operator didn't create isn't used",
+			intermediateZone: &fakeZone{},
+			baseRecordSet: &armdns.RecordSet{
+				Name: pointer.String("acme"),
+				Type: pointer.String(RecordSetTypeNS),
+				Properties: &armdns.RecordSetProperties{
+					NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1.acme.basedomain.io")}, {Nsdname: pointer.String("ns2.acme.basedomain.io")}},
+				},
+			},
+			wantConflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newIntermediateZoneTestService(t, ctx, scope.IntermediateZoneModeOrganization, map[string]string{scope.LabelOrganization: "acme"}, nil)
+			baseZone := &fakeZone{}
+			if tt.baseRecordSet != nil {
+				baseZone.recordSets = append(baseZone.recordSets, tt.baseRecordSet)
+			}
+			zones := &zonesClient{zones: map[string]*fakeZone{"basedomain.io": baseZone}}
+			if tt.intermediateZone != nil {
+				zones.zones["acme.basedomain.io"] = tt.intermediateZone
+			}
+			svc.azureBaseZoneClient = zones
+
+			err := svc.reconcileIntermediateZone(ctx)
+			if IsIntermediateZoneConflict(err) != tt.wantConflict {
+				t.Fatalf("reconcileIntermediateZone() error = %v, want conflict %v", err, tt.wantConflict)
+			}
+			if !tt.wantConflict && err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(zones.calls, tt.wantCalls) {
+				t.Errorf("calls = %#v, want %#v", zones.calls, tt.wantCalls)
+			}
+
+			// the next reconciliation finds everything in place
+			if !tt.wantConflict {
+				zones.calls = nil
+				if err := svc.reconcileIntermediateZone(ctx); err != nil {
+					t.Fatal(err)
+				}
+				if len(zones.calls) != 0 {
+					t.Errorf("calls = %#v, want none", zones.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestService_ReconcileDelete_intermediateZone(t *testing.T) {
+	ctx := context.TODO()
+
+	delegation := func(name string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String(RecordSetTypeNS),
+			Properties: &armdns.RecordSetProperties{},
+		}
+	}
+	intermediateDelegation := delegation("acme")
+	intermediateDelegation.Properties.Metadata = intermediateZoneTags()
+
+	tests := []struct {
+		name             string
+		intermediateZone *fakeZone
+		baseRecordSet    *armdns.RecordSet
+		wantCalls        []string
+	}{
+		{
+			name: "case0: the intermediate zone is deleted with its last cluster",
+			intermediateZone: &fakeZone{
+				tags:       intermediateZoneTags(),
+				recordSets: []*armdns.RecordSet{delegation("@"), delegation("test-cluster")},
+			},
+			wantCalls: []string{
+				"DeleteRecordSet acme.basedomain.io NS test-cluster",
+				"DeleteRecordSet basedomain.io NS acme",
+				"DeleteZone acme.basedomain.io",
+			},
+		},
+		{
+			name: "case1: the intermediate zone is kept while other clusters are delegated from it",
+			intermediateZone: &fakeZone{
+				tags:       intermediateZoneTags(),
+				recordSets: []*armdns.RecordSet{delegation("test-cluster"), delegation("other-cluster")},
+			},
+			wantCalls: []string{
+				"DeleteRecordSet acme.basedomain.io NS test-cluster",
+			},
+		},
+		{
+			name: "case2: intermediate zones the operator didn't create are kept",
+			intermediateZone: &fakeZone{
+				recordSets: []*armdns.RecordSet{delegation("test-cluster")},
+			},
+			wantCalls: []string{
+				"DeleteRecordSet acme.basedomain.io NS test-cluster",
+			},
+		},
+		{
+			name: "case3: a missing intermediate zone holds nothing to delete",
+			wantCalls: []string{
+				"DeleteRecordSet acme.basedomain.io NS test-cluster",
+			},
+		},
+		{
+			name: "case4: intermediate zones delegated by a record set the operator didn't write are kept",
+			intermediateZone: &fakeZone{
+				tags:       intermediateZoneTags(),
+				recordSets: []*armdns.RecordSet{delegation("@"), delegation("test-cluster")},
+			},
+			baseRecordSet: delegation("acme"),
+			wantCalls: []string{
+				"DeleteRecordSet acme.basedomain.io NS test-cluster",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newIntermediateZoneTestService(t, ctx, scope.IntermediateZoneModeOrganization, map[string]string{scope.LabelOrganization: "acme"}, nil)
+			svc.azureClient = externalProviderClient{Provider: &recordingProvider{}}
+			svc.external = true
+
+			baseRecordSet := intermediateDelegation
+			if tt.baseRecordSet != nil {
+				baseRecordSet = tt.baseRecordSet
+			}
+			zones := &zonesClient{zones: map[string]*fakeZone{
+				"basedomain.io": {recordSets: []*armdns.RecordSet{baseRecordSet}},
+			}}
+			if tt.intermediateZone != nil {
+				zones.zones["acme.basedomain.io"] = tt.intermediateZone
+			}
+			svc.azureBaseZoneClient = zones
+
+			if err := svc.ReconcileDelete(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(zones.calls, tt.wantCalls) {
+				t.Errorf("calls = %#v, want %#v", zones.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
+	"github.com/giantswarm/microerror"
+	capzazure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+)
+
+const (
+	// managementLockName is the name of the management locks the operator
+	// places on the cluster zone and on the resource group of non-Azure
+	// clusters.
+	managementLockName = "dns-operator-azure"
+	// managementLockAPIVersion is the Microsoft.Authorization/locks API
+	// version the locks are managed with.
+	managementLockAPIVersion = "2016-09-01"
+
+	managementLockLevelCanNotDelete = "CanNotDelete"
+	managementLockNotes             = "Protects the DNS zone of the cluster, managed by dns-operator-azure"
+)
+
+// managementLockID returns the resource ID of the management lock lockName of
+// the resource resourceID.
+func managementLockID(resourceID string, lockName string) string {
+	return resourceID + "/providers/Microsoft.Authorization/locks/" + lockName
+}
+
+// lockedResource is a resource protected by a management lock of the
+// operator.
+type lockedResource struct {
+	kind string
+	name string
+	id   string
+}
+
+// lockedResources returns the resources the management locks of the cluster
+// are placed on: the cluster zone and, for non-Azure clusters, the resource
+// group the operator created for it. The resource groups of CAPZ clusters
+// belong to CAPZ, which must be able to delete them, and resource groups
+// without the owner tags of the cluster aren't locked either. Their ownership
+// is read from their tags, as it isn't known yet when the locks of a deleted
+// cluster are removed.
+func (s *Service) lockedResources(ctx context.Context) ([]lockedResource, error) {
+	resourceGroupID := capzazure.ResourceGroupID(s.scope.Patcher.SubscriptionID(), s.scope.ResourceGroup())
+
+	resources := []lockedResource{
+		{
+			kind: "DNS zone",
+			name: s.scope.ClusterDomain(),
+			id:   resourceGroupID + "/providers/Microsoft.Network/dnszones/" + s.scope.ClusterDomain(),
+		},
+	}
+	if s.scope.IsAzureCluster() {
+		return resources, nil
+	}
+
+	resourceGroup, err := s.azureClient.GetResourceGroup(ctx, s.scope.ResourceGroup())
+	if IsResourceNotFoundError(err) {
+		return resources, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if s.isOwnedTags(resourceGroup.Tags) {
+		resources = append(resources, lockedResource{
+			kind: "resource group",
+			name: s.scope.ResourceGroup(),
+			id:   resourceGroupID,
+		})
+	}
+
+	return resources, nil
+}
+
+// managementLockLevel returns the level of a management lock.
+func managementLockLevel(lock armresources.GenericResource) string {
+	properties, ok := lock.Properties.(map[string]any)
+	if !ok {
+		return ""
+	}
+	level, _ := properties["level"].(string)
+	return level
+}
+
+// reconcileManagementLocks places the CanNotDelete management locks on the
+// resources of the cluster, and recreates the ones that were removed or
+// changed.
+func (s *Service) reconcileManagementLocks(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("reconcileManagementLocks")
+
+	resources, err := s.lockedResources(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for _, resource := range resources {
+		lock, err := s.azureClient.GetManagementLock(ctx, resource.id, managementLockName)
+		if err == nil && managementLockLevel(lock) == managementLockLevelCanNotDelete {
+			continue
+		} else if err != nil && !azure.IsNotFound(err) {
+			return microerror.Mask(err)
+		}
+
+		logger.Info("Placing management lock", "resource", resource.id, "lock", managementLockName)
+		err = s.azureClient.CreateOrUpdateManagementLock(ctx, resource.id, managementLockName, managementLockNotes)
+		if err != nil {
+			s.scope.Warnf("DNSManagementLockCreationFailed", "Failed to place management lock on %s %s: %s", resource.kind, resource.name, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("DNSManagementLockCreated", "Placed %s management lock on %s %s", managementLockLevelCanNotDelete, resource.kind, resource.name)
+	}
+
+	return nil
+}
+
+// deleteManagementLocks removes the management locks of the cluster before
+// its resources are deleted. Missing locks are not an error.
+func (s *Service) deleteManagementLocks(ctx context.Context) error {
+	resources, err := s.lockedResources(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for _, resource := range resources {
+		err = s.azureClient.DeleteManagementLock(ctx, resource.id, managementLockName)
+		if azure.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			s.scope.Warnf("DNSManagementLockDeletionFailed", "Failed to remove management lock from %s %s: %s", resource.kind, resource.name, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("DNSManagementLockDeleted", "Removed management lock from %s %s", resource.kind, resource.name)
+	}
+
+	return nil
+}
+
+// liftManagementLocks removes the management locks of the cluster before the
+// first deletion of a reconciliation. CanNotDelete locks on a zone or its
+// resource group also block deleting record sets, so the locks in place are
+// lifted once for all deletions of the reconciliation and placed again by
+// restoreManagementLocks when it is done. Locks already removed, e.g. while
+// the cluster is deleted, stay removed.
+func (s *Service) liftManagementLocks(ctx context.Context) error {
+	if s.locksLifted {
+		return nil
+	}
+	logger := log.FromContext(ctx).WithName("liftManagementLocks")
+
+	resources, err := s.lockedResources(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for _, resource := range resources {
+		_, err := s.azureClient.GetManagementLock(ctx, resource.id, managementLockName)
+		if azure.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		logger.V(1).Info("Lifting management lock", "resource", resource.id, "lock", managementLockName)
+		if err := s.azureClient.DeleteManagementLock(ctx, resource.id, managementLockName); err != nil {
+			return microerror.Mask(err)
+		}
+		s.liftedLocks = append(s.liftedLocks, resource)
+	}
+	s.locksLifted = true
+
+	return nil
+}
+
+// restoreManagementLocks places the management locks lifted by
+// liftManagementLocks again. Locks that fail to be placed are recreated by
+// the next reconciliation.
+func (s *Service) restoreManagementLocks(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("restoreManagementLocks")
+
+	var restoreErr error
+	for _, resource := range s.liftedLocks {
+		logger.V(1).Info("Placing lifted management lock", "resource", resource.id, "lock", managementLockName)
+		if err := s.azureClient.CreateOrUpdateManagementLock(ctx, resource.id, managementLockName, managementLockNotes); err != nil {
+			s.scope.Warnf("DNSManagementLockCreationFailed", "Failed to place management lock on %s %s: %s", resource.kind, resource.name, err)
+			if restoreErr == nil {
+				restoreErr = microerror.Mask(err)
+			}
+		}
+	}
+	s.liftedLocks = nil
+	s.locksLifted = false
+
+	return restoreErr
+}
+
+// unlockingClient lifts the management locks of the cluster before record set
+// deletions in the cluster zone, see liftManagementLocks.
+type unlockingClient struct {
+	client
+	service *Service
+}
+
+func (c unlockingClient) DeleteRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, recordSetName string) error {
+	if err := c.service.liftManagementLocks(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+	return c.client.DeleteRecordSet(ctx, resourceGroupName, zoneName, recordType, recordSetName)
+}
+
+// unlockingPrivateZoneClient lifts the management locks of the cluster before
+// deletions in the private zone, which the lock on the resource group of
+// non-Azure clusters covers.
+type unlockingPrivateZoneClient struct {
+	privateZoneClient
+	service *Service
+}
+
+func (c unlockingPrivateZoneClient) DeletePrivateZone(ctx context.Context, resourceGroupName string, zoneName string) error {
+	if err := c.service.liftManagementLocks(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+	return c.privateZoneClient.DeletePrivateZone(ctx, resourceGroupName, zoneName)
+}
+
+func (c unlockingPrivateZoneClient) DeletePrivateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armprivatedns.RecordType, recordSetName string) error {
+	if err := c.service.liftManagementLocks(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+	return c.privateZoneClient.DeletePrivateRecordSet(ctx, resourceGroupName, zoneName, recordType, recordSetName)
+}
+
+func (c unlockingPrivateZoneClient) DeleteVirtualNetworkLink(ctx context.Context, resourceGroupName string, zoneName string, linkName string) error {
+	if err := c.service.liftManagementLocks(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+	return c.privateZoneClient.DeleteVirtualNetworkLink(ctx, resourceGroupName, zoneName, linkName)
+}
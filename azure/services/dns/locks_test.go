@@ -0,0 +1,256 @@
+package dns
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+)
+
+// lockClient serves management locks keyed by resource ID and records the
+// changes. Like Azure, it refuses to delete record sets below a locked
+// resource.
+type lockClient struct {
+	client
+
+	// locks holds the level of the lock of each resource
+	locks map[string]string
+	// resourceGroupTags are the tags of the resource group of the cluster
+	resourceGroupTags map[string]*string
+	calls             []string
+}
+
+func (c *lockClient) GetResourceGroup(context.Context, string) (armresources.ResourceGroup, error) {
+	return armresources.ResourceGroup{Tags: c.resourceGroupTags}, nil
+}
+
+func (c *lockClient) GetManagementLock(_ context.Context, resourceID string, lockName string) (armresources.GenericResource, error) {
+	level, ok := c.locks[resourceID]
+	if !ok {
+		return armresources.GenericResource{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return armresources.GenericResource{
+		Name:       pointer.String(lockName),
+		Properties: map[string]any{"level": level},
+	}, nil
+}
+
+func (c *lockClient) CreateOrUpdateManagementLock(_ context.Context, resourceID string, _ string, _ string) error {
+	c.calls = append(c.calls, "CreateOrUpdateManagementLock "+resourceID)
+	c.locks[resourceID] = managementLockLevelCanNotDelete
+	return nil
+}
+
+func (c *lockClient) DeleteManagementLock(_ context.Context, resourceID string, _ string) error {
+	c.calls = append(c.calls, "DeleteManagementLock "+resourceID)
+	if _, ok := c.locks[resourceID]; !ok {
+		return &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	delete(c.locks, resourceID)
+	return nil
+}
+
+func (c *lockClient) DeleteRecordSet(_ context.Context, _ string, zoneName string, _ armdns.RecordType, name string) error {
+	for resourceID := range c.locks {
+		if strings.HasSuffix(resourceID, "/resourceGroups/test-cluster") || strings.HasSuffix(resourceID, "/"+zoneName) {
+			return &azcore.ResponseError{StatusCode: http.StatusConflict, ErrorCode: "ScopeLocked"}
+		}
+	}
+	c.calls = append(c.calls, "DeleteRecordSet "+name)
+	return nil
+}
+
+func TestService_reconcileManagementLocks(t *testing.T) {
+	ctx := context.TODO()
+
+	testCases := []struct {
+		name        string
+		nonAzure    bool
+		zoneLock    string
+		expectCalls []string
+	}{
+		{
+			name:        "case0: a missing lock is placed on the cluster zone",
+			expectCalls: []string{"CreateOrUpdateManagementLock zone"},
+		},
+		{
+			name:     "case1: an existing lock is kept",
+			zoneLock: managementLockLevelCanNotDelete,
+		},
+		{
+			name:        "case2: a lock changed to another level is rewritten",
+			zoneLock:    "ReadOnly",
+			expectCalls: []string{"CreateOrUpdateManagementLock zone"},
+		},
+		{
+			name:        "case3: the resource group of non-Azure clusters is locked too",
+			nonAzure:    true,
+			zoneLock:    managementLockLevelCanNotDelete,
+			expectCalls: []string{"CreateOrUpdateManagementLock resource group"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newZonesTestService(t, ctx, nil)
+			if tc.nonAzure {
+				svc.scope.InfraCluster = &unstructured.Unstructured{Object: map[string]interface{}{"kind": "VSphereCluster"}}
+			}
+			zoneID, resourceGroupID := lockedResourceIDs(svc)
+
+			locks := &lockClient{locks: map[string]string{}, resourceGroupTags: svc.ownerMetadata()}
+			if tc.zoneLock != "" {
+				locks.locks[zoneID] = tc.zoneLock
+			}
+			svc.azureClient = locks
+
+			if err := svc.reconcileManagementLocks(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := shortenLockCalls(locks.calls, zoneID, resourceGroupID)
+			if !reflect.DeepEqual(calls, tc.expectCalls) {
+				t.Errorf("calls = %#v, want %#v", calls, tc.expectCalls)
+			}
+		})
+	}
+}
+
+func TestService_deleteManagementLocks(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.InfraCluster = &unstructured.Unstructured{Object: map[string]interface{}{"kind": "VSphereCluster"}}
+	zoneID, resourceGroupID := lockedResourceIDs(svc)
+
+	// the lock of the resource group was already removed by hand
+	locks := &lockClient{locks: map[string]string{zoneID: managementLockLevelCanNotDelete}, resourceGroupTags: svc.ownerMetadata()}
+	svc.azureClient = locks
+
+	if err := svc.deleteManagementLocks(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(locks.locks) != 0 {
+		t.Errorf("locks = %v, want none", locks.locks)
+	}
+	expectCalls := []string{"DeleteManagementLock zone", "DeleteManagementLock resource group"}
+	if calls := shortenLockCalls(locks.calls, zoneID, resourceGroupID); !reflect.DeepEqual(calls, expectCalls) {
+		t.Errorf("calls = %#v, want %#v", calls, expectCalls)
+	}
+}
+
+func TestService_deleteManagementLocks_foreignResourceGroup(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.InfraCluster = &unstructured.Unstructured{Object: map[string]interface{}{"kind": "VSphereCluster"}}
+	zoneID, resourceGroupID := lockedResourceIDs(svc)
+
+	// someone else's lock of the same name on a resource group of the same
+	// name the operator didn't create
+	locks := &lockClient{
+		locks: map[string]string{
+			zoneID:          managementLockLevelCanNotDelete,
+			resourceGroupID: managementLockLevelCanNotDelete,
+		},
+		resourceGroupTags: map[string]*string{"owner": pointer.String("someone-else")},
+	}
+	svc.azureClient = locks
+
+	if err := svc.deleteManagementLocks(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expectCalls := []string{"DeleteManagementLock zone"}
+	if calls := shortenLockCalls(locks.calls, zoneID, resourceGroupID); !reflect.DeepEqual(calls, expectCalls) {
+		t.Errorf("calls = %#v, want %#v", calls, expectCalls)
+	}
+	if _, ok := locks.locks[resourceGroupID]; !ok {
+		t.Errorf("lock of the resource group was removed")
+	}
+}
+
+func TestUnlockingClient_DeleteRecordSet(t *testing.T) {
+	ctx := context.TODO()
+
+	testCases := []struct {
+		name        string
+		locked      bool
+		expectCalls []string
+	}{
+		{
+			name:   "case0: locks are lifted once for all deletions and placed again",
+			locked: true,
+			expectCalls: []string{
+				"DeleteManagementLock zone",
+				"DeleteRecordSet api",
+				"DeleteRecordSet apiserver",
+				"CreateOrUpdateManagementLock zone",
+			},
+		},
+		{
+			name: "case1: removed locks stay removed",
+			expectCalls: []string{
+				"DeleteRecordSet api",
+				"DeleteRecordSet apiserver",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newZonesTestService(t, ctx, nil)
+			zoneID, resourceGroupID := lockedResourceIDs(svc)
+
+			locks := &lockClient{locks: map[string]string{}}
+			if tc.locked {
+				locks.locks[zoneID] = managementLockLevelCanNotDelete
+			}
+			svc.azureClient = unlockingClient{client: locks, service: svc}
+
+			for _, name := range []string{"api", "apiserver"} {
+				if err := svc.azureClient.DeleteRecordSet(ctx, "test-cluster", svc.scope.ClusterDomain(), armdns.RecordTypeA, name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := svc.restoreManagementLocks(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := shortenLockCalls(locks.calls, zoneID, resourceGroupID)
+			if !reflect.DeepEqual(calls, tc.expectCalls) {
+				t.Errorf("calls = %#v, want %#v", calls, tc.expectCalls)
+			}
+			if _, ok := locks.locks[zoneID]; ok != tc.locked {
+				t.Errorf("zone locked = %v, want %v", ok, tc.locked)
+			}
+		})
+	}
+}
+
+// lockedResourceIDs returns the resource IDs of the cluster zone and the
+// resource group of the test service.
+func lockedResourceIDs(svc *Service) (string, string) {
+	resourceGroupID := "/subscriptions/" + svc.scope.Patcher.SubscriptionID() + "/resourceGroups/test-cluster"
+	return resourceGroupID + "/providers/Microsoft.Network/dnszones/test-cluster.basedomain.io", resourceGroupID
+}
+
+// shortenLockCalls replaces the resource IDs in calls with the kind of the
+// resource.
+func shortenLockCalls(calls []string, zoneID string, resourceGroupID string) []string {
+	var shortened []string
+	for _, call := range calls {
+		call = strings.Replace(call, zoneID, "zone", 1)
+		call = strings.Replace(call, resourceGroupID, "resource group", 1)
+		shortened = append(shortened, call)
+	}
+	return shortened
+}
@@ -2,51 +2,70 @@ package dns
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
 	"k8s.io/utils/pointer"
-)
 
-const (
-	zoneRecordTTL = 3600
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
 )
 
+// deleteClusterNSRecords deletes the NS delegation of the cluster zone from
+// the base zone or its intermediate zone. A missing intermediate zone holds no
+// delegation to delete.
 func (s *Service) deleteClusterNSRecords(ctx context.Context) error {
 
 	err := s.azureBaseZoneClient.DeleteRecordSet(
 		ctx,
 		s.scope.BaseDomainResourceGroup(),
-		s.scope.BaseDomain(),
+		s.scope.ParentDomain(),
 		armdns.RecordTypeNS,
 		s.scope.Patcher.ClusterName(),
 	)
-	if err != nil {
+	if s.scope.IntermediateDomain() != "" && azure.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		s.scope.Warnf("DNSDelegationDeletionFailed", "Failed to delete NS delegation %s: %s", s.scope.ClusterDomain(), err)
 		return err
 	}
+	s.scope.Eventf("DNSDelegationDeleted", "Deleted NS delegation %s from zone %s", s.scope.ClusterDomain(), s.scope.ParentDomain())
 
 	return nil
 }
 
-// createClusterNSRecord create a NS record in the basedomain
-// for zone delegation
+// createClusterNSRecord create a NS record in the basedomain or the
+// intermediate zone for zone delegation
 func (s *Service) createClusterNSRecord(ctx context.Context, nameServerRecords []*armdns.NsRecord) error {
 
 	_, err := s.azureBaseZoneClient.CreateOrUpdateRecordSet(
 		ctx,
 		s.scope.BaseDomainResourceGroup(),
-		s.scope.BaseDomain(),
+		s.scope.ParentDomain(),
 		armdns.RecordTypeNS,
 		s.scope.Patcher.ClusterName(),
 		armdns.RecordSet{
 			Properties: &armdns.RecordSetProperties{
-				TTL:       pointer.Int64(zoneRecordTTL),
+				TTL:       pointer.Int64(s.scope.RecordTTLs().NS),
 				NsRecords: nameServerRecords,
+				Metadata:  s.ownerMetadata(),
 			},
 		},
 	)
 	if err != nil {
+		s.scope.Warnf("DNSDelegationUpdateFailed", "Failed to write NS delegation %s to zone %s: %s", s.scope.ClusterDomain(), s.scope.ParentDomain(), err)
 		return err
 	}
+	s.scope.Eventf("DNSDelegationUpdated", "Delegated %s to name servers %s in zone %s", s.scope.ClusterDomain(), nameServers(nameServerRecords), s.scope.ParentDomain())
 
 	return nil
 }
+
+func nameServers(nameServerRecords []*armdns.NsRecord) string {
+	var names []string
+	for _, record := range nameServerRecords {
+		if record.Nsdname != nil {
+			names = append(names, *record.Nsdname)
+		}
+	}
+	return strings.Join(names, ",")
+}
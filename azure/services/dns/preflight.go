@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"k8s.io/utils/pointer"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+// preflightRecordName is the TXT record the base zone check writes and
+// deletes again to make sure the credentials can write record sets.
+const preflightRecordName = "_dns-operator-azure-preflight"
+
+type baseZoneCheckClient interface {
+	GetZone(ctx context.Context, resourceGroupName string, zoneName string) (armdns.Zone, error)
+	ListRecordSets(ctx context.Context, resourceGroupName string, zoneName string) ([]*armdns.RecordSet, error)
+	CreateOrUpdateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, recordSetName string, recordSet armdns.RecordSet) (armdns.RecordSet, error)
+	DeleteRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, recordSetName string) error
+}
+
+type BaseZoneCheckParams struct {
+	BaseDomain              string
+	BaseDomainResourceGroup string
+	BaseZoneCredentials     scope.BaseZoneCredentials
+	// Provider checks the base zone instead of Azure DNS if set, the
+	// credentials aren't used then.
+	Provider Provider
+}
+
+// BaseZoneCheck checks that the base zone exists and that the base zone
+// credentials can read and write its record sets, without which every
+// reconciliation fails.
+type BaseZoneCheck struct {
+	baseDomain              string
+	baseDomainResourceGroup string
+
+	client baseZoneCheckClient
+}
+
+// NewBaseZoneCheck creates a new base zone check.
+func NewBaseZoneCheck(params BaseZoneCheckParams) (*BaseZoneCheck, error) {
+	var client baseZoneCheckClient = params.Provider
+	if params.Provider == nil {
+		azureClient, err := newBaseZoneClient(params.BaseZoneCredentials)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		client = azureClient
+	}
+
+	return &BaseZoneCheck{
+		baseDomain:              params.BaseDomain,
+		baseDomainResourceGroup: params.BaseDomainResourceGroup,
+		client:                  client,
+	}, nil
+}
+
+// Check returns a baseZoneCheckFailedError describing the first check that
+// failed. It only reads from the base zone and is safe to run on every
+// replica.
+func (c *BaseZoneCheck) Check(ctx context.Context) error {
+	_, err := c.client.GetZone(ctx, c.baseDomainResourceGroup, c.baseDomain)
+	if azure.IsNotFound(err) {
+		return microerror.Maskf(baseZoneCheckFailedError, "base zone %s doesn't exist in resource group %s", c.baseDomain, c.baseDomainResourceGroup)
+	} else if err != nil {
+		return microerror.Maskf(baseZoneCheckFailedError, "failed to get base zone %s in resource group %s: %s", c.baseDomain, c.baseDomainResourceGroup, err)
+	}
+
+	_, err = c.client.ListRecordSets(ctx, c.baseDomainResourceGroup, c.baseDomain)
+	if err != nil {
+		return microerror.Maskf(baseZoneCheckFailedError, "failed to read record sets of base zone %s: %s", c.baseDomain, err)
+	}
+
+	return nil
+}
+
+// CheckWrite returns a baseZoneCheckFailedError if the base zone credentials
+// can't write record sets. Writing is checked by creating a TXT record, which
+// is deleted right away, so it must only run on the leader: replicas checking
+// at the same time would delete each other's record.
+func (c *BaseZoneCheck) CheckWrite(ctx context.Context) error {
+	_, err := c.client.CreateOrUpdateRecordSet(ctx, c.baseDomainResourceGroup, c.baseDomain, armdns.RecordTypeTXT, preflightRecordName, armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL: pointer.Int64(60),
+			TxtRecords: []*armdns.TxtRecord{
+				{Value: []*string{pointer.String("written by the dns-operator-azure preflight check")}},
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Maskf(baseZoneCheckFailedError, "failed to write record sets of base zone %s: %s", c.baseDomain, err)
+	}
+
+	err = c.client.DeleteRecordSet(ctx, c.baseDomainResourceGroup, c.baseDomain, armdns.RecordTypeTXT, preflightRecordName)
+	if err != nil {
+		return microerror.Maskf(baseZoneCheckFailedError, "failed to delete record sets of base zone %s: %s", c.baseDomain, err)
+	}
+
+	return nil
+}
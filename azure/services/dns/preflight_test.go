@@ -0,0 +1,93 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+type fakeBaseZoneCheckClient struct {
+	getZoneErr error
+	listErr    error
+	writeErr   error
+
+	calls []string
+}
+
+func (c *fakeBaseZoneCheckClient) GetZone(context.Context, string, string) (armdns.Zone, error) {
+	c.calls = append(c.calls, "get zone")
+	return armdns.Zone{}, c.getZoneErr
+}
+
+func (c *fakeBaseZoneCheckClient) ListRecordSets(context.Context, string, string) ([]*armdns.RecordSet, error) {
+	c.calls = append(c.calls, "list")
+	return nil, c.listErr
+}
+
+func (c *fakeBaseZoneCheckClient) CreateOrUpdateRecordSet(_ context.Context, _ string, _ string, recordType armdns.RecordType, name string, recordSet armdns.RecordSet) (armdns.RecordSet, error) {
+	c.calls = append(c.calls, "write "+name+" "+string(recordType))
+	return recordSet, c.writeErr
+}
+
+func (c *fakeBaseZoneCheckClient) DeleteRecordSet(_ context.Context, _ string, _ string, recordType armdns.RecordType, name string) error {
+	c.calls = append(c.calls, "delete "+name+" "+string(recordType))
+	return nil
+}
+
+func Test_BaseZoneCheck(t *testing.T) {
+	testCases := []struct {
+		name        string
+		client      *fakeBaseZoneCheckClient
+		expectErr   string
+		expectCalls []string
+	}{
+		{
+			name:        "case0: base zone is readable and writable",
+			client:      &fakeBaseZoneCheckClient{},
+			expectCalls: []string{"get zone", "list", "write _dns-operator-azure-preflight TXT", "delete _dns-operator-azure-preflight TXT"},
+		},
+		{
+			name:        "case1: base zone doesn't exist",
+			client:      &fakeBaseZoneCheckClient{getZoneErr: &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ResourceNotFound"}},
+			expectErr:   "base zone check failed error: base zone example.com doesn't exist in resource group dns_rg",
+			expectCalls: []string{"get zone"},
+		},
+		{
+			name:        "case2: credentials can't write record sets",
+			client:      &fakeBaseZoneCheckClient{writeErr: errors.New("AuthorizationFailed")},
+			expectErr:   "base zone check failed error: failed to write record sets of base zone example.com: AuthorizationFailed",
+			expectCalls: []string{"get zone", "list", "write _dns-operator-azure-preflight TXT"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := &BaseZoneCheck{
+				baseDomain:              "example.com",
+				baseDomainResourceGroup: "dns_rg",
+				client:                  tc.client,
+			}
+
+			err := check.Check(context.TODO())
+			if err == nil {
+				err = check.CheckWrite(context.TODO())
+			}
+			switch {
+			case tc.expectErr == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tc.expectErr != "" && !IsBaseZoneCheckFailed(err):
+				t.Errorf("expected baseZoneCheckFailedError, got %#v", err)
+			case tc.expectErr != "" && err.Error() != tc.expectErr:
+				t.Errorf("Check() = %q, want %q", err.Error(), tc.expectErr)
+			}
+			if !reflect.DeepEqual(tc.client.calls, tc.expectCalls) {
+				t.Errorf("calls = %v, want %v", tc.client.calls, tc.expectCalls)
+			}
+		})
+	}
+}
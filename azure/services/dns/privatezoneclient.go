@@ -0,0 +1,224 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/giantswarm/microerror"
+	"k8s.io/utils/pointer"
+	capzazure "sigs.k8s.io/cluster-api-provider-azure/azure"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+// privateZoneClient manages the private zones of split-horizon clusters.
+type privateZoneClient interface {
+	GetPrivateZone(ctx context.Context, resourceGroupName string, zoneName string) (armprivatedns.PrivateZone, error)
+	CreateOrUpdatePrivateZone(ctx context.Context, resourceGroupName string, zoneName string, zone armprivatedns.PrivateZone) error
+	DeletePrivateZone(ctx context.Context, resourceGroupName string, zoneName string) error
+
+	ListPrivateRecordSets(ctx context.Context, resourceGroupName string, zoneName string) ([]*armprivatedns.RecordSet, error)
+	CreateOrUpdatePrivateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armprivatedns.RecordType, recordSetName string, recordSet armprivatedns.RecordSet) error
+	DeletePrivateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armprivatedns.RecordType, recordSetName string) error
+
+	ListVirtualNetworkLinks(ctx context.Context, resourceGroupName string, zoneName string) ([]*armprivatedns.VirtualNetworkLink, error)
+	CreateOrUpdateVirtualNetworkLink(ctx context.Context, resourceGroupName string, zoneName string, linkName string, link armprivatedns.VirtualNetworkLink) error
+	DeleteVirtualNetworkLink(ctx context.Context, resourceGroupName string, zoneName string, linkName string) error
+}
+
+type azurePrivateZoneClient struct {
+	privateZones        *armprivatedns.PrivateZonesClient
+	recordSets          *armprivatedns.RecordSetsClient
+	virtualNetworkLinks *armprivatedns.VirtualNetworkLinksClient
+}
+
+var _ privateZoneClient = (*azurePrivateZoneClient)(nil)
+
+func newPrivateZoneClient(subscriptionID string, cred azcore.TokenCredential) (*azurePrivateZoneClient, error) {
+	privateZonesClient, err := armprivatedns.NewPrivateZonesClient(subscriptionID, cred, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	recordSetsClient, err := armprivatedns.NewRecordSetsClient(subscriptionID, cred, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	virtualNetworkLinksClient, err := armprivatedns.NewVirtualNetworkLinksClient(subscriptionID, cred, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return &azurePrivateZoneClient{
+		privateZones:        privateZonesClient,
+		recordSets:          recordSetsClient,
+		virtualNetworkLinks: virtualNetworkLinksClient,
+	}, nil
+}
+
+func (ac *azurePrivateZoneClient) GetPrivateZone(ctx context.Context, resourceGroupName string, zoneName string) (armprivatedns.PrivateZone, error) {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="privateZones.Get"}
+	metrics.AzureRequest.WithLabelValues("privateZones.Get").Inc()
+
+	resp, err := ac.privateZones.Get(ctx, resourceGroupName, zoneName, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="privateZones.Get"}
+		metrics.AzureRequestError.WithLabelValues("privateZones.Get").Inc()
+		return armprivatedns.PrivateZone{}, microerror.Mask(err)
+	}
+
+	return resp.PrivateZone, nil
+}
+
+func (ac *azurePrivateZoneClient) CreateOrUpdatePrivateZone(ctx context.Context, resourceGroupName string, zoneName string, zone armprivatedns.PrivateZone) error {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="privateZones.BeginCreateOrUpdate"}
+	metrics.AzureRequest.WithLabelValues("privateZones.BeginCreateOrUpdate").Inc()
+
+	poller, err := ac.privateZones.BeginCreateOrUpdate(ctx, resourceGroupName, zoneName, zone, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="privateZones.BeginCreateOrUpdate"}
+		metrics.AzureRequestError.WithLabelValues("privateZones.BeginCreateOrUpdate").Inc()
+		return microerror.Mask(err)
+	}
+
+	return pollUntilDone(ctx, poller)
+}
+
+func (ac *azurePrivateZoneClient) DeletePrivateZone(ctx context.Context, resourceGroupName string, zoneName string) error {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="privateZones.BeginDelete"}
+	metrics.AzureRequest.WithLabelValues("privateZones.BeginDelete").Inc()
+
+	poller, err := ac.privateZones.BeginDelete(ctx, resourceGroupName, zoneName, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="privateZones.BeginDelete"}
+		metrics.AzureRequestError.WithLabelValues("privateZones.BeginDelete").Inc()
+		return microerror.Mask(err)
+	}
+
+	return pollUntilDone(ctx, poller)
+}
+
+// ListPrivateRecordSets lists the record sets of all types in the private
+// zone zoneName.
+func (ac *azurePrivateZoneClient) ListPrivateRecordSets(ctx context.Context, resourceGroupName string, zoneName string) ([]*armprivatedns.RecordSet, error) {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="privateRecordSets.NewListPager"}
+	metrics.AzureRequest.WithLabelValues("privateRecordSets.NewListPager").Inc()
+
+	recordSetsResultPager := ac.recordSets.NewListPager(resourceGroupName, zoneName, nil)
+	var recordSets []*armprivatedns.RecordSet
+	for recordSetsResultPager.More() {
+		nextPage, err := recordSetsResultPager.NextPage(ctx)
+		if err != nil {
+			// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="privateRecordSets.NewListPager"}
+			metrics.AzureRequestError.WithLabelValues("privateRecordSets.NewListPager").Inc()
+			return nil, microerror.Mask(err)
+		}
+		recordSets = append(recordSets, nextPage.Value...)
+	}
+
+	return recordSets, nil
+}
+
+func (ac *azurePrivateZoneClient) CreateOrUpdatePrivateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armprivatedns.RecordType, recordSetName string, recordSet armprivatedns.RecordSet) error {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="privateRecordSets.CreateOrUpdate"}
+	metrics.AzureRequest.WithLabelValues("privateRecordSets.CreateOrUpdate").Inc()
+
+	_, err := ac.recordSets.CreateOrUpdate(ctx, resourceGroupName, zoneName, recordType, recordSetName, recordSet, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="privateRecordSets.CreateOrUpdate"}
+		metrics.AzureRequestError.WithLabelValues("privateRecordSets.CreateOrUpdate").Inc()
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (ac *azurePrivateZoneClient) DeletePrivateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armprivatedns.RecordType, recordSetName string) error {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="privateRecordSets.Delete"}
+	metrics.AzureRequest.WithLabelValues("privateRecordSets.Delete").Inc()
+
+	_, err := ac.recordSets.Delete(ctx, resourceGroupName, zoneName, recordType, recordSetName, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="privateRecordSets.Delete"}
+		metrics.AzureRequestError.WithLabelValues("privateRecordSets.Delete").Inc()
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (ac *azurePrivateZoneClient) ListVirtualNetworkLinks(ctx context.Context, resourceGroupName string, zoneName string) ([]*armprivatedns.VirtualNetworkLink, error) {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="virtualNetworkLinkClient.NewListPager"}
+	metrics.AzureRequest.WithLabelValues("virtualNetworkLinkClient.NewListPager").Inc()
+
+	linksResultPager := ac.virtualNetworkLinks.NewListPager(resourceGroupName, zoneName, nil)
+	var links []*armprivatedns.VirtualNetworkLink
+	for linksResultPager.More() {
+		nextPage, err := linksResultPager.NextPage(ctx)
+		if err != nil {
+			// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="virtualNetworkLinkClient.NewListPager"}
+			metrics.AzureRequestError.WithLabelValues("virtualNetworkLinkClient.NewListPager").Inc()
+			return nil, microerror.Mask(err)
+		}
+		links = append(links, nextPage.Value...)
+	}
+
+	return links, nil
+}
+
+func (ac *azurePrivateZoneClient) CreateOrUpdateVirtualNetworkLink(ctx context.Context, resourceGroupName string, zoneName string, linkName string, link armprivatedns.VirtualNetworkLink) error {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="virtualNetworkLinkClient.BeginCreateOrUpdate"}
+	metrics.AzureRequest.WithLabelValues("virtualNetworkLinkClient.BeginCreateOrUpdate").Inc()
+
+	if link.Location == nil {
+		link.Location = pointer.String(capzazure.Global)
+	}
+
+	poller, err := ac.virtualNetworkLinks.BeginCreateOrUpdate(ctx, resourceGroupName, zoneName, linkName, link, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="virtualNetworkLinkClient.BeginCreateOrUpdate"}
+		metrics.AzureRequestError.WithLabelValues("virtualNetworkLinkClient.BeginCreateOrUpdate").Inc()
+		return microerror.Mask(err)
+	}
+
+	return pollUntilDone(ctx, poller)
+}
+
+func (ac *azurePrivateZoneClient) DeleteVirtualNetworkLink(ctx context.Context, resourceGroupName string, zoneName string, linkName string) error {
+
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="virtualNetworkLinkClient.BeginDelete"}
+	metrics.AzureRequest.WithLabelValues("virtualNetworkLinkClient.BeginDelete").Inc()
+
+	poller, err := ac.virtualNetworkLinks.BeginDelete(ctx, resourceGroupName, zoneName, linkName, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="virtualNetworkLinkClient.BeginDelete"}
+		metrics.AzureRequestError.WithLabelValues("virtualNetworkLinkClient.BeginDelete").Inc()
+		return microerror.Mask(err)
+	}
+
+	return pollUntilDone(ctx, poller)
+}
+
+// pollUntilDone waits for the long-running operation of poller to finish.
+func pollUntilDone[T any](ctx context.Context, poller *runtime.Poller[T]) error {
+	// dns_operator_api_request_total{controller="dns-operator-azure",method="poller.PollUntilDone"}
+	metrics.AzureRequest.WithLabelValues("poller.PollUntilDone").Inc()
+
+	_, err := poller.PollUntilDone(ctx, nil)
+	if err != nil {
+		// dns_operator_api_request_errors_total{controller="dns-operator-azure",method="poller.PollUntilDone"}
+		metrics.AzureRequestError.WithLabelValues("poller.PollUntilDone").Inc()
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
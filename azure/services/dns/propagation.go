@@ -0,0 +1,246 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/dnsquery"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+// Querier asks a name server directly, see dnsquery.Querier.
+type Querier interface {
+	Query(ctx context.Context, server, name string, qtype dnsmessage.Type) (dnsquery.Answer, error)
+}
+
+// PropagationReport is the outcome of CheckPropagation.
+type PropagationReport struct {
+	// Checked is the number of answers compared.
+	Checked int
+	// Mismatches describe the answers that differ from the records in Azure,
+	// e.g. "api.glippy.example.com A at ns1-01.azure-dns.com: got none, want 1.2.3.4".
+	Mismatches []string
+}
+
+// Propagated reports whether all answers match the records in Azure.
+func (r PropagationReport) Propagated() bool {
+	return len(r.Mismatches) == 0
+}
+
+const (
+	// propagationCheckTimeout bounds all queries of a propagation check, so
+	// that unresponsive name servers don't hold up the reconciliation.
+	propagationCheckTimeout = 30 * time.Second
+	// propagationCheckConcurrency is the number of queries sent at once.
+	propagationCheckConcurrency = 10
+)
+
+// propagationQuery is a question to a name server and the answer expected
+// from the records in Azure.
+type propagationQuery struct {
+	server     string
+	name       string
+	recordType armdns.RecordType
+	want       []string
+}
+
+// CheckPropagation asks every name server of the base zone, or of the
+// intermediate zone, for the NS delegation of the cluster zone, every name
+// server of the base zone for the delegation of the intermediate zone, and
+// every name server of each zone the operator writes A and CNAME records to,
+// i.e. the cluster zone, the base zone and the additional zones, for those
+// records. Records left alone because of conflicts, see Conflicts, aren't
+// checked. The queries are sent concurrently and share one deadline,
+// unanswered queries are reported as mismatches. It must be called after
+// Reconcile.
+func (s *Service) CheckPropagation(ctx context.Context, querier Querier) (PropagationReport, error) {
+	clusterZoneName := s.scope.ClusterDomain()
+
+	clusterZone, err := s.azureClient.GetZone(ctx, s.scope.ResourceGroup(), clusterZoneName)
+	if err != nil {
+		return PropagationReport{}, microerror.Mask(err)
+	}
+	parentZone, err := s.azureBaseZoneClient.GetZone(ctx, s.scope.BaseDomainResourceGroup(), s.scope.ParentDomain())
+	if err != nil {
+		return PropagationReport{}, microerror.Mask(err)
+	}
+
+	// name servers are keyed by zone name
+	nameServers := map[string][]string{
+		clusterZoneName:        zoneNameServers(clusterZone),
+		s.scope.ParentDomain(): zoneNameServers(parentZone),
+	}
+
+	var queries []propagationQuery
+	delegate := func(parentZoneName, zoneName string) {
+		for _, server := range nameServers[parentZoneName] {
+			queries = append(queries, propagationQuery{server: server, name: zoneName, recordType: armdns.RecordTypeNS, want: nameServers[zoneName]})
+		}
+	}
+	delegate(s.scope.ParentDomain(), clusterZoneName)
+
+	if intermediateZoneName := s.scope.IntermediateDomain(); intermediateZoneName != "" {
+		baseZone, err := s.azureBaseZoneClient.GetZone(ctx, s.scope.BaseDomainResourceGroup(), s.scope.BaseDomain())
+		if err != nil {
+			return PropagationReport{}, microerror.Mask(err)
+		}
+		nameServers[s.scope.BaseDomain()] = zoneNameServers(baseZone)
+		delegate(s.scope.BaseDomain(), intermediateZoneName)
+	}
+
+	if s.scope.ManagesRecords(infracluster.ManagedRecordsRecords) {
+		desiredRecordSets, err := s.getDesiredARecords(ctx)
+		if err != nil {
+			return PropagationReport{}, microerror.Mask(err)
+		}
+		desiredRecordSets[clusterZoneName] = append(desiredRecordSets[clusterZoneName], s.desiredCnameRecords()...)
+
+		for _, z := range s.managedZones() {
+			if len(desiredRecordSets[z.name]) == 0 {
+				continue
+			}
+			if _, ok := nameServers[z.name]; !ok {
+				zone, err := z.client.GetZone(ctx, z.resourceGroup, z.name)
+				if err != nil {
+					return PropagationReport{}, microerror.Mask(err)
+				}
+				nameServers[z.name] = zoneNameServers(zone)
+			}
+
+			for _, recordSet := range desiredRecordSets[z.name] {
+				fqdn := recordSetFQDN(*recordSet.Name, z.name)
+				if slices.Contains(s.conflicts, fqdn) {
+					continue
+				}
+				recordType, want := s.expectedAnswer(recordSet)
+				for _, server := range nameServers[z.name] {
+					queries = append(queries, propagationQuery{server: server, name: fqdn, recordType: recordType, want: want})
+				}
+			}
+		}
+	}
+
+	report := checkPropagation(ctx, querier, queries)
+
+	// dns_operator_azure_zone_propagation_mismatches{controller="dns-operator-azure",zone="glippy.azuretest.gigantic.io"} 0
+	metrics.ZonePropagationMismatches.WithLabelValues(clusterZoneName).Set(float64(len(report.Mismatches)))
+
+	return report, nil
+}
+
+// checkPropagation sends queries concurrently until propagationCheckTimeout
+// passed, and reports the mismatches in the order of queries.
+func checkPropagation(ctx context.Context, querier Querier, queries []propagationQuery) PropagationReport {
+	ctx, cancel := context.WithTimeout(ctx, propagationCheckTimeout)
+	defer cancel()
+
+	mismatches := make([]string, len(queries))
+	semaphore := make(chan struct{}, propagationCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			mismatches[i] = query.check(ctx, querier)
+		}()
+	}
+	wg.Wait()
+
+	report := PropagationReport{Checked: len(queries)}
+	for _, mismatch := range mismatches {
+		if mismatch != "" {
+			report.Mismatches = append(report.Mismatches, mismatch)
+		}
+	}
+	return report
+}
+
+// check asks the name server for the records and returns the mismatch if
+// they aren't the expected ones, an empty string otherwise.
+func (q propagationQuery) check(ctx context.Context, querier Querier) string {
+	var qtype dnsmessage.Type
+	switch q.recordType {
+	case armdns.RecordTypeA:
+		qtype = dnsmessage.TypeA
+	case armdns.RecordTypeCNAME:
+		qtype = dnsmessage.TypeCNAME
+	case armdns.RecordTypeNS:
+		qtype = dnsmessage.TypeNS
+	}
+
+	answer, err := querier.Query(ctx, q.server, q.name, qtype)
+	if err != nil {
+		return fmt.Sprintf("%s %s at %s: %s", q.name, q.recordType, q.server, err)
+	}
+
+	var got []string
+	switch q.recordType {
+	case armdns.RecordTypeA:
+		got = answer.A
+	case armdns.RecordTypeCNAME:
+		if answer.CNAME != "" {
+			got = []string{answer.CNAME}
+		}
+	case armdns.RecordTypeNS:
+		got = answer.NS
+	}
+
+	if !slices.Equal(got, q.want) {
+		return fmt.Sprintf("%s %s at %s: got %s, want %s", q.name, q.recordType, q.server, answerValue(got), answerValue(q.want))
+	}
+	return ""
+}
+
+// expectedAnswer returns the type and the sorted, normalized values a name
+// server should answer for recordSet. Alias record sets are answered with the
+// addresses of their target.
+func (s *Service) expectedAnswer(recordSet *armdns.RecordSet) (armdns.RecordType, []string) {
+	recordType := recordSetType(recordSet)
+
+	want := s.recordSetAddresses(recordSet)
+	if recordSet.Properties != nil {
+		if record := recordSet.Properties.CnameRecord; record != nil && record.Cname != nil {
+			want = append(want, normalizeName(*record.Cname))
+		}
+	}
+
+	return recordType, want
+}
+
+// zoneNameServers returns the sorted, normalized name servers Azure assigned
+// to zone.
+func zoneNameServers(zone armdns.Zone) []string {
+	var nameServers []string
+	if zone.Properties != nil {
+		for _, nameServer := range zone.Properties.NameServers {
+			if nameServer != nil {
+				nameServers = append(nameServers, normalizeName(*nameServer))
+			}
+		}
+	}
+	sort.Strings(nameServers)
+	return nameServers
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+func answerValue(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ",")
+}
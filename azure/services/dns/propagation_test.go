@@ -0,0 +1,177 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"golang.org/x/net/dns/dnsmessage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzscope "sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/dnsquery"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+)
+
+// zoneClient serves a zone with the given name servers.
+type zoneClient struct {
+	client
+
+	nameServers []string
+}
+
+func (c *zoneClient) GetZone(context.Context, string, string) (armdns.Zone, error) {
+	zone := armdns.Zone{Properties: &armdns.ZoneProperties{}}
+	for _, nameServer := range c.nameServers {
+		zone.Properties.NameServers = append(zone.Properties.NameServers, pointer.String(nameServer))
+	}
+	return zone, nil
+}
+
+// fakeQuerier answers from a map keyed by "<server> <name> <type>".
+type fakeQuerier map[string]dnsquery.Answer
+
+func (q fakeQuerier) Query(_ context.Context, server, name string, qtype dnsmessage.Type) (dnsquery.Answer, error) {
+	answer, ok := q[server+" "+name+" "+qtype.String()]
+	if !ok {
+		return dnsquery.Answer{}, errors.New("i/o timeout")
+	}
+	return answer, nil
+}
+
+func TestService_CheckPropagation(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.Patcher.(*capzscope.ClusterScope).AzureCluster.Spec.NetworkSpec.APIServerLB = &infrav1.LoadBalancerSpec{
+		FrontendIPs: []infrav1.FrontendIP{{PublicIP: &infrav1.PublicIPSpec{Name: "1.2.3.4"}}},
+	}
+	svc.azureClient = &zoneClient{nameServers: []string{"NS1-01.azure-dns.com.", "ns2-01.azure-dns.net."}}
+	svc.azureBaseZoneClient = &zoneClient{nameServers: []string{"ns1-09.azure-dns.com.", "ns2-09.azure-dns.net."}}
+
+	delegation := dnsquery.Answer{NS: []string{"ns1-01.azure-dns.com", "ns2-01.azure-dns.net"}}
+	api := dnsquery.Answer{A: []string{"1.2.3.4"}}
+	wildcard := dnsquery.Answer{CNAME: "ingress.test-cluster.basedomain.io"}
+
+	querier := fakeQuerier{
+		"ns1-09.azure-dns.com test-cluster.basedomain.io TypeNS":          delegation,
+		"ns2-09.azure-dns.net test-cluster.basedomain.io TypeNS":          {NS: []string{"ns1-05.azure-dns.com"}},
+		"ns1-01.azure-dns.com api.test-cluster.basedomain.io TypeA":       api,
+		"ns2-01.azure-dns.net api.test-cluster.basedomain.io TypeA":       api,
+		"ns1-01.azure-dns.com apiserver.test-cluster.basedomain.io TypeA": api,
+		"ns2-01.azure-dns.net apiserver.test-cluster.basedomain.io TypeA": {},
+		"ns1-01.azure-dns.com *.test-cluster.basedomain.io TypeCNAME":     wildcard,
+		"ns2-01.azure-dns.net *.test-cluster.basedomain.io TypeCNAME":     wildcard,
+	}
+
+	report, err := svc.CheckPropagation(ctx, querier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := PropagationReport{
+		Checked: 8,
+		Mismatches: []string{
+			"test-cluster.basedomain.io NS at ns2-09.azure-dns.net: got ns1-05.azure-dns.com, want ns1-01.azure-dns.com,ns2-01.azure-dns.net",
+			"apiserver.test-cluster.basedomain.io A at ns2-01.azure-dns.net: got none, want 1.2.3.4",
+		},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CheckPropagation() = %+v, want %+v", report, want)
+	}
+
+	// conflicting records are left alone, so they aren't expected to resolve
+	svc.conflicts = []string{"apiserver.test-cluster.basedomain.io"}
+	report, err = svc.CheckPropagation(ctx, querier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 6 || len(report.Mismatches) != 1 {
+		t.Errorf("CheckPropagation() with conflicts = %+v, want 6 answers checked and 1 mismatch", report)
+	}
+}
+
+func TestService_CheckPropagation_sharedZones(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newGatewayTestService(t, ctx, []*corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "envoy-gateway",
+				Namespace: gatewayNamespace,
+				Annotations: map[string]string{
+					externalDNSManagedAnnotation:  externalDNSManagedValue,
+					externalDNSHostnameAnnotation: "gw.basedomain.io",
+				},
+			},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{{IP: "5.6.7.8"}},
+				},
+			},
+		},
+	})
+	// service hostnames are only published for non-Azure clusters
+	svc.scope.InfraCluster.SetKind("VSphereCluster")
+	svc.scope.Provider = infracluster.ProviderFor(svc.scope.InfraCluster.GroupVersionKind().GroupKind())
+	svc.azureClient = &zoneClient{nameServers: []string{"ns1-01.azure-dns.com."}}
+	svc.azureBaseZoneClient = &zoneClient{nameServers: []string{"ns1-09.azure-dns.com."}}
+
+	api := dnsquery.Answer{CNAME: "api-server.mydomain.io"}
+	querier := fakeQuerier{
+		"ns1-09.azure-dns.com test-cluster.basedomain.io TypeNS":              {NS: []string{"ns1-01.azure-dns.com"}},
+		"ns1-01.azure-dns.com api.test-cluster.basedomain.io TypeCNAME":       api,
+		"ns1-01.azure-dns.com apiserver.test-cluster.basedomain.io TypeCNAME": api,
+		"ns1-01.azure-dns.com *.test-cluster.basedomain.io TypeCNAME":         {CNAME: "ingress.test-cluster.basedomain.io"},
+	}
+
+	report, err := svc.CheckPropagation(ctx, querier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hostname record in the base zone is asked from its name servers
+	want := PropagationReport{
+		Checked:    5,
+		Mismatches: []string{"gw.basedomain.io A at ns1-09.azure-dns.com: i/o timeout"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CheckPropagation() = %+v, want %+v", report, want)
+	}
+}
+
+func TestService_CheckPropagation_intermediateZone(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newIntermediateZoneTestService(t, ctx, scope.IntermediateZoneModeOrganization, map[string]string{scope.LabelOrganization: "acme"}, map[string]string{
+		infracluster.AnnotationManagedRecords: infracluster.ManagedRecordsZone,
+	})
+	svc.azureClient = &zoneClient{nameServers: []string{"ns1-01.azure-dns.com."}}
+	svc.azureBaseZoneClient = &zoneClient{nameServers: []string{"ns1-09.azure-dns.com."}}
+
+	querier := fakeQuerier{
+		"ns1-09.azure-dns.com test-cluster.acme.basedomain.io TypeNS": {NS: []string{"ns1-01.azure-dns.com"}},
+	}
+
+	report, err := svc.CheckPropagation(ctx, querier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the delegation of the intermediate zone from the base zone is checked
+	// as well
+	want := PropagationReport{
+		Checked:    2,
+		Mismatches: []string{"acme.basedomain.io NS at ns1-09.azure-dns.com: i/o timeout"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CheckPropagation() = %+v, want %+v", report, want)
+	}
+}
@@ -2,11 +2,23 @@ package dns
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
 	"github.com/giantswarm/microerror"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+)
+
+const (
+	dnsZoneResourceType = "Microsoft.Network/dnszones"
+
+	// maxForeignResources limits the foreign resources listed when a
+	// resource group is kept.
+	maxForeignResources = 5
 )
 
 func (s *Service) createClusterResourceGroup(ctx context.Context) (armresources.ResourceGroup, error) {
@@ -44,15 +56,15 @@ func (s *Service) createClusterResourceGroup(ctx context.Context) (armresources.
 	resourceGroupParams := armresources.ResourceGroup{
 		Name:     &resourceGroupName,
 		Location: location,
-	}
-	if tags := s.scope.ResourceTags(); tags != nil {
-		resourceGroupParams.Tags = tags
+		Tags:     azure.UpdateManagedTags(nil, s.resourceGroupTags()),
 	}
 
 	resourceGroup, err := s.azureClient.CreateOrUpdateResourceGroup(ctx, resourceGroupName, resourceGroupParams)
 	if err != nil {
+		s.scope.Warnf("DNSResourceGroupCreationFailed", "Failed to create resource group %s: %s", resourceGroupName, err)
 		return armresources.ResourceGroup{}, microerror.Mask(err)
 	}
+	s.scope.Eventf("DNSResourceGroupCreated", "Created resource group %s", resourceGroupName)
 	logger.Info("Successfully created resource group", "resource group", resourceGroupName)
 
 	return resourceGroup, nil
@@ -62,53 +74,172 @@ func (s *Service) updateClusterResourceGroup(ctx context.Context, existingResour
 	logger := log.FromContext(ctx)
 	resourceGroupName := s.scope.ResourceGroup()
 
-	// check whether tags need to be updated
-	tags := s.scope.ResourceTags()
-	if !resourceGroupTagsEqual(existingResourceGroup.Tags, tags) {
+	// resource groups the operator didn't create, e.g. one of the same name
+	// created by someone else, are never claimed
+	owned := s.isOwnedTags(existingResourceGroup.Tags)
+	if !owned {
+		legacy, err := s.isLegacyResourceGroup(ctx, existingResourceGroup)
+		if err != nil {
+			return armresources.ResourceGroup{}, microerror.Mask(err)
+		}
+		if legacy {
+			logger.Info("Tagging resource group created before ownership tracking", "resource group", resourceGroupName)
+		}
+		owned = legacy
+	}
+	if !owned {
+		s.resourceGroupNotOwned = microerror.Maskf(resourceGroupNotOwnedError, "resource group %s wasn't created by dns-operator-azure for the cluster, it is neither tagged, locked nor deleted", resourceGroupName)
+		logger.Info("Resource group isn't owned by the cluster, leaving it alone", "resource group", resourceGroupName)
+		s.scope.Warnf("DNSResourceGroupNotOwned", "Resource group %s wasn't created by dns-operator-azure for this cluster, its tags are left alone and it is neither locked nor deleted", resourceGroupName)
+		return existingResourceGroup, nil
+	}
+
+	// check whether tags need to be updated, tags whose annotation was
+	// removed are removed as well
+	tags := azure.UpdateManagedTags(existingResourceGroup.Tags, s.resourceGroupTags())
+	if !azure.TagsEqual(existingResourceGroup.Tags, tags) {
 		logger.V(1).Info("updating resource group tags",
 			"resource group", resourceGroupName,
 			"tags", tags,
 		)
 
-		existingResourceGroup.Tags = mergeResourceTags(existingResourceGroup.Tags, tags)
+		existingResourceGroup.Tags = tags
 		existingResourceGroup.Properties.ProvisioningState = nil
 		_, err := s.azureClient.CreateOrUpdateResourceGroup(ctx, resourceGroupName, existingResourceGroup)
 		if err != nil {
+			s.scope.Warnf("DNSResourceGroupUpdateFailed", "Failed to update tags of resource group %s: %s", resourceGroupName, err)
 			return armresources.ResourceGroup{}, microerror.Mask(err)
 		}
+		s.scope.Eventf("DNSResourceGroupTagged", "Updated tags of resource group %s", resourceGroupName)
 		logger.Info("Successfully updated resource group tags", "resource group", resourceGroupName)
 	}
 
 	return existingResourceGroup, nil
 }
 
+// deleteClusterResourceGroup deletes the resource group of non-Azure
+// clusters. Resource groups the operator doesn't own, or that hold other
+// resources than the cluster zone, are kept and only the cluster zone is
+// deleted, see ResourceGroupKept.
 func (s *Service) deleteClusterResourceGroup(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 
 	resourceGroupName := s.scope.ResourceGroup()
+	s.resourceGroupKept = nil
+
+	resourceGroup, err := s.azureClient.GetResourceGroup(ctx, resourceGroupName)
+	if IsResourceNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	owned := s.isOwnedTags(resourceGroup.Tags)
+	if !owned {
+		owned, err = s.isLegacyResourceGroup(ctx, resourceGroup)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+	if !owned {
+		s.resourceGroupKept = microerror.Maskf(resourceGroupKeptError, "resource group %s is not owned by the cluster", resourceGroupName)
+		return s.deleteClusterZoneOnly(ctx)
+	}
+
+	resources, err := s.azureClient.ListResourceGroupResources(ctx, resourceGroupName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if foreign := s.foreignResources(resources); len(foreign) > 0 {
+		if len(foreign) > maxForeignResources {
+			foreign = append(foreign[:maxForeignResources:maxForeignResources], fmt.Sprintf("%d more", len(foreign)-maxForeignResources))
+		}
+		s.resourceGroupKept = microerror.Maskf(resourceGroupKeptError, "resource group %s holds resources the operator didn't create: %s", resourceGroupName, strings.Join(foreign, ", "))
+		return s.deleteClusterZoneOnly(ctx)
+	}
 
-	err := s.azureClient.DeleteResourceGroup(ctx, resourceGroupName)
-	if err != nil && !IsResourceNotFoundError(err) {
+	err = s.azureClient.DeleteResourceGroup(ctx, resourceGroupName)
+	if IsResourceNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		s.scope.Warnf("DNSResourceGroupDeletionFailed", "Failed to delete resource group %s: %s", resourceGroupName, err)
 		return microerror.Mask(err)
 	}
+	s.scope.Eventf("DNSResourceGroupDeleted", "Deleted resource group %s with the DNS zone %s", resourceGroupName, s.scope.ClusterDomain())
 
 	logger.Info("Successfully deleted resource group", "resource group", resourceGroupName)
 	return nil
 }
 
-func resourceGroupTagsEqual(existingTags map[string]*string, newTags map[string]*string) bool {
-	if len(existingTags) != len(newTags) {
-		return false
+// isLegacyResourceGroup reports whether resourceGroup, the resource group of
+// the cluster without its owner tags, was created by an operator version that
+// didn't tag resource groups with their owner yet: it carries no owner tag of
+// any cluster or operator instance and holds nothing but the cluster zone.
+func (s *Service) isLegacyResourceGroup(ctx context.Context, resourceGroup armresources.ResourceGroup) (bool, error) {
+	if hasOwnerMarker(resourceGroup.Tags) {
+		return false, nil
 	}
 
-	for key, value := range newTags {
-		existingValue, ok := existingTags[key]
-		if !ok || *existingValue != *value {
-			return false
+	resources, err := s.azureClient.ListResourceGroupResources(ctx, s.scope.ResourceGroup())
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return len(resources) > 0 && len(s.foreignResources(resources)) == 0, nil
+}
+
+// deleteClusterZoneOnly deletes the cluster zone of a non-Azure cluster
+// whose resource group is kept for the reason in resourceGroupKept.
+func (s *Service) deleteClusterZoneOnly(ctx context.Context) error {
+	resourceGroupName := s.scope.ResourceGroup()
+	zoneName := s.scope.ClusterDomain()
+
+	log.FromContext(ctx).Info("Keeping resource group, deleting the DNS zone only", "resource group", resourceGroupName, "reason", s.resourceGroupKept.Error())
+	s.scope.Warnf("DNSResourceGroupKept", "Kept resource group %s: %s", resourceGroupName, s.resourceGroupKept)
+
+	err := s.azureClient.DeleteZone(ctx, resourceGroupName, zoneName)
+	if azure.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		s.scope.Warnf("DNSZoneDeletionFailed", "Failed to delete DNS zone %s: %s", zoneName, err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("DNSZoneDeleted", "Deleted DNS zone %s from resource group %s", zoneName, resourceGroupName)
+
+	return nil
+}
+
+// foreignResources describes the resources that aren't the cluster zone, e.g.
+// "Microsoft.Compute/virtualMachines/bastion".
+func (s *Service) foreignResources(resources []*armresources.GenericResourceExpanded) []string {
+	var foreign []string
+	for _, resource := range resources {
+		if resource.Type != nil && strings.EqualFold(*resource.Type, dnsZoneResourceType) &&
+			resource.Name != nil && strings.EqualFold(*resource.Name, s.scope.ClusterDomain()) {
+			continue
 		}
+		foreign = append(foreign, fmt.Sprintf("%s/%s", pointer.StringDeref(resource.Type, "<unknown>"), pointer.StringDeref(resource.Name, "<unknown>")))
 	}
+	return foreign
+}
+
+// ResourceGroupOwned returns whether the last Reconcile checked the existing
+// resource group of a non-Azure cluster, and why it was left alone if it
+// isn't owned by the cluster.
+func (s *Service) ResourceGroupOwned() (bool, error) {
+	return s.resourceGroupChecked, s.resourceGroupNotOwned
+}
+
+// ResourceGroupKept returns why the resource group of a non-Azure cluster was
+// kept during the last deletion, nil if it was deleted or there is none.
+func (s *Service) ResourceGroupKept() error {
+	return s.resourceGroupKept
+}
 
-	return true
+// resourceGroupTags returns the tags of the resource group of non-Azure
+// clusters: the tags configured on the infrastructure cluster and the owner.
+func (s *Service) resourceGroupTags() map[string]*string {
+	return mergeResourceTags(s.scope.ResourceTags(), s.ownerMetadata())
 }
 
 func mergeResourceTags(existingTags map[string]*string, newTags map[string]*string) map[string]*string {
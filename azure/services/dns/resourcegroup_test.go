@@ -1,9 +1,57 @@
 package dns
 
 import (
+	"context"
+	"reflect"
 	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
 )
 
+// resourceGroupClient serves a single resource group and records the changes.
+type resourceGroupClient struct {
+	client
+
+	missing       bool
+	resourceGroup armresources.ResourceGroup
+	resources     []*armresources.GenericResourceExpanded
+	updates       int
+	calls         []string
+}
+
+func (c *resourceGroupClient) GetResourceGroup(context.Context, string) (armresources.ResourceGroup, error) {
+	if c.missing {
+		return armresources.ResourceGroup{}, microerror.Mask(resourceNotFoundError)
+	}
+	return c.resourceGroup, nil
+}
+
+func (c *resourceGroupClient) ListResourceGroupResources(context.Context, string) ([]*armresources.GenericResourceExpanded, error) {
+	return c.resources, nil
+}
+
+func (c *resourceGroupClient) DeleteResourceGroup(_ context.Context, resourceGroupName string) error {
+	c.calls = append(c.calls, "DeleteResourceGroup "+resourceGroupName)
+	return nil
+}
+
+func (c *resourceGroupClient) DeleteZone(_ context.Context, resourceGroupName string, zoneName string) error {
+	c.calls = append(c.calls, "DeleteZone "+resourceGroupName+" "+zoneName)
+	return nil
+}
+
+func (c *resourceGroupClient) CreateOrUpdateResourceGroup(_ context.Context, _ string, resourceGroup armresources.ResourceGroup) (armresources.ResourceGroup, error) {
+	c.resourceGroup = resourceGroup
+	c.updates++
+	return resourceGroup, nil
+}
+
 func Test_ResourceGroupTags(t *testing.T) {
 	testValue := "test-value"
 	testValue2 := "test-value2"
@@ -12,7 +60,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 		name    string
 		oldTags map[string]*string
 		newTags map[string]*string
-		equal   bool
 		merged  map[string]*string
 	}{
 		{
@@ -23,7 +70,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			newTags: map[string]*string{
 				"test-key": &testValue,
 			},
-			equal: true,
 			merged: map[string]*string{
 				"test-key": &testValue,
 			},
@@ -32,7 +78,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			name:    "case1: Both are nil",
 			oldTags: nil,
 			newTags: nil,
-			equal:   true,
 			merged:  nil,
 		},
 		{
@@ -43,7 +88,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			newTags: map[string]*string{
 				"test-key1": &testValue,
 			},
-			equal: false,
 			merged: map[string]*string{
 				"test-key":  &testValue,
 				"test-key1": &testValue,
@@ -57,7 +101,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			newTags: map[string]*string{
 				"test-key": &testValue2,
 			},
-			equal: false,
 			merged: map[string]*string{
 				"test-key": &testValue2,
 			},
@@ -68,7 +111,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			newTags: map[string]*string{
 				"test-key": &testValue,
 			},
-			equal: false,
 			merged: map[string]*string{
 				"test-key": &testValue,
 			},
@@ -79,7 +121,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 				"test-key": &testValue,
 			},
 			newTags: nil,
-			equal:   false,
 			merged: map[string]*string{
 				"test-key": &testValue,
 			},
@@ -90,7 +131,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			newTags: map[string]*string{
 				"test-key": &testValue,
 			},
-			equal: false,
 			merged: map[string]*string{
 				"test-key": &testValue,
 			},
@@ -101,7 +141,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 				"test-key": &testValue,
 			},
 			newTags: map[string]*string{},
-			equal:   false,
 			merged: map[string]*string{
 				"test-key": &testValue,
 			},
@@ -115,7 +154,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 			newTags: map[string]*string{
 				"test-key": &testValue2,
 			},
-			equal: false,
 			merged: map[string]*string{
 				"test-key":  &testValue2,
 				"test-key1": &testValue,
@@ -125,14 +163,12 @@ func Test_ResourceGroupTags(t *testing.T) {
 			name:    "case9: Old tags are nil and new tags are empty",
 			oldTags: nil,
 			newTags: map[string]*string{},
-			equal:   true,
 			merged:  map[string]*string{},
 		},
 		{
 			name:    "case10: Old tags are empty and new tags are nil",
 			oldTags: map[string]*string{},
 			newTags: nil,
-			equal:   true,
 			merged:  map[string]*string{},
 		},
 	}
@@ -140,11 +176,6 @@ func Test_ResourceGroupTags(t *testing.T) {
 	for _, tc := range testCases {
 
 		t.Run(tc.name, func(t *testing.T) {
-			equal := resourceGroupTagsEqual(tc.oldTags, tc.newTags)
-			if equal != tc.equal {
-				t.Fatalf("expected %v, got %v", tc.equal, equal)
-			}
-
 			merged := mergeResourceTags(tc.oldTags, tc.newTags)
 			if len(merged) != len(tc.merged) {
 				t.Fatalf("expected %v, got %v", tc.merged, merged)
@@ -158,3 +189,210 @@ func Test_ResourceGroupTags(t *testing.T) {
 		})
 	}
 }
+
+func TestService_updateClusterResourceGroup(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+		ClusterScope:            &svc.scope.Scope,
+		BaseDomain:              svc.scope.BaseDomain(),
+		BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+		BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+		ResourceTags:            map[string]*string{"team": pointer.String("rocket")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.scope = *dnsScope
+
+	// the env annotation was removed, the policy tag was set by others
+	resourceGroups := &resourceGroupClient{
+		resourceGroup: armresources.ResourceGroup{
+			Properties: &armresources.ResourceGroupProperties{},
+			Tags: mergeResourceTags(svc.ownerMetadata(), map[string]*string{
+				"team":               pointer.String("rocket"),
+				"env":                pointer.String("prod"),
+				"policy":             pointer.String("kept"),
+				azure.ManagedTagsKey: pointer.String("dns_operator_azure_cluster,dns_operator_azure_instance,env,team"),
+			}),
+		},
+	}
+	svc.azureClient = resourceGroups
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.createClusterResourceGroup(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if resourceGroups.updates != 1 {
+		t.Errorf("resource group updated %d times, want once", resourceGroups.updates)
+	}
+	expectedTags := mergeResourceTags(svc.ownerMetadata(), map[string]*string{
+		"team":               pointer.String("rocket"),
+		"policy":             pointer.String("kept"),
+		azure.ManagedTagsKey: pointer.String("dns_operator_azure_cluster,dns_operator_azure_instance,team"),
+	})
+	if !azure.TagsEqual(resourceGroups.resourceGroup.Tags, expectedTags) {
+		t.Errorf("tags = %v, want %v", resourceGroups.resourceGroup.Tags, expectedTags)
+	}
+}
+
+func TestService_updateClusterResourceGroup_legacy(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+
+	// a resource group created before resource groups were tagged with their
+	// owner, holding the cluster zone only
+	resourceGroups := &resourceGroupClient{
+		resourceGroup: armresources.ResourceGroup{
+			Properties: &armresources.ResourceGroupProperties{},
+			Tags:       map[string]*string{"policy": pointer.String("kept")},
+		},
+		resources: []*armresources.GenericResourceExpanded{
+			{Name: pointer.String("test-cluster.basedomain.io"), Type: pointer.String("Microsoft.Network/dnszones")},
+		},
+	}
+	svc.azureClient = resourceGroups
+
+	if _, err := svc.createClusterResourceGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.resourceGroupNotOwned != nil {
+		t.Errorf("resourceGroupNotOwned = %v, want nil", svc.resourceGroupNotOwned)
+	}
+	if !svc.isOwnedTags(resourceGroups.resourceGroup.Tags) {
+		t.Errorf("tags = %v, want the owner tags", resourceGroups.resourceGroup.Tags)
+	}
+	if value := resourceGroups.resourceGroup.Tags["policy"]; value == nil || *value != "kept" {
+		t.Errorf("policy tag = %v, want kept", value)
+	}
+}
+
+func TestService_updateClusterResourceGroup_notOwned(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.InfraCluster = &unstructured.Unstructured{Object: map[string]interface{}{"kind": "VSphereCluster"}}
+
+	// a resource group of the same name someone else created
+	tags := map[string]*string{"owner": pointer.String("someone-else")}
+	resourceGroups := &resourceGroupClient{
+		resourceGroup: armresources.ResourceGroup{
+			Properties: &armresources.ResourceGroupProperties{},
+			Tags:       tags,
+		},
+	}
+	svc.azureClient = resourceGroups
+
+	if _, err := svc.createClusterResourceGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if resourceGroups.updates != 0 {
+		t.Errorf("resource group updated %d times, want never", resourceGroups.updates)
+	}
+	if !azure.TagsEqual(resourceGroups.resourceGroup.Tags, tags) {
+		t.Errorf("tags = %v, want %v", resourceGroups.resourceGroup.Tags, tags)
+	}
+	if !IsResourceGroupNotOwned(svc.resourceGroupNotOwned) {
+		t.Errorf("resourceGroupNotOwned = %v, want resourceGroupNotOwnedError", svc.resourceGroupNotOwned)
+	}
+	resources, err := svc.lockedResources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range resources {
+		if resource.kind == "resource group" {
+			t.Errorf("resource group %s is locked", resource.name)
+		}
+	}
+}
+
+func TestService_deleteClusterResourceGroup(t *testing.T) {
+	ctx := context.TODO()
+
+	clusterZone := &armresources.GenericResourceExpanded{
+		Name: pointer.String("test-cluster.basedomain.io"),
+		Type: pointer.String("Microsoft.Network/dnsZones"),
+	}
+
+	testCases := []struct {
+		name          string
+		missing       bool
+		owned         bool
+		tags          map[string]*string
+		resources     []*armresources.GenericResourceExpanded
+		expectCalls   []string
+		expectKeptErr bool
+	}{
+		{
+			name:        "case0: owned resource groups holding the cluster zone only are deleted",
+			owned:       true,
+			resources:   []*armresources.GenericResourceExpanded{clusterZone},
+			expectCalls: []string{"DeleteResourceGroup test-cluster"},
+		},
+		{
+			name:  "case1: resource groups with foreign resources are kept",
+			owned: true,
+			resources: []*armresources.GenericResourceExpanded{
+				clusterZone,
+				{Name: pointer.String("bastion"), Type: pointer.String("Microsoft.Compute/virtualMachines")},
+			},
+			expectCalls:   []string{"DeleteZone test-cluster test-cluster.basedomain.io"},
+			expectKeptErr: true,
+		},
+		{
+			name:          "case2: resource groups owned by another cluster are kept",
+			tags:          map[string]*string{ownerMetadataKey: pointer.String("default/other-cluster")},
+			resources:     []*armresources.GenericResourceExpanded{clusterZone},
+			expectCalls:   []string{"DeleteZone test-cluster test-cluster.basedomain.io"},
+			expectKeptErr: true,
+		},
+		{
+			name:    "case3: missing resource groups are ignored",
+			missing: true,
+		},
+		{
+			name:        "case4: resource groups created before ownership tracking holding the cluster zone only are deleted",
+			resources:   []*armresources.GenericResourceExpanded{clusterZone},
+			expectCalls: []string{"DeleteResourceGroup test-cluster"},
+		},
+		{
+			name:          "case5: empty resource groups without owner tags are kept",
+			expectCalls:   []string{"DeleteZone test-cluster test-cluster.basedomain.io"},
+			expectKeptErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newZonesTestService(t, ctx, nil)
+
+			resourceGroup := armresources.ResourceGroup{Tags: tc.tags}
+			if tc.owned {
+				resourceGroup.Tags = svc.ownerMetadata()
+			}
+			resourceGroups := &resourceGroupClient{
+				missing:       tc.missing,
+				resourceGroup: resourceGroup,
+				resources:     tc.resources,
+			}
+			svc.azureClient = resourceGroups
+
+			if err := svc.deleteClusterResourceGroup(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(resourceGroups.calls, tc.expectCalls) {
+				t.Errorf("calls = %#v, want %#v", resourceGroups.calls, tc.expectCalls)
+			}
+			if keptErr := svc.ResourceGroupKept(); IsResourceGroupKept(keptErr) != tc.expectKeptErr {
+				t.Errorf("ResourceGroupKept() = %v, want kept %v", keptErr, tc.expectKeptErr)
+			}
+		})
+	}
+}
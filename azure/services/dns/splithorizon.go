@@ -0,0 +1,525 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/giantswarm/microerror"
+	"golang.org/x/exp/slices"
+	"k8s.io/utils/pointer"
+	capzazure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+const (
+	// splitHorizonTagKey marks the public cluster zone of a cluster that has a
+	// private zone, so that the private zone is deleted once the cluster
+	// leaves the split-horizon mode.
+	splitHorizonTagKey = "dns_operator_azure_split_horizon"
+
+	privateRecordSetTypePrefix = "Microsoft.Network/privateDnsZones/"
+
+	// maxVirtualNetworkLinkNameLength is the maximum length of the name of a
+	// virtual network link.
+	maxVirtualNetworkLinkNameLength = 80
+)
+
+// externalRecordSets returns the record sets of the cluster zone as they are
+// published in the public zone of a split-horizon cluster: internal addresses
+// are removed and record sets left without addresses are dropped. The api
+// and apiserver records point to the split-horizon public IP instead if the
+// cluster has one.
+func (s *Service) externalRecordSets(recordSets []*armdns.RecordSet) []*armdns.RecordSet {
+	publicIP := s.scope.SplitHorizonPublicIP()
+
+	var external []*armdns.RecordSet
+	for _, recordSet := range recordSets {
+		name := pointer.StringDeref(recordSet.Name, "")
+		if publicIP != "" && (name == apiRecordName || name == apiserverRecordName) {
+			external = append(external, &armdns.RecordSet{
+				Name: pointer.String(name),
+				Type: pointer.String(string(armdns.RecordTypeA)),
+				Properties: &armdns.RecordSetProperties{
+					TTL:      pointer.Int64(s.scope.RecordTTLs().API),
+					ARecords: []*armdns.ARecord{{IPv4Address: pointer.String(publicIP)}},
+				},
+			})
+			continue
+		}
+
+		if recordSet.Properties == nil || len(recordSet.Properties.ARecords) == 0 {
+			// CNAME and alias record sets point to public names and resources
+			external = append(external, recordSet)
+			continue
+		}
+
+		var aRecords []*armdns.ARecord
+		for _, aRecord := range recordSet.Properties.ARecords {
+			if aRecord.IPv4Address != nil && !isInternalAddress(*aRecord.IPv4Address) {
+				aRecords = append(aRecords, aRecord)
+			}
+		}
+		if len(aRecords) == 0 {
+			continue
+		}
+
+		properties := *recordSet.Properties
+		properties.ARecords = aRecords
+		external = append(external, &armdns.RecordSet{
+			Name:       recordSet.Name,
+			Type:       recordSet.Type,
+			Properties: &properties,
+		})
+	}
+
+	return external
+}
+
+// reconcileSplitHorizon publishes the records of the cluster zone, including
+// the ones with internal addresses, in the private zone of a split-horizon
+// cluster and removes the records with internal addresses from the public
+// zone clusterZone, whose current records are currentRecordSets. The private
+// zone of a cluster that left the split-horizon mode is deleted.
+func (s *Service) reconcileSplitHorizon(ctx context.Context, clusterZone armdns.Zone, currentRecordSets []*armdns.RecordSet) error {
+	logger := log.FromContext(ctx).WithName("splithorizon")
+	zoneName := s.scope.ClusterDomain()
+
+	if !s.scope.IsSplitHorizon() {
+		if clusterZone.Tags[splitHorizonTagKey] == nil {
+			return nil
+		}
+
+		logger.Info("Cluster left the split-horizon mode, deleting its private DNS zone", "privateDNSZone", zoneName)
+		if err := s.deletePrivateZone(ctx); err != nil {
+			return microerror.Mask(err)
+		}
+		return s.setSplitHorizonTag(ctx, clusterZone, false)
+	}
+
+	if s.privateZones == nil {
+		return microerror.Maskf(splitHorizonNotSupportedError, "private zones are only supported with Azure DNS")
+	}
+
+	if err := s.ensurePrivateZone(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+	if clusterZone.Tags[splitHorizonTagKey] == nil {
+		if err := s.setSplitHorizonTag(ctx, clusterZone, true); err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if err := s.updateVirtualNetworkLinks(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+
+	if err := s.updatePrivateRecordSets(ctx); err != nil {
+		return microerror.Mask(err)
+	}
+
+	// the records with internal addresses are only removed from the public
+	// zone once the private zone answers for them
+	for _, recordSet := range currentRecordSets {
+		if recordSetType(recordSet) != armdns.RecordTypeA || recordSet.Properties == nil || len(recordSet.Properties.ARecords) == 0 {
+			continue
+		}
+		name := pointer.StringDeref(recordSet.Name, "")
+		if !s.isOwnedRecordSet(recordSet) && name != apiRecordName && name != apiserverRecordName {
+			continue
+		}
+		if slices.ContainsFunc(recordSet.Properties.ARecords, func(aRecord *armdns.ARecord) bool {
+			return aRecord.IPv4Address != nil && !isInternalAddress(*aRecord.IPv4Address)
+		}) {
+			continue
+		}
+
+		logger.Info("Deleting DNS record with internal addresses from the public zone", "DNSZone", zoneName, "hostname", name)
+		err := s.azureClient.DeleteRecordSet(ctx, s.scope.ResourceGroup(), zoneName, armdns.RecordTypeA, name)
+		s.recordSetDeleted(zoneName, recordSet, err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// ensurePrivateZone creates the private zone of the cluster next to its public
+// zone if it doesn't exist yet.
+func (s *Service) ensurePrivateZone(ctx context.Context) error {
+	zoneName := s.scope.ClusterDomain()
+
+	privateZone, err := s.privateZones.GetPrivateZone(ctx, s.scope.ResourceGroup(), zoneName)
+	if err == nil {
+		return s.updatePrivateZoneTags(ctx, privateZone)
+	} else if !azure.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	log.FromContext(ctx).Info("Creating private DNS zone", "privateDNSZone", zoneName)
+	err = s.privateZones.CreateOrUpdatePrivateZone(ctx, s.scope.ResourceGroup(), zoneName, armprivatedns.PrivateZone{
+		Location: pointer.String(capzazure.Global),
+		Tags:     s.updatedZoneTags(nil),
+	})
+	if err != nil {
+		s.scope.Warnf("PrivateDNSZoneCreationFailed", "Failed to create private DNS zone %s in resource group %s: %s", zoneName, s.scope.ResourceGroup(), err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("PrivateDNSZoneCreated", "Created private DNS zone %s in resource group %s", zoneName, s.scope.ResourceGroup())
+
+	return nil
+}
+
+// updatePrivateZoneTags updates the tags of the private zone if the resource
+// tags of the cluster changed. Tags set by others are kept.
+func (s *Service) updatePrivateZoneTags(ctx context.Context, privateZone armprivatedns.PrivateZone) error {
+	zoneName := s.scope.ClusterDomain()
+	tags := s.updatedZoneTags(privateZone.Tags)
+	if azure.TagsEqual(privateZone.Tags, tags) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Updating tags of private DNS zone", "privateDNSZone", zoneName)
+	err := s.privateZones.CreateOrUpdatePrivateZone(ctx, s.scope.ResourceGroup(), zoneName, armprivatedns.PrivateZone{
+		Location: pointer.String(capzazure.Global),
+		Tags:     tags,
+	})
+	if err != nil {
+		s.scope.Warnf("PrivateDNSZoneUpdateFailed", "Failed to update tags of private DNS zone %s: %s", zoneName, err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("PrivateDNSZoneTagged", "Updated tags of private DNS zone %s", zoneName)
+
+	return nil
+}
+
+// updateVirtualNetworkLinks links the private zone to the split-horizon
+// virtual networks and removes the links the operator created to other
+// virtual networks.
+func (s *Service) updateVirtualNetworkLinks(ctx context.Context) error {
+	zoneName := s.scope.ClusterDomain()
+
+	links, err := s.privateZones.ListVirtualNetworkLinks(ctx, s.scope.ResourceGroup(), zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	desiredIDs := s.scope.SplitHorizonVirtualNetworkIDs()
+	for _, vnetID := range desiredIDs {
+		if slices.ContainsFunc(links, func(link *armprivatedns.VirtualNetworkLink) bool {
+			return strings.EqualFold(linkedVirtualNetworkID(link), vnetID)
+		}) {
+			continue
+		}
+
+		linkName := virtualNetworkLinkName(vnetID)
+		err := s.privateZones.CreateOrUpdateVirtualNetworkLink(ctx, s.scope.ResourceGroup(), zoneName, linkName, armprivatedns.VirtualNetworkLink{
+			Tags: s.ownerMetadata(),
+			Properties: &armprivatedns.VirtualNetworkLinkProperties{
+				RegistrationEnabled: pointer.Bool(false),
+				VirtualNetwork:      &armprivatedns.SubResource{ID: pointer.String(vnetID)},
+			},
+		})
+		if err != nil {
+			s.scope.Warnf("PrivateDNSVnetLinkCreationFailed", "Failed to link private DNS zone %s to virtual network %s: %s", zoneName, vnetID, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("PrivateDNSVnetLinkCreated", "Linked private DNS zone %s to virtual network %s as %s", zoneName, vnetID, linkName)
+	}
+
+	for _, link := range links {
+		if !s.isOwnedTags(link.Tags) || slices.ContainsFunc(desiredIDs, func(vnetID string) bool {
+			return strings.EqualFold(linkedVirtualNetworkID(link), vnetID)
+		}) {
+			continue
+		}
+
+		linkName := pointer.StringDeref(link.Name, "")
+		if err := s.privateZones.DeleteVirtualNetworkLink(ctx, s.scope.ResourceGroup(), zoneName, linkName); err != nil {
+			s.scope.Warnf("PrivateDNSVnetLinkDeletionFailed", "Failed to delete virtual network link %s of private DNS zone %s: %s", linkName, zoneName, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("PrivateDNSVnetLinkDeleted", "Deleted virtual network link %s of private DNS zone %s", linkName, zoneName)
+	}
+
+	return nil
+}
+
+// updatePrivateRecordSets writes the desired private record sets and deletes
+// the ones the operator wrote before that aren't desired anymore.
+func (s *Service) updatePrivateRecordSets(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("splithorizon")
+	zoneName := s.scope.ClusterDomain()
+
+	currentRecordSets, err := s.privateZones.ListPrivateRecordSets(ctx, s.scope.ResourceGroup(), zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	desiredRecordSets := s.desiredPrivateRecordSets()
+	for _, desiredRecordSet := range desiredRecordSets {
+		recordType := privateRecordSetType(desiredRecordSet)
+
+		var current *armprivatedns.RecordSet
+		for _, currentRecordSet := range currentRecordSets {
+			if *currentRecordSet.Name != *desiredRecordSet.Name || !isPrivateAddressRecordSet(currentRecordSet) {
+				continue
+			}
+			if privateRecordSetType(currentRecordSet) == recordType {
+				current = currentRecordSet
+				continue
+			}
+
+			// A and CNAME record sets can't have the same name
+			err := s.privateZones.DeletePrivateRecordSet(ctx, s.scope.ResourceGroup(), zoneName, privateRecordSetType(currentRecordSet), *currentRecordSet.Name)
+			s.privateRecordSetDeleted(currentRecordSet, err)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		if current != nil && privateRecordSetsEqual(current, desiredRecordSet) && s.isOwnedTags(current.Properties.Metadata) {
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("Writing private DNS %s record", recordType), "privateDNSZone", zoneName, "hostname", *desiredRecordSet.Name)
+		err := s.privateZones.CreateOrUpdatePrivateRecordSet(ctx, s.scope.ResourceGroup(), zoneName, recordType, *desiredRecordSet.Name, *desiredRecordSet)
+		s.privateRecordSetWritten(recordType, desiredRecordSet, current, err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, currentRecordSet := range currentRecordSets {
+		if !isPrivateAddressRecordSet(currentRecordSet) || currentRecordSet.Properties == nil || !s.isOwnedTags(currentRecordSet.Properties.Metadata) {
+			continue
+		}
+		if slices.ContainsFunc(desiredRecordSets, func(recordSet *armprivatedns.RecordSet) bool { return *recordSet.Name == *currentRecordSet.Name }) {
+			continue
+		}
+
+		logger.Info("Deleting private DNS record that is no longer desired", "privateDNSZone", zoneName, "hostname", *currentRecordSet.Name)
+		err := s.privateZones.DeletePrivateRecordSet(ctx, s.scope.ResourceGroup(), zoneName, privateRecordSetType(currentRecordSet), *currentRecordSet.Name)
+		s.privateRecordSetDeleted(currentRecordSet, err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// desiredPrivateRecordSets returns the record sets of the private zone: the
+// records of the cluster zone including the ones with internal addresses, as
+// far as they were looked up while getting the desired A records, and the
+// wildcard CNAME record. Alias record sets are resolved to the addresses of
+// their target, as private zones don't support them.
+func (s *Service) desiredPrivateRecordSets() []*armprivatedns.RecordSet {
+	recordSets := append(append([]*armdns.RecordSet{}, s.internalRecordSets...), s.desiredCnameRecords()...)
+
+	var privateRecordSets []*armprivatedns.RecordSet
+	for _, recordSet := range recordSets {
+		if recordSet.Properties == nil {
+			continue
+		}
+
+		properties := &armprivatedns.RecordSetProperties{
+			TTL:      recordSet.Properties.TTL,
+			Metadata: s.ownerMetadata(),
+		}
+		switch recordSetType(recordSet) {
+		case armdns.RecordTypeA:
+			for _, address := range s.recordSetAddresses(recordSet) {
+				properties.ARecords = append(properties.ARecords, &armprivatedns.ARecord{IPv4Address: pointer.String(address)})
+			}
+			if len(properties.ARecords) == 0 {
+				continue
+			}
+		case armdns.RecordTypeCNAME:
+			if recordSet.Properties.CnameRecord == nil {
+				continue
+			}
+			properties.CnameRecord = &armprivatedns.CnameRecord{Cname: recordSet.Properties.CnameRecord.Cname}
+		default:
+			continue
+		}
+
+		privateRecordSets = append(privateRecordSets, &armprivatedns.RecordSet{
+			Name:       recordSet.Name,
+			Type:       recordSet.Type,
+			Properties: properties,
+		})
+	}
+
+	return privateRecordSets
+}
+
+// deletePrivateZone deletes the private zone of the cluster and its virtual
+// network links. A missing zone is not an error.
+func (s *Service) deletePrivateZone(ctx context.Context) error {
+	if s.privateZones == nil {
+		return nil
+	}
+	zoneName := s.scope.ClusterDomain()
+
+	links, err := s.privateZones.ListVirtualNetworkLinks(ctx, s.scope.ResourceGroup(), zoneName)
+	if azure.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Azure refuses to delete private zones that are still linked
+	for _, link := range links {
+		linkName := pointer.StringDeref(link.Name, "")
+		if err := s.privateZones.DeleteVirtualNetworkLink(ctx, s.scope.ResourceGroup(), zoneName, linkName); err != nil {
+			s.scope.Warnf("PrivateDNSVnetLinkDeletionFailed", "Failed to delete virtual network link %s of private DNS zone %s: %s", linkName, zoneName, err)
+			return microerror.Mask(err)
+		}
+		s.scope.Eventf("PrivateDNSVnetLinkDeleted", "Deleted virtual network link %s of private DNS zone %s", linkName, zoneName)
+	}
+
+	if err := s.privateZones.DeletePrivateZone(ctx, s.scope.ResourceGroup(), zoneName); err != nil && !azure.IsNotFound(err) {
+		s.scope.Warnf("PrivateDNSZoneDeletionFailed", "Failed to delete private DNS zone %s: %s", zoneName, err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("PrivateDNSZoneDeleted", "Deleted private DNS zone %s", zoneName)
+
+	return nil
+}
+
+// setSplitHorizonTag adds or removes splitHorizonTagKey on the public cluster
+// zone.
+func (s *Service) setSplitHorizonTag(ctx context.Context, clusterZone armdns.Zone, splitHorizon bool) error {
+	tags := mergeResourceTags(clusterZone.Tags, nil)
+	if splitHorizon {
+		tags[splitHorizonTagKey] = pointer.String("true")
+	} else {
+		delete(tags, splitHorizonTagKey)
+	}
+
+	_, err := s.azureClient.UpdateZoneTags(ctx, s.scope.ResourceGroup(), s.scope.ClusterDomain(), tags)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// privateRecordSetWritten records an event about desired being written to
+// the private zone, replacing current if not nil. A failed write, err not nil,
+// is recorded as Warning event.
+func (s *Service) privateRecordSetWritten(recordType armprivatedns.RecordType, desired, current *armprivatedns.RecordSet, err error) {
+	fqdn := recordSetFQDN(*desired.Name, s.scope.ClusterDomain())
+
+	switch {
+	case err != nil:
+		s.scope.Warnf("PrivateDNSRecordUpdateFailed", "Failed to write private DNS %s record %s: %s", recordType, fqdn, err)
+	case current == nil:
+		s.scope.Eventf("PrivateDNSRecordCreated", "Created private DNS %s record %s with value %s", recordType, fqdn, privateRecordSetValue(desired))
+	default:
+		s.scope.Eventf("PrivateDNSRecordUpdated", "Updated private DNS %s record %s from %s to %s", recordType, fqdn, privateRecordSetValue(current), privateRecordSetValue(desired))
+	}
+}
+
+// privateRecordSetDeleted records an event about recordSet being deleted from
+// the private zone. A failed deletion, err not nil, is recorded as Warning
+// event.
+func (s *Service) privateRecordSetDeleted(recordSet *armprivatedns.RecordSet, err error) {
+	fqdn := recordSetFQDN(*recordSet.Name, s.scope.ClusterDomain())
+
+	if err != nil {
+		s.scope.Warnf("PrivateDNSRecordDeletionFailed", "Failed to delete private DNS %s record %s: %s", privateRecordSetType(recordSet), fqdn, err)
+		return
+	}
+	s.scope.Eventf("PrivateDNSRecordDeleted", "Deleted private DNS %s record %s with value %s", privateRecordSetType(recordSet), fqdn, privateRecordSetValue(recordSet))
+}
+
+// privateRecordSetType returns the record type of recordSet, see
+// recordSetType.
+func privateRecordSetType(recordSet *armprivatedns.RecordSet) armprivatedns.RecordType {
+	if recordSet.Type == nil {
+		return ""
+	}
+	return armprivatedns.RecordType(strings.TrimPrefix(*recordSet.Type, privateRecordSetTypePrefix))
+}
+
+// isPrivateAddressRecordSet reports whether recordSet is an A or CNAME record
+// set.
+func isPrivateAddressRecordSet(recordSet *armprivatedns.RecordSet) bool {
+	switch privateRecordSetType(recordSet) {
+	case armprivatedns.RecordTypeA, armprivatedns.RecordTypeCNAME:
+		return true
+	}
+	return false
+}
+
+// privateRecordSetsEqual reports whether a and b hold the same records with
+// the same TTL.
+func privateRecordSetsEqual(a, b *armprivatedns.RecordSet) bool {
+	if a.Properties == nil || b.Properties == nil {
+		return a.Properties == b.Properties
+	}
+	return pointer.Int64Deref(a.Properties.TTL, 0) == pointer.Int64Deref(b.Properties.TTL, 0) &&
+		privateRecordSetValue(a) == privateRecordSetValue(b)
+}
+
+// privateRecordSetValue describes the records of recordSet, with sorted
+// addresses, e.g. "10.0.0.4,10.0.0.5".
+func privateRecordSetValue(recordSet *armprivatedns.RecordSet) string {
+	if recordSet.Properties == nil {
+		return "<none>"
+	}
+
+	var aRecords []*armdns.ARecord
+	for _, record := range recordSet.Properties.ARecords {
+		aRecords = append(aRecords, &armdns.ARecord{IPv4Address: record.IPv4Address})
+	}
+	values := ipv4Addresses(aRecords)
+	if record := recordSet.Properties.CnameRecord; record != nil && record.Cname != nil {
+		values = append(values, *record.Cname)
+	}
+
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
+
+// linkedVirtualNetworkID returns the ID of the virtual network link links to.
+func linkedVirtualNetworkID(link *armprivatedns.VirtualNetworkLink) string {
+	if link.Properties == nil || link.Properties.VirtualNetwork == nil {
+		return ""
+	}
+	return pointer.StringDeref(link.Properties.VirtualNetwork.ID, "")
+}
+
+// virtualNetworkLinkName returns the name of the link to the virtual network
+// vnetID, "<resource group>-<virtual network>".
+func virtualNetworkLinkName(vnetID string) string {
+	name := vnetID
+	if resourceID, err := arm.ParseResourceID(vnetID); err == nil {
+		name = fmt.Sprintf("%s-%s", resourceID.ResourceGroupName, resourceID.Name)
+	}
+
+	name = strings.ToLower(name)
+	if len(name) > maxVirtualNetworkLinkNameLength {
+		name = name[:maxVirtualNetworkLinkNameLength]
+	}
+	return name
+}
+
+// isInternalAddress reports whether address is an IP only reachable from
+// within private networks, see scope.IsInternalIP.
+func isInternalAddress(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && scope.IsInternalIP(ip)
+}
@@ -0,0 +1,433 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"k8s.io/utils/pointer"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzscope "sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+const (
+	hubVNetID   = "/subscriptions/123/resourceGroups/hub/providers/Microsoft.Network/virtualNetworks/vpn"
+	otherVNetID = "/subscriptions/123/resourceGroups/old/providers/Microsoft.Network/virtualNetworks/old"
+)
+
+// fakePrivateZones is a privateZoneClient holding a single private zone. It
+// records the changes.
+type fakePrivateZones struct {
+	exists     bool
+	tags       map[string]*string
+	links      []*armprivatedns.VirtualNetworkLink
+	recordSets []*armprivatedns.RecordSet
+
+	calls []string
+}
+
+func (f *fakePrivateZones) GetPrivateZone(context.Context, string, string) (armprivatedns.PrivateZone, error) {
+	if !f.exists {
+		return armprivatedns.PrivateZone{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return armprivatedns.PrivateZone{Tags: f.tags}, nil
+}
+
+func (f *fakePrivateZones) CreateOrUpdatePrivateZone(_ context.Context, _ string, zoneName string, zone armprivatedns.PrivateZone) error {
+	f.exists = true
+	f.tags = zone.Tags
+	f.calls = append(f.calls, "CreateOrUpdatePrivateZone "+zoneName+" "+tagsValue(zone.Tags))
+	return nil
+}
+
+func (f *fakePrivateZones) DeletePrivateZone(_ context.Context, _ string, zoneName string) error {
+	f.calls = append(f.calls, "DeletePrivateZone "+zoneName)
+	return nil
+}
+
+func (f *fakePrivateZones) ListPrivateRecordSets(context.Context, string, string) ([]*armprivatedns.RecordSet, error) {
+	return f.recordSets, nil
+}
+
+func (f *fakePrivateZones) CreateOrUpdatePrivateRecordSet(_ context.Context, _ string, _ string, recordType armprivatedns.RecordType, recordSetName string, recordSet armprivatedns.RecordSet) error {
+	f.calls = append(f.calls, "CreateOrUpdatePrivateRecordSet "+string(recordType)+" "+recordSetName+" "+privateRecordSetValue(&recordSet))
+	return nil
+}
+
+func (f *fakePrivateZones) DeletePrivateRecordSet(_ context.Context, _ string, _ string, recordType armprivatedns.RecordType, recordSetName string) error {
+	f.calls = append(f.calls, "DeletePrivateRecordSet "+string(recordType)+" "+recordSetName)
+	return nil
+}
+
+func (f *fakePrivateZones) ListVirtualNetworkLinks(context.Context, string, string) ([]*armprivatedns.VirtualNetworkLink, error) {
+	if !f.exists {
+		return nil, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return f.links, nil
+}
+
+func (f *fakePrivateZones) CreateOrUpdateVirtualNetworkLink(_ context.Context, _ string, _ string, linkName string, link armprivatedns.VirtualNetworkLink) error {
+	f.calls = append(f.calls, "CreateOrUpdateVirtualNetworkLink "+linkName+" "+linkedVirtualNetworkID(&link))
+	return nil
+}
+
+func (f *fakePrivateZones) DeleteVirtualNetworkLink(_ context.Context, _ string, _ string, linkName string) error {
+	f.calls = append(f.calls, "DeleteVirtualNetworkLink "+linkName)
+	return nil
+}
+
+// tagsValue describes tags for the calls of the fakes, e.g. "a=1,b=2".
+func tagsValue(tags map[string]*string) string {
+	var values []string
+	for key, value := range tags {
+		values = append(values, key+"="+*value)
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// newSplitHorizonTestService returns a service for the CAPZ cluster
+// test-cluster with a private API server at 10.0.0.4, annotated with
+// annotations.
+func newSplitHorizonTestService(t *testing.T, ctx context.Context, annotations map[string]string) *Service {
+	t.Helper()
+
+	svc := newZonesTestService(t, ctx, nil)
+	svc.scope.Patcher.(*capzscope.ClusterScope).AzureCluster.Spec.NetworkSpec.APIServerLB = &infrav1.LoadBalancerSpec{
+		LoadBalancerClassSpec: infrav1.LoadBalancerClassSpec{Type: infrav1.Internal},
+		FrontendIPs: []infrav1.FrontendIP{
+			{FrontendIPClass: infrav1.FrontendIPClass{PrivateIPAddress: "10.0.0.4"}},
+		},
+	}
+	svc.scope.Cluster.SetAnnotations(annotations)
+
+	return svc
+}
+
+func TestService_externalRecordSets(t *testing.T) {
+	ctx := context.TODO()
+
+	aRecord := func(name string, addresses ...string) *armdns.RecordSet {
+		recordSet := &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String(string(armdns.RecordTypeA)),
+			Properties: &armdns.RecordSetProperties{
+				TTL: pointer.Int64(scope.DefaultAPIRecordTTL),
+			},
+		}
+		for _, address := range addresses {
+			recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(address)})
+		}
+		return recordSet
+	}
+	cnameRecord := &armdns.RecordSet{
+		Name: pointer.String("ingress"),
+		Type: pointer.String(string(armdns.RecordTypeCNAME)),
+		Properties: &armdns.RecordSetProperties{
+			TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+			CnameRecord: &armdns.CnameRecord{Cname: pointer.String("lb.example.com")},
+		},
+	}
+
+	recordSets := []*armdns.RecordSet{
+		aRecord(apiRecordName, "10.0.0.4"),
+		aRecord(apiserverRecordName, "10.0.0.4"),
+		aRecord("bastion", "20.1.2.3", "192.168.0.5", "100.64.1.1"),
+		aRecord("internal-lb", "172.16.0.1"),
+		cnameRecord,
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        []*armdns.RecordSet
+	}{
+		{
+			name: "case0: records with internal addresses are dropped, mixed ones keep their public addresses",
+			want: []*armdns.RecordSet{
+				aRecord("bastion", "20.1.2.3"),
+				cnameRecord,
+			},
+		},
+		{
+			name: "case1: the api records point to the split-horizon public IP",
+			annotations: map[string]string{
+				scope.AnnotationSplitHorizonPublicIP: "20.9.9.9",
+			},
+			want: []*armdns.RecordSet{
+				aRecord(apiRecordName, "20.9.9.9"),
+				aRecord(apiserverRecordName, "20.9.9.9"),
+				aRecord("bastion", "20.1.2.3"),
+				cnameRecord,
+			},
+		},
+		{
+			name: "case2: a private split-horizon public IP is ignored",
+			annotations: map[string]string{
+				scope.AnnotationSplitHorizonPublicIP: "10.1.1.1",
+			},
+			want: []*armdns.RecordSet{
+				aRecord("bastion", "20.1.2.3"),
+				cnameRecord,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newSplitHorizonTestService(t, ctx, tt.annotations)
+
+			got := svc.externalRecordSets(recordSets)
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("externalRecordSets() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+
+	// the record sets of the internal view are left as they are
+	if len(recordSets[2].Properties.ARecords) != 3 {
+		t.Errorf("externalRecordSets() modified the internal record sets")
+	}
+}
+
+func TestService_reconcileSplitHorizon(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newSplitHorizonTestService(t, ctx, map[string]string{
+		scope.AnnotationPrivateRecordsMode:          scope.PrivateRecordsModeSplitHorizon,
+		scope.AnnotationSplitHorizonVirtualNetworks: hubVNetID + ",not-a-vnet",
+	})
+	provider := &recordingProvider{}
+	svc.azureClient = newRecordingClient(provider)
+	privateZones := &fakePrivateZones{}
+	svc.privateZones = privateZones
+
+	desiredRecordSets, _, err := svc.desiredARecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recordSets := desiredRecordSets[svc.scope.ClusterDomain()]; len(recordSets) != 0 {
+		t.Errorf("public cluster zone records = %d, want none", len(recordSets))
+	}
+
+	clusterZone := armdns.Zone{Location: pointer.String("global"), Tags: svc.ownerMetadata()}
+	currentRecordSets := []*armdns.RecordSet{
+		{
+			Name: pointer.String(apiRecordName),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("10.0.0.4")}},
+			},
+		},
+		{
+			Name: pointer.String("foreign"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("10.0.0.9")}},
+			},
+		},
+	}
+
+	if err := svc.reconcileSplitHorizon(ctx, clusterZone, currentRecordSets); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPrivateCalls := []string{
+		"CreateOrUpdatePrivateZone test-cluster.basedomain.io dns_operator_azure_cluster=default/test-cluster,dns_operator_azure_instance=basedomain.io,dns_operator_azure_managed_tags=dns_operator_azure_cluster,dns_operator_azure_instance",
+		"CreateOrUpdateVirtualNetworkLink hub-vpn " + hubVNetID,
+		"CreateOrUpdatePrivateRecordSet A api 10.0.0.4",
+		"CreateOrUpdatePrivateRecordSet A apiserver 10.0.0.4",
+		"CreateOrUpdatePrivateRecordSet CNAME * ingress.test-cluster.basedomain.io",
+	}
+	if !reflect.DeepEqual(privateZones.calls, expectedPrivateCalls) {
+		t.Errorf("private zone calls = %#v, want %#v", privateZones.calls, expectedPrivateCalls)
+	}
+
+	// the zone is marked, the api record is moved out of the public zone and
+	// foreign records are left alone
+	expectedCalls := []string{
+		"UpdateZoneTags test-cluster.basedomain.io dns_operator_azure_cluster,dns_operator_azure_instance,dns_operator_azure_split_horizon",
+		"DeleteRecordSet test-cluster.basedomain.io A api",
+	}
+	if !reflect.DeepEqual(provider.calls, expectedCalls) {
+		t.Errorf("calls = %#v, want %#v", provider.calls, expectedCalls)
+	}
+
+	// the next reconciliation only removes what isn't desired anymore
+	owner := svc.ownerMetadata()
+	privateZones.calls = nil
+	privateZones.links = []*armprivatedns.VirtualNetworkLink{
+		{
+			Name:       pointer.String("hub-vpn"),
+			Tags:       owner,
+			Properties: &armprivatedns.VirtualNetworkLinkProperties{VirtualNetwork: &armprivatedns.SubResource{ID: pointer.String(hubVNetID)}},
+		},
+		{
+			Name:       pointer.String("old-old"),
+			Tags:       owner,
+			Properties: &armprivatedns.VirtualNetworkLinkProperties{VirtualNetwork: &armprivatedns.SubResource{ID: pointer.String(otherVNetID)}},
+		},
+	}
+	privateZones.recordSets = []*armprivatedns.RecordSet{
+		privateARecordSet(apiRecordName, owner, "10.0.0.4"),
+		privateARecordSet(apiserverRecordName, owner, "10.0.0.4"),
+		privateARecordSet("old", owner, "10.0.0.5"),
+		privateARecordSet("manual", nil, "10.0.0.6"),
+		{
+			Name: pointer.String("*"),
+			Type: pointer.String(privateRecordSetTypePrefix + "CNAME"),
+			Properties: &armprivatedns.RecordSetProperties{
+				TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+				Metadata:    owner,
+				CnameRecord: &armprivatedns.CnameRecord{Cname: pointer.String("ingress.test-cluster.basedomain.io")},
+			},
+		},
+	}
+	clusterZone.Tags = mergeResourceTags(owner, map[string]*string{splitHorizonTagKey: pointer.String("true")})
+
+	if err := svc.reconcileSplitHorizon(ctx, clusterZone, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPrivateCalls = []string{
+		"DeleteVirtualNetworkLink old-old",
+		"DeletePrivateRecordSet A old",
+	}
+	if !reflect.DeepEqual(privateZones.calls, expectedPrivateCalls) {
+		t.Errorf("private zone calls = %#v, want %#v", privateZones.calls, expectedPrivateCalls)
+	}
+}
+
+func TestService_reconcileSplitHorizon_leave(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newSplitHorizonTestService(t, ctx, map[string]string{
+		scope.AnnotationPrivateRecordsMode: scope.PrivateRecordsModePublic,
+	})
+	provider := &recordingProvider{}
+	svc.azureClient = newRecordingClient(provider)
+	privateZones := &fakePrivateZones{
+		exists: true,
+		links:  []*armprivatedns.VirtualNetworkLink{{Name: pointer.String("hub-vpn")}},
+	}
+	svc.privateZones = privateZones
+
+	owner := svc.ownerMetadata()
+
+	// clusters that never used split-horizon DNS don't cause any requests
+	if err := svc.reconcileSplitHorizon(ctx, armdns.Zone{Tags: owner}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(privateZones.calls) != 0 || len(provider.calls) != 0 {
+		t.Fatalf("unexpected calls %v %v", privateZones.calls, provider.calls)
+	}
+
+	clusterZone := armdns.Zone{Tags: mergeResourceTags(owner, map[string]*string{splitHorizonTagKey: pointer.String("true")})}
+	if err := svc.reconcileSplitHorizon(ctx, clusterZone, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPrivateCalls := []string{
+		"DeleteVirtualNetworkLink hub-vpn",
+		"DeletePrivateZone test-cluster.basedomain.io",
+	}
+	if !reflect.DeepEqual(privateZones.calls, expectedPrivateCalls) {
+		t.Errorf("private zone calls = %#v, want %#v", privateZones.calls, expectedPrivateCalls)
+	}
+	expectedCalls := []string{
+		"UpdateZoneTags test-cluster.basedomain.io dns_operator_azure_cluster,dns_operator_azure_instance",
+	}
+	if !reflect.DeepEqual(provider.calls, expectedCalls) {
+		t.Errorf("calls = %#v, want %#v", provider.calls, expectedCalls)
+	}
+}
+
+func TestService_reconcileSplitHorizon_provider(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := NewWithProvider(newSplitHorizonTestService(t, ctx, map[string]string{
+		scope.AnnotationPrivateRecordsMode: scope.PrivateRecordsModeSplitHorizon,
+	}).scope, nil, &recordingProvider{})
+
+	err := svc.reconcileSplitHorizon(ctx, armdns.Zone{}, nil)
+	if !IsSplitHorizonNotSupported(err) {
+		t.Errorf("err = %v, want splitHorizonNotSupportedError", err)
+	}
+}
+
+func privateARecordSet(name string, metadata map[string]*string, addresses ...string) *armprivatedns.RecordSet {
+	recordSet := &armprivatedns.RecordSet{
+		Name: pointer.String(name),
+		Type: pointer.String(privateRecordSetTypePrefix + "A"),
+		Properties: &armprivatedns.RecordSetProperties{
+			TTL:      pointer.Int64(scope.DefaultAPIRecordTTL),
+			Metadata: metadata,
+		},
+	}
+	for _, address := range addresses {
+		recordSet.Properties.ARecords = append(recordSet.Properties.ARecords, &armprivatedns.ARecord{IPv4Address: pointer.String(address)})
+	}
+	return recordSet
+}
+
+func TestService_ensurePrivateZone_tags(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newSplitHorizonTestService(t, ctx, nil)
+	dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+		ClusterScope:            &svc.scope.Scope,
+		BaseDomain:              svc.scope.BaseDomain(),
+		BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+		BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+		ResourceTags:            map[string]*string{"team": pointer.String("rocket")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.scope = *dnsScope
+
+	// tags set by others are kept, changed resource tags are updated and
+	// removed ones deleted
+	privateZones := &fakePrivateZones{
+		exists: true,
+		tags: mergeResourceTags(svc.ownerMetadata(), map[string]*string{
+			"team":                            pointer.String("old"),
+			"env":                             pointer.String("prod"),
+			"foreign":                         pointer.String("kept"),
+			"dns_operator_azure_managed_tags": pointer.String("dns_operator_azure_cluster,dns_operator_azure_instance,env,team"),
+		}),
+	}
+	svc.privateZones = privateZones
+
+	if err := svc.ensurePrivateZone(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectedCalls := []string{
+		"CreateOrUpdatePrivateZone test-cluster.basedomain.io dns_operator_azure_cluster=default/test-cluster,dns_operator_azure_instance=basedomain.io,dns_operator_azure_managed_tags=dns_operator_azure_cluster,dns_operator_azure_instance,team,foreign=kept,team=rocket",
+	}
+	if !reflect.DeepEqual(privateZones.calls, expectedCalls) {
+		t.Errorf("private zone calls = %#v, want %#v", privateZones.calls, expectedCalls)
+	}
+
+	// zones with the desired tags aren't updated
+	privateZones.calls = nil
+	if err := svc.ensurePrivateZone(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(privateZones.calls) != 0 {
+		t.Errorf("unexpected private zone calls %v", privateZones.calls)
+	}
+}
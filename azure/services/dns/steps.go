@@ -0,0 +1,61 @@
+package dns
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// Step is a part of the public DNS reconciliation whose outcome is reported
+// on its own.
+type Step string
+
+const (
+	// StepZone covers the cluster zone and, for non-Azure clusters, its
+	// resource group.
+	StepZone Step = "Zone"
+	// StepNSDelegation covers the NS delegation of the cluster zone in the
+	// base zone and, for clusters below an intermediate zone, that zone and
+	// its delegation.
+	StepNSDelegation Step = "NSDelegation"
+	// StepAPIRecords covers the api and apiserver records.
+	StepAPIRecords Step = "APIRecords"
+	// StepIngressRecords covers the ingress, service hostname and wildcard
+	// records.
+	StepIngressRecords Step = "IngressRecords"
+	// StepSplitHorizon covers the private zone of split-horizon clusters and
+	// the removal of records with internal addresses from the public zone.
+	StepSplitHorizon Step = "SplitHorizon"
+)
+
+// StepResult returns whether step was reconciled by the last Reconcile and
+// the error it failed with. Steps after a failed one aren't reconciled, and
+// neither are records the cluster doesn't let the operator manage.
+func (s *Service) StepResult(step Step) (bool, error) {
+	err, ok := s.steps[step]
+	return ok, err
+}
+
+// stepDone marks step as reconciled successfully.
+func (s *Service) stepDone(step Step) {
+	if s.steps == nil {
+		s.steps = map[Step]error{}
+	}
+	s.steps[step] = nil
+}
+
+// stepFailed marks step as failed with err, which is returned as it is.
+func (s *Service) stepFailed(step Step, err error) error {
+	if s.steps == nil {
+		s.steps = map[Step]error{}
+	}
+	s.steps[step] = err
+	return err
+}
+
+// recordStep returns the step the record set name of the zone zoneName
+// belongs to.
+func (s *Service) recordStep(zoneName string, recordSet *armdns.RecordSet) Step {
+	if zoneName == s.scope.ClusterDomain() && recordSet.Name != nil && (*recordSet.Name == apiRecordName || *recordSet.Name == apiserverRecordName) {
+		return StepAPIRecords
+	}
+	return StepIngressRecords
+}
@@ -0,0 +1,99 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/utils/pointer"
+)
+
+func TestService_StepResult(t *testing.T) {
+	ctx := context.TODO()
+	svc := newZonesTestService(t, ctx, nil)
+
+	failed := errors.New("forbidden")
+	svc.stepDone(StepZone)
+	if err := svc.stepFailed(StepAPIRecords, failed); err != failed {
+		t.Errorf("stepFailed() = %v, want the given error", err)
+	}
+
+	testCases := []struct {
+		name             string
+		step             Step
+		expectReconciled bool
+		expectErr        error
+	}{
+		{
+			name:             "case0: successful step",
+			step:             StepZone,
+			expectReconciled: true,
+		},
+		{
+			name:             "case1: failed step",
+			step:             StepAPIRecords,
+			expectReconciled: true,
+			expectErr:        failed,
+		},
+		{
+			name: "case2: step not reached",
+			step: StepIngressRecords,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reconciled, err := svc.StepResult(tc.step)
+			if reconciled != tc.expectReconciled || err != tc.expectErr {
+				t.Errorf("StepResult() = %t, %v, want %t, %v", reconciled, err, tc.expectReconciled, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestService_recordStep(t *testing.T) {
+	ctx := context.TODO()
+	svc := newZonesTestService(t, ctx, nil)
+
+	testCases := []struct {
+		name       string
+		zoneName   string
+		recordName string
+		expectStep Step
+	}{
+		{
+			name:       "case0: api record in the cluster zone",
+			zoneName:   svc.scope.ClusterDomain(),
+			recordName: apiRecordName,
+			expectStep: StepAPIRecords,
+		},
+		{
+			name:       "case1: apiserver record in the cluster zone",
+			zoneName:   svc.scope.ClusterDomain(),
+			recordName: apiserverRecordName,
+			expectStep: StepAPIRecords,
+		},
+		{
+			name:       "case2: ingress record in the cluster zone",
+			zoneName:   svc.scope.ClusterDomain(),
+			recordName: "ingress",
+			expectStep: StepIngressRecords,
+		},
+		{
+			name:       "case3: api record in a shared zone",
+			zoneName:   "shared.example.com",
+			recordName: apiRecordName,
+			expectStep: StepIngressRecords,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			step := svc.recordStep(tc.zoneName, &armdns.RecordSet{Name: pointer.String(tc.recordName)})
+			if step != tc.expectStep {
+				t.Errorf("recordStep() = %s, want %s", step, tc.expectStep)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
+	"github.com/giantswarm/microerror"
+	capzazure "sigs.k8s.io/cluster-api-provider-azure/azure"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+const (
+	// OrphanKindDelegation is an NS record in the base zone delegating to the
+	// zone of a cluster that is gone.
+	OrphanKindDelegation = "delegation"
+	// OrphanKindZone is a cluster zone of a cluster that is gone.
+	OrphanKindZone = "zone"
+	// OrphanKindResourceGroup is the resource group of a non-Azure cluster
+	// that is gone.
+	OrphanKindResourceGroup = "resource_group"
+)
+
+// Orphan is a DNS resource left behind by a cluster that no longer exists,
+// e.g. because the Cluster was force-deleted or its finalizer was removed by
+// hand.
+type Orphan struct {
+	Kind           string
+	Name           string
+	ResourceGroup  string
+	SubscriptionID string
+	// Owner is the "<namespace>/<name>" of the cluster the resource was
+	// created for, empty for resources created before ownership tracking.
+	Owner string
+}
+
+// Owned reports whether the orphan carries the ownership marker of the
+// operator. Only owned orphans are ever deleted.
+func (o Orphan) Owned() bool {
+	return o.Owner != ""
+}
+
+// Key identifies the orphan across sweeps.
+func (o Orphan) Key() string {
+	return strings.Join([]string{o.Kind, o.SubscriptionID, o.ResourceGroup, o.Name}, "/")
+}
+
+type sweeperClient interface {
+	ListRecordSets(ctx context.Context, resourceGroupName string, zoneName string) ([]*armdns.RecordSet, error)
+	DeleteRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, recordSetName string) error
+	ListZones(ctx context.Context) ([]*armdns.Zone, error)
+	DeleteZone(ctx context.Context, resourceGroupName string, zoneName string) error
+	ListResourceGroups(ctx context.Context, tagName string) ([]*armresources.ResourceGroup, error)
+	DeleteResourceGroup(ctx context.Context, resourceGroupName string) error
+	DeleteManagementLock(ctx context.Context, resourceID string, lockName string) error
+}
+
+type SweeperParams struct {
+	BaseDomain              string
+	BaseDomainResourceGroup string
+	BaseZoneCredentials     scope.BaseZoneCredentials
+	// ClusterZoneCredentials give access to the subscription the zones and
+	// resource groups of non-Azure clusters are created in. Only the base
+	// zone subscription is swept if they are incomplete.
+	ClusterZoneCredentials scope.BaseZoneCredentials
+	// ManagementLocks removes the management lock of the operator from
+	// orphaned zones and resource groups before they are deleted.
+	ManagementLocks bool
+	// OperatorIdentity is the identity of the operator instance, see
+	// scope.OperatorIdentity. Only resources marked with it are swept.
+	OperatorIdentity string
+}
+
+// Sweeper finds the NS delegations in the base zone and the operator-owned
+// zones and resource groups of clusters that no longer exist.
+type Sweeper struct {
+	baseDomain              string
+	baseDomainResourceGroup string
+	baseZoneSubscriptionID  string
+	managementLocks         bool
+	operatorIdentity        string
+
+	// clients are keyed by subscription ID
+	clients map[string]sweeperClient
+}
+
+// NewSweeper creates a new orphan sweeper.
+func NewSweeper(params SweeperParams) (*Sweeper, error) {
+	baseZoneClient, err := newBaseZoneClient(params.BaseZoneCredentials)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	clients := map[string]sweeperClient{
+		params.BaseZoneCredentials.SubscriptionID: baseZoneClient,
+	}
+
+	credentials := params.ClusterZoneCredentials
+	if credentials.SubscriptionID != "" && credentials.ClientID != "" && credentials.ClientSecret != "" && credentials.TenantID != "" {
+		if _, ok := clients[credentials.SubscriptionID]; !ok {
+			clusterZoneClient, err := newBaseZoneClient(credentials)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			clients[credentials.SubscriptionID] = clusterZoneClient
+		}
+	}
+
+	return &Sweeper{
+		baseDomain:              params.BaseDomain,
+		baseDomainResourceGroup: params.BaseDomainResourceGroup,
+		baseZoneSubscriptionID:  params.BaseZoneCredentials.SubscriptionID,
+		managementLocks:         params.ManagementLocks,
+		operatorIdentity:        params.OperatorIdentity,
+		clients:                 clients,
+	}, nil
+}
+
+// FindOrphans returns the orphans of all clusters not in clusters, which must
+// be every Cluster of the management cluster.
+func (s *Sweeper) FindOrphans(ctx context.Context, clusters []capi.Cluster) ([]Orphan, error) {
+	live := newLiveClusters(clusters)
+
+	recordSets, err := s.clients[s.baseZoneSubscriptionID].ListRecordSets(ctx, s.baseDomainResourceGroup, s.baseDomain)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	orphans := orphanedDelegations(recordSets, live, s.operatorIdentity, s.baseDomainResourceGroup, s.baseZoneSubscriptionID)
+
+	for _, subscriptionID := range s.subscriptionIDs() {
+		client := s.clients[subscriptionID]
+
+		zones, err := client.ListZones(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		orphans = append(orphans, orphanedZones(zones, live, s.operatorIdentity, subscriptionID)...)
+
+		resourceGroups, err := client.ListResourceGroups(ctx, ownerMetadataKey)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		orphans = append(orphans, orphanedResourceGroups(resourceGroups, live, s.operatorIdentity, subscriptionID)...)
+	}
+
+	return orphans, nil
+}
+
+// DeleteOrphan deletes an owned orphan.
+func (s *Sweeper) DeleteOrphan(ctx context.Context, orphan Orphan) error {
+	if !orphan.Owned() {
+		return microerror.Maskf(notOwnedError, "%s %s is not owned by the operator", orphan.Kind, orphan.Name)
+	}
+
+	client, ok := s.clients[orphan.SubscriptionID]
+	if !ok {
+		return microerror.Maskf(notOwnedError, "no client for subscription %s", orphan.SubscriptionID)
+	}
+
+	if s.managementLocks && orphan.Kind != OrphanKindDelegation {
+		resourceID := capzazure.ResourceGroupID(orphan.SubscriptionID, orphan.ResourceGroup)
+		if orphan.Kind == OrphanKindZone {
+			resourceID += "/providers/Microsoft.Network/dnszones/" + orphan.Name
+		}
+		err := client.DeleteManagementLock(ctx, resourceID, managementLockName)
+		if err != nil && !azure.IsNotFound(err) {
+			return microerror.Mask(err)
+		}
+	}
+
+	var err error
+	switch orphan.Kind {
+	case OrphanKindDelegation:
+		err = client.DeleteRecordSet(ctx, orphan.ResourceGroup, s.baseDomain, armdns.RecordTypeNS, orphan.Name)
+	case OrphanKindZone:
+		err = client.DeleteZone(ctx, orphan.ResourceGroup, orphan.Name)
+	case OrphanKindResourceGroup:
+		err = client.DeleteResourceGroup(ctx, orphan.Name)
+	}
+	if err != nil && !IsResourceNotFoundError(err) {
+		return microerror.Mask(err)
+	}
+
+	// dns_operator_azure_orphan_deleted_total{controller="dns-operator-azure",kind="delegation"}
+	metrics.OrphanDeleted.WithLabelValues(orphan.Kind).Inc()
+
+	return nil
+}
+
+func (s *Sweeper) subscriptionIDs() []string {
+	var subscriptionIDs []string
+	for subscriptionID := range s.clients {
+		subscriptionIDs = append(subscriptionIDs, subscriptionID)
+	}
+	sort.Strings(subscriptionIDs)
+	return subscriptionIDs
+}
+
+// liveClusters are the clusters existing in the management cluster.
+type liveClusters struct {
+	// owners are keyed by "<namespace>/<name>"
+	owners map[string]bool
+	names  map[string]bool
+}
+
+func newLiveClusters(clusters []capi.Cluster) liveClusters {
+	live := liveClusters{owners: map[string]bool{}, names: map[string]bool{}}
+	for _, cluster := range clusters {
+		live.owners[fmt.Sprintf("%s/%s", cluster.Namespace, cluster.Name)] = true
+		live.names[cluster.Name] = true
+	}
+	return live
+}
+
+// orphanedDelegations returns the NS records in the base zone that delegate
+// to zones of clusters that are gone. NS records without ownership metadata
+// are reported if no cluster of the same name exists, but never owned.
+// Delegations of intermediate zones are left to the clusters below them, the
+// ones of other operator instances to those instances.
+func orphanedDelegations(recordSets []*armdns.RecordSet, live liveClusters, identity, resourceGroup, subscriptionID string) []Orphan {
+	var orphans []Orphan
+	for _, recordSet := range recordSets {
+		if recordSet.Name == nil || *recordSet.Name == "@" || recordSetType(recordSet) != armdns.RecordTypeNS {
+			continue
+		}
+
+		name := *recordSet.Name
+		owner, foreign := ownerOf(recordSetMetadata(recordSet), identity)
+		switch {
+		case foreign || isIntermediateZoneTags(recordSetMetadata(recordSet)):
+			continue
+		case owner != "" && live.owners[owner]:
+			continue
+		case owner == "" && (strings.Contains(name, ".") || live.names[name]):
+			continue
+		}
+
+		orphans = append(orphans, Orphan{
+			Kind:           OrphanKindDelegation,
+			Name:           name,
+			ResourceGroup:  resourceGroup,
+			SubscriptionID: subscriptionID,
+			Owner:          owner,
+		})
+	}
+	return orphans
+}
+
+// orphanedZones returns the zones owned by the operator instance identity of
+// clusters that are gone.
+func orphanedZones(zones []*armdns.Zone, live liveClusters, identity, subscriptionID string) []Orphan {
+	var orphans []Orphan
+	for _, zone := range zones {
+		owner, _ := ownerOf(zone.Tags, identity)
+		if zone.Name == nil || zone.ID == nil || owner == "" || live.owners[owner] {
+			continue
+		}
+
+		resourceID, err := arm.ParseResourceID(*zone.ID)
+		if err != nil {
+			continue
+		}
+
+		orphans = append(orphans, Orphan{
+			Kind:           OrphanKindZone,
+			Name:           *zone.Name,
+			ResourceGroup:  resourceID.ResourceGroupName,
+			SubscriptionID: subscriptionID,
+			Owner:          owner,
+		})
+	}
+	return orphans
+}
+
+// orphanedResourceGroups returns the resource groups owned by the operator
+// instance identity of clusters that are gone.
+func orphanedResourceGroups(resourceGroups []*armresources.ResourceGroup, live liveClusters, identity, subscriptionID string) []Orphan {
+	var orphans []Orphan
+	for _, resourceGroup := range resourceGroups {
+		owner, _ := ownerOf(resourceGroup.Tags, identity)
+		if resourceGroup.Name == nil || owner == "" || live.owners[owner] {
+			continue
+		}
+
+		orphans = append(orphans, Orphan{
+			Kind:           OrphanKindResourceGroup,
+			Name:           *resourceGroup.Name,
+			ResourceGroup:  *resourceGroup.Name,
+			SubscriptionID: subscriptionID,
+			Owner:          owner,
+		})
+	}
+	return orphans
+}
+
+func recordSetMetadata(recordSet *armdns.RecordSet) map[string]*string {
+	if recordSet.Properties == nil {
+		return nil
+	}
+	return recordSet.Properties.Metadata
+}
+
+// ownerOf returns the owner cluster from record set metadata or resource
+// tags marked by the operator instance identity. foreign is set for owner
+// markers of other operator instances, whose owner is never returned.
+func ownerOf(tags map[string]*string, identity string) (owner string, foreign bool) {
+	value, ok := tags[ownerMetadataKey]
+	if !ok || value == nil {
+		return "", false
+	}
+	if instance, ok := tags[ownerInstanceMetadataKey]; !ok || instance == nil || *instance != identity {
+		return "", true
+	}
+	return *value, false
+}
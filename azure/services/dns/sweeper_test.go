@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+func Test_FindOrphans(t *testing.T) {
+	live := newLiveClusters([]capi.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "alive", Namespace: "org-a"}},
+	})
+	owner := func(owner string) map[string]*string {
+		return map[string]*string{ownerMetadataKey: pointer.String(owner), ownerInstanceMetadataKey: pointer.String("mc")}
+	}
+	// foreign marks resources of the operator of another management cluster
+	// sharing the base zone and the subscription
+	foreign := func(owner string) map[string]*string {
+		return map[string]*string{ownerMetadataKey: pointer.String(owner), ownerInstanceMetadataKey: pointer.String("other-mc")}
+	}
+	nsRecord := func(name string, metadata map[string]*string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String(RecordSetTypeNS),
+			Properties: &armdns.RecordSetProperties{Metadata: metadata},
+		}
+	}
+
+	t.Run("delegations", func(t *testing.T) {
+		recordSets := []*armdns.RecordSet{
+			nsRecord("@", nil),
+			nsRecord("alive", owner("org-a/alive")),
+			nsRecord("gone", owner("org-a/gone")),
+			// a cluster with the same name in another namespace doesn't keep it alive
+			nsRecord("alive-elsewhere", owner("org-b/alive")),
+			nsRecord("gone-elsewhere", foreign("org-a/gone")),
+			nsRecord("unmarked", map[string]*string{ownerMetadataKey: pointer.String("org-a/unmarked")}),
+			nsRecord("legacy", nil),
+			nsRecord("alive", nil),
+			nsRecord("sub.delegation", nil),
+			// intermediate zones are removed with their last cluster
+			nsRecord("acme", intermediateZoneTags()),
+			{
+				Name:       pointer.String("gone-a"),
+				Type:       pointer.String(RecordSetTypeA),
+				Properties: &armdns.RecordSetProperties{Metadata: owner("org-a/gone")},
+			},
+		}
+
+		got := orphanedDelegations(recordSets, live, "mc", "base_rg", "sub")
+		want := []Orphan{
+			{Kind: OrphanKindDelegation, Name: "gone", ResourceGroup: "base_rg", SubscriptionID: "sub", Owner: "org-a/gone"},
+			{Kind: OrphanKindDelegation, Name: "alive-elsewhere", ResourceGroup: "base_rg", SubscriptionID: "sub", Owner: "org-b/alive"},
+			{Kind: OrphanKindDelegation, Name: "legacy", ResourceGroup: "base_rg", SubscriptionID: "sub"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("orphanedDelegations() = %v, want %v", got, want)
+		}
+		if got[2].Owned() {
+			t.Errorf("expected delegation without metadata not to be owned")
+		}
+	})
+
+	t.Run("zones", func(t *testing.T) {
+		zones := []*armdns.Zone{
+			{
+				ID:   pointer.String("/subscriptions/sub/resourceGroups/alive/providers/Microsoft.Network/dnszones/alive.base.io"),
+				Name: pointer.String("alive.base.io"),
+				Tags: owner("org-a/alive"),
+			},
+			{
+				ID:   pointer.String("/subscriptions/sub/resourceGroups/gone/providers/Microsoft.Network/dnszones/gone.base.io"),
+				Name: pointer.String("gone.base.io"),
+				Tags: owner("org-a/gone"),
+			},
+			{
+				ID:   pointer.String("/subscriptions/sub/resourceGroups/gone/providers/Microsoft.Network/dnszones/gone.other.io"),
+				Name: pointer.String("gone.other.io"),
+				Tags: foreign("org-a/gone"),
+			},
+			{
+				ID:   pointer.String("/subscriptions/sub/resourceGroups/other/providers/Microsoft.Network/dnszones/other.io"),
+				Name: pointer.String("other.io"),
+			},
+		}
+
+		got := orphanedZones(zones, live, "mc", "sub")
+		want := []Orphan{
+			{Kind: OrphanKindZone, Name: "gone.base.io", ResourceGroup: "gone", SubscriptionID: "sub", Owner: "org-a/gone"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("orphanedZones() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("resource groups", func(t *testing.T) {
+		resourceGroups := []*armresources.ResourceGroup{
+			{Name: pointer.String("alive"), Tags: owner("org-a/alive")},
+			{Name: pointer.String("gone"), Tags: owner("org-a/gone")},
+			{Name: pointer.String("gone-elsewhere"), Tags: foreign("org-a/gone")},
+			{Name: pointer.String("untagged")},
+		}
+
+		got := orphanedResourceGroups(resourceGroups, live, "mc", "sub")
+		want := []Orphan{
+			{Kind: OrphanKindResourceGroup, Name: "gone", ResourceGroup: "gone", SubscriptionID: "sub", Owner: "org-a/gone"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("orphanedResourceGroups() = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,398 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+const (
+	// ownerMetadataKey is the record set metadata key and tag marking the
+	// records, zones and resource groups the operator created for a cluster,
+	// <namespace>/<name> of the Cluster. Together with
+	// ownerInstanceMetadataKey it makes up the owner marker, resources only
+	// count as owned if both match, see isOwnedTags. Azure only allows
+	// alphanumeric characters and underscores in metadata keys.
+	ownerMetadataKey = "dns_operator_azure_cluster"
+	// ownerInstanceMetadataKey is the record set metadata key and tag marking
+	// the operator instance the owner marker belongs to, see
+	// scope.OperatorIdentity. Clusters of the same name in different
+	// management clusters sharing a zone or subscription aren't mistaken for
+	// each other.
+	ownerInstanceMetadataKey = "dns_operator_azure_instance"
+)
+
+// zone is a DNS zone the operator writes records to.
+type zone struct {
+	name          string
+	resourceGroup string
+	client        client
+	// shared zones are not owned by a single cluster, records written to them
+	// are marked with ownership metadata and never overwrite foreign records.
+	shared bool
+}
+
+// managedZones returns the cluster zone, the base zone and all additional
+// zones, ordered by descending name length so that the most specific zone
+// matches a hostname first.
+func (s *Service) managedZones() []zone {
+	zones := []zone{
+		{
+			name:          s.scope.ClusterDomain(),
+			resourceGroup: s.scope.ResourceGroup(),
+			client:        s.azureClient,
+		},
+		{
+			name:          s.scope.BaseDomain(),
+			resourceGroup: s.scope.BaseDomainResourceGroup(),
+			client:        s.azureBaseZoneClient,
+			shared:        true,
+		},
+	}
+
+	for _, additionalZone := range s.scope.AdditionalZones() {
+		zones = append(zones, zone{
+			name:          additionalZone.Name,
+			resourceGroup: additionalZone.ResourceGroup,
+			client:        s.azureBaseZoneClient,
+			shared:        true,
+		})
+	}
+
+	sort.SliceStable(zones, func(i, j int) bool { return len(zones[i].name) > len(zones[j].name) })
+
+	return zones
+}
+
+// clusterZone returns the zone owned by the cluster.
+func (s *Service) clusterZone() zone {
+	for _, z := range s.managedZones() {
+		if !z.shared {
+			return z
+		}
+	}
+	return zone{}
+}
+
+// sharedZones returns all managed zones shared between clusters.
+func (s *Service) sharedZones() []zone {
+	var zones []zone
+	for _, z := range s.managedZones() {
+		if z.shared {
+			zones = append(zones, z)
+		}
+	}
+	return zones
+}
+
+// zoneForHostname returns the most specific managed zone for hostname and the
+// record name relative to that zone.
+func (s *Service) zoneForHostname(hostname string) (zone, string, bool) {
+	for _, z := range s.managedZones() {
+		if hostname == z.name {
+			return z, "@", true
+		}
+		if strings.HasSuffix(hostname, "."+z.name) {
+			return z, strings.TrimSuffix(hostname, "."+z.name), true
+		}
+	}
+	return zone{}, "", false
+}
+
+// placeHostnameRecordSets moves record sets named by hostname into the zone
+// managing that hostname and makes their names relative to the zone. Record
+// sets for hostnames outside of all managed zones are dropped and reported.
+func (s *Service) placeHostnameRecordSets(ctx context.Context, recordSets []*armdns.RecordSet) map[string][]*armdns.RecordSet {
+	logger := log.FromContext(ctx).WithName("placeHostnameRecordSets")
+
+	placed := map[string][]*armdns.RecordSet{}
+	for _, recordSet := range recordSets {
+		hostname := *recordSet.Name
+
+		z, recordName, ok := s.zoneForHostname(hostname)
+		if !ok {
+			logger.Info("Hostname is not part of any managed DNS zone, skipping", "hostname", hostname)
+			s.scope.Warnf("DNSHostnameRejected", "Hostname %s is not part of any DNS zone managed by dns-operator-azure", hostname)
+			continue
+		}
+
+		// CNAME records can't coexist with the SOA and NS records at the zone apex.
+		if recordName == "@" && recordSetType(recordSet) == armdns.RecordTypeCNAME {
+			logger.Info("Hostname is the apex of a DNS zone and can't be a CNAME record, skipping", "hostname", hostname)
+			s.scope.Warnf("DNSHostnameRejected", "Hostname %s is the apex of zone %s and can't point to a load balancer hostname", hostname, z.name)
+			continue
+		}
+
+		recordSet.Name = pointer.String(recordName)
+		if z.shared {
+			recordSet.Properties.Metadata = s.ownerMetadata()
+		}
+		placed[z.name] = append(placed[z.name], recordSet)
+	}
+
+	return placed
+}
+
+// ownerMetadata returns the metadata marking a record set as owned by the
+// current cluster of this operator instance.
+func (s *Service) ownerMetadata() map[string]*string {
+	return map[string]*string{
+		ownerMetadataKey:         pointer.String(fmt.Sprintf("%s/%s", s.scope.Cluster.Namespace, s.scope.Cluster.Name)),
+		ownerInstanceMetadataKey: pointer.String(s.scope.OperatorIdentity()),
+	}
+}
+
+// zoneTags returns the tags of the zones of the cluster: the resource tags of
+// the cluster and the owner.
+func (s *Service) zoneTags() map[string]*string {
+	return mergeResourceTags(s.scope.ResourceTags(), s.ownerMetadata())
+}
+
+// updatedZoneTags returns the tags existing of a zone of the cluster updated
+// to zoneTags. Resource tags whose annotation was removed are removed, tags
+// the operator doesn't manage are kept, see azure.UpdateManagedTags.
+func (s *Service) updatedZoneTags(existing map[string]*string) map[string]*string {
+	return azure.UpdateManagedTags(existing, s.zoneTags())
+}
+
+// reconcileZoneTags updates the tags of the existing cluster zone to
+// updatedZoneTags with a tags-only update, and reports whether the zone is
+// owned by the cluster. Zones without the owner marker of the cluster are
+// only tagged if record sets marked as owned by the cluster prove the
+// operator created them, or if they were created before zones were tagged
+// with their owner, see isLegacyZone. Zones of other clusters, other operator
+// instances or created by someone else are never claimed. Zones of providers
+// other than Azure DNS have no tags and are never owned, the operator doesn't
+// create them.
+func (s *Service) reconcileZoneTags(ctx context.Context, clusterZone *armdns.Zone, recordSets []*armdns.RecordSet) (bool, error) {
+	logger := log.FromContext(ctx).WithName("reconcileZoneTags")
+	zoneName := s.scope.ClusterDomain()
+
+	if s.external {
+		return false, nil
+	}
+
+	if !s.isOwnedTags(clusterZone.Tags) && !slices.ContainsFunc(recordSets, s.isOwnedRecordSet) && !s.isLegacyZone(*clusterZone, recordSets) {
+		logger.Info("DNS zone isn't owned by the cluster, leaving its tags alone", "zone", zoneName)
+		s.scope.Warnf("DNSZoneNotOwned", "DNS zone %s wasn't created by dns-operator-azure for this cluster, its tags are left alone", zoneName)
+		return false, nil
+	}
+
+	tags := s.updatedZoneTags(clusterZone.Tags)
+	if azure.TagsEqual(clusterZone.Tags, tags) {
+		return true, nil
+	}
+
+	logger.Info("Updating tags of DNS zone", "zone", zoneName)
+	_, err := s.azureClient.UpdateZoneTags(ctx, s.scope.ResourceGroup(), zoneName, tags)
+	if err != nil {
+		s.scope.Warnf("DNSZoneUpdateFailed", "Failed to update tags of DNS zone %s: %s", zoneName, err)
+		return true, microerror.Mask(err)
+	}
+	clusterZone.Tags = tags
+	s.scope.Eventf("DNSZoneTagged", "Updated tags of DNS zone %s", zoneName)
+
+	return true, nil
+}
+
+// isLegacyZone reports whether clusterZone, the zone named after the cluster
+// in its resource group, was created by an operator version that didn't tag
+// zones with their owner yet: neither the zone nor any of its record sets
+// carry the owner marker of another cluster or operator instance.
+func (s *Service) isLegacyZone(clusterZone armdns.Zone, recordSets []*armdns.RecordSet) bool {
+	if hasOwnerMarker(clusterZone.Tags) {
+		return false
+	}
+	return !slices.ContainsFunc(recordSets, func(recordSet *armdns.RecordSet) bool {
+		return recordSet.Properties != nil && hasOwnerMarker(recordSet.Properties.Metadata)
+	})
+}
+
+// isOwnedRecordSet reports whether recordSet is marked as owned by the
+// current cluster of this operator instance.
+func (s *Service) isOwnedRecordSet(recordSet *armdns.RecordSet) bool {
+	if recordSet.Properties == nil {
+		return false
+	}
+	return s.isOwnedTags(recordSet.Properties.Metadata)
+}
+
+// hasOwnerMarker reports whether tags carry an owner marker of any cluster
+// or operator instance.
+func hasOwnerMarker(tags map[string]*string) bool {
+	_, cluster := tags[ownerMetadataKey]
+	_, instance := tags[ownerInstanceMetadataKey]
+	return cluster || instance
+}
+
+// isOwnedTags reports whether the tags of a zone or resource group mark it as
+// owned by the current cluster of this operator instance.
+func (s *Service) isOwnedTags(tags map[string]*string) bool {
+	for key, value := range s.ownerMetadata() {
+		if tag, ok := tags[key]; !ok || tag == nil || *tag != *value {
+			return false
+		}
+	}
+	return true
+}
+
+// isDelegatedName reports whether recordName is at or below a subdomain that
+// is delegated to another zone by an NS record set in recordSets. Records for
+// such names would never be served.
+func isDelegatedName(recordName string, recordSets []*armdns.RecordSet) bool {
+	for _, recordSet := range recordSets {
+		if recordSet.Type == nil || *recordSet.Type != RecordSetTypeNS || recordSet.Name == nil || *recordSet.Name == "@" {
+			continue
+		}
+		if recordName == *recordSet.Name || strings.HasSuffix(recordName, "."+*recordSet.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSetType returns the record type of recordSet. Listed record sets carry
+// the full resource type, e.g. Microsoft.Network/dnszones/A, desired record
+// sets only the record type. An empty type is returned if it is unknown.
+func recordSetType(recordSet *armdns.RecordSet) armdns.RecordType {
+	if recordSet.Type == nil {
+		return ""
+	}
+	return armdns.RecordType(strings.TrimPrefix(*recordSet.Type, RecordSetTypePrefix))
+}
+
+// isAddressRecordSet reports whether recordSet is an A or CNAME record set,
+// the types the operator publishes hostnames with. Record sets of unknown type
+// are treated as address record sets.
+func isAddressRecordSet(recordSet *armdns.RecordSet) bool {
+	switch recordSetType(recordSet) {
+	case "", armdns.RecordTypeA, armdns.RecordTypeCNAME:
+		return true
+	}
+	return false
+}
+
+// aRecordsEqual reports whether a and b hold the same IPv4 addresses,
+// regardless of their order.
+func aRecordsEqual(a, b []*armdns.ARecord) bool {
+	return slices.Equal(ipv4Addresses(a), ipv4Addresses(b))
+}
+
+// ipv4Addresses returns the sorted, unique addresses of records.
+func ipv4Addresses(records []*armdns.ARecord) []string {
+	var addresses []string
+	for _, record := range records {
+		if record != nil && record.IPv4Address != nil {
+			addresses = append(addresses, *record.IPv4Address)
+		}
+	}
+	sort.Strings(addresses)
+	return slices.Compact(addresses)
+}
+
+// targetResourceID returns the ID of the Azure resource the alias record set
+// recordSet points at, empty for other record sets.
+func targetResourceID(recordSet *armdns.RecordSet) string {
+	if recordSet.Properties == nil || recordSet.Properties.TargetResource == nil {
+		return ""
+	}
+	return pointer.StringDeref(recordSet.Properties.TargetResource.ID, "")
+}
+
+// setAliasAddresses remembers the addresses the alias target resource
+// resolves to, see aliasAddresses.
+func (s *Service) setAliasAddresses(targetResource string, addresses []string) {
+	if s.aliasAddresses == nil {
+		s.aliasAddresses = map[string][]string{}
+	}
+	s.aliasAddresses[strings.ToLower(targetResource)] = addresses
+}
+
+// recordSetAddresses returns the sorted IPv4 addresses recordSet resolves to.
+// For alias record sets these are the addresses of the target resource, as
+// far as it was looked up while getting the desired records.
+func (s *Service) recordSetAddresses(recordSet *armdns.RecordSet) []string {
+	if targetResource := targetResourceID(recordSet); targetResource != "" {
+		return s.aliasAddresses[strings.ToLower(targetResource)]
+	}
+	if recordSet.Properties == nil {
+		return nil
+	}
+	return ipv4Addresses(recordSet.Properties.ARecords)
+}
+
+// deleteOwnedRecordSets deletes all A and CNAME record sets in the shared zones
+// that are owned by the current cluster. The cluster zones of providers other
+// than Azure DNS outlive the cluster, the owned record sets are deleted from
+// them as well, leaving the records of others alone.
+func (s *Service) deleteOwnedRecordSets(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("deleteOwnedRecordSets")
+
+	zones := s.sharedZones()
+	if s.external {
+		zones = append([]zone{s.clusterZone()}, zones...)
+	}
+
+	for _, z := range zones {
+		recordSets, err := z.client.ListRecordSets(ctx, z.resourceGroup, z.name)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, recordSet := range recordSets {
+			if recordSetType(recordSet) == "" || !isAddressRecordSet(recordSet) || !s.isOwnedRecordSet(recordSet) {
+				continue
+			}
+
+			logger.Info("Deleting DNS record", "DNSZone", z.name, "hostname", *recordSet.Name, "type", recordSetType(recordSet))
+			err = z.client.DeleteRecordSet(ctx, z.resourceGroup, z.name, recordSetType(recordSet), *recordSet.Name)
+			s.recordSetDeleted(z.name, recordSet, err)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			metrics.RecordInfo.DeletePartialMatch(prometheus.Labels{
+				metrics.MetricZone: z.name,
+				"fqdn":             fmt.Sprintf("%s.%s", *recordSet.Name, z.name),
+			})
+		}
+	}
+
+	return nil
+}
+
+// parseHostnames parses the value of an external-dns hostname annotation,
+// which may hold a comma separated list of hostnames. Hostnames are
+// normalized to lower case without a trailing dot. Invalid hostnames are
+// returned separately.
+func parseHostnames(value string) (hostnames []string, invalid []string) {
+	seen := map[string]bool{}
+
+	for _, hostname := range strings.Split(value, ",") {
+		hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+		if hostname == "" || seen[hostname] {
+			continue
+		}
+		seen[hostname] = true
+
+		if len(validation.IsDNS1123Subdomain(strings.TrimPrefix(hostname, "*."))) > 0 {
+			invalid = append(invalid, hostname)
+			continue
+		}
+		hostnames = append(hostnames, hostname)
+	}
+
+	return hostnames, invalid
+}
@@ -0,0 +1,472 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/go-logr/logr"
+	"k8s.io/utils/pointer"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+func Test_parseHostnames(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		wantHostnames []string
+		wantInvalid   []string
+	}{
+		{
+			name:  "empty annotation",
+			value: "",
+		},
+		{
+			name:          "single hostname",
+			value:         "ingress.test-cluster.basedomain.io",
+			wantHostnames: []string{"ingress.test-cluster.basedomain.io"},
+		},
+		{
+			name:          "comma separated hostnames are normalized and deduplicated",
+			value:         "Ingress.test-cluster.basedomain.io., ingress.test-cluster.basedomain.io ,*.apps.test-cluster.basedomain.io,,",
+			wantHostnames: []string{"ingress.test-cluster.basedomain.io", "*.apps.test-cluster.basedomain.io"},
+		},
+		{
+			name:          "invalid hostnames are returned separately",
+			value:         "ingress.test-cluster.basedomain.io,in_valid.basedomain.io",
+			wantHostnames: []string{"ingress.test-cluster.basedomain.io"},
+			wantInvalid:   []string{"in_valid.basedomain.io"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHostnames, gotInvalid := parseHostnames(tt.value)
+			if !reflect.DeepEqual(gotHostnames, tt.wantHostnames) {
+				t.Errorf("parseHostnames() hostnames = %v, want %v", gotHostnames, tt.wantHostnames)
+			}
+			if !reflect.DeepEqual(gotInvalid, tt.wantInvalid) {
+				t.Errorf("parseHostnames() invalid = %v, want %v", gotInvalid, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+// newZonesTestService builds a DNS Service for test-cluster.basedomain.io with
+// the given additional zones.
+func newZonesTestService(t *testing.T, ctx context.Context, additionalZones []scope.Zone) *Service {
+	t.Helper()
+
+	svc := newGatewayTestService(t, ctx, nil)
+
+	dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+		ClusterScope:            &svc.scope.Scope,
+		BaseDomain:              svc.scope.BaseDomain(),
+		BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+		BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+		AdditionalZones:         additionalZones,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.scope = *dnsScope
+
+	return svc
+}
+
+func TestService_placeHostnameRecordSets(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, []scope.Zone{
+		{Name: "apps.example.com", ResourceGroup: "apps_rg"},
+	})
+	owner := svc.ownerMetadata()
+
+	aRecord := func(name string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String("A"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+			},
+		}
+	}
+	ownedARecord := func(name string) *armdns.RecordSet {
+		recordSet := aRecord(name)
+		recordSet.Properties.Metadata = owner
+		return recordSet
+	}
+
+	got := svc.placeHostnameRecordSets(ctx, []*armdns.RecordSet{
+		aRecord("ingress.test-cluster.basedomain.io"),
+		aRecord("shop.basedomain.io"),
+		aRecord("basedomain.io"),
+		aRecord("team-a.apps.example.com"),
+		aRecord("ingress.other-domain.io"),
+		{
+			Name: pointer.String("basedomain.io"),
+			Type: pointer.String("CNAME"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+				CnameRecord: &armdns.CnameRecord{Cname: pointer.String("lb.example.com")},
+			},
+		},
+	})
+
+	want := map[string][]*armdns.RecordSet{
+		"test-cluster.basedomain.io": {aRecord("ingress")},
+		"basedomain.io":              {ownedARecord("shop"), ownedARecord("@")},
+		"apps.example.com":           {ownedARecord("team-a")},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("placeHostnameRecordSets() = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestService_calculateMissingARecords_sharedZone(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	owner := svc.ownerMetadata()
+
+	baseZone := svc.sharedZones()[0]
+	if baseZone.name != "basedomain.io" {
+		t.Fatalf("expected base zone to be shared, got %s", baseZone.name)
+	}
+
+	desired := func(name, ip string) *armdns.RecordSet {
+		return &armdns.RecordSet{
+			Name: pointer.String(name),
+			Type: pointer.String("A"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String(ip)}},
+				Metadata: owner,
+			},
+		}
+	}
+
+	desiredRecordSets := []*armdns.RecordSet{
+		desired("owned", "1.2.3.4"),
+		desired("foreign", "1.2.3.4"),
+		desired("api.other-cluster", "1.2.3.4"),
+		desired("new", "1.2.3.4"),
+	}
+	currentRecordSets := []*armdns.RecordSet{
+		{
+			Name: pointer.String("owned"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("5.6.7.8")}},
+				Metadata: owner,
+			},
+		},
+		{
+			Name: pointer.String("foreign"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("5.6.7.8")}},
+			},
+		},
+		{
+			Name: pointer.String("other-cluster"),
+			Type: pointer.String(RecordSetTypeNS),
+			Properties: &armdns.RecordSetProperties{
+				TTL:       pointer.Int64(scope.DefaultNSRecordTTL),
+				NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1-01.azure-dns.com.")}},
+			},
+		},
+	}
+
+	got := svc.calculateMissingARecords(logr.Discard(), baseZone, desiredRecordSets, currentRecordSets)
+
+	want := []*armdns.RecordSet{
+		desired("owned", "1.2.3.4"),
+		desired("new", "1.2.3.4"),
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("calculateMissingARecords() = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestService_calculateMissingARecords_typeSwitch(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	clusterZone := svc.managedZones()[0]
+
+	cnameRecord := &armdns.RecordSet{
+		Name: pointer.String("ingress"),
+		Type: pointer.String("CNAME"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+			CnameRecord: &armdns.CnameRecord{Cname: pointer.String("abc123.elb.eu-west-1.amazonaws.com")},
+		},
+	}
+
+	currentRecordSets := []*armdns.RecordSet{
+		{
+			Name: pointer.String("@"),
+			Type: pointer.String(RecordSetTypeNS),
+			Properties: &armdns.RecordSetProperties{
+				TTL:       pointer.Int64(scope.DefaultNSRecordTTL),
+				NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1-01.azure-dns.com.")}},
+			},
+		},
+		{
+			Name: pointer.String("ingress"),
+			Type: pointer.String(RecordSetTypeA),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      pointer.Int64(scope.DefaultIngressRecordTTL),
+				ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+				Metadata: svc.ownerMetadata(),
+			},
+		},
+	}
+
+	got := svc.calculateMissingARecords(logr.Discard(), clusterZone, []*armdns.RecordSet{cnameRecord}, currentRecordSets)
+	if !reflect.DeepEqual(got, []*armdns.RecordSet{cnameRecord}) {
+		gotJSON, _ := json.Marshal(got)
+		t.Errorf("calculateMissingARecords() = %s, want the CNAME record to replace the A record", gotJSON)
+	}
+
+	currentRecordSets[1] = &armdns.RecordSet{
+		Name: pointer.String("ingress"),
+		Type: pointer.String(RecordSetTypeCNAME),
+		Properties: &armdns.RecordSetProperties{
+			TTL:         pointer.Int64(scope.DefaultIngressRecordTTL),
+			CnameRecord: &armdns.CnameRecord{Cname: pointer.String("abc123.elb.eu-west-1.amazonaws.com")},
+			Metadata:    svc.ownerMetadata(),
+		},
+	}
+
+	got = svc.calculateMissingARecords(logr.Discard(), clusterZone, []*armdns.RecordSet{cnameRecord}, currentRecordSets)
+	if len(got) != 0 {
+		gotJSON, _ := json.Marshal(got)
+		t.Errorf("calculateMissingARecords() = %s, want no records for an up to date CNAME record", gotJSON)
+	}
+}
+
+func Test_aRecordsEqual(t *testing.T) {
+	aRecords := func(addresses ...string) []*armdns.ARecord {
+		var records []*armdns.ARecord
+		for _, address := range addresses {
+			records = append(records, &armdns.ARecord{IPv4Address: pointer.String(address)})
+		}
+		return records
+	}
+
+	testCases := []struct {
+		name     string
+		a        []*armdns.ARecord
+		b        []*armdns.ARecord
+		expected bool
+	}{
+		{
+			name:     "case0: same addresses in another order",
+			a:        aRecords("1.2.3.4", "5.6.7.8"),
+			b:        aRecords("5.6.7.8", "1.2.3.4"),
+			expected: true,
+		},
+		{
+			name:     "case1: duplicate addresses",
+			a:        aRecords("1.2.3.4", "1.2.3.4"),
+			b:        aRecords("1.2.3.4"),
+			expected: true,
+		},
+		{
+			name: "case2: missing address",
+			a:    aRecords("1.2.3.4", "5.6.7.8"),
+			b:    aRecords("1.2.3.4"),
+		},
+		{
+			name:     "case3: no records",
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if equal := aRecordsEqual(tc.a, tc.b); equal != tc.expected {
+				t.Errorf("aRecordsEqual() = %t, want %t", equal, tc.expected)
+			}
+		})
+	}
+}
+
+func TestService_zoneTags(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	dnsScope, err := scope.NewDNSScope(ctx, scope.DNSScopeParams{
+		ClusterScope:            &svc.scope.Scope,
+		BaseDomain:              svc.scope.BaseDomain(),
+		BaseDomainResourceGroup: svc.scope.BaseDomainResourceGroup(),
+		BaseZoneCredentials:     svc.scope.BaseZoneCredentials(),
+		ResourceTags: map[string]*string{
+			"team":           pointer.String("rocket"),
+			ownerMetadataKey: pointer.String("someone/else"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.scope = *dnsScope
+
+	want := map[string]*string{
+		"team":                   pointer.String("rocket"),
+		ownerMetadataKey:         pointer.String("default/test-cluster"),
+		ownerInstanceMetadataKey: pointer.String("basedomain.io"),
+	}
+	if got := svc.zoneTags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("zoneTags() = %v, want %v", got, want)
+	}
+
+	// resource tags whose annotation was removed are removed, foreign tags
+	// are kept
+	existing := map[string]*string{
+		"env":                pointer.String("prod"),
+		"foreign":            pointer.String("x"),
+		azure.ManagedTagsKey: pointer.String("dns_operator_azure_cluster,env"),
+	}
+	want = mergeResourceTags(want, map[string]*string{
+		"foreign":            pointer.String("x"),
+		azure.ManagedTagsKey: pointer.String("dns_operator_azure_cluster,dns_operator_azure_instance,team"),
+	})
+	if got := svc.updatedZoneTags(existing); !reflect.DeepEqual(got, want) {
+		t.Errorf("updatedZoneTags() = %v, want %v", got, want)
+	}
+}
+
+// zoneTagsClient records the tags-only updates of zones.
+type zoneTagsClient struct {
+	client
+
+	updates []map[string]*string
+}
+
+func (c *zoneTagsClient) UpdateZoneTags(_ context.Context, _ string, _ string, tags map[string]*string) (armdns.Zone, error) {
+	c.updates = append(c.updates, tags)
+	return armdns.Zone{Tags: tags}, nil
+}
+
+func TestService_reconcileZoneTags(t *testing.T) {
+	ctx := context.TODO()
+
+	svc := newZonesTestService(t, ctx, nil)
+	owner := svc.ownerMetadata()
+	ownedRecordSet := &armdns.RecordSet{
+		Name:       pointer.String("api"),
+		Type:       pointer.String(RecordSetTypeA),
+		Properties: &armdns.RecordSetProperties{Metadata: owner},
+	}
+	legacyRecordSet := &armdns.RecordSet{
+		Name:       pointer.String("api"),
+		Type:       pointer.String(RecordSetTypeA),
+		Properties: &armdns.RecordSetProperties{},
+	}
+	foreignRecordSet := &armdns.RecordSet{
+		Name: pointer.String("www"),
+		Type: pointer.String(RecordSetTypeA),
+		Properties: &armdns.RecordSetProperties{Metadata: map[string]*string{
+			ownerMetadataKey:         pointer.String("default/test-cluster"),
+			ownerInstanceMetadataKey: pointer.String("other-mc"),
+		}},
+	}
+
+	testCases := []struct {
+		name        string
+		tags        map[string]*string
+		recordSets  []*armdns.RecordSet
+		external    bool
+		wantOwned   bool
+		wantUpdated bool
+	}{
+		{
+			name:       "owned zone with current tags",
+			tags:       svc.updatedZoneTags(nil),
+			recordSets: []*armdns.RecordSet{ownedRecordSet},
+			wantOwned:  true,
+		},
+		{
+			name:        "owned zone with outdated tags",
+			tags:        mergeResourceTags(owner, map[string]*string{"foreign": pointer.String("kept")}),
+			wantOwned:   true,
+			wantUpdated: true,
+		},
+		{
+			name:        "untagged zone with records of the cluster",
+			recordSets:  []*armdns.RecordSet{ownedRecordSet},
+			wantOwned:   true,
+			wantUpdated: true,
+		},
+		{
+			name:       "untagged zone with records of another operator instance",
+			recordSets: []*armdns.RecordSet{foreignRecordSet},
+		},
+		{
+			name:        "untagged zone created before ownership tracking",
+			tags:        map[string]*string{"foreign": pointer.String("kept")},
+			recordSets:  []*armdns.RecordSet{legacyRecordSet},
+			wantOwned:   true,
+			wantUpdated: true,
+		},
+		{
+			name: "zone of another operator instance",
+			tags: map[string]*string{
+				ownerMetadataKey:         pointer.String("default/test-cluster"),
+				ownerInstanceMetadataKey: pointer.String("other-mc"),
+			},
+		},
+		{
+			name:       "zone of another provider",
+			recordSets: []*armdns.RecordSet{ownedRecordSet},
+			external:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			zones := &zoneTagsClient{}
+			svc.azureClient = zones
+			svc.external = tc.external
+
+			clusterZone := armdns.Zone{Tags: tc.tags}
+			owned, err := svc.reconcileZoneTags(ctx, &clusterZone, tc.recordSets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owned != tc.wantOwned {
+				t.Errorf("reconcileZoneTags() = %t, want %t", owned, tc.wantOwned)
+			}
+
+			if !tc.wantUpdated {
+				if len(zones.updates) != 0 {
+					t.Errorf("zone tags updated to %v, want no update", zones.updates)
+				}
+				return
+			}
+			if len(zones.updates) != 1 {
+				t.Fatalf("zone tags updated %d times, want once", len(zones.updates))
+			}
+			// tags set by others are kept
+			want := svc.updatedZoneTags(tc.tags)
+			if !azure.TagsEqual(zones.updates[0], want) || !azure.TagsEqual(clusterZone.Tags, want) {
+				t.Errorf("zone tags = %v, want %v", zones.updates[0], want)
+			}
+		})
+	}
+}
@@ -20,8 +20,6 @@ import (
 const (
 	apiserverRecordName = "apiserver"
 	mcIngressRecordName = "ingress"
-
-	apiRecordTTL = 300
 )
 
 func (s *Service) updateARecords(ctx context.Context, currentRecordSets []*armprivatedns.RecordSet) error {
@@ -61,6 +59,7 @@ func (s *Service) updateARecords(ctx context.Context, currentRecordSets []*armpr
 			armprivatedns.RecordTypeA,
 			*aRecord.Name,
 			*aRecord)
+		s.recordSetWritten(armprivatedns.RecordTypeA, aRecord, currentRecordSets, err)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -131,7 +130,7 @@ func (s *Service) getDesiredPrivateARecords(ctx context.Context) []*armprivatedn
 				Name: pointer.String(apiserverRecordName),
 				Type: pointer.String(string(armprivatedns.RecordTypeA)),
 				Properties: &armprivatedns.RecordSetProperties{
-					TTL: pointer.Int64(apiRecordTTL),
+					TTL: pointer.Int64(s.scope.RecordTTLs().API),
 				},
 			},
 		)
@@ -152,7 +151,7 @@ func (s *Service) getDesiredPrivateARecords(ctx context.Context) []*armprivatedn
 				Name: pointer.String(mcIngressRecordName),
 				Type: pointer.String(string(armprivatedns.RecordTypeA)),
 				Properties: &armprivatedns.RecordSetProperties{
-					TTL: pointer.Int64(apiRecordTTL),
+					TTL: pointer.Int64(s.scope.RecordTTLs().API),
 				},
 			},
 		)
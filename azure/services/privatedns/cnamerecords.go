@@ -13,10 +13,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-const (
-	cnameRecordTTL = 300
-)
-
 func (s *Service) updateCnameRecords(ctx context.Context, currentRecordSets []*armprivatedns.RecordSet) error {
 	logger := log.FromContext(ctx).WithName("cnamerecords")
 
@@ -50,6 +46,7 @@ func (s *Service) updateCnameRecords(ctx context.Context, currentRecordSets []*a
 			armprivatedns.RecordTypeCNAME,
 			*cnameRecord.Name,
 			*cnameRecord)
+		s.recordSetWritten(armprivatedns.RecordTypeCNAME, cnameRecord, currentRecordSets, err)
 		if err != nil {
 			return err
 		}
@@ -66,7 +63,7 @@ func (s *Service) updateCnameRecords(ctx context.Context, currentRecordSets []*a
 
 func (s *Service) calculateMissingCnameRecords(logger logr.Logger, currentRecordSets []*armprivatedns.RecordSet) []*armprivatedns.RecordSet {
 
-	desiredRecords := desiredCnameRecords(s.scope.WildcardFQDN())
+	desiredRecords := s.desiredCnameRecords()
 
 	var recordsToCreate []*armprivatedns.RecordSet
 
@@ -93,15 +90,15 @@ func (s *Service) calculateMissingCnameRecords(logger logr.Logger, currentRecord
 	return recordsToCreate
 }
 
-func desiredCnameRecords(wildcardTarget string) []*armprivatedns.RecordSet {
+func (s *Service) desiredCnameRecords() []*armprivatedns.RecordSet {
 	return []*armprivatedns.RecordSet{
 		{
 			Name: pointer.String("*"),
 			Type: pointer.String(string(armdns.RecordTypeCNAME)),
 			Properties: &armprivatedns.RecordSetProperties{
-				TTL: pointer.Int64(cnameRecordTTL),
+				TTL: pointer.Int64(s.scope.RecordTTLs().Ingress),
 				CnameRecord: &armprivatedns.CnameRecord{
-					Cname: pointer.String(wildcardTarget),
+					Cname: pointer.String(s.scope.WildcardFQDN()),
 				},
 			},
 		},
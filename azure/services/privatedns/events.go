@@ -0,0 +1,55 @@
+package privatedns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+)
+
+// recordSetWritten records an event about desired being written to the
+// private zone, replacing the record set of the same name in
+// currentRecordSets if there is one. A failed write, err not nil, is recorded
+// as Warning event.
+func (s *Service) recordSetWritten(recordType armprivatedns.RecordType, desired *armprivatedns.RecordSet, currentRecordSets []*armprivatedns.RecordSet, err error) {
+	fqdn := fmt.Sprintf("%s.%s", *desired.Name, s.scope.ClusterDomain())
+
+	var current *armprivatedns.RecordSet
+	for _, recordSet := range currentRecordSets {
+		if recordSet.Name != nil && *recordSet.Name == *desired.Name {
+			current = recordSet
+		}
+	}
+
+	switch {
+	case err != nil:
+		s.scope.Warnf("PrivateDNSRecordUpdateFailed", "Failed to write private DNS %s record %s: %s", recordType, fqdn, err)
+	case current == nil:
+		s.scope.Eventf("PrivateDNSRecordCreated", "Created private DNS %s record %s with value %s", recordType, fqdn, recordSetValue(desired))
+	default:
+		s.scope.Eventf("PrivateDNSRecordUpdated", "Updated private DNS %s record %s from %s to %s", recordType, fqdn, recordSetValue(current), recordSetValue(desired))
+	}
+}
+
+// recordSetValue describes the records of recordSet for events, e.g.
+// "10.0.0.4,10.0.0.5".
+func recordSetValue(recordSet *armprivatedns.RecordSet) string {
+	if recordSet.Properties == nil {
+		return "<none>"
+	}
+
+	var values []string
+	for _, record := range recordSet.Properties.ARecords {
+		if record.IPv4Address != nil {
+			values = append(values, *record.IPv4Address)
+		}
+	}
+	if record := recordSet.Properties.CnameRecord; record != nil && record.Cname != nil {
+		values = append(values, *record.Cname)
+	}
+
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
@@ -60,10 +60,13 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		err = s.privateDNSClient.CreateOrUpdatePrivateZone(ctx, managementClusterResourceGroup, clusterZoneName, armprivatedns.PrivateZone{
 			Name:     &clusterZoneName,
 			Location: pointer.String(capzazure.Global),
+			Tags:     azure.UpdateManagedTags(nil, s.scope.ResourceTags()),
 		})
 		if err != nil {
+			s.scope.Warnf("PrivateDNSZoneCreationFailed", "Failed to create private DNS zone %s in resource group %s: %s", clusterZoneName, managementClusterResourceGroup, err)
 			return microerror.Mask(err)
 		}
+		s.scope.Eventf("PrivateDNSZoneCreated", "Created private DNS zone %s in resource group %s", clusterZoneName, managementClusterResourceGroup)
 	}
 
 	log.Info("list virtualNetworkLinks")
@@ -90,8 +93,10 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			existingVirtualNetworkLink := networkLinks[existingVirtualNetworkLinkIndex]
 			err = s.privateDNSClient.DeleteVirtualNetworkLink(ctx, managementClusterResourceGroup, clusterZoneName, *existingVirtualNetworkLink.Name)
 			if err != nil {
+				s.scope.Warnf("PrivateDNSVnetLinkDeletionFailed", "Failed to delete virtual network link %s of private DNS zone %s: %s", *existingVirtualNetworkLink.Name, clusterZoneName, err)
 				return microerror.Mask(err)
 			}
+			s.scope.Eventf("PrivateDNSVnetLinkDeleted", "Deleted virtual network link %s of private DNS zone %s, it is replaced by %s", *existingVirtualNetworkLink.Name, clusterZoneName, vnetLinkName)
 		}
 
 		log.V(1).Info("virtual network link not found, creating a new one")
@@ -105,14 +110,18 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			vnetLinkName,
 		)
 		if err != nil {
+			s.scope.Warnf("PrivateDNSVnetLinkCreationFailed", "Failed to link private DNS zone %s to virtual network %s: %s", clusterZoneName, s.scope.ManagementClusterVnetID(), err)
 			return microerror.Mask(err)
 		}
+		s.scope.Eventf("PrivateDNSVnetLinkCreated", "Linked private DNS zone %s to virtual network %s as %s", clusterZoneName, s.scope.ManagementClusterVnetID(), vnetLinkName)
 	}
 
 	log.V(1).Info("get privateDNSZone Object", "privateDNSZone", clusterZoneName)
 	privateZones, err := s.privateDNSClient.GetPrivateZone(ctx, s.scope.ManagementClusterResourceGroup(), clusterZoneName)
 	if err != nil {
 		log.V(1).Info("new error", "error", err.Error())
+	} else if err := s.updatePrivateZoneTags(ctx, privateZones); err != nil {
+		return microerror.Mask(err)
 	}
 
 	// dns_operator_zone_records_sum
@@ -134,6 +143,31 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return nil
 }
 
+// updatePrivateZoneTags updates the tags of the private zone to the resource
+// tags of the cluster. Tags set by others are kept.
+func (s *Service) updatePrivateZoneTags(ctx context.Context, privateZone armprivatedns.PrivateZone) error {
+	clusterZoneName := s.scope.ClusterDomain()
+
+	tags := azure.UpdateManagedTags(privateZone.Tags, s.scope.ResourceTags())
+	if azure.TagsEqual(privateZone.Tags, tags) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Updating tags of private DNS zone", "privateDNSZone", clusterZoneName)
+	err := s.privateDNSClient.CreateOrUpdatePrivateZone(ctx, s.scope.ManagementClusterResourceGroup(), clusterZoneName, armprivatedns.PrivateZone{
+		Name:     &clusterZoneName,
+		Location: pointer.String(capzazure.Global),
+		Tags:     tags,
+	})
+	if err != nil {
+		s.scope.Warnf("PrivateDNSZoneUpdateFailed", "Failed to update tags of private DNS zone %s: %s", clusterZoneName, err)
+		return microerror.Mask(err)
+	}
+	s.scope.Eventf("PrivateDNSZoneTagged", "Updated tags of private DNS zone %s", clusterZoneName)
+
+	return nil
+}
+
 func (s *Service) ReconcileDelete(ctx context.Context) error {
 	log := log.FromContext(ctx).WithName("azure-private-dns-delete")
 	clusterZoneName := s.scope.ClusterDomain()
@@ -142,12 +176,16 @@ func (s *Service) ReconcileDelete(ctx context.Context) error {
 	mcResourceGroup := s.scope.ManagementClusterResourceGroup()
 	vnetLinkName := virtualNetworkLinkName(s.scope.ManagementClusterResourceGroup())
 	if err := s.privateDNSClient.DeleteVirtualNetworkLink(ctx, mcResourceGroup, clusterZoneName, vnetLinkName); err != nil {
+		s.scope.Warnf("PrivateDNSVnetLinkDeletionFailed", "Failed to delete virtual network link %s of private DNS zone %s: %s", vnetLinkName, clusterZoneName, err)
 		return microerror.Mask(err)
 	}
+	s.scope.Eventf("PrivateDNSVnetLinkDeleted", "Deleted virtual network link %s of private DNS zone %s", vnetLinkName, clusterZoneName)
 
 	if err := s.privateDNSClient.DeletePrivateZone(ctx, s.scope.ManagementClusterResourceGroup(), clusterZoneName); err != nil {
+		s.scope.Warnf("PrivateDNSZoneDeletionFailed", "Failed to delete private DNS zone %s: %s", clusterZoneName, err)
 		return microerror.Mask(err)
 	}
+	s.scope.Eventf("PrivateDNSZoneDeleted", "Deleted private DNS zone %s", clusterZoneName)
 
 	log.Info("Successfully reconciled DNS", "privateDNSZone", clusterZoneName)
 
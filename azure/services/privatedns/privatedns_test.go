@@ -0,0 +1,90 @@
+package privatedns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"k8s.io/utils/pointer"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+// zoneTagsClient records the tags written to the private zone.
+type zoneTagsClient struct {
+	Client
+
+	tags []map[string]*string
+}
+
+func (c *zoneTagsClient) CreateOrUpdatePrivateZone(_ context.Context, _ string, _ string, zone armprivatedns.PrivateZone) error {
+	c.tags = append(c.tags, zone.Tags)
+	return nil
+}
+
+func TestService_updatePrivateZoneTags(t *testing.T) {
+	testCases := []struct {
+		name         string
+		resourceTags map[string]*string
+		zoneTags     map[string]*string
+		expectedTags []map[string]*string
+	}{
+		{
+			name:         "case0: resource tags are added, other tags are kept",
+			resourceTags: map[string]*string{"team": pointer.String("rocket")},
+			zoneTags:     map[string]*string{"foreign": pointer.String("kept")},
+			expectedTags: []map[string]*string{
+				{"team": pointer.String("rocket"), "foreign": pointer.String("kept"), azure.ManagedTagsKey: pointer.String("team")},
+			},
+		},
+		{
+			name:         "case1: changed tag values are updated, removed tags are deleted",
+			resourceTags: map[string]*string{"team": pointer.String("rocket")},
+			zoneTags:     map[string]*string{"team": pointer.String("old"), "env": pointer.String("prod"), azure.ManagedTagsKey: pointer.String("env,team")},
+			expectedTags: []map[string]*string{
+				{"team": pointer.String("rocket"), azure.ManagedTagsKey: pointer.String("team")},
+			},
+		},
+		{
+			name:         "case2: zones with the resource tags aren't updated",
+			resourceTags: map[string]*string{"team": pointer.String("rocket")},
+			zoneTags:     map[string]*string{"team": pointer.String("rocket"), "foreign": pointer.String("kept"), azure.ManagedTagsKey: pointer.String("team")},
+		},
+		{
+			name:     "case3: clusters without resource tags don't update zones",
+			zoneTags: map[string]*string{"foreign": pointer.String("kept")},
+		},
+		{
+			name:         "case4: foreign tags of zones tagged before the managed tags were listed are kept",
+			resourceTags: map[string]*string{"team": pointer.String("rocket")},
+			zoneTags:     map[string]*string{"team": pointer.String("old"), "foreign": pointer.String("kept")},
+			expectedTags: []map[string]*string{
+				{"team": pointer.String("rocket"), "foreign": pointer.String("kept"), azure.ManagedTagsKey: pointer.String("team")},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			privateDNSScope, err := scope.NewPrivateDNSScope(context.TODO(), scope.PrivateDNSScopeParams{
+				BaseDomain:   "basedomain.io",
+				ClusterName:  "test-cluster",
+				ResourceTags: tc.resourceTags,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &zoneTagsClient{}
+			s := &Service{scope: *privateDNSScope, privateDNSClient: client}
+
+			if err := s.updatePrivateZoneTags(context.TODO(), armprivatedns.PrivateZone{Tags: tc.zoneTags}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(client.tags, tc.expectedTags) {
+				t.Errorf("written tags = %v, want %v", client.tags, tc.expectedTags)
+			}
+		})
+	}
+}
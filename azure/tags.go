@@ -0,0 +1,72 @@
+package azure
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// ManagedTagsKey is the tag listing the keys of the tags the operator
+	// set on a resource, comma separated. Tags the operator doesn't list are
+	// left alone, e.g. tags set by policies or by hand.
+	ManagedTagsKey = "dns_operator_azure_managed_tags"
+)
+
+// UpdateManagedTags returns the tags of a resource with the tags existing
+// updated to desired: desired tags are added or updated, tags listed in the
+// ManagedTagsKey tag of existing that aren't desired anymore are removed, and
+// the ManagedTagsKey tag lists the desired tags.
+//
+// Resources the operator tagged before the ManagedTagsKey tag was introduced
+// don't have it. Only their tags that are still desired are taken over as
+// managed, all others may have been set by someone else and are left alone.
+// Once written, the ManagedTagsKey tag is kept even if it lists no tags, so
+// that removed tags aren't taken over again.
+func UpdateManagedTags(existing map[string]*string, desired map[string]*string) map[string]*string {
+	tags := map[string]*string{}
+	for key, value := range existing {
+		tags[key] = value
+	}
+
+	value, tracked := existing[ManagedTagsKey]
+	tracked = tracked && value != nil
+	if tracked {
+		for _, key := range strings.Split(*value, ",") {
+			if _, ok := desired[key]; !ok {
+				delete(tags, key)
+			}
+		}
+	}
+
+	var keys []string
+	for key, value := range desired {
+		tags[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	delete(tags, ManagedTagsKey)
+	if tracked || len(keys) > 0 {
+		managed := strings.Join(keys, ",")
+		tags[ManagedTagsKey] = &managed
+	}
+
+	return tags
+}
+
+// TagsEqual reports whether two sets of tags hold the same tags with the same
+// values.
+func TagsEqual(existingTags map[string]*string, newTags map[string]*string) bool {
+	if len(existingTags) != len(newTags) {
+		return false
+	}
+
+	for key, value := range newTags {
+		existingValue, ok := existingTags[key]
+		if !ok || (existingValue == nil) != (value == nil) || (value != nil && *existingValue != *value) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,180 @@
+package azure
+
+import (
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+func TestUpdateManagedTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing map[string]*string
+		desired  map[string]*string
+		expected map[string]*string
+	}{
+		{
+			name:    "case0: desired tags are added and listed",
+			desired: map[string]*string{"team": pointer.String("rocket"), "env": pointer.String("prod")},
+			expected: map[string]*string{
+				"team":         pointer.String("rocket"),
+				"env":          pointer.String("prod"),
+				ManagedTagsKey: pointer.String("env,team"),
+			},
+		},
+		{
+			name: "case1: managed tags that aren't desired anymore are removed, other tags are kept",
+			existing: map[string]*string{
+				"team":         pointer.String("rocket"),
+				"env":          pointer.String("prod"),
+				"policy":       pointer.String("kept"),
+				ManagedTagsKey: pointer.String("env,team"),
+			},
+			desired: map[string]*string{"team": pointer.String("rocket")},
+			expected: map[string]*string{
+				"team":         pointer.String("rocket"),
+				"policy":       pointer.String("kept"),
+				ManagedTagsKey: pointer.String("team"),
+			},
+		},
+		{
+			name: "case2: the list is emptied with the last managed tag",
+			existing: map[string]*string{
+				"team":         pointer.String("rocket"),
+				"policy":       pointer.String("kept"),
+				ManagedTagsKey: pointer.String("team"),
+			},
+			expected: map[string]*string{
+				"policy":       pointer.String("kept"),
+				ManagedTagsKey: pointer.String(""),
+			},
+		},
+		{
+			name: "case3: foreign tags of resources tagged before the list are kept",
+			existing: map[string]*string{
+				"team":   pointer.String("old"),
+				"policy": pointer.String("kept"),
+			},
+			desired: map[string]*string{"team": pointer.String("rocket")},
+			expected: map[string]*string{
+				"team":         pointer.String("rocket"),
+				"policy":       pointer.String("kept"),
+				ManagedTagsKey: pointer.String("team"),
+			},
+		},
+		{
+			name: "case4: untracked resources without desired tags are left alone",
+			existing: map[string]*string{
+				"policy": pointer.String("kept"),
+			},
+			expected: map[string]*string{
+				"policy": pointer.String("kept"),
+			},
+		},
+		{
+			name:     "case5: no tags",
+			expected: map[string]*string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tags := UpdateManagedTags(tc.existing, tc.desired)
+			if !TagsEqual(tags, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, tags)
+			}
+
+			// updating again doesn't change the tags
+			if again := UpdateManagedTags(tags, tc.desired); !TagsEqual(again, tags) {
+				t.Fatalf("expected %v, got %v on the second update", tags, again)
+			}
+		})
+	}
+}
+
+func TestTagsEqual(t *testing.T) {
+	testValue := "test-value"
+	testValue2 := "test-value2"
+
+	testCases := []struct {
+		name    string
+		oldTags map[string]*string
+		newTags map[string]*string
+		equal   bool
+	}{
+		{
+			name:    "case0: Equal tags",
+			oldTags: map[string]*string{"test-key": &testValue},
+			newTags: map[string]*string{"test-key": &testValue},
+			equal:   true,
+		},
+		{
+			name:  "case1: Both are nil",
+			equal: true,
+		},
+		{
+			name:    "case2: Different tags",
+			oldTags: map[string]*string{"test-key": &testValue},
+			newTags: map[string]*string{"test-key1": &testValue},
+			equal:   false,
+		},
+		{
+			name:    "case3: Different values",
+			oldTags: map[string]*string{"test-key": &testValue},
+			newTags: map[string]*string{"test-key": &testValue2},
+			equal:   false,
+		},
+		{
+			name:    "case4: Old tags are nil",
+			newTags: map[string]*string{"test-key": &testValue},
+			equal:   false,
+		},
+		{
+			name:    "case5: New tags are nil",
+			oldTags: map[string]*string{"test-key": &testValue},
+			equal:   false,
+		},
+		{
+			name:    "case6: Old tags are empty",
+			oldTags: map[string]*string{},
+			newTags: map[string]*string{"test-key": &testValue},
+			equal:   false,
+		},
+		{
+			name:    "case7: New tags are empty",
+			oldTags: map[string]*string{"test-key": &testValue},
+			newTags: map[string]*string{},
+			equal:   false,
+		},
+		{
+			name:    "case8: Old tags contains various tags",
+			oldTags: map[string]*string{"test-key": &testValue, "test-key1": &testValue},
+			newTags: map[string]*string{"test-key": &testValue2},
+			equal:   false,
+		},
+		{
+			name:    "case9: Old tags are nil and new tags are empty",
+			newTags: map[string]*string{},
+			equal:   true,
+		},
+		{
+			name:    "case10: Old tags are empty and new tags are nil",
+			oldTags: map[string]*string{},
+			equal:   true,
+		},
+		{
+			name:    "case11: nil values",
+			oldTags: map[string]*string{"test-key": nil},
+			newTags: map[string]*string{"test-key": &testValue},
+			equal:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if equal := TagsEqual(tc.oldTags, tc.newTags); equal != tc.equal {
+				t.Fatalf("expected %v, got %v", tc.equal, equal)
+			}
+		})
+	}
+}
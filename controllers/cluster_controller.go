@@ -17,21 +17,24 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
-	clusterv1beta1 "sigs.k8s.io/cluster-api/api/core/v1beta1"
 	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -69,9 +72,27 @@ type ClusterReconciler struct {
 	InfraClusterZoneAzureConfig infracluster.ClusterZoneAzureConfig
 
 	ClusterAzureIdentityRef *corev1.ObjectReference
+
+	AdditionalZones []azurescope.Zone
+	// Settings must only be changed with UpdateSettings once the reconciler
+	// runs.
+	Settings   Settings
+	settingsMu sync.RWMutex
+	// ManagementLocks places CanNotDelete management locks on cluster zones
+	// and on the resource groups of non-Azure clusters.
+	ManagementLocks bool
+	// PropagationQuerier asks the name servers of the zones for the records
+	// after every reconciliation. The check is skipped if nil.
+	PropagationQuerier dns.Querier
+	// DNSProvider writes the public zones instead of Azure DNS if set, e.g.
+	// an rfc2136.Client.
+	DNSProvider dns.Provider
+
+	Shard Shard
 }
 
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters/status,verbs=get;update;patch
 
 func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
 	logger := log.FromContext(ctx)
@@ -90,7 +111,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Info("infrastructure cluster for core cluster is not ready", "Cluster", cluster.Name)
-			return reconcile.Result{RequeueAfter: 1 * time.Minute}, nil
+			return reconcile.Result{RequeueAfter: r.settings().Requeue.InfrastructureNotFound}, nil
 		}
 		return reconcile.Result{}, microerror.Mask(err)
 	}
@@ -104,6 +125,19 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, nil
 	}
 
+	// Clusters that opted out of DNS management are left alone. A finalizer
+	// added before the opt-out is removed without deleting anything, so the
+	// DNS resources are handed over as they are.
+	if infracluster.IsDNSManagementDisabled(cluster.GetAnnotations()) {
+		logger.Info(fmt.Sprintf("cluster is annotated with %s. Won't reconcile", infracluster.AnnotationDNSDisabled))
+		if controllerutil.RemoveFinalizer(infraCluster, AzureClusterControllerFinalizer) {
+			if err := r.Client.Update(ctx, infraCluster); err != nil {
+				return reconcile.Result{}, microerror.Mask(err)
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// Create the cluster scope.
 	clusterScope, err := infracluster.NewScope(ctx, infracluster.ScopeParams{
 		Client:                  r.Client,
@@ -112,11 +146,24 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		ClusterZoneAzureConfig:  r.InfraClusterZoneAzureConfig,
 		ClusterIdentityRef:      r.ClusterAzureIdentityRef,
 		ManagementClusterConfig: r.ManagementClusterConfig,
+		Recorder:                r.Recorder,
 	})
 	if err != nil {
 		return reconcile.Result{}, microerror.Mask(err)
 	}
 
+	// failures are recorded as events, so that they show up on the Cluster
+	// without digging through the operator logs
+	deleting := !cluster.GetDeletionTimestamp().IsZero() || !infraCluster.GetDeletionTimestamp().IsZero()
+	defer func() {
+		switch {
+		case reterr != nil && deleting:
+			clusterScope.Warnf("DNSDeletionFailed", "Failed to delete DNS resources: %s", reterr)
+		case reterr != nil:
+			clusterScope.Warnf("DNSReconciliationFailed", "Failed to reconcile DNS resources: %s", reterr)
+		}
+	}()
+
 	defer func() {
 		if err = clusterScope.Patcher.Close(ctx); err != nil && reterr == nil {
 			reterr = microerror.Mask(err)
@@ -124,7 +171,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	}()
 
 	// Handle deleted clusters
-	if !cluster.GetDeletionTimestamp().IsZero() || !infraCluster.GetDeletionTimestamp().IsZero() {
+	if deleting {
 		return r.reconcileDelete(ctx, clusterScope)
 	}
 
@@ -140,8 +187,12 @@ func (r *ClusterReconciler) reconcileNormal(ctx context.Context, clusterScope *i
 	cluster := clusterScope.Cluster
 	infraCluster := clusterScope.InfraCluster
 
+	// Clusters with our finalizer were managed before their zone was
+	// recorded.
+	managed := controllerutil.ContainsFinalizer(infraCluster, AzureClusterControllerFinalizer)
+
 	// If the AzureCluster doesn't have our finalizer, add it.
-	if !controllerutil.ContainsFinalizer(infraCluster, AzureClusterControllerFinalizer) {
+	if !managed {
 		controllerutil.AddFinalizer(infraCluster, AzureClusterControllerFinalizer)
 		// Register the finalizer immediately to avoid orphaning Azure resources on delete
 		if err = clusterScope.Patcher.PatchObject(ctx); err != nil {
@@ -149,41 +200,94 @@ func (r *ClusterReconciler) reconcileNormal(ctx context.Context, clusterScope *i
 		}
 	}
 
-	result, err, isReady := isClusterReadyForDnsManagements(cluster, logger, clusterScope)
+	if err = r.pinClusterZone(ctx, clusterScope, managed); err != nil {
+		return reconcile.Result{}, microerror.Mask(err)
+	}
+
+	result, err, isReady := isClusterReadyForDnsManagements(cluster, logger, clusterScope, r.settings().Requeue.NotReady)
 	if !isReady {
+		condErr := r.setClusterCondition(ctx, cluster, metav1.Condition{
+			Type:    DNSReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "InfrastructureNotReady",
+			Message: "Waiting for the cluster infrastructure to be provisioned",
+		})
+		if condErr != nil && err == nil {
+			return reconcile.Result{}, microerror.Mask(condErr)
+		}
 		return result, err
 	}
 
-	infraClusterAnnotations := infraCluster.GetAnnotations()
+	// The conditions are written even if the reconciliation fails, so that
+	// they tell which step failed and why.
+	var infraConditions dnsConditions
+	result, err = r.reconcileDNS(ctx, clusterScope, &infraConditions)
+	condErr := r.setDNSConditions(ctx, clusterScope, infraConditions, dnsReadyCondition(infraConditions, err))
+	if err != nil {
+		return result, err
+	}
+	if condErr != nil {
+		return reconcile.Result{}, microerror.Mask(condErr)
+	}
+
+	if err := clusterScope.Patcher.PatchObject(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Successfully reconciled InfraCluster DNS zones")
+	return reconcile.Result{RequeueAfter: r.settings().Requeue.Reconciled}, nil
+}
+
+// reconcileDNS reconciles the private and public DNS resources of the
+// cluster and adds the conditions of the steps it reached to infraConditions.
+func (r *ClusterReconciler) reconcileDNS(ctx context.Context, clusterScope *infracluster.Scope, infraConditions *dnsConditions) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cluster := clusterScope.Cluster
+	infraClusterAnnotations := clusterScope.InfraCluster.GetAnnotations()
 	azureClusterSpec := clusterScope.AzureClusterSpec()
 
-	// Private DNS for MC-to-WC api
-	if azureClusterSpec != nil && infraClusterAnnotations[azurePrivateEndpointOperatorApiServerAnnotation] != "" {
+	managesPrivateDNS := clusterScope.ManagesRecords(infracluster.ManagedRecordsPrivate)
 
+	// Private DNS for MC-to-WC api
+	switch {
+	case !managesPrivateDNS:
+		infraConditions.set(privateAPIDNSStep.skipped(reasonNotManaged, notManagedMessage))
+	case azureClusterSpec == nil || infraClusterAnnotations[azurePrivateEndpointOperatorApiServerAnnotation] == "":
+		infraConditions.set(privateAPIDNSStep.skipped(reasonNotRequired, "The cluster has no private API endpoint"))
+	default:
 		logger.V(1).Info(fmt.Sprintf("annotation %s found", azurePrivateEndpointOperatorApiServerAnnotation))
 
 		privateDnsService, result, err := r.getPrivateDnsServiceForMcToWcApi(ctx, logger, clusterScope)
 		if err != nil {
+			infraConditions.set(privateAPIDNSStep.condition(err))
 			return result, err
 		}
 
 		err = privateDnsService.Reconcile(ctx)
+		infraConditions.set(privateAPIDNSStep.condition(err))
 		if err != nil {
 			return reconcile.Result{}, microerror.Mask(err)
 		}
 	}
 
 	// Private DNS for WC-to-MC ingress
-	if azureClusterSpec != nil && infraClusterAnnotations[azurePrivateEndpointOperatorMcIngressAnnotation] != "" {
-
+	switch {
+	case !managesPrivateDNS:
+		infraConditions.set(privateIngressDNSStep.skipped(reasonNotManaged, notManagedMessage))
+	case azureClusterSpec == nil || infraClusterAnnotations[azurePrivateEndpointOperatorMcIngressAnnotation] == "":
+		infraConditions.set(privateIngressDNSStep.skipped(reasonNotRequired, "The cluster has no private endpoint for the management cluster ingress"))
+	default:
 		logger.V(1).Info(fmt.Sprintf("annotation %s found", azurePrivateEndpointOperatorMcIngressAnnotation))
 
 		privateDnsService, result, err := r.getPrivateDnsServiceForWcToMcIngress(ctx, logger, clusterScope)
 		if err != nil {
+			infraConditions.set(privateIngressDNSStep.condition(err))
 			return result, microerror.Mask(err)
 		}
 
 		err = privateDnsService.Reconcile(ctx)
+		infraConditions.set(privateIngressDNSStep.condition(err))
 		if err != nil {
 			return reconcile.Result{}, microerror.Mask(err)
 		}
@@ -206,34 +310,39 @@ func (r *ClusterReconciler) reconcileNormal(ctx context.Context, clusterScope *i
 	).Set(1)
 
 	err = dnsService.Reconcile(ctx)
+	infraConditions.setPublicDNSSteps(dnsService, clusterScope.ManagesRecords(infracluster.ManagedRecordsRecords))
+	if checked, notOwnedErr := dnsService.ResourceGroupOwned(); checked {
+		infraConditions.set(resourceGroupOwnedCondition(notOwnedErr))
+	}
 	if err != nil {
 		return reconcile.Result{}, microerror.Mask(err)
 	}
+	infraConditions.set(recordsAdoptedCondition(dnsService.Conflicts()))
 
-	// AKS (AzureASOManagedCluster) infra clusters have their status managed by
-	// CAPZ/ASO and their schema has no conditions field, so we don't set the
-	// GSDNSZoneReady condition on them.
-	if !clusterScope.IsASOManagedCluster() {
-		err = infracluster.SetUnstructuredCondition(infraCluster, clusterv1beta1.Condition{
-			Type:   "GSDNSZoneReady",
-			Status: corev1.ConditionTrue,
-			// Reason and Message will be required in CAPI v1beta2.
-			Reason:  "GSDNSZonesCreated",
-			Message: "GiantSwarm DNS Zones have been created",
-		})
+	// Verify the answers of the authoritative name servers
+	if r.PropagationQuerier != nil {
+		report, err := dnsService.CheckPropagation(ctx, r.PropagationQuerier)
 		if err != nil {
-			return reconcile.Result{}, err
-		}
-		if err := clusterScope.Client.Status().Update(ctx, infraCluster); err != nil {
-			return reconcile.Result{}, err
+			logger.Error(err, "failed to check the propagation of the DNS records")
+		} else if !report.Propagated() {
+			logger.Info("DNS records are not propagated to all name servers yet", "mismatches", report.Mismatches)
 		}
+		infraConditions.set(propagatedCondition(report, err))
 	}
-	if err := clusterScope.Patcher.PatchObject(ctx); err != nil {
-		return reconcile.Result{}, err
+
+	// Zone file import, e.g. of records migrated from another DNS setup. The
+	// condition of the last import is kept while the zone file is unchanged.
+	if configMapName := cluster.GetAnnotations()[infracluster.AnnotationImportZoneConfigMap]; configMapName != "" {
+		report, imported, err := r.importZoneConfigMap(ctx, dnsService, cluster, configMapName)
+		if err != nil && !IsInvalidZoneImport(err) {
+			return reconcile.Result{}, microerror.Mask(err)
+		}
+		if imported {
+			infraConditions.set(zoneImportedCondition(report, err))
+		}
 	}
 
-	logger.Info("Successfully reconciled InfraCluster DNS zones")
-	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	return reconcile.Result{}, nil
 }
 
 func (r *ClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *infracluster.Scope) (ctrl.Result, error) {
@@ -247,8 +356,10 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *i
 	infraClusterAnnotations := infraCluster.GetAnnotations()
 	azureClusterSpec := clusterScope.AzureClusterSpec()
 
+	managesPrivateDNS := clusterScope.ManagesRecords(infracluster.ManagedRecordsPrivate)
+
 	// Private DNS for MC-to-WC api
-	if azureClusterSpec != nil && infraClusterAnnotations[azurePrivateEndpointOperatorApiServerAnnotation] != "" {
+	if managesPrivateDNS && azureClusterSpec != nil && infraClusterAnnotations[azurePrivateEndpointOperatorApiServerAnnotation] != "" {
 
 		logger.V(1).Info(fmt.Sprintf("annotation %s found", azurePrivateEndpointOperatorApiServerAnnotation))
 
@@ -263,13 +374,13 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *i
 		}
 
 		deletedMetrics += deleteClusterMetrics(
-			fmt.Sprintf("%s.%s", clusterScope.Patcher.ClusterName(), r.BaseDomain),
+			r.clusterZoneName(clusterScope),
 			metrics.ZoneTypePrivate,
 		)
 	}
 
 	// Private DNS for WC-to-MC ingress
-	if azureClusterSpec != nil && infraClusterAnnotations[azurePrivateEndpointOperatorMcIngressAnnotation] != "" {
+	if managesPrivateDNS && azureClusterSpec != nil && infraClusterAnnotations[azurePrivateEndpointOperatorMcIngressAnnotation] != "" {
 
 		logger.V(1).Info(fmt.Sprintf("annotation %s found", azurePrivateEndpointOperatorMcIngressAnnotation))
 
@@ -299,6 +410,14 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *i
 	if err != nil {
 		return reconcile.Result{}, microerror.Mask(err)
 	}
+	clusterScope.Eventf("DNSResourcesDeleted", "Deleted the DNS resources of the cluster")
+
+	// tell why the resource group of a non-Azure cluster outlives it
+	if keptErr := dnsService.ResourceGroupKept(); keptErr != nil {
+		if err := setInfraClusterConditions(ctx, clusterScope, dnsConditions{resourceGroupKeptCondition(keptErr)}); err != nil {
+			return reconcile.Result{}, microerror.Mask(err)
+		}
+	}
 
 	// remove finalizer
 	if controllerutil.ContainsFinalizer(clusterScope.InfraCluster, AzureClusterControllerFinalizer) {
@@ -306,7 +425,7 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *i
 	}
 
 	deletedMetrics += deleteClusterMetrics(
-		fmt.Sprintf("%s.%s", clusterScope.Patcher.ClusterName(), r.BaseDomain),
+		r.clusterZoneName(clusterScope),
 		metrics.ZoneTypePublic,
 	)
 	logger.V(1).Info(fmt.Sprintf("%d metrics for cluster %s got deleted", deletedMetrics, clusterScope.Patcher.ClusterName()))
@@ -317,7 +436,7 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *i
 
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&capi.Cluster{}).
+		For(&capi.Cluster{}, builder.WithPredicates(r.Shard.Predicate())).
 		WithOptions(options).
 		Complete(r)
 }
@@ -333,6 +452,7 @@ func (r *ClusterReconciler) getDnsServiceForPublicRecords(ctx context.Context, l
 		return nil, reconcile.Result{}, microerror.Mask(err)
 	}
 
+	settings := r.settings()
 	params := azurescope.DNSScopeParams{
 		ClusterScope:                       clusterScope,
 		AzureClusterIdentity:               *azureClusterIdentity,
@@ -345,7 +465,18 @@ func (r *ClusterReconciler) getDnsServiceForPublicRecords(ctx context.Context, l
 			SubscriptionID: r.BaseZoneSubscriptionID,
 			TenantID:       r.BaseZoneTenantID,
 		},
-		ResourceTags: infracluster.GetResourceTagsFromInfraClusterAnnotations(clusterScope.InfraClusterAnnotations()),
+		AdditionalZones:               r.AdditionalZones,
+		IngressServiceDiscovery:       settings.IngressServiceDiscovery,
+		APIServerHostnameMode:         settings.APIServerHostnameMode,
+		APIServerRecordMode:           settings.APIServerRecordMode,
+		AdoptionPolicy:                settings.AdoptionPolicy,
+		PrivateRecordsMode:            settings.PrivateRecordsMode,
+		IntermediateZoneMode:          settings.IntermediateZoneMode,
+		SplitHorizonVirtualNetworkIDs: settings.SplitHorizonVirtualNetworkIDs,
+		ResourceTags:                  clusterScope.AzureResourceTags(),
+		ManagementLocks:               r.ManagementLocks,
+		OperatorIdentity:              r.ManagementClusterConfig.Name,
+		RecordTTLs:                    settings.RecordTTLs,
 	}
 
 	dnsScope, err := azurescope.NewDNSScope(ctx, params)
@@ -353,6 +484,10 @@ func (r *ClusterReconciler) getDnsServiceForPublicRecords(ctx context.Context, l
 		return nil, reconcile.Result{}, microerror.Mask(err)
 	}
 
+	if r.DNSProvider != nil {
+		return dns.NewWithProvider(*dnsScope, clusterScope.PublicIPsService(), r.DNSProvider), ctrl.Result{}, nil
+	}
+
 	dnsService, err := dns.New(*dnsScope, clusterScope.PublicIPsService())
 	if err != nil {
 		return nil, reconcile.Result{}, microerror.Mask(err)
@@ -361,6 +496,74 @@ func (r *ClusterReconciler) getDnsServiceForPublicRecords(ctx context.Context, l
 	return dnsService, ctrl.Result{}, nil
 }
 
+// clusterZoneName returns the name of the public zone of the cluster.
+func (r *ClusterReconciler) clusterZoneName(clusterScope *infracluster.Scope) string {
+	return azurescope.ClusterZoneName(clusterScope.Cluster, clusterScope.AzureClusterSpec(), r.BaseDomain, r.settings().IntermediateZoneMode)
+}
+
+// pinClusterZone records the name of the cluster zone in the
+// AnnotationClusterZone annotation of the Cluster, so that later changes of
+// the intermediate zone configuration don't move the zone of existing
+// clusters. Clusters managed before the zone was recorded keep their zone
+// below the base zone.
+func (r *ClusterReconciler) pinClusterZone(ctx context.Context, clusterScope *infracluster.Scope, managed bool) error {
+	cluster := clusterScope.Cluster
+	if _, ok := cluster.GetAnnotations()[azurescope.AnnotationClusterZone]; ok {
+		return nil
+	}
+
+	zoneName := r.clusterZoneName(clusterScope)
+	if managed {
+		zoneName = fmt.Sprintf("%s.%s", cluster.Name, r.BaseDomain)
+	}
+
+	patch := client.MergeFrom(cluster.DeepCopy())
+	annotations := cluster.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[azurescope.AnnotationClusterZone] = zoneName
+	cluster.SetAnnotations(annotations)
+
+	if err := r.Client.Patch(ctx, cluster, patch); err != nil {
+		return microerror.Mask(err)
+	}
+	log.FromContext(ctx).Info("Recorded cluster zone", "zone", zoneName)
+
+	return nil
+}
+
+// getDnsServiceForCluster builds the public DNS service of cluster outside of
+// a reconciliation, e.g. for the zone export.
+func (r *ClusterReconciler) getDnsServiceForCluster(ctx context.Context, cluster *capi.Cluster) (*dns.Service, error) {
+	logger := log.FromContext(ctx)
+
+	infraCluster, err := external.GetObjectFromContractVersionedRef(ctx, r.Client, cluster.Spec.InfrastructureRef, cluster.Namespace)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	clusterScope, err := infracluster.NewScope(ctx, infracluster.ScopeParams{
+		Client:                  r.Client,
+		Cluster:                 cluster,
+		InfraCluster:            infraCluster,
+		ClusterZoneAzureConfig:  r.InfraClusterZoneAzureConfig,
+		ClusterIdentityRef:      r.ClusterAzureIdentityRef,
+		ManagementClusterConfig: r.ManagementClusterConfig,
+		Recorder:                r.Recorder,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	dnsService, _, err := r.getDnsServiceForPublicRecords(ctx, logger, clusterScope)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return dnsService, nil
+}
+
 func (r *ClusterReconciler) getPrivateDnsServiceForMcToWcApi(ctx context.Context, logger logr.Logger, clusterScope *infracluster.Scope) (*privatedns.Service, ctrl.Result, error) {
 	managementCluster, err := clusterScope.ManagementCluster(ctx)
 	if err != nil {
@@ -393,14 +596,19 @@ func (r *ClusterReconciler) getPrivateDnsServiceForMcToWcApi(ctx context.Context
 	infraClusterAnnotations := infraCluster.GetAnnotations()
 
 	privateParams := azurescope.PrivateDNSScopeParams{
-		BaseDomain:                             r.BaseDomain,
+		// the private zone mirrors the public cluster zone, which may be below
+		// an intermediate zone
+		BaseDomain:                             strings.TrimPrefix(r.clusterZoneName(clusterScope), clusterScope.Cluster.Name+"."),
 		ClusterName:                            infraCluster.GetName(),
 		ClusterSpecToAttachPrivateDNS:          managementCluster.Spec,
 		ClusterAzureIdentityToAttachPrivateDNS: *managementClusterAzureIdentity,
 		ClusterServicePrincipalSecretToAttachPrivateDNS: *managementClusterStaticServicePrincipalSecret,
 		VirtualNetworkIDToAttachPrivateDNS:              managementCluster.Spec.NetworkSpec.Vnet.ID,
 		APIServerIP:                                     infraClusterAnnotations[azurePrivateEndpointOperatorApiServerAnnotation],
+		ResourceTags:                                    clusterScope.AzureResourceTags(),
+		RecordTTLs:                                      r.settings().RecordTTLs,
 		WildcardCNAMETarget:                             clusterScope.Cluster.GetAnnotations()[azurescope.AnnotationWildcardCNAMETarget],
+		Events:                                          clusterScope,
 	}
 
 	privateDnsScope, err := azurescope.NewPrivateDNSScope(ctx, privateParams)
@@ -462,7 +670,10 @@ func (r *ClusterReconciler) getPrivateDnsServiceForWcToMcIngress(ctx context.Con
 		ClusterServicePrincipalSecretToAttachPrivateDNS: *infraClusterStaticServicePrincipalSecret,
 		VirtualNetworkIDToAttachPrivateDNS:              (*azureClusterSpec).NetworkSpec.Vnet.ID,
 		MCIngressIP:                                     infraClusterAnnotations[azurePrivateEndpointOperatorMcIngressAnnotation],
+		ResourceTags:                                    clusterScope.AzureResourceTags(),
+		RecordTTLs:                                      r.settings().RecordTTLs,
 		WildcardCNAMETarget:                             managementCAPICluster.GetAnnotations()[azurescope.AnnotationWildcardCNAMETarget],
+		Events:                                          clusterScope,
 	}
 
 	privateDnsScope, err := azurescope.NewPrivateDNSScope(ctx, privateParams)
@@ -492,30 +703,19 @@ func (r *ClusterReconciler) getInfraClusterStaticServicePrincipalSecret(ctx cont
 	return staticServicePrincipalSecret, nil
 }
 
-func isClusterReadyForDnsManagements(cluster *capi.Cluster, logger logr.Logger, clusterScope *infracluster.Scope) (ctrl.Result, error, bool) {
+func isClusterReadyForDnsManagements(cluster *capi.Cluster, logger logr.Logger, clusterScope *infracluster.Scope, requeueAfter time.Duration) (ctrl.Result, error, bool) {
 	// If a cluster isn't provisioned we don't need to reconcile it
 	// as not all information for creating DNS records are available yet.
 	if cluster.Status.Phase != string(capi.ClusterPhaseProvisioned) {
 		logger.Info(fmt.Sprintf("Requeuing cluster %s - phase %s", cluster.Name, cluster.Status.Phase))
-		return ctrl.Result{RequeueAfter: 2 * time.Minute}, nil, false
-	}
-
-	if clusterScope.IsAzureCluster() {
-		// only act on Clusters where the LoadBalancersReady condition is true
-		infraClusterStatus := clusterScope.AzureClusterStatus()
-		infraClusterIsReady := false
-		if infraClusterStatus != nil {
-			for _, condition := range infraClusterStatus.Conditions {
-				if condition.Type == infrav1.LoadBalancersReadyCondition {
-					infraClusterIsReady = true
-					break
-				}
-			}
-		}
-		if !infraClusterIsReady {
-			logger.Info(fmt.Sprintf("Requeuing cluster %s. Load Balancer is not ready.", cluster.Name))
-			return ctrl.Result{RequeueAfter: 2 * time.Minute}, nil, false
-		}
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil, false
+	}
+
+	// the infrastructure provider decides when the information needed for
+	// DNS records, e.g. the load balancers, is available
+	if ready, reason := clusterScope.IsInfraClusterReady(); !ready {
+		logger.Info(fmt.Sprintf("Requeuing cluster %s. %s", cluster.Name, reason))
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil, false
 	}
 
 	return ctrl.Result{}, nil, true
@@ -542,6 +742,10 @@ func deleteClusterMetrics(zoneName, zoneType string) int {
 		metrics.ZoneType:   zoneType,
 	})
 
+	deletedMetrics += metrics.ZonePropagationMismatches.DeletePartialMatch(prometheus.Labels{
+		metrics.MetricZone: zoneName,
+	})
+
 	return deletedMetrics
 
 }
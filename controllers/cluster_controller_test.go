@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	azurescope "github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+)
+
+func Test_pinClusterZone(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		managed     bool
+		expectZone  string
+	}{
+		{
+			name:       "case0: new clusters get a zone below their intermediate zone",
+			expectZone: "glippy.acme.base.io",
+		},
+		{
+			name:       "case1: clusters managed before keep their zone below the base zone",
+			managed:    true,
+			expectZone: "glippy.base.io",
+		},
+		{
+			name:        "case2: a recorded zone is kept",
+			annotations: map[string]string{azurescope.AnnotationClusterZone: "glippy.other.base.io"},
+			expectZone:  "glippy.other.base.io",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := capi.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+
+			cluster := &capi.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "glippy",
+					Namespace:   "org-acme",
+					Annotations: tc.annotations,
+				},
+			}
+			k8sClient := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+
+			r := &ClusterReconciler{
+				Client:     k8sClient,
+				BaseDomain: "base.io",
+				Settings: Settings{
+					IntermediateZoneMode: azurescope.IntermediateZoneModeOrganization,
+				},
+			}
+			clusterScope := &infracluster.Scope{
+				Cluster:      cluster,
+				InfraCluster: &unstructured.Unstructured{Object: map[string]interface{}{}},
+			}
+
+			if err := r.pinClusterZone(context.TODO(), clusterScope, tc.managed); err != nil {
+				t.Fatal(err)
+			}
+
+			stored := &capi.Cluster{}
+			if err := k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), stored); err != nil {
+				t.Fatal(err)
+			}
+			if zone := stored.GetAnnotations()[azurescope.AnnotationClusterZone]; zone != tc.expectZone {
+				t.Errorf("recorded zone = %q, want %q", zone, tc.expectZone)
+			}
+		})
+	}
+}
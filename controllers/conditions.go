@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1beta1 "sigs.k8s.io/cluster-api/api/core/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+)
+
+const (
+	// DNSReadyCondition is the condition on the Cluster summarizing the DNS
+	// conditions of the infra cluster. Unlike those it is also set for AKS
+	// clusters.
+	DNSReadyCondition = "DNSReady"
+
+	// reasonNotManaged is the reason of steps the cluster doesn't let the
+	// operator manage, see infracluster.AnnotationManagedRecords.
+	reasonNotManaged = "NotManaged"
+	// reasonNotRequired is the reason of private DNS steps of clusters
+	// without a private endpoint or split-horizon DNS.
+	reasonNotRequired = "NotRequired"
+
+	// maxConditionMismatches limits the propagation mismatches listed in a
+	// condition message.
+	maxConditionMismatches = 5
+)
+
+var notManagedMessage = fmt.Sprintf("The cluster limits DNS management with the %s annotation", infracluster.AnnotationManagedRecords)
+
+// dnsStep describes the condition of a part of the DNS reconciliation.
+type dnsStep struct {
+	conditionType clusterv1beta1.ConditionType
+	readyReason   string
+	readyMessage  string
+	failedReason  string
+}
+
+var (
+	zoneStep = dnsStep{
+		conditionType: "GSDNSZoneReady",
+		readyReason:   "GSDNSZonesCreated",
+		readyMessage:  "GiantSwarm DNS Zones have been created",
+		failedReason:  "ZoneReconciliationFailed",
+	}
+	nsDelegationStep = dnsStep{
+		conditionType: "GSDNSNSDelegationReady",
+		readyReason:   "NSDelegationCreated",
+		readyMessage:  "The cluster zone is delegated from the base zone",
+		failedReason:  "NSDelegationFailed",
+	}
+	apiRecordsStep = dnsStep{
+		conditionType: "GSDNSAPIRecordsReady",
+		readyReason:   "APIRecordsCreated",
+		readyMessage:  "The api and apiserver records are up to date",
+		failedReason:  "APIRecordsFailed",
+	}
+	ingressRecordsStep = dnsStep{
+		conditionType: "GSDNSIngressRecordsReady",
+		readyReason:   "IngressRecordsCreated",
+		readyMessage:  "The ingress and wildcard records are up to date",
+		failedReason:  "IngressRecordsFailed",
+	}
+	splitHorizonStep = dnsStep{
+		conditionType: "GSDNSSplitHorizonReady",
+		readyReason:   "SplitHorizonDNSCreated",
+		readyMessage:  "The private DNS zone holds the records with internal addresses, the public one only the others",
+		failedReason:  "SplitHorizonDNSFailed",
+	}
+	privateAPIDNSStep = dnsStep{
+		conditionType: "GSDNSPrivateAPIDNSReady",
+		readyReason:   "PrivateAPIDNSCreated",
+		readyMessage:  "The private DNS zone for the API of the cluster is up to date",
+		failedReason:  "PrivateAPIDNSFailed",
+	}
+	privateIngressDNSStep = dnsStep{
+		conditionType: "GSDNSPrivateIngressDNSReady",
+		readyReason:   "PrivateIngressDNSCreated",
+		readyMessage:  "The private DNS zone for the management cluster ingress is up to date",
+		failedReason:  "PrivateIngressDNSFailed",
+	}
+
+	// dnsSteps are the steps in the order their conditions are summarized
+	// in DNSReadyCondition.
+	dnsSteps = []dnsStep{
+		zoneStep,
+		nsDelegationStep,
+		apiRecordsStep,
+		ingressRecordsStep,
+		splitHorizonStep,
+		privateAPIDNSStep,
+		privateIngressDNSStep,
+	}
+	// publicDNSSteps maps the steps of the public DNS service to theirs.
+	publicDNSSteps = map[dns.Step]dnsStep{
+		dns.StepZone:           zoneStep,
+		dns.StepNSDelegation:   nsDelegationStep,
+		dns.StepAPIRecords:     apiRecordsStep,
+		dns.StepIngressRecords: ingressRecordsStep,
+		dns.StepSplitHorizon:   splitHorizonStep,
+	}
+)
+
+// condition returns the condition of the step after it was reconciled with
+// err.
+func (s dnsStep) condition(err error) clusterv1beta1.Condition {
+	if err == nil {
+		return clusterv1beta1.Condition{
+			Type:    s.conditionType,
+			Status:  corev1.ConditionTrue,
+			Reason:  s.readyReason,
+			Message: s.readyMessage,
+		}
+	}
+
+	reason := s.failedReason
+	severity := clusterv1beta1.ConditionSeverityError
+	switch {
+	case dns.IsAPIServerHostnameNotResolvable(err):
+		reason = "APIServerHostnameNotResolvable"
+	case dns.IsSplitHorizonNotSupported(err):
+		reason = "SplitHorizonNotSupported"
+	case dns.IsIntermediateZoneConflict(err):
+		reason = "IntermediateZoneConflict"
+	case dns.IsIngressNotReady(err):
+		// the ingress load balancer usually just didn't get its IP yet
+		reason = "IngressNotReady"
+		severity = clusterv1beta1.ConditionSeverityInfo
+	}
+
+	return clusterv1beta1.Condition{
+		Type:     s.conditionType,
+		Status:   corev1.ConditionFalse,
+		Severity: severity,
+		Reason:   reason,
+		Message:  err.Error(),
+	}
+}
+
+// skipped returns the condition of the step when it isn't reconciled for
+// reason, e.g. reasonNotManaged.
+func (s dnsStep) skipped(reason, message string) clusterv1beta1.Condition {
+	return clusterv1beta1.Condition{
+		Type:    s.conditionType,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// propagatedCondition reports whether the authoritative name servers answer
+// with the records in Azure, see dns.Service.CheckPropagation. It isn't part
+// of DNSReadyCondition, as changes take a while to reach all name servers.
+func propagatedCondition(report dns.PropagationReport, err error) clusterv1beta1.Condition {
+	switch {
+	case err != nil:
+		return clusterv1beta1.Condition{
+			Type:     "GSDNSPropagated",
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1beta1.ConditionSeverityWarning,
+			Reason:   "PropagationCheckFailed",
+			Message:  err.Error(),
+		}
+	case !report.Propagated():
+		mismatches := report.Mismatches
+		if len(mismatches) > maxConditionMismatches {
+			mismatches = append(mismatches[:maxConditionMismatches:maxConditionMismatches], fmt.Sprintf("%d more", len(report.Mismatches)-maxConditionMismatches))
+		}
+		return clusterv1beta1.Condition{
+			Type:     "GSDNSPropagated",
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1beta1.ConditionSeverityWarning,
+			Reason:   "PropagationMismatch",
+			Message:  fmt.Sprintf("%d of %d answers of the name servers differ from the records in Azure: %s", len(report.Mismatches), report.Checked, strings.Join(mismatches, "; ")),
+		}
+	}
+	return clusterv1beta1.Condition{
+		Type:    "GSDNSPropagated",
+		Status:  corev1.ConditionTrue,
+		Reason:  "RecordsPropagated",
+		Message: fmt.Sprintf("All %d answers of the name servers match the records in Azure", report.Checked),
+	}
+}
+
+// recordsAdoptedCondition reports whether all records the operator wants to
+// write in the cluster zone are owned by the cluster. conflicts are the FQDNs
+// of existing records that point somewhere else and were not taken over.
+func recordsAdoptedCondition(conflicts []string) clusterv1beta1.Condition {
+	if len(conflicts) == 0 {
+		return clusterv1beta1.Condition{
+			Type:    "GSDNSRecordsAdopted",
+			Status:  corev1.ConditionTrue,
+			Reason:  "RecordsAdopted",
+			Message: "All DNS records are owned by the cluster",
+		}
+	}
+	return clusterv1beta1.Condition{
+		Type:     "GSDNSRecordsAdopted",
+		Status:   corev1.ConditionFalse,
+		Severity: clusterv1beta1.ConditionSeverityWarning,
+		Reason:   "RecordConflicts",
+		Message:  fmt.Sprintf("Existing DNS records conflict with the desired records and were left alone: %s", strings.Join(conflicts, ", ")),
+	}
+}
+
+// dnsConditions collects the infra cluster conditions of a reconciliation.
+// Steps that weren't reached have no condition, so their previous one is kept.
+type dnsConditions []clusterv1beta1.Condition
+
+// set adds condition, replacing a condition of the same type.
+func (c *dnsConditions) set(condition clusterv1beta1.Condition) {
+	for i := range *c {
+		if (*c)[i].Type == condition.Type {
+			(*c)[i] = condition
+			return
+		}
+	}
+	*c = append(*c, condition)
+}
+
+// get returns the condition of conditionType, nil if there is none.
+func (c dnsConditions) get(conditionType clusterv1beta1.ConditionType) *clusterv1beta1.Condition {
+	for i := range c {
+		if c[i].Type == conditionType {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// setPublicDNSSteps adds the conditions of the steps the public DNS service
+// reconciled. Records are reported as not managed if the cluster limits DNS
+// management to the zone, and split-horizon DNS as not required for clusters
+// that publish all records publicly.
+func (c *dnsConditions) setPublicDNSSteps(dnsService *dns.Service, managesRecords bool) {
+	for _, step := range []dns.Step{dns.StepZone, dns.StepNSDelegation, dns.StepAPIRecords, dns.StepIngressRecords, dns.StepSplitHorizon} {
+		reconciled, err := dnsService.StepResult(step)
+		switch {
+		case !managesRecords && (step == dns.StepAPIRecords || step == dns.StepIngressRecords || step == dns.StepSplitHorizon):
+			c.set(publicDNSSteps[step].skipped(reasonNotManaged, notManagedMessage))
+		case step == dns.StepSplitHorizon && reconciled && err == nil && !dnsService.IsSplitHorizon():
+			c.set(publicDNSSteps[step].skipped(reasonNotRequired, "The cluster publishes all records in the public zone"))
+		case reconciled:
+			c.set(publicDNSSteps[step].condition(err))
+		}
+	}
+}
+
+// dnsReadyCondition summarizes conditions and err, the error the
+// reconciliation failed with, as DNSReadyCondition. The first failed step
+// wins, errors outside of the steps are reported as ReconciliationFailed.
+func dnsReadyCondition(conditions dnsConditions, err error) metav1.Condition {
+	for _, step := range dnsSteps {
+		condition := conditions.get(step.conditionType)
+		if condition != nil && condition.Status == corev1.ConditionFalse {
+			return metav1.Condition{
+				Type:    DNSReadyCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  condition.Reason,
+				Message: fmt.Sprintf("%s: %s", step.conditionType, condition.Message),
+			}
+		}
+	}
+
+	if err != nil {
+		return metav1.Condition{
+			Type:    DNSReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ReconciliationFailed",
+			Message: err.Error(),
+		}
+	}
+
+	return metav1.Condition{
+		Type:    DNSReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "DNSReady",
+		Message: "The DNS zone, its delegation and records are up to date",
+	}
+}
+
+// setDNSConditions writes conditions to the infra cluster and their summary
+// to the Cluster. AKS (AzureASOManagedCluster) infra clusters have their
+// status managed by CAPZ/ASO and their schema has no conditions field, so they
+// only get the summary.
+func (r *ClusterReconciler) setDNSConditions(ctx context.Context, clusterScope *infracluster.Scope, infraConditions dnsConditions, dnsReady metav1.Condition) error {
+	if err := setInfraClusterConditions(ctx, clusterScope, infraConditions); err != nil {
+		return microerror.Mask(err)
+	}
+
+	return r.setClusterCondition(ctx, clusterScope.Cluster, dnsReady)
+}
+
+// setInfraClusterConditions writes infraConditions to the status of the infra
+// cluster. AKS clusters have no infra cluster conditions.
+func setInfraClusterConditions(ctx context.Context, clusterScope *infracluster.Scope, infraConditions dnsConditions) error {
+	if clusterScope.IsASOManagedCluster() || len(infraConditions) == 0 {
+		return nil
+	}
+
+	for _, condition := range infraConditions {
+		if err := infracluster.SetUnstructuredCondition(clusterScope.InfraCluster, condition); err != nil {
+			return microerror.Mask(err)
+		}
+	}
+	if err := clusterScope.Client.Status().Update(ctx, clusterScope.InfraCluster); err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// resourceGroupOwnedCondition reports whether the resource group of a
+// non-Azure cluster was created by the operator, see
+// dns.Service.ResourceGroupOwned.
+func resourceGroupOwnedCondition(err error) clusterv1beta1.Condition {
+	if err != nil {
+		return clusterv1beta1.Condition{
+			Type:     "GSDNSResourceGroupOwned",
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1beta1.ConditionSeverityWarning,
+			Reason:   "ResourceGroupNotOwned",
+			Message:  err.Error(),
+		}
+	}
+	return clusterv1beta1.Condition{
+		Type:    "GSDNSResourceGroupOwned",
+		Status:  corev1.ConditionTrue,
+		Reason:  "ResourceGroupOwned",
+		Message: "The resource group was created by dns-operator-azure for the cluster",
+	}
+}
+
+// resourceGroupKeptCondition reports that the resource group of a deleted
+// non-Azure cluster was kept for err, see dns.Service.ResourceGroupKept.
+func resourceGroupKeptCondition(err error) clusterv1beta1.Condition {
+	return clusterv1beta1.Condition{
+		Type:     "GSDNSResourceGroupDeleted",
+		Status:   corev1.ConditionFalse,
+		Severity: clusterv1beta1.ConditionSeverityWarning,
+		Reason:   "ResourceGroupKept",
+		Message:  fmt.Sprintf("Only the DNS zone was deleted: %s", err),
+	}
+}
+
+// setClusterCondition patches condition into the status of cluster.
+func (r *ClusterReconciler) setClusterCondition(ctx context.Context, cluster *capi.Cluster, condition metav1.Condition) error {
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	conditions.Set(cluster, condition)
+
+	err = patchHelper.Patch(ctx, cluster, patch.WithOwnedConditions{Conditions: []string{condition.Type}})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
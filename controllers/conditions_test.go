@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1beta1 "sigs.k8s.io/cluster-api/api/core/v1beta1"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
+)
+
+func Test_dnsReadyCondition(t *testing.T) {
+	zoneFailed := errors.New("zone quota exceeded")
+	reconcileFailed := errors.New("identity secret not found")
+
+	testCases := []struct {
+		name          string
+		conditions    dnsConditions
+		err           error
+		expectStatus  metav1.ConditionStatus
+		expectReason  string
+		expectMessage string
+	}{
+		{
+			name: "case0: all steps ready",
+			conditions: dnsConditions{
+				zoneStep.condition(nil),
+				nsDelegationStep.condition(nil),
+				apiRecordsStep.skipped(reasonNotManaged, notManagedMessage),
+				privateAPIDNSStep.skipped(reasonNotRequired, "The cluster has no private API endpoint"),
+			},
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  "DNSReady",
+			expectMessage: "The DNS zone, its delegation and records are up to date",
+		},
+		{
+			name: "case1: failed step wins over the reconciliation error",
+			conditions: dnsConditions{
+				privateAPIDNSStep.condition(nil),
+				zoneStep.condition(zoneFailed),
+			},
+			err:           zoneFailed,
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  "ZoneReconciliationFailed",
+			expectMessage: "GSDNSZoneReady: zone quota exceeded",
+		},
+		{
+			name: "case2: steps are summarized in order",
+			conditions: dnsConditions{
+				privateIngressDNSStep.condition(errors.New("vnet link failed")),
+				ingressRecordsStep.condition(errors.New("record write failed")),
+			},
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  "IngressRecordsFailed",
+			expectMessage: "GSDNSIngressRecordsReady: record write failed",
+		},
+		{
+			name: "case3: error outside of the steps",
+			conditions: dnsConditions{
+				privateAPIDNSStep.condition(nil),
+			},
+			err:           reconcileFailed,
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  "ReconciliationFailed",
+			expectMessage: "identity secret not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := dnsReadyCondition(tc.conditions, tc.err)
+			if condition.Type != DNSReadyCondition {
+				t.Errorf("condition type = %q, want %q", condition.Type, DNSReadyCondition)
+			}
+			if condition.Status != tc.expectStatus || condition.Reason != tc.expectReason || condition.Message != tc.expectMessage {
+				t.Errorf("condition = %s/%s/%q, want %s/%s/%q", condition.Status, condition.Reason, condition.Message, tc.expectStatus, tc.expectReason, tc.expectMessage)
+			}
+		})
+	}
+}
+
+func Test_dnsConditions_set(t *testing.T) {
+	var conditions dnsConditions
+	conditions.set(zoneStep.condition(errors.New("zone quota exceeded")))
+	conditions.set(nsDelegationStep.condition(nil))
+	conditions.set(zoneStep.condition(nil))
+
+	if len(conditions) != 2 {
+		t.Fatalf("got %d conditions, want 2", len(conditions))
+	}
+	zone := conditions.get(zoneStep.conditionType)
+	if zone == nil || zone.Status != corev1.ConditionTrue || zone.Reason != "GSDNSZonesCreated" {
+		t.Errorf("zone condition = %+v, want it replaced by the ready condition", zone)
+	}
+	if severity := nsDelegationStep.condition(errors.New("forbidden")).Severity; severity != clusterv1beta1.ConditionSeverityError {
+		t.Errorf("failed step severity = %q, want %q", severity, clusterv1beta1.ConditionSeverityError)
+	}
+}
+
+func Test_propagatedCondition(t *testing.T) {
+	mismatches := []string{
+		"api.glippy.example.com A at ns1-01.azure-dns.com: got none, want 1.2.3.4",
+		"api.glippy.example.com A at ns2-01.azure-dns.net: got none, want 1.2.3.4",
+		"api.glippy.example.com A at ns3-01.azure-dns.org: got none, want 1.2.3.4",
+		"api.glippy.example.com A at ns4-01.azure-dns.info: got none, want 1.2.3.4",
+		"*.glippy.example.com CNAME at ns1-01.azure-dns.com: got none, want ingress.glippy.example.com",
+		"*.glippy.example.com CNAME at ns2-01.azure-dns.net: got none, want ingress.glippy.example.com",
+	}
+
+	testCases := []struct {
+		name          string
+		report        dns.PropagationReport
+		err           error
+		expectStatus  corev1.ConditionStatus
+		expectReason  string
+		expectMessage string
+	}{
+		{
+			name:          "case0: all answers match",
+			report:        dns.PropagationReport{Checked: 8},
+			expectStatus:  corev1.ConditionTrue,
+			expectReason:  "RecordsPropagated",
+			expectMessage: "All 8 answers of the name servers match the records in Azure",
+		},
+		{
+			name:          "case1: mismatch",
+			report:        dns.PropagationReport{Checked: 8, Mismatches: mismatches[:1]},
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "PropagationMismatch",
+			expectMessage: "1 of 8 answers of the name servers differ from the records in Azure: " + mismatches[0],
+		},
+		{
+			name:          "case2: too many mismatches are cut",
+			report:        dns.PropagationReport{Checked: 8, Mismatches: mismatches},
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "PropagationMismatch",
+			expectMessage: "6 of 8 answers of the name servers differ from the records in Azure: " + strings.Join(mismatches[:5], "; ") + "; 1 more",
+		},
+		{
+			name:          "case3: check failed",
+			err:           errors.New("cluster zone not found"),
+			expectStatus:  corev1.ConditionFalse,
+			expectReason:  "PropagationCheckFailed",
+			expectMessage: "cluster zone not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := propagatedCondition(tc.report, tc.err)
+			if condition.Status != tc.expectStatus {
+				t.Errorf("status = %q, want %q", condition.Status, tc.expectStatus)
+			}
+			if condition.Reason != tc.expectReason {
+				t.Errorf("reason = %q, want %q", condition.Reason, tc.expectReason)
+			}
+			if condition.Message != tc.expectMessage {
+				t.Errorf("message = %q, want %q", condition.Message, tc.expectMessage)
+			}
+		})
+	}
+}
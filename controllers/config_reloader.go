@@ -0,0 +1,121 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/config"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+// ConfigReloader reports the configuration the operator was started with and
+// loads it again every Interval. The records and requeue settings of a valid
+// configuration are applied to the Reconciler right away, changes of the
+// other fields are logged and only apply after a restart. The configuration
+// is reported by the dns_operator_azure_config_info metric, failed reloads
+// by dns_operator_azure_config_reload_failed.
+type ConfigReloader struct {
+	Loader     config.Loader
+	Reconciler *ClusterReconciler
+	// Config is the configuration the operator was started with.
+	Config *config.Config
+	// Interval between two reloads. The configuration is not reloaded if
+	// zero or if Loader has no configuration file.
+	Interval time.Duration
+
+	// checksum is the checksum of the configuration applied last.
+	checksum string
+}
+
+var _ manager.Runnable = (*ConfigReloader)(nil)
+var _ manager.LeaderElectionRunnable = (*ConfigReloader)(nil)
+
+func (c *ConfigReloader) SetupWithManager(mgr manager.Manager) error {
+	return mgr.Add(c)
+}
+
+// NeedLeaderElection returns false, the settings of replicas waiting for the
+// leader election are kept up to date as well.
+func (c *ConfigReloader) NeedLeaderElection() bool {
+	return false
+}
+
+// Start reloads the configuration every Interval until ctx is done.
+func (c *ConfigReloader) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("config-reloader")
+	ctx = log.IntoContext(ctx, logger)
+
+	c.checksum = c.Config.Checksum()
+	reportConfig(c.Config, false)
+	metrics.ConfigReloadFailed.Set(0)
+
+	if c.Interval == 0 || c.Loader.Path == "" {
+		return nil
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.reload(ctx)
+		}
+	}
+}
+
+func (c *ConfigReloader) reload(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	cfg, err := c.Loader.Load()
+	if err != nil {
+		logger.Error(err, "Failed to reload configuration, keeping the current settings", "path", c.Loader.Path)
+		// dns_operator_azure_config_reload_failed{controller="dns-operator-azure"}
+		metrics.ConfigReloadFailed.Set(1)
+		return
+	}
+	metrics.ConfigReloadFailed.Set(0)
+
+	checksum := cfg.Checksum()
+	if checksum == c.checksum {
+		return
+	}
+	c.checksum = checksum
+
+	c.Reconciler.UpdateSettings(SettingsFromConfig(cfg))
+	logger.Info("Reloaded configuration", "path", c.Loader.Path, "checksum", checksum)
+
+	changes := config.StaticChanges(c.Config, cfg)
+	if len(changes) > 0 {
+		logger.Info("Configuration changes only apply after a restart", "fields", changes)
+	}
+	reportConfig(cfg, len(changes) > 0)
+}
+
+// reportConfig replaces the dns_operator_azure_config_info metric with the
+// one of cfg.
+func reportConfig(cfg *config.Config, restartRequired bool) {
+	metrics.ConfigInfo.Reset()
+	// dns_operator_azure_config_info{api_version="dns-operator-azure.giantswarm.io/v1alpha1",checksum="...",controller="dns-operator-azure",restart_required="false"}
+	metrics.ConfigInfo.WithLabelValues(cfg.APIVersion, cfg.Checksum(), strconv.FormatBool(restartRequired)).Set(1)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/config"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+const reloaderConfigFile = `apiVersion: dns-operator-azure.giantswarm.io/v1alpha1
+kind: OperatorConfig
+baseDomain: base.io
+baseDomainResourceGroup: dns_rg
+azure:
+  baseZone:
+    subscriptionID: subscription
+    tenantID: tenant
+    clientID: client
+records:
+  ttl:
+    api: 60
+`
+
+func Test_ConfigReloader_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(reloaderConfigFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := config.Loader{Path: path}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconciler := &ClusterReconciler{Settings: SettingsFromConfig(cfg)}
+	reloader := &ConfigReloader{Loader: loader, Reconciler: reconciler, Config: cfg, checksum: cfg.Checksum()}
+
+	testCases := []struct {
+		name           string
+		file           string
+		expectAPITTL   int64
+		expectFailed   float64
+		expectRestart  string
+		expectZoneMode string
+	}{
+		{
+			name:           "case0: reloadable settings apply right away",
+			file:           strings.Replace(reloaderConfigFile, "api: 60", "api: 30", 1),
+			expectAPITTL:   30,
+			expectRestart:  "false",
+			expectZoneMode: "none",
+		},
+		{
+			name:           "case1: an invalid file keeps the current settings",
+			file:           strings.Replace(reloaderConfigFile, "api: 60", "api: -1", 1),
+			expectAPITTL:   30,
+			expectFailed:   1,
+			expectRestart:  "false",
+			expectZoneMode: "none",
+		},
+		{
+			name:           "case2: static changes are reported as needing a restart",
+			file:           strings.Replace(reloaderConfigFile, "api: 60", "api: 30\n  intermediateZoneMode: region\nmanagementLocks: true", 1),
+			expectAPITTL:   30,
+			expectRestart:  "true",
+			expectZoneMode: "region",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(tc.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			reloader.reload(context.TODO())
+
+			settings := reconciler.settings()
+			if settings.RecordTTLs.API != tc.expectAPITTL {
+				t.Errorf("api record TTL = %d, want %d", settings.RecordTTLs.API, tc.expectAPITTL)
+			}
+			if settings.IntermediateZoneMode != tc.expectZoneMode {
+				t.Errorf("intermediate zone mode = %q, want %q", settings.IntermediateZoneMode, tc.expectZoneMode)
+			}
+			if failed := testutil.ToFloat64(metrics.ConfigReloadFailed); failed != tc.expectFailed {
+				t.Errorf("reload failed = %v, want %v", failed, tc.expectFailed)
+			}
+			if tc.expectFailed == 0 {
+				info := metrics.ConfigInfo.WithLabelValues(config.APIVersion, reloader.checksum, tc.expectRestart)
+				if value := testutil.ToFloat64(info); value != 1 {
+					t.Errorf("config info with restart_required=%s = %v, want 1", tc.expectRestart, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+type orphanSweeper interface {
+	FindOrphans(ctx context.Context, clusters []capi.Cluster) ([]dns.Orphan, error)
+	DeleteOrphan(ctx context.Context, orphan dns.Orphan) error
+}
+
+// OrphanSweeper periodically looks for NS delegations, zones and resource
+// groups of clusters that no longer exist. Orphans are reported as metrics
+// and events, and deleted once they have been orphaned for GracePeriod.
+type OrphanSweeper struct {
+	// Reader lists the Clusters of all namespaces. It must not be restricted
+	// to the shard, otherwise clusters of other shards look orphaned.
+	Reader   client.Reader
+	Sweeper  orphanSweeper
+	Recorder record.EventRecorder
+	// EventObject is the object events about orphans are emitted on, usually
+	// the management cluster's Cluster.
+	EventObject *corev1.ObjectReference
+
+	Interval time.Duration
+	// GracePeriod orphans must be seen for before they are deleted. Orphans
+	// are never deleted if zero.
+	GracePeriod time.Duration
+
+	// firstSeen is keyed by Orphan.Key. It lives in memory only, so the
+	// grace period restarts with the operator.
+	firstSeen map[string]time.Time
+	now       func() time.Time
+}
+
+var _ manager.Runnable = (*OrphanSweeper)(nil)
+
+func (s *OrphanSweeper) SetupWithManager(mgr manager.Manager) error {
+	return mgr.Add(s)
+}
+
+// Start runs a sweep every Interval until ctx is done. As a manager.Runnable
+// it only runs on the leader.
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("orphan-sweeper")
+	ctx = log.IntoContext(ctx, logger)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sweep(ctx); err != nil {
+			logger.Error(err, "failed to sweep orphaned DNS resources")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *OrphanSweeper) sweep(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	if s.firstSeen == nil {
+		s.firstSeen = map[string]time.Time{}
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	var clusters capi.ClusterList
+	if err := s.Reader.List(ctx, &clusters); err != nil {
+		return microerror.Mask(err)
+	}
+
+	orphans, err := s.Sweeper.FindOrphans(ctx, clusters.Items)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	metrics.OrphanInfo.Reset()
+
+	seen := map[string]time.Time{}
+	for _, orphan := range orphans {
+		firstSeen, ok := s.firstSeen[orphan.Key()]
+		if !ok {
+			firstSeen = s.now()
+			logger.Info("Found orphaned DNS resource", "kind", orphan.Kind, "name", orphan.Name, "resourceGroup", orphan.ResourceGroup, "owner", orphan.Owner)
+			s.event(corev1.EventTypeWarning, "DNSOrphanFound", "Found orphaned %s %s in resource group %s of subscription %s, owner %q", orphan.Kind, orphan.Name, orphan.ResourceGroup, orphan.SubscriptionID, orphan.Owner)
+		}
+
+		if s.GracePeriod > 0 && orphan.Owned() && s.now().Sub(firstSeen) >= s.GracePeriod {
+			if err := s.Sweeper.DeleteOrphan(ctx, orphan); err != nil {
+				logger.Error(err, "failed to delete orphaned DNS resource", "kind", orphan.Kind, "name", orphan.Name)
+			} else {
+				logger.Info("Deleted orphaned DNS resource", "kind", orphan.Kind, "name", orphan.Name, "resourceGroup", orphan.ResourceGroup, "owner", orphan.Owner)
+				s.event(corev1.EventTypeNormal, "DNSOrphanDeleted", "Deleted orphaned %s %s in resource group %s of subscription %s, owner %q", orphan.Kind, orphan.Name, orphan.ResourceGroup, orphan.SubscriptionID, orphan.Owner)
+				continue
+			}
+		}
+
+		seen[orphan.Key()] = firstSeen
+
+		// dns_operator_azure_orphan_info{controller="dns-operator-azure",kind="delegation",name="glippy",owned="true",owner="org-giantswarm/glippy",resource_group="root_dns_zone_rg",subscription_id="6b1f6e4a-6d0e-4aa4-9a5a-fbaca65a23b3"} 1
+		metrics.OrphanInfo.WithLabelValues(
+			orphan.Kind,
+			orphan.Name,
+			orphan.ResourceGroup,
+			orphan.SubscriptionID,
+			orphan.Owner,
+			strconv.FormatBool(orphan.Owned()),
+		).Set(1)
+	}
+
+	// forget orphans that are gone, e.g. because they were deleted by hand
+	s.firstSeen = seen
+
+	return nil
+}
+
+func (s *OrphanSweeper) event(eventType, reason, messageFmt string, args ...interface{}) {
+	if s.Recorder == nil || s.EventObject == nil {
+		return
+	}
+	s.Recorder.Eventf(s.EventObject, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
+)
+
+type fakeOrphanSweeper struct {
+	orphans []dns.Orphan
+	deleted []string
+}
+
+func (f *fakeOrphanSweeper) FindOrphans(context.Context, []capi.Cluster) ([]dns.Orphan, error) {
+	return f.orphans, nil
+}
+
+func (f *fakeOrphanSweeper) DeleteOrphan(_ context.Context, orphan dns.Orphan) error {
+	f.deleted = append(f.deleted, orphan.Name)
+	return nil
+}
+
+func Test_OrphanSweeper(t *testing.T) {
+	owned := dns.Orphan{Kind: dns.OrphanKindDelegation, Name: "owned", Owner: "org-a/owned"}
+	legacy := dns.Orphan{Kind: dns.OrphanKindDelegation, Name: "legacy"}
+
+	testCases := []struct {
+		name          string
+		gracePeriod   time.Duration
+		elapsed       time.Duration
+		expectDeleted []string
+	}{
+		{
+			name:    "case0: orphans are only reported without grace period",
+			elapsed: 24 * time.Hour,
+		},
+		{
+			name:        "case1: orphans are kept during the grace period",
+			gracePeriod: time.Hour,
+			elapsed:     30 * time.Minute,
+		},
+		{
+			name:          "case2: owned orphans are deleted after the grace period",
+			gracePeriod:   time.Hour,
+			elapsed:       time.Hour,
+			expectDeleted: []string{"owned"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := capi.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			sweeper := &fakeOrphanSweeper{orphans: []dns.Orphan{owned, legacy}}
+			s := &OrphanSweeper{
+				Reader:      fakeclient.NewClientBuilder().WithScheme(scheme).Build(),
+				Sweeper:     sweeper,
+				GracePeriod: tc.gracePeriod,
+				now:         func() time.Time { return now },
+			}
+
+			if err := s.sweep(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			if len(sweeper.deleted) != 0 && tc.gracePeriod > 0 {
+				t.Fatalf("expected no deletion on first sight, got %v", sweeper.deleted)
+			}
+
+			now = now.Add(tc.elapsed)
+			if err := s.sweep(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sweeper.deleted, tc.expectDeleted) {
+				t.Errorf("deleted = %v, want %v", sweeper.deleted, tc.expectDeleted)
+			}
+
+			// deleted orphans are forgotten, remaining ones keep their first sighting
+			for _, orphan := range []dns.Orphan{owned, legacy} {
+				_, tracked := s.firstSeen[orphan.Key()]
+				deleted := len(tc.expectDeleted) > 0 && orphan.Owned()
+				if tracked == deleted {
+					t.Errorf("orphan %s tracked = %t, want %t", orphan.Name, tracked, !deleted)
+				}
+			}
+		})
+	}
+}
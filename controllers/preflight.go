@@ -0,0 +1,191 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/metrics"
+)
+
+// preflightCheckTimeout bounds a single check, so that a hanging Azure API
+// call doesn't keep the result from being updated.
+const preflightCheckTimeout = time.Minute
+
+// PreflightCheck is a check of something every reconciliation depends on,
+// e.g. access to the base zone.
+type PreflightCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// LeaderOnly checks only run on the elected leader, e.g. checks writing
+	// to the base zone, which replicas running them at the same time would
+	// interfere with.
+	LeaderOnly bool
+}
+
+// Preflight runs its checks at startup and then every Interval, and serves
+// the result as readiness check. Failed checks are logged and reported by the
+// dns_operator_azure_preflight_check_failed metric.
+type Preflight struct {
+	Checks []PreflightCheck
+	// Interval between two runs of the checks. The checks only run at
+	// startup if zero.
+	Interval time.Duration
+	// Elected is closed once the replica is elected leader, see
+	// manager.Manager.Elected. LeaderOnly checks never run if nil.
+	Elected <-chan struct{}
+
+	mu sync.RWMutex
+	// failures are keyed by check name. It is nil until the checks ran once.
+	failures map[string]error
+}
+
+var _ manager.Runnable = (*Preflight)(nil)
+var _ manager.LeaderElectionRunnable = (*Preflight)(nil)
+
+func (p *Preflight) SetupWithManager(mgr manager.Manager) error {
+	p.Elected = mgr.Elected()
+	if err := mgr.Add(p); err != nil {
+		return err
+	}
+	return mgr.AddReadyzCheck("preflight", p.ReadyzCheck)
+}
+
+// NeedLeaderElection returns false, replicas waiting for the leader election
+// report their readiness as well. Only LeaderOnly checks wait for the
+// election.
+func (p *Preflight) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the checks every Interval until ctx is done, and once more as
+// soon as the replica is elected leader.
+func (p *Preflight) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("preflight")
+	ctx = log.IntoContext(ctx, logger)
+
+	elected := p.Elected
+	p.run(ctx)
+	if p.Interval == 0 && elected == nil {
+		return nil
+	}
+
+	var tick <-chan time.Time
+	if p.Interval > 0 {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-elected:
+			// a closed channel is always ready, it is only waited for once
+			elected = nil
+			p.run(ctx)
+			if tick == nil {
+				return nil
+			}
+		case <-tick:
+			p.run(ctx)
+		}
+	}
+}
+
+// isLeader reports whether the replica was elected leader.
+func (p *Preflight) isLeader() bool {
+	if p.Elected == nil {
+		return false
+	}
+	select {
+	case <-p.Elected:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Preflight) run(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	failures := map[string]error{}
+	leader := p.isLeader()
+	for _, check := range p.Checks {
+		if check.LeaderOnly && !leader {
+			continue
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, preflightCheckTimeout)
+		err := check.Check(checkCtx)
+		cancel()
+
+		if err != nil {
+			logger.Error(err, "Preflight check failed, the operator is not ready", "check", check.Name)
+			// dns_operator_azure_preflight_check_failed{check="base-zone",controller="dns-operator-azure"}
+			metrics.PreflightCheckFailed.WithLabelValues(check.Name).Set(1)
+			failures[check.Name] = err
+			continue
+		}
+		if p.failed(check.Name) {
+			logger.Info("Preflight check succeeded again", "check", check.Name)
+		}
+		metrics.PreflightCheckFailed.WithLabelValues(check.Name).Set(0)
+	}
+
+	p.mu.Lock()
+	p.failures = failures
+	p.mu.Unlock()
+}
+
+// failed reports whether the check called name failed in the last run.
+func (p *Preflight) failed(name string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, failed := p.failures[name]
+	return failed
+}
+
+// ReadyzCheck fails until the checks ran once, and as long as one of them
+// failed in the last run.
+func (p *Preflight) ReadyzCheck(_ *http.Request) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.failures == nil {
+		return errors.New("preflight checks haven't run yet")
+	}
+
+	var failures []string
+	for _, check := range p.Checks {
+		if err, failed := p.failures[check.Name]; failed {
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("preflight checks failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
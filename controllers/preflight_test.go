@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_Preflight(t *testing.T) {
+	var baseZoneErr error
+	preflight := &Preflight{
+		Checks: []PreflightCheck{
+			{Name: "base-zone", Check: func(context.Context) error { return baseZoneErr }},
+			{Name: "management-cluster", Check: func(context.Context) error { return nil }},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		run         bool
+		baseZoneErr error
+		expectErr   string
+	}{
+		{
+			name:      "case0: not ready before the first run",
+			expectErr: "preflight checks haven't run yet",
+		},
+		{
+			name:        "case1: failed check",
+			run:         true,
+			baseZoneErr: errors.New("base zone example.com doesn't exist in resource group dns_rg"),
+			expectErr:   "preflight checks failed: base-zone: base zone example.com doesn't exist in resource group dns_rg",
+		},
+		{
+			name: "case2: ready once the check succeeds again",
+			run:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			baseZoneErr = tc.baseZoneErr
+			if tc.run {
+				preflight.run(context.TODO())
+			}
+
+			err := preflight.ReadyzCheck(nil)
+			switch {
+			case tc.expectErr == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tc.expectErr != "" && (err == nil || err.Error() != tc.expectErr):
+				t.Errorf("ReadyzCheck() = %v, want %q", err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func Test_Preflight_leaderOnly(t *testing.T) {
+	var writes int
+	elected := make(chan struct{})
+	preflight := &Preflight{
+		Checks: []PreflightCheck{
+			{Name: "base-zone", Check: func(context.Context) error { return nil }},
+			{Name: "base-zone-write", LeaderOnly: true, Check: func(context.Context) error {
+				writes++
+				return errors.New("AuthorizationFailed")
+			}},
+		},
+		Elected: elected,
+	}
+
+	// replicas waiting for the election only run the other checks
+	preflight.run(context.TODO())
+	if writes != 0 {
+		t.Errorf("leader only check ran %d times before the election", writes)
+	}
+	if err := preflight.ReadyzCheck(nil); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	close(elected)
+	preflight.run(context.TODO())
+	if writes != 1 {
+		t.Errorf("leader only check ran %d times after the election, want once", writes)
+	}
+	expectErr := "preflight checks failed: base-zone-write: AuthorizationFailed"
+	if err := preflight.ReadyzCheck(nil); err == nil || err.Error() != expectErr {
+		t.Errorf("ReadyzCheck() = %v, want %q", err, expectErr)
+	}
+}
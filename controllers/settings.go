@@ -0,0 +1,123 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	azurescope "github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/config"
+)
+
+const (
+	// DefaultRequeueInfrastructureNotFound, DefaultRequeueNotReady and
+	// DefaultRequeueReconciled are the requeue intervals RequeueIntervals
+	// leaves unset.
+	DefaultRequeueInfrastructureNotFound = time.Minute
+	DefaultRequeueNotReady               = 2 * time.Minute
+	DefaultRequeueReconciled             = 5 * time.Minute
+)
+
+// Settings are the settings of the ClusterReconciler that are read on every
+// reconciliation, so that they can be changed while the operator runs, see
+// ClusterReconciler.UpdateSettings.
+type Settings struct {
+	IngressServiceDiscovery azurescope.IngressServiceDiscovery
+	APIServerHostnameMode   string
+	APIServerRecordMode     string
+	AdoptionPolicy          string
+	PrivateRecordsMode      string
+	// IntermediateZoneMode chooses the intermediate zone new cluster zones
+	// are delegated from.
+	IntermediateZoneMode string
+	// SplitHorizonVirtualNetworkIDs are the virtual networks the private
+	// zones of split-horizon clusters are linked to.
+	SplitHorizonVirtualNetworkIDs []string
+	// RecordTTLs are the TTLs of the records the operator writes.
+	RecordTTLs azurescope.RecordTTLs
+	// Requeue are the intervals after which clusters are reconciled again.
+	Requeue RequeueIntervals
+}
+
+// SettingsFromConfig returns the settings of the records and requeue sections
+// of the configuration.
+func SettingsFromConfig(cfg *config.Config) Settings {
+	return Settings{
+		IngressServiceDiscovery: azurescope.IngressServiceDiscovery{
+			Namespaces: cfg.Records.Ingress.ServiceNamespaces,
+			Selectors:  cfg.Records.Ingress.ServiceSelectors,
+		},
+		APIServerHostnameMode:         cfg.Records.APIServerHostnameMode,
+		APIServerRecordMode:           cfg.Records.APIServerRecordMode,
+		AdoptionPolicy:                cfg.Records.AdoptionPolicy,
+		PrivateRecordsMode:            cfg.Records.PrivateRecordsMode,
+		IntermediateZoneMode:          cfg.Records.IntermediateZoneMode,
+		SplitHorizonVirtualNetworkIDs: cfg.Records.SplitHorizonVirtualNetworkIDs,
+		RecordTTLs: azurescope.RecordTTLs{
+			API:     cfg.Records.TTL.API,
+			Ingress: cfg.Records.TTL.Ingress,
+			NS:      cfg.Records.TTL.NS,
+		},
+		Requeue: RequeueIntervals{
+			InfrastructureNotFound: cfg.Requeue.InfrastructureNotFound.Duration,
+			NotReady:               cfg.Requeue.NotReady.Duration,
+			Reconciled:             cfg.Requeue.Reconciled.Duration,
+		},
+	}
+}
+
+// RequeueIntervals are the intervals after which a cluster is reconciled
+// again. Unset intervals default to DefaultRequeueInfrastructureNotFound,
+// DefaultRequeueNotReady and DefaultRequeueReconciled.
+type RequeueIntervals struct {
+	// InfrastructureNotFound applies while the infrastructure cluster of a
+	// Cluster doesn't exist yet.
+	InfrastructureNotFound time.Duration
+	// NotReady applies while the cluster or its infrastructure isn't
+	// provisioned yet.
+	NotReady time.Duration
+	// Reconciled applies after a successful reconciliation.
+	Reconciled time.Duration
+}
+
+func (i RequeueIntervals) withDefaults() RequeueIntervals {
+	if i.InfrastructureNotFound <= 0 {
+		i.InfrastructureNotFound = DefaultRequeueInfrastructureNotFound
+	}
+	if i.NotReady <= 0 {
+		i.NotReady = DefaultRequeueNotReady
+	}
+	if i.Reconciled <= 0 {
+		i.Reconciled = DefaultRequeueReconciled
+	}
+	return i
+}
+
+// UpdateSettings replaces the settings of the reconciler, they apply from the
+// next reconciliation on.
+func (r *ClusterReconciler) UpdateSettings(settings Settings) {
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+	r.Settings = settings
+}
+
+// settings returns the current settings of the reconciler.
+func (r *ClusterReconciler) settings() Settings {
+	r.settingsMu.RLock()
+	defer r.settingsMu.RUnlock()
+	settings := r.Settings
+	settings.Requeue = settings.Requeue.withDefaults()
+	return settings
+}
@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/labels"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
+)
+
+// Shard restricts an operator instance to a subset of the Clusters in the
+// management cluster, so that several instances, e.g. with different base
+// domains or credentials, can split the cluster fleet between them.
+type Shard struct {
+	// Namespaces the Clusters are watched in, all namespaces if empty.
+	Namespaces []string
+	// Selector the Clusters must match, all Clusters if nil.
+	Selector labels.Selector
+}
+
+// NewShard builds a Shard from a comma separated list of namespaces and a
+// label selector.
+func NewShard(namespaces, selector string) (Shard, error) {
+	var shard Shard
+
+	for _, namespace := range strings.Split(namespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && !slices.Contains(shard.Namespaces, namespace) {
+			shard.Namespaces = append(shard.Namespaces, namespace)
+		}
+	}
+	sort.Strings(shard.Namespaces)
+
+	if strings.TrimSpace(selector) != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return Shard{}, microerror.Maskf(errors.InvalidConfigError, "invalid cluster selector %q: %s", selector, err)
+		}
+		shard.Selector = parsed
+	}
+
+	return shard, nil
+}
+
+// IsSharded reports whether the shard is restricted at all.
+func (s Shard) IsSharded() bool {
+	return len(s.Namespaces) > 0 || s.Selector != nil
+}
+
+// CacheByObject returns the cache options restricting Clusters to the shard.
+// Other objects, e.g. identities and secrets referenced across namespaces,
+// are not restricted.
+func (s Shard) CacheByObject() map[client.Object]cache.ByObject {
+	if !s.IsSharded() {
+		return nil
+	}
+
+	byObject := cache.ByObject{Label: s.Selector}
+	if len(s.Namespaces) > 0 {
+		byObject.Namespaces = map[string]cache.Config{}
+		for _, namespace := range s.Namespaces {
+			byObject.Namespaces[namespace] = cache.Config{}
+		}
+	}
+
+	return map[client.Object]cache.ByObject{&capi.Cluster{}: byObject}
+}
+
+// Contains reports whether object belongs to the shard.
+func (s Shard) Contains(object client.Object) bool {
+	if len(s.Namespaces) > 0 && !slices.Contains(s.Namespaces, object.GetNamespace()) {
+		return false
+	}
+	return s.Selector == nil || s.Selector.Matches(labels.Set(object.GetLabels()))
+}
+
+// Predicate filters Clusters outside of the shard.
+func (s Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(s.Contains)
+}
+
+// LeaderElectionID derives the leader election ID of the shard from base, so
+// that instances of different shards don't compete for the same lease.
+func (s Shard) LeaderElectionID(base string) string {
+	if !s.IsSharded() {
+		return base
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.Join(s.Namespaces, ",")))
+	if s.Selector != nil {
+		_, _ = hash.Write([]byte("/" + s.Selector.String()))
+	}
+
+	return fmt.Sprintf("%s-%08x", base, hash.Sum32())
+}
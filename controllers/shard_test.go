@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
+)
+
+func Test_Shard(t *testing.T) {
+	const baseID = "dns-operator-azure-leader-election"
+
+	testCases := []struct {
+		name          string
+		namespaces    string
+		selector      string
+		cluster       metav1.ObjectMeta
+		expectErr     bool
+		expectSharded bool
+		expectInShard bool
+		expectBaseID  bool
+	}{
+		{
+			name:          "case0: no shard watches every cluster",
+			cluster:       metav1.ObjectMeta{Name: "a", Namespace: "org-a"},
+			expectInShard: true,
+			expectBaseID:  true,
+		},
+		{
+			name:          "case1: cluster in a watched namespace",
+			namespaces:    "org-b, org-a,,org-a",
+			cluster:       metav1.ObjectMeta{Name: "a", Namespace: "org-a"},
+			expectSharded: true,
+			expectInShard: true,
+		},
+		{
+			name:          "case2: cluster outside of the watched namespaces",
+			namespaces:    "org-b",
+			cluster:       metav1.ObjectMeta{Name: "a", Namespace: "org-a"},
+			expectSharded: true,
+		},
+		{
+			name:          "case3: cluster matching the selector",
+			selector:      "dns-shard=eu",
+			cluster:       metav1.ObjectMeta{Name: "a", Namespace: "org-a", Labels: map[string]string{"dns-shard": "eu"}},
+			expectSharded: true,
+			expectInShard: true,
+		},
+		{
+			name:          "case4: cluster not matching the selector",
+			namespaces:    "org-a",
+			selector:      "dns-shard=eu",
+			cluster:       metav1.ObjectMeta{Name: "a", Namespace: "org-a", Labels: map[string]string{"dns-shard": "us"}},
+			expectSharded: true,
+		},
+		{
+			name:      "case5: invalid selector",
+			selector:  "dns-shard in (",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shard, err := NewShard(tc.namespaces, tc.selector)
+			if tc.expectErr {
+				if !errors.IsInvalidConfig(err) {
+					t.Fatalf("expected invalid config error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if shard.IsSharded() != tc.expectSharded {
+				t.Errorf("IsSharded() = %t, want %t", shard.IsSharded(), tc.expectSharded)
+			}
+			if (shard.CacheByObject() != nil) != tc.expectSharded {
+				t.Errorf("CacheByObject() = %v, want restriction %t", shard.CacheByObject(), tc.expectSharded)
+			}
+
+			inShard := shard.Predicate().Create(event.CreateEvent{Object: &capi.Cluster{ObjectMeta: tc.cluster}})
+			if inShard != tc.expectInShard {
+				t.Errorf("Predicate() = %t, want %t", inShard, tc.expectInShard)
+			}
+
+			if id := shard.LeaderElectionID(baseID); (id == baseID) != tc.expectBaseID {
+				t.Errorf("LeaderElectionID() = %q, want base ID kept %t", id, tc.expectBaseID)
+			}
+		})
+	}
+}
+
+func Test_Shard_LeaderElectionID(t *testing.T) {
+	const baseID = "dns-operator-azure-leader-election"
+
+	a, _ := NewShard("org-a,org-b", "dns-shard=eu")
+	b, _ := NewShard("org-b, org-a", "dns-shard=eu")
+	c, _ := NewShard("org-a,org-b", "dns-shard=us")
+
+	if a.LeaderElectionID(baseID) != b.LeaderElectionID(baseID) {
+		t.Errorf("expected equal shards to share the leader election ID, got %q and %q", a.LeaderElectionID(baseID), b.LeaderElectionID(baseID))
+	}
+	if a.LeaderElectionID(baseID) == c.LeaderElectionID(baseID) {
+		t.Errorf("expected different shards to use different leader election IDs, got %q", a.LeaderElectionID(baseID))
+	}
+}
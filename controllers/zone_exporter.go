@@ -0,0 +1,224 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	azurescope "github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/zonefile"
+)
+
+const (
+	// BaseZoneConfigMapName is the name of the ConfigMap the base zone is
+	// exported to.
+	BaseZoneConfigMapName = "dns-operator-azure-base-zone"
+	// ZoneConfigMapSuffix is appended to the Cluster name to build the name
+	// of the ConfigMap its zone is exported to.
+	ZoneConfigMapSuffix = "-dns-zone"
+)
+
+// ZoneExporter exports the base zone and the cluster zones as zone files, for
+// disaster recovery and audits.
+type ZoneExporter struct {
+	// Reconciler provides the configuration to access the zones with.
+	Reconciler *ClusterReconciler
+	// Client lists the Clusters and writes the ConfigMaps.
+	Client client.Client
+
+	// Directory zone files are written to as <zone>.zone, if set.
+	Directory string
+	// ConfigMaps enables the export to a ConfigMap per cluster, named
+	// <cluster>-dns-zone in the namespace of the Cluster. The base zone is
+	// exported to the dns-operator-azure-base-zone ConfigMap in
+	// ConfigMapNamespace.
+	ConfigMaps         bool
+	ConfigMapNamespace string
+
+	// Interval the zones are exported at when run by the manager.
+	Interval time.Duration
+}
+
+var _ manager.Runnable = (*ZoneExporter)(nil)
+
+// Validate returns an InvalidConfigError if the exporter has no target.
+func (e *ZoneExporter) Validate() error {
+	if e.Directory == "" && !e.ConfigMaps {
+		return microerror.Maskf(errors.InvalidConfigError, "zone export needs a directory or ConfigMaps to be enabled")
+	}
+	return nil
+}
+
+func (e *ZoneExporter) SetupWithManager(mgr manager.Manager) error {
+	return mgr.Add(e)
+}
+
+// Start exports the zones every Interval until ctx is done. As a
+// manager.Runnable it only runs on the leader.
+func (e *ZoneExporter) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("zone-exporter")
+	ctx = log.IntoContext(ctx, logger)
+
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Export(ctx); err != nil {
+			logger.Error(err, "failed to export DNS zones")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Export exports the base zone and the zones of all Clusters in the shard
+// that didn't opt out of DNS management. Failing zones don't stop the export
+// of the others, an exportFailedError is returned at the end instead.
+func (e *ZoneExporter) Export(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+	r := e.Reconciler
+
+	var failed []string
+
+	recordSets, err := dns.BaseZoneRecordSets(ctx, r.DNSProvider, azurescope.BaseZoneCredentials{
+		ClientID:       r.BaseZoneClientID,
+		ClientSecret:   r.BaseZoneClientSecret,
+		SubscriptionID: r.BaseZoneSubscriptionID,
+		TenantID:       r.BaseZoneTenantID,
+	}, r.BaseDomainResourceGroup, r.BaseDomain)
+	if err == nil {
+		err = e.write(ctx, r.BaseDomain, nil, recordSets)
+	}
+	if err != nil {
+		logger.Error(err, "failed to export base zone", "zone", r.BaseDomain)
+		failed = append(failed, r.BaseDomain)
+	}
+
+	var clusters capi.ClusterList
+	if err := e.Client.List(ctx, &clusters); err != nil {
+		return microerror.Mask(err)
+	}
+
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if !r.Shard.Contains(cluster) || infracluster.IsDNSManagementDisabled(cluster.GetAnnotations()) {
+			continue
+		}
+
+		zoneName, err := e.exportCluster(ctx, cluster)
+		if err != nil {
+			logger.Error(err, "failed to export cluster zone", "cluster", client.ObjectKeyFromObject(cluster))
+			failed = append(failed, fmt.Sprintf("%s/%s", cluster.Namespace, cluster.Name))
+			continue
+		}
+		logger.Info("Exported cluster zone", "cluster", client.ObjectKeyFromObject(cluster), "zone", zoneName)
+	}
+
+	if len(failed) > 0 {
+		return microerror.Maskf(exportFailedError, "failed to export %v", failed)
+	}
+	return nil
+}
+
+func (e *ZoneExporter) exportCluster(ctx context.Context, cluster *capi.Cluster) (string, error) {
+	dnsService, err := e.Reconciler.getDnsServiceForCluster(ctx, cluster)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	recordSets, err := dnsService.ClusterZoneRecordSets(ctx)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	zoneName := dnsService.ClusterZoneName()
+	if err := e.write(ctx, zoneName, cluster, recordSets); err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return zoneName, nil
+}
+
+// write writes the zone file of zoneName to the configured targets. cluster
+// is nil for the base zone.
+func (e *ZoneExporter) write(ctx context.Context, zoneName string, cluster *capi.Cluster, recordSets []*armdns.RecordSet) error {
+	var buffer bytes.Buffer
+	if err := zonefile.Write(&buffer, zoneName, recordSets); err != nil {
+		return microerror.Mask(err)
+	}
+	fileName := zoneName + ".zone"
+
+	if e.Directory != "" {
+		if err := os.WriteFile(filepath.Join(e.Directory, fileName), buffer.Bytes(), 0o600); err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if !e.ConfigMaps {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BaseZoneConfigMapName,
+			Namespace: e.ConfigMapNamespace,
+		},
+	}
+	if cluster != nil {
+		configMap.Name = cluster.Name + ZoneConfigMapSuffix
+		configMap.Namespace = cluster.Namespace
+	} else if e.ConfigMapNamespace == "" {
+		return nil
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, e.Client, configMap, func() error {
+		if configMap.Labels == nil {
+			configMap.Labels = map[string]string{}
+		}
+		configMap.Labels["app.kubernetes.io/managed-by"] = "dns-operator-azure"
+		if cluster != nil {
+			configMap.Labels[capi.ClusterNameLabel] = cluster.Name
+		}
+		configMap.Data = map[string]string{fileName: buffer.String()}
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
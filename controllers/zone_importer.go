@@ -0,0 +1,165 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1beta1 "sigs.k8s.io/cluster-api/api/core/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/zonefile"
+)
+
+// ImportZoneFile imports the zone file read from zoneFile into the zone of
+// cluster, see dns.Service.ImportRecordSets. Invalid zone files return an
+// invalidZoneImportError.
+func (r *ClusterReconciler) ImportZoneFile(ctx context.Context, cluster *capi.Cluster, zoneFile io.Reader) (dns.ImportReport, error) {
+	dnsService, err := r.getDnsServiceForCluster(ctx, cluster)
+	if err != nil {
+		return dns.ImportReport{}, microerror.Mask(err)
+	}
+
+	return importZoneFile(ctx, dnsService, zoneFile)
+}
+
+func importZoneFile(ctx context.Context, dnsService *dns.Service, zoneFile io.Reader) (dns.ImportReport, error) {
+	recordSets, err := zonefile.Parse(zoneFile, dnsService.ClusterZoneName())
+	if zonefile.IsInvalidZoneFile(err) {
+		return dns.ImportReport{}, microerror.Maskf(invalidZoneImportError, "%s", err)
+	} else if err != nil {
+		return dns.ImportReport{}, microerror.Mask(err)
+	}
+
+	report, err := dnsService.ImportRecordSets(ctx, recordSets)
+	if err != nil {
+		return report, microerror.Mask(err)
+	}
+
+	return report, nil
+}
+
+// importZoneConfigMap imports the zone file held by the ConfigMap name in the
+// namespace of cluster. The zone file is read from the <zone>.zone key, as
+// written by the ZoneExporter, or from the only key of the ConfigMap. Zone
+// files whose checksum matches the AnnotationImportedZoneChecksum annotation
+// of cluster were imported before and are skipped, imported is false then.
+func (r *ClusterReconciler) importZoneConfigMap(ctx context.Context, dnsService *dns.Service, cluster *capi.Cluster, name string) (_ dns.ImportReport, imported bool, _ error) {
+	var configMap corev1.ConfigMap
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, &configMap)
+	if apierrors.IsNotFound(err) {
+		return dns.ImportReport{}, true, microerror.Maskf(invalidZoneImportError, "ConfigMap %s/%s not found", cluster.Namespace, name)
+	} else if err != nil {
+		return dns.ImportReport{}, false, microerror.Mask(err)
+	}
+
+	zoneFile, ok := configMap.Data[dnsService.ClusterZoneName()+".zone"]
+	if !ok {
+		if len(configMap.Data) != 1 {
+			return dns.ImportReport{}, true, microerror.Maskf(invalidZoneImportError, "ConfigMap %s/%s has no %s.zone key", cluster.Namespace, name, dnsService.ClusterZoneName())
+		}
+		for _, value := range configMap.Data {
+			zoneFile = value
+		}
+	}
+
+	checksum := zoneFileChecksum(zoneFile)
+	if cluster.GetAnnotations()[infracluster.AnnotationImportedZoneChecksum] == checksum {
+		return dns.ImportReport{}, false, nil
+	}
+
+	report, err := importZoneFile(ctx, dnsService, strings.NewReader(zoneFile))
+	if err != nil {
+		return report, true, microerror.Mask(err)
+	}
+
+	if err := r.recordZoneImport(ctx, cluster, checksum); err != nil {
+		return report, true, microerror.Mask(err)
+	}
+	log.FromContext(ctx).Info("Imported zone file", "configMap", name, "checksum", checksum)
+
+	return report, true, nil
+}
+
+// zoneFileChecksum returns the checksum of zoneFile recorded in the
+// AnnotationImportedZoneChecksum annotation.
+func zoneFileChecksum(zoneFile string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(zoneFile)))
+}
+
+// recordZoneImport records the checksum of the imported zone file in the
+// AnnotationImportedZoneChecksum annotation of cluster.
+func (r *ClusterReconciler) recordZoneImport(ctx context.Context, cluster *capi.Cluster, checksum string) error {
+	patch := client.MergeFrom(cluster.DeepCopy())
+	annotations := cluster.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[infracluster.AnnotationImportedZoneChecksum] = checksum
+	cluster.SetAnnotations(annotations)
+
+	if err := r.Client.Patch(ctx, cluster, patch); err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// zoneImportedCondition reports the outcome of a zone file import. err is an
+// invalidZoneImportError or nil.
+func zoneImportedCondition(report dns.ImportReport, err error) clusterv1beta1.Condition {
+	switch {
+	case err != nil:
+		return clusterv1beta1.Condition{
+			Type:     "GSDNSZoneImported",
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1beta1.ConditionSeverityWarning,
+			Reason:   "InvalidZoneFile",
+			Message:  err.Error(),
+		}
+	case report.HasProblems():
+		var problems []string
+		if len(report.Refused) > 0 {
+			problems = append(problems, fmt.Sprintf("refused records managed by the operator: %s", strings.Join(report.Refused, ", ")))
+		}
+		if len(report.Conflicts) > 0 {
+			problems = append(problems, fmt.Sprintf("conflicting records: %s", strings.Join(report.Conflicts, ", ")))
+		}
+		return clusterv1beta1.Condition{
+			Type:     "GSDNSZoneImported",
+			Status:   corev1.ConditionFalse,
+			Severity: clusterv1beta1.ConditionSeverityWarning,
+			Reason:   "ImportConflicts",
+			Message:  fmt.Sprintf("Zone file was imported partially, %s", strings.Join(problems, "; ")),
+		}
+	}
+	return clusterv1beta1.Condition{
+		Type:    "GSDNSZoneImported",
+		Status:  corev1.ConditionTrue,
+		Reason:  "ZoneImported",
+		Message: fmt.Sprintf("Zone file was imported, %d record sets created, %d unchanged", len(report.Created), len(report.Unchanged)),
+	}
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"os"
-	"time"
+	"strings"
 
 	aadpodv1 "github.com/Azure/aad-pod-identity/pkg/apis/aadpodidentity/v1"
 	"go.uber.org/zap/zapcore"
@@ -34,16 +36,23 @@ import (
 	"sigs.k8s.io/cluster-api/util/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	webhookserver "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/giantswarm/microerror"
 
+	azurescope "github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/azure/services/dns"
 	"github.com/giantswarm/dns-operator-azure/v3/controllers"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/config"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/dnsquery"
 	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
 	"github.com/giantswarm/dns-operator-azure/v3/pkg/infracluster"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/rfc2136"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -53,16 +62,19 @@ var (
 )
 
 const (
-	SubscriptionId = "AZURE_SUBSCRIPTION_ID"
-	TenantId       = "AZURE_TENANT_ID"
-	ClientId       = "AZURE_CLIENT_ID"
-	ClientSecret   = "AZURE_CLIENT_SECRET" //nolint
-
-	InfraClusterSubscriptionID = "CLUSTER_AZURE_SUBSCRIPTION_ID"
-	InfraClusterClientID       = "CLUSTER_AZURE_CLIENT_ID"
-	InfraClusterClientSecret   = "CLUSTER_AZURE_CLIENT_SECRET" //nolint
-	InfraClusterTenantID       = "CLUSTER_AZURE_TENANT_ID"
-	InfraClusterLocation       = "CLUSTER_AZURE_LOCATION"
+	ClientSecret = "AZURE_CLIENT_SECRET" //nolint
+
+	InfraClusterClientSecret = "CLUSTER_AZURE_CLIENT_SECRET" //nolint
+
+	// RFC2136TSIGSecret holds the base64 encoded secret of the TSIG key
+	// signing RFC 2136 updates.
+	RFC2136TSIGSecret = "RFC2136_TSIG_SECRET" //nolint
+
+	// CommandExport exports the zones once and exits, instead of running
+	// the operator.
+	CommandExport = "export"
+	// CommandImport imports a zone file into a cluster zone and exits.
+	CommandImport = "import"
 )
 
 func init() {
@@ -93,43 +105,30 @@ func main() {
 
 func mainError() error {
 	var (
-		metricsAddr                string
-		enableLeaderElection       bool
-		baseDomain                 string
-		baseDomainResourceGroup    string
-		baseZoneClientID           string
-		baseZoneClientSecret       string
-		baseZoneSubscriptionID     string
-		baseZoneTenantID           string
-		syncPeriod                 time.Duration
-		clusterConcurrency         int
-		managementClusterName      string
-		managementClusterNamespace string
-		azureIdentityRefName       string
-		azureIdentityRefNamespace  string
+		configFile     string
+		importCluster  string
+		importZoneFile string
 	)
 
-	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
-
-	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
-		"Enable leader election for controller manager. "+
-			"Enabling this will ensure there is only one active controller manager.")
-	flag.StringVar(&baseDomain, "base-domain", "",
-		"Domain for which to create the DNS entries, e.g. customer.gigantic.io.")
-	flag.StringVar(&baseDomainResourceGroup, "base-domain-resource-group", "",
-		"Resource Group where the base-domain is placed.")
-	flag.DurationVar(&syncPeriod, "sync-period", 5*time.Minute,
-		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
-	flag.IntVar(&clusterConcurrency, "cluster-concurrency", 5,
-		"Number of clusters to process simultaneously")
-	flag.StringVar(&managementClusterName, "management-cluster-name", "",
-		"The name of the management cluster where this operator is running (also MC AzureCluster CR name)")
-	flag.StringVar(&managementClusterNamespace, "management-cluster-namespace", "",
-		"The namespace where the management cluster AzureCluster CR is deployed")
-	flag.StringVar(&azureIdentityRefName, "azure-identity-ref-name", "",
-		"The name of the Azure Cluster Identity reference to be used when reconciling non-Azure clusters")
-	flag.StringVar(&azureIdentityRefNamespace, "azure-identity-ref-namespace", "",
-		"The namespace of the Azure Cluster Identity reference to be used when reconciling non-Azure clusters")
+	// subcommands share the flags and the environment of the operator
+	var command string
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	if command != "" && command != CommandExport && command != CommandImport {
+		return microerror.Maskf(errors.InvalidConfigError, "unknown command %q", command)
+	}
+
+	// the operator flags override the configuration file, they are bound to
+	// a throwaway configuration and applied by the loader
+	config.BindFlags(flag.CommandLine, config.Default())
+	flag.StringVar(&configFile, "config", "",
+		"Configuration file of kind "+config.Kind+", overridden by the environment and the other flags")
+	flag.StringVar(&importCluster, "import-cluster", "",
+		"Cluster, as <namespace>/<name>, whose zone the import command imports the zone file into")
+	flag.StringVar(&importZoneFile, "import-zone-file", "",
+		"Zone file the import command imports, - for stdin")
 
 	// configure the logger
 	opts := zap.Options{
@@ -141,21 +140,48 @@ func mainError() error {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	loader := config.Loader{
+		Path:   configFile,
+		Getenv: os.Getenv,
+		Flags:  config.FlagOverrides(flag.CommandLine),
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	setupLog.Info("Loaded configuration", "path", configFile, "checksum", cfg.Checksum())
+
+	shard, err := controllers.NewShard(strings.Join(cfg.Manager.WatchNamespaces, ","), cfg.Manager.ClusterSelector)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	restConfig := ctrl.GetConfigOrDie()
 	restConfig.UserAgent = "dns-operator-azure"
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
-			BindAddress: metricsAddr,
+			BindAddress: cfg.Manager.MetricsBindAddress,
 		},
+		HealthProbeBindAddress: cfg.Manager.HealthProbeBindAddress,
 		WebhookServer: webhookserver.NewServer(
 			webhookserver.Options{
 				Port: 9443,
 			},
 		),
-		LeaderElection:   enableLeaderElection,
-		LeaderElectionID: "dns-operator-azure-leader-election",
-		Cache:            cache.Options{SyncPeriod: &syncPeriod},
+		LeaderElection:   cfg.Manager.LeaderElection,
+		LeaderElectionID: shard.LeaderElectionID("dns-operator-azure-leader-election"),
+		Cache: cache.Options{
+			SyncPeriod: &cfg.Manager.SyncPeriod.Duration,
+			ByObject:   shard.CacheByObject(),
+		},
+		// ConfigMaps are only read and written occasionally, caching all of
+		// them isn't worth it
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&corev1.ConfigMap{}},
+			},
+		},
 	})
 	if err != nil {
 		setupLog.Error(errors.FatalError, "unable to start manager")
@@ -165,59 +191,259 @@ func mainError() error {
 	// Initialize event recorder.
 	record.InitFromRecorder(mgr.GetEventRecorderFor("dns-operator-azure"))
 
-	baseZoneSubscriptionID = os.Getenv(SubscriptionId)
-	if baseZoneSubscriptionID == "" {
-		return microerror.Mask(fmt.Errorf("environment variable %s not set", SubscriptionId))
-	}
-	baseZoneClientID = os.Getenv(ClientId)
-	if baseZoneClientID == "" {
-		return microerror.Mask(fmt.Errorf("environment variable %s not set", ClientId))
-	}
-	baseZoneClientSecret = os.Getenv(ClientSecret)
-	if baseZoneClientSecret == "" {
-		return microerror.Mask(fmt.Errorf("environment variable %s not set", ClientSecret))
+	// the client secrets are only read from the environment, the other
+	// credentials were checked by the loader
+	var baseZoneClientSecret string
+	var dnsProviderClient dns.Provider
+	switch cfg.DNSProvider.Name {
+	case config.DNSProviderAzure:
+		baseZoneClientSecret = os.Getenv(ClientSecret)
+		if baseZoneClientSecret == "" {
+			return microerror.Mask(fmt.Errorf("environment variable %s not set", ClientSecret))
+		}
+	case config.DNSProviderRFC2136:
+		var tsigSecret []byte
+		if cfg.DNSProvider.RFC2136.TSIGKeyName != "" {
+			tsigSecret, err = base64.StdEncoding.DecodeString(os.Getenv(RFC2136TSIGSecret))
+			if err != nil {
+				return microerror.Maskf(errors.InvalidConfigError, "environment variable %s is not base64 encoded: %s", RFC2136TSIGSecret, err)
+			}
+		}
+		dnsProviderClient, err = rfc2136.New(rfc2136.Config{
+			Server:        cfg.DNSProvider.RFC2136.Server,
+			TSIGKeyName:   cfg.DNSProvider.RFC2136.TSIGKeyName,
+			TSIGAlgorithm: cfg.DNSProvider.RFC2136.TSIGAlgorithm,
+			TSIGSecret:    tsigSecret,
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
 	}
-	baseZoneTenantID = os.Getenv(TenantId)
-	if baseZoneTenantID == "" {
-		return microerror.Mask(fmt.Errorf("environment variable %s not set", TenantId))
+
+	baseZoneCredentials := azurescope.BaseZoneCredentials{
+		ClientID:       cfg.Azure.BaseZone.ClientID,
+		ClientSecret:   baseZoneClientSecret,
+		SubscriptionID: cfg.Azure.BaseZone.SubscriptionID,
+		TenantID:       cfg.Azure.BaseZone.TenantID,
 	}
 
 	infraClusterZoneAzureConfig := infracluster.ClusterZoneAzureConfig{
-		SubscriptionID: os.Getenv(InfraClusterSubscriptionID),
-		ClientID:       os.Getenv(InfraClusterClientID),
+		SubscriptionID: cfg.Azure.ClusterZone.SubscriptionID,
+		ClientID:       cfg.Azure.ClusterZone.ClientID,
 		ClientSecret:   os.Getenv(InfraClusterClientSecret),
-		TenantID:       os.Getenv(InfraClusterTenantID),
-		Location:       os.Getenv(InfraClusterLocation),
+		TenantID:       cfg.Azure.ClusterZone.TenantID,
+		Location:       cfg.Azure.ClusterZone.Location,
+	}
+
+	var zones []azurescope.Zone
+	for _, zone := range cfg.AdditionalZones {
+		zones = append(zones, azurescope.Zone{
+			Name:          strings.TrimSuffix(strings.ToLower(zone.Name), "."),
+			ResourceGroup: zone.ResourceGroup,
+		})
 	}
 
 	var clusterIdentityRef *corev1.ObjectReference
-	if azureIdentityRefName != "" && azureIdentityRefNamespace != "" {
+	if cfg.Azure.IdentityRef.Name != "" && cfg.Azure.IdentityRef.Namespace != "" {
 		clusterIdentityRef = &corev1.ObjectReference{
-			Name:      azureIdentityRefName,
-			Namespace: azureIdentityRefNamespace,
+			Name:      cfg.Azure.IdentityRef.Name,
+			Namespace: cfg.Azure.IdentityRef.Namespace,
 		}
 	}
 
-	if err := (&controllers.ClusterReconciler{
+	var propagationQuerier dns.Querier
+	if cfg.PropagationCheck.Enabled {
+		propagationQuerier = dnsquery.New(cfg.PropagationCheck.Resolver)
+	}
+
+	reconciler := &controllers.ClusterReconciler{
 		Client:                  mgr.GetClient(),
-		BaseDomain:              baseDomain,
-		BaseDomainResourceGroup: baseDomainResourceGroup,
-		BaseZoneClientID:        baseZoneClientID,
-		BaseZoneClientSecret:    baseZoneClientSecret,
-		BaseZoneSubscriptionID:  baseZoneSubscriptionID,
-		BaseZoneTenantID:        baseZoneTenantID,
+		BaseDomain:              cfg.BaseDomain,
+		BaseDomainResourceGroup: cfg.BaseDomainResourceGroup,
+		BaseZoneClientID:        baseZoneCredentials.ClientID,
+		BaseZoneClientSecret:    baseZoneCredentials.ClientSecret,
+		BaseZoneSubscriptionID:  baseZoneCredentials.SubscriptionID,
+		BaseZoneTenantID:        baseZoneCredentials.TenantID,
 		Recorder:                mgr.GetEventRecorderFor("azurecluster-reconciler"),
 		ManagementClusterConfig: infracluster.ManagementClusterConfig{
-			Name:      managementClusterName,
-			Namespace: managementClusterNamespace,
+			Name:      cfg.ManagementCluster.Name,
+			Namespace: cfg.ManagementCluster.Namespace,
 		},
 		InfraClusterZoneAzureConfig: infraClusterZoneAzureConfig,
 		ClusterAzureIdentityRef:     clusterIdentityRef,
-	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: clusterConcurrency}); err != nil {
+		AdditionalZones:             zones,
+		Settings:                    controllers.SettingsFromConfig(cfg),
+		ManagementLocks:             cfg.ManagementLocks,
+		PropagationQuerier:          propagationQuerier,
+		DNSProvider:                 dnsProviderClient,
+		Shard:                       shard,
+	}
+
+	zoneExporter := &controllers.ZoneExporter{
+		Reconciler:         reconciler,
+		Client:             mgr.GetClient(),
+		Directory:          cfg.ZoneExport.Dir,
+		ConfigMaps:         cfg.ZoneExport.ConfigMaps,
+		ConfigMapNamespace: cfg.ManagementCluster.Namespace,
+		Interval:           cfg.ZoneExport.Interval.Duration,
+	}
+
+	if command == CommandExport {
+		if err := zoneExporter.Validate(); err != nil {
+			return microerror.Mask(err)
+		}
+
+		// the manager isn't started, so the exporter reads and writes through
+		// an uncached client
+		directClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		reconciler.Client = directClient
+		zoneExporter.Client = directClient
+
+		return microerror.Mask(zoneExporter.Export(ctrl.SetupSignalHandler()))
+	}
+
+	if command == CommandImport {
+		namespace, name, ok := strings.Cut(importCluster, "/")
+		if !ok || namespace == "" || name == "" || importZoneFile == "" {
+			return microerror.Maskf(errors.InvalidConfigError, "import needs --import-cluster=<namespace>/<name> and --import-zone-file")
+		}
+
+		zoneFile := os.Stdin
+		if importZoneFile != "-" {
+			zoneFile, err = os.Open(importZoneFile)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			defer zoneFile.Close() //nolint:errcheck
+		}
+
+		directClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		reconciler.Client = directClient
+
+		ctx := ctrl.SetupSignalHandler()
+		var cluster capi.Cluster
+		if err := directClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &cluster); err != nil {
+			return microerror.Mask(err)
+		}
+
+		report, err := reconciler.ImportZoneFile(ctx, &cluster, zoneFile)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		setupLog.Info("Imported zone file", "cluster", importCluster, "created", report.Created, "unchanged", report.Unchanged)
+		if report.HasProblems() {
+			setupLog.Info("Some record sets were not imported", "cluster", importCluster, "refused", report.Refused, "conflicts", report.Conflicts)
+		}
+		return nil
+	}
+
+	if err := reconciler.SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: cfg.Manager.ClusterConcurrency}); err != nil {
 		setupLog.Error(errors.FatalError, "unable to create controller AzureCluster")
 		return microerror.Mask(err)
 	}
 
+	// the sweeper looks for orphans in Azure DNS and resource groups only
+	if cfg.OrphanSweeper.Interval.Duration > 0 && cfg.DNSProvider.Name == config.DNSProviderAzure {
+		sweeper, err := dns.NewSweeper(dns.SweeperParams{
+			BaseDomain:              cfg.BaseDomain,
+			BaseDomainResourceGroup: cfg.BaseDomainResourceGroup,
+			BaseZoneCredentials:     baseZoneCredentials,
+			ClusterZoneCredentials: azurescope.BaseZoneCredentials{
+				ClientID:       infraClusterZoneAzureConfig.ClientID,
+				ClientSecret:   infraClusterZoneAzureConfig.ClientSecret,
+				SubscriptionID: infraClusterZoneAzureConfig.SubscriptionID,
+				TenantID:       infraClusterZoneAzureConfig.TenantID,
+			},
+			ManagementLocks:  cfg.ManagementLocks,
+			OperatorIdentity: azurescope.OperatorIdentity(cfg.ManagementCluster.Name, cfg.BaseDomain),
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var eventObject *corev1.ObjectReference
+		if cfg.ManagementCluster.Name != "" && cfg.ManagementCluster.Namespace != "" {
+			eventObject = &corev1.ObjectReference{
+				APIVersion: capi.GroupVersion.String(),
+				Kind:       "Cluster",
+				Name:       cfg.ManagementCluster.Name,
+				Namespace:  cfg.ManagementCluster.Namespace,
+			}
+		}
+
+		if err := (&controllers.OrphanSweeper{
+			Reader:      mgr.GetAPIReader(),
+			Sweeper:     sweeper,
+			Recorder:    mgr.GetEventRecorderFor("orphan-sweeper"),
+			EventObject: eventObject,
+			Interval:    cfg.OrphanSweeper.Interval.Duration,
+			GracePeriod: cfg.OrphanSweeper.DeletionGracePeriod.Duration,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(errors.FatalError, "unable to create orphan sweeper")
+			return microerror.Mask(err)
+		}
+	}
+
+	if cfg.ZoneExport.Interval.Duration > 0 {
+		if err := zoneExporter.Validate(); err != nil {
+			return microerror.Mask(err)
+		}
+		if err := zoneExporter.SetupWithManager(mgr); err != nil {
+			setupLog.Error(errors.FatalError, "unable to create zone exporter")
+			return microerror.Mask(err)
+		}
+	}
+
+	baseZoneCheck, err := dns.NewBaseZoneCheck(dns.BaseZoneCheckParams{
+		BaseDomain:              cfg.BaseDomain,
+		BaseDomainResourceGroup: cfg.BaseDomainResourceGroup,
+		BaseZoneCredentials:     baseZoneCredentials,
+		Provider:                dnsProviderClient,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// the management cluster doesn't have to be in the shard, so it is read
+	// without the cache
+	apiReader := mgr.GetAPIReader()
+	managementClusterConfig := reconciler.ManagementClusterConfig
+
+	if err := (&controllers.Preflight{
+		Checks: []controllers.PreflightCheck{
+			{Name: "base-zone", Check: baseZoneCheck.Check},
+			{Name: "base-zone-write", Check: baseZoneCheck.CheckWrite, LeaderOnly: true},
+			{Name: "management-cluster", Check: func(ctx context.Context) error {
+				return infracluster.CheckManagementCluster(ctx, apiReader, managementClusterConfig)
+			}},
+		},
+		Interval: cfg.Preflight.Interval.Duration,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(errors.FatalError, "unable to create preflight checks")
+		return microerror.Mask(err)
+	}
+
+	if err := (&controllers.ConfigReloader{
+		Loader:     loader,
+		Reconciler: reconciler,
+		Config:     cfg,
+		Interval:   cfg.ReloadInterval.Duration,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(errors.FatalError, "unable to create config reloader")
+		return microerror.Mask(err)
+	}
+
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(errors.FatalError, "unable to create health check")
+		return microerror.Mask(err)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
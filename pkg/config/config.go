@@ -0,0 +1,425 @@
+// Package config holds the configuration of the operator, read from a
+// versioned configuration file and overridden by environment variables and
+// command line flags.
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	azurescope "github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/rfc2136"
+)
+
+const (
+	// APIVersion and Kind identify the configuration files this version of
+	// the operator reads.
+	APIVersion = "dns-operator-azure.giantswarm.io/v1alpha1"
+	Kind       = "OperatorConfig"
+
+	// DNSProviderAzure and DNSProviderRFC2136 are the values of
+	// dnsProvider.name.
+	DNSProviderAzure   = "azure"
+	DNSProviderRFC2136 = "rfc2136"
+
+	// maxRecordTTL is the largest TTL Azure DNS accepts.
+	maxRecordTTL = 2147483647
+)
+
+// Config is the configuration of the operator. Records and Requeue are
+// reloaded while the operator runs, the other fields only apply at startup.
+type Config struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// BaseDomain is the domain the cluster zones are created below, e.g.
+	// customer.gigantic.io.
+	BaseDomain string `json:"baseDomain"`
+	// BaseDomainResourceGroup is the resource group of the base zone.
+	BaseDomainResourceGroup string            `json:"baseDomainResourceGroup"`
+	ManagementCluster       ManagementCluster `json:"managementCluster"`
+	Azure                   Azure             `json:"azure"`
+	Manager                 Manager           `json:"manager"`
+	DNSProvider             DNSProvider       `json:"dnsProvider"`
+	// AdditionalZones, reachable with the base zone credentials, are zones
+	// service hostnames may be published in.
+	AdditionalZones []Zone `json:"additionalZones,omitempty"`
+	// ManagementLocks places CanNotDelete management locks on cluster zones
+	// and on the resource groups of non-Azure clusters.
+	ManagementLocks  bool             `json:"managementLocks"`
+	OrphanSweeper    OrphanSweeper    `json:"orphanSweeper"`
+	ZoneExport       ZoneExport       `json:"zoneExport"`
+	Preflight        Preflight        `json:"preflight"`
+	PropagationCheck PropagationCheck `json:"propagationCheck"`
+	// ReloadInterval is the interval at which the configuration file is read
+	// again, it is only read at startup if zero.
+	ReloadInterval metav1.Duration `json:"reloadInterval"`
+
+	Records Records `json:"records"`
+	Requeue Requeue `json:"requeue"`
+}
+
+// ManagementCluster references the Cluster the operator runs in.
+type ManagementCluster struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Azure holds the Azure identities of the operator. Client secrets are only
+// read from the environment.
+type Azure struct {
+	// BaseZone is the identity the base zone and the additional zones are
+	// managed with.
+	BaseZone Credentials `json:"baseZone"`
+	// ClusterZone is the identity the zones of non-Azure clusters are
+	// managed with.
+	ClusterZone ClusterZone `json:"clusterZone"`
+	// IdentityRef references the AzureClusterIdentity used for non-Azure
+	// clusters.
+	IdentityRef IdentityRef `json:"identityRef"`
+}
+
+// Credentials identify an Azure service principal and the subscription it
+// works in.
+type Credentials struct {
+	SubscriptionID string `json:"subscriptionID"`
+	TenantID       string `json:"tenantID"`
+	ClientID       string `json:"clientID"`
+}
+
+// ClusterZone holds the credentials the zones of non-Azure clusters are
+// managed with and the location of their resource groups.
+type ClusterZone struct {
+	SubscriptionID string `json:"subscriptionID"`
+	TenantID       string `json:"tenantID"`
+	ClientID       string `json:"clientID"`
+	Location       string `json:"location"`
+}
+
+// IdentityRef references an AzureClusterIdentity.
+type IdentityRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Manager configures the controller manager.
+type Manager struct {
+	MetricsBindAddress     string `json:"metricsBindAddress"`
+	HealthProbeBindAddress string `json:"healthProbeBindAddress"`
+	LeaderElection         bool   `json:"leaderElection"`
+	// SyncPeriod is the minimum interval at which watched resources are
+	// reconciled.
+	SyncPeriod metav1.Duration `json:"syncPeriod"`
+	// ClusterConcurrency is the number of clusters reconciled at the same
+	// time.
+	ClusterConcurrency int `json:"clusterConcurrency"`
+	// WatchNamespaces and ClusterSelector restrict the operator to a shard
+	// of the Clusters.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	ClusterSelector string   `json:"clusterSelector,omitempty"`
+}
+
+// DNSProvider chooses where the base zone and the cluster zones are written
+// to.
+type DNSProvider struct {
+	Name    string  `json:"name"`
+	RFC2136 RFC2136 `json:"rfc2136"`
+}
+
+// RFC2136 configures the rfc2136 DNS provider. The TSIG secret is only read
+// from the environment.
+type RFC2136 struct {
+	Server        string `json:"server"`
+	TSIGKeyName   string `json:"tsigKeyName"`
+	TSIGAlgorithm string `json:"tsigAlgorithm"`
+}
+
+// Zone references an existing DNS zone.
+type Zone struct {
+	Name          string `json:"name"`
+	ResourceGroup string `json:"resourceGroup"`
+}
+
+// OrphanSweeper configures the sweeper of the DNS resources of clusters that
+// no longer exist.
+type OrphanSweeper struct {
+	Interval            metav1.Duration `json:"interval"`
+	DeletionGracePeriod metav1.Duration `json:"deletionGracePeriod"`
+}
+
+// ZoneExport configures the periodic export of the zones.
+type ZoneExport struct {
+	Dir        string          `json:"dir,omitempty"`
+	ConfigMaps bool            `json:"configMaps"`
+	Interval   metav1.Duration `json:"interval"`
+}
+
+// Preflight configures the preflight checks behind /readyz.
+type Preflight struct {
+	Interval metav1.Duration `json:"interval"`
+}
+
+// PropagationCheck configures the check of the delegation and the records
+// on the name servers of the zones.
+type PropagationCheck struct {
+	Enabled  bool   `json:"enabled"`
+	Resolver string `json:"resolver,omitempty"`
+}
+
+// Records configures the records the operator writes, it is reloaded while
+// the operator runs.
+type Records struct {
+	TTL     RecordTTLs `json:"ttl"`
+	Ingress Ingress    `json:"ingress"`
+
+	APIServerHostnameMode         string   `json:"apiServerHostnameMode"`
+	APIServerRecordMode           string   `json:"apiServerRecordMode"`
+	AdoptionPolicy                string   `json:"adoptionPolicy"`
+	PrivateRecordsMode            string   `json:"privateRecordsMode"`
+	SplitHorizonVirtualNetworkIDs []string `json:"splitHorizonVirtualNetworkIDs,omitempty"`
+	IntermediateZoneMode          string   `json:"intermediateZoneMode"`
+}
+
+// RecordTTLs are the TTLs in seconds of the records the operator writes.
+type RecordTTLs struct {
+	API     int64 `json:"api"`
+	Ingress int64 `json:"ingress"`
+	NS      int64 `json:"ns"`
+}
+
+// Ingress configures where the ingress controller services of non-Azure
+// workload clusters are looked up.
+type Ingress struct {
+	ServiceNamespaces []string `json:"serviceNamespaces"`
+	ServiceSelectors  []string `json:"serviceSelectors"`
+}
+
+// Requeue holds the intervals after which clusters are reconciled again, it
+// is reloaded while the operator runs.
+type Requeue struct {
+	InfrastructureNotFound metav1.Duration `json:"infrastructureNotFound"`
+	NotReady               metav1.Duration `json:"notReady"`
+	Reconciled             metav1.Duration `json:"reconciled"`
+}
+
+// Default returns the configuration the operator runs with if neither the
+// configuration file, the environment nor the flags set anything.
+func Default() *Config {
+	return &Config{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Manager: Manager{
+			MetricsBindAddress:     ":8080",
+			HealthProbeBindAddress: ":8081",
+			SyncPeriod:             metav1.Duration{Duration: 5 * time.Minute},
+			ClusterConcurrency:     5,
+		},
+		DNSProvider: DNSProvider{
+			Name: DNSProviderAzure,
+			RFC2136: RFC2136{
+				TSIGAlgorithm: rfc2136.AlgorithmHMACSHA256,
+			},
+		},
+		OrphanSweeper: OrphanSweeper{
+			Interval: metav1.Duration{Duration: time.Hour},
+		},
+		Preflight: Preflight{
+			Interval: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		ReloadInterval: metav1.Duration{Duration: time.Minute},
+		Records: Records{
+			TTL: RecordTTLs{
+				API:     azurescope.DefaultAPIRecordTTL,
+				Ingress: azurescope.DefaultIngressRecordTTL,
+				NS:      azurescope.DefaultNSRecordTTL,
+			},
+			Ingress: Ingress{
+				ServiceNamespaces: []string{azurescope.DefaultIngressServiceNamespace},
+				ServiceSelectors:  []string{azurescope.DefaultIngressServiceSelector},
+			},
+			APIServerHostnameMode: azurescope.APIServerHostnameModeCNAME,
+			APIServerRecordMode:   azurescope.APIServerRecordModeAddress,
+			AdoptionPolicy:        azurescope.AdoptionPolicyMatching,
+			PrivateRecordsMode:    azurescope.PrivateRecordsModePublic,
+			IntermediateZoneMode:  azurescope.IntermediateZoneModeNone,
+		},
+		Requeue: Requeue{
+			InfrastructureNotFound: metav1.Duration{Duration: time.Minute},
+			NotReady:               metav1.Duration{Duration: 2 * time.Minute},
+			Reconciled:             metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+}
+
+// Validate returns an InvalidConfigError listing every problem of the
+// configuration, each prefixed with the path of the field in the
+// configuration file.
+func (c *Config) Validate() error {
+	var problems []string
+	problem := func(field string, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+	check := func(field string, err error) {
+		if err != nil {
+			problem(field, "%s", strings.TrimPrefix(err.Error(), errors.InvalidConfigError.Error()+": "))
+		}
+	}
+	positive := func(field string, duration metav1.Duration) {
+		if duration.Duration <= 0 {
+			problem(field, "must be positive, got %s", duration.Duration)
+		}
+	}
+	nonNegative := func(field string, duration metav1.Duration) {
+		if duration.Duration < 0 {
+			problem(field, "must not be negative, got %s", duration.Duration)
+		}
+	}
+	ttl := func(field string, ttl int64) {
+		if ttl < 1 || ttl > maxRecordTTL {
+			problem(field, "must be between 1 and %d seconds, got %d", maxRecordTTL, ttl)
+		}
+	}
+
+	if c.APIVersion != APIVersion {
+		problem("apiVersion", "must be %q, got %q", APIVersion, c.APIVersion)
+	}
+	if c.Kind != Kind {
+		problem("kind", "must be %q, got %q", Kind, c.Kind)
+	}
+	if c.BaseDomain == "" {
+		problem("baseDomain", "must be set")
+	}
+
+	switch c.DNSProvider.Name {
+	case DNSProviderAzure:
+		if c.BaseDomainResourceGroup == "" {
+			problem("baseDomainResourceGroup", "must be set for the %s DNS provider", DNSProviderAzure)
+		}
+		if c.Azure.BaseZone.SubscriptionID == "" {
+			problem("azure.baseZone.subscriptionID", "must be set, in the file or with %s", EnvBaseZoneSubscriptionID)
+		}
+		if c.Azure.BaseZone.TenantID == "" {
+			problem("azure.baseZone.tenantID", "must be set, in the file or with %s", EnvBaseZoneTenantID)
+		}
+		if c.Azure.BaseZone.ClientID == "" {
+			problem("azure.baseZone.clientID", "must be set, in the file or with %s", EnvBaseZoneClientID)
+		}
+	case DNSProviderRFC2136:
+		if c.DNSProvider.RFC2136.Server == "" {
+			problem("dnsProvider.rfc2136.server", "must be set for the %s DNS provider", DNSProviderRFC2136)
+		}
+		switch c.DNSProvider.RFC2136.TSIGAlgorithm {
+		case rfc2136.AlgorithmHMACSHA1, rfc2136.AlgorithmHMACSHA256, rfc2136.AlgorithmHMACSHA512:
+		default:
+			problem("dnsProvider.rfc2136.tsigAlgorithm", "must be %q, %q or %q, got %q", rfc2136.AlgorithmHMACSHA1, rfc2136.AlgorithmHMACSHA256, rfc2136.AlgorithmHMACSHA512, c.DNSProvider.RFC2136.TSIGAlgorithm)
+		}
+		// alias records point to Azure resources, which other providers
+		// can't reference
+		if c.Records.APIServerRecordMode == azurescope.APIServerRecordModeAlias {
+			problem("records.apiServerRecordMode", "%q needs the %s DNS provider", c.Records.APIServerRecordMode, DNSProviderAzure)
+		}
+		// the private zones of split-horizon clusters are Azure private DNS
+		// zones
+		if c.Records.PrivateRecordsMode == azurescope.PrivateRecordsModeSplitHorizon {
+			problem("records.privateRecordsMode", "%q needs the %s DNS provider", c.Records.PrivateRecordsMode, DNSProviderAzure)
+		}
+	default:
+		problem("dnsProvider.name", "must be %q or %q, got %q", DNSProviderAzure, DNSProviderRFC2136, c.DNSProvider.Name)
+	}
+
+	for i, zone := range c.AdditionalZones {
+		if zone.Name == "" {
+			problem(fmt.Sprintf("additionalZones[%d].name", i), "must be set")
+		}
+		if zone.ResourceGroup == "" {
+			problem(fmt.Sprintf("additionalZones[%d].resourceGroup", i), "must be set")
+		}
+	}
+
+	positive("manager.syncPeriod", c.Manager.SyncPeriod)
+	if c.Manager.ClusterConcurrency < 1 {
+		problem("manager.clusterConcurrency", "must be at least 1, got %d", c.Manager.ClusterConcurrency)
+	}
+	if c.Manager.ClusterSelector != "" {
+		if _, err := labels.Parse(c.Manager.ClusterSelector); err != nil {
+			problem("manager.clusterSelector", "%s", err)
+		}
+	}
+
+	nonNegative("orphanSweeper.interval", c.OrphanSweeper.Interval)
+	nonNegative("orphanSweeper.deletionGracePeriod", c.OrphanSweeper.DeletionGracePeriod)
+	nonNegative("zoneExport.interval", c.ZoneExport.Interval)
+	nonNegative("preflight.interval", c.Preflight.Interval)
+	nonNegative("reloadInterval", c.ReloadInterval)
+
+	if c.PropagationCheck.Resolver != "" {
+		if _, _, err := net.SplitHostPort(c.PropagationCheck.Resolver); err != nil {
+			problem("propagationCheck.resolver", "must be host:port, got %q", c.PropagationCheck.Resolver)
+		}
+	}
+
+	ttl("records.ttl.api", c.Records.TTL.API)
+	ttl("records.ttl.ingress", c.Records.TTL.Ingress)
+	ttl("records.ttl.ns", c.Records.TTL.NS)
+	for i, selector := range c.Records.Ingress.ServiceSelectors {
+		if _, err := labels.Parse(selector); err != nil {
+			problem(fmt.Sprintf("records.ingress.serviceSelectors[%d]", i), "%s", err)
+		}
+	}
+	check("records.apiServerHostnameMode", azurescope.ValidateAPIServerHostnameMode(c.Records.APIServerHostnameMode))
+	check("records.apiServerRecordMode", azurescope.ValidateAPIServerRecordMode(c.Records.APIServerRecordMode))
+	check("records.adoptionPolicy", azurescope.ValidateAdoptionPolicy(c.Records.AdoptionPolicy))
+	check("records.privateRecordsMode", azurescope.ValidatePrivateRecordsMode(c.Records.PrivateRecordsMode))
+	check("records.intermediateZoneMode", azurescope.ValidateIntermediateZoneMode(c.Records.IntermediateZoneMode))
+	for i, id := range c.Records.SplitHorizonVirtualNetworkIDs {
+		_, err := azurescope.ParseVirtualNetworkIDs(id)
+		check(fmt.Sprintf("records.splitHorizonVirtualNetworkIDs[%d]", i), err)
+	}
+
+	positive("requeue.infrastructureNotFound", c.Requeue.InfrastructureNotFound)
+	positive("requeue.notReady", c.Requeue.NotReady)
+	positive("requeue.reconciled", c.Requeue.Reconciled)
+
+	if len(problems) > 0 {
+		return microerror.Maskf(errors.InvalidConfigError, "invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Checksum identifies the configuration, e.g. to tell which configuration
+// the replicas of the operator run with.
+func (c *Config) Checksum() string {
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// StaticChanges returns the paths of the top-level fields that differ between
+// two configurations and only apply when the operator starts.
+func StaticChanges(old *Config, new *Config) []string {
+	var changes []string
+
+	oldValue, newValue := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		if field.Name == "Records" || field.Name == "Requeue" {
+			continue
+		}
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			changes = append(changes, strings.Split(field.Tag.Get("json"), ",")[0])
+		}
+	}
+
+	return changes
+}
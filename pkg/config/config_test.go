@@ -0,0 +1,183 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
+)
+
+const validConfigFile = `apiVersion: dns-operator-azure.giantswarm.io/v1alpha1
+kind: OperatorConfig
+baseDomain: base.io
+baseDomainResourceGroup: dns_rg
+azure:
+  baseZone:
+    subscriptionID: file-subscription
+    tenantID: file-tenant
+    clientID: file-client
+records:
+  ttl:
+    api: 60
+  intermediateZoneMode: organization
+requeue:
+  reconciled: 10m
+`
+
+func Test_Loader_Load(t *testing.T) {
+	testCases := []struct {
+		name      string
+		file      string
+		env       map[string]string
+		flags     []string
+		expectErr string
+		check     func(t *testing.T, config *Config)
+	}{
+		{
+			name: "case0: the file overrides the defaults",
+			file: validConfigFile,
+			check: func(t *testing.T, config *Config) {
+				if config.Records.TTL.API != 60 || config.Records.TTL.NS != 3600 {
+					t.Errorf("ttl = %+v, want api 60 and ns 3600", config.Records.TTL)
+				}
+				if config.Requeue.Reconciled.Duration != 10*time.Minute || config.Requeue.NotReady.Duration != 2*time.Minute {
+					t.Errorf("requeue = %+v, want reconciled 10m and notReady 2m", config.Requeue)
+				}
+				if config.Manager.ClusterConcurrency != 5 {
+					t.Errorf("clusterConcurrency = %d, want 5", config.Manager.ClusterConcurrency)
+				}
+			},
+		},
+		{
+			name:  "case1: the environment overrides the file, the flags override both",
+			file:  validConfigFile,
+			env:   map[string]string{EnvBaseZoneSubscriptionID: "env-subscription", EnvBaseZoneTenantID: "env-tenant"},
+			flags: []string{"--intermediate-zone-mode=region", "--ingress-service-selectors=app=a; app=b", "--additional-zones=Other.IO.=other_rg"},
+			check: func(t *testing.T, config *Config) {
+				if config.Azure.BaseZone.SubscriptionID != "env-subscription" || config.Azure.BaseZone.ClientID != "file-client" {
+					t.Errorf("baseZone = %+v, want the subscription of the environment and the client of the file", config.Azure.BaseZone)
+				}
+				if config.Records.IntermediateZoneMode != "region" {
+					t.Errorf("intermediateZoneMode = %q, want region", config.Records.IntermediateZoneMode)
+				}
+				if expect := []string{"app=a", "app=b"}; !reflect.DeepEqual(config.Records.Ingress.ServiceSelectors, expect) {
+					t.Errorf("serviceSelectors = %#v, want %#v", config.Records.Ingress.ServiceSelectors, expect)
+				}
+				if expect := []Zone{{Name: "other.io", ResourceGroup: "other_rg"}}; !reflect.DeepEqual(config.AdditionalZones, expect) {
+					t.Errorf("additionalZones = %#v, want %#v", config.AdditionalZones, expect)
+				}
+			},
+		},
+		{
+			name:      "case2: other versions are refused",
+			file:      strings.Replace(validConfigFile, "v1alpha1", "v1", 1),
+			expectErr: `unsupported configuration file: apiVersion must be "dns-operator-azure.giantswarm.io/v1alpha1" and kind "OperatorConfig", got "dns-operator-azure.giantswarm.io/v1" and "OperatorConfig"`,
+		},
+		{
+			name:      "case3: unknown fields are refused",
+			file:      validConfigFile + "recordTTL: 60\n",
+			expectErr: `unknown field "recordTTL"`,
+		},
+		{
+			name: "case4: all problems are reported with their field",
+			file: validConfigFile + "manager:\n  clusterConcurrency: 0\n",
+			env:  map[string]string{},
+			flags: []string{
+				"--base-domain-resource-group=",
+				"--adoption-policy=always",
+				"--split-horizon-virtual-network-ids=hub",
+			},
+			expectErr: `invalid configuration: baseDomainResourceGroup: must be set for the azure DNS provider; ` +
+				`manager.clusterConcurrency: must be at least 1, got 0; ` +
+				`records.adoptionPolicy: adoption policy must be "matching" or "takeover", got "always"; ` +
+				`records.splitHorizonVirtualNetworkIDs[0]: "hub" is not a virtual network resource ID`,
+		},
+		{
+			name:      "case5: the rfc2136 provider can't publish alias records",
+			file:      validConfigFile,
+			flags:     []string{"--dns-provider=rfc2136", "--rfc2136-server=ns.base.io:53", "--api-server-record-mode=alias"},
+			expectErr: `invalid configuration: records.apiServerRecordMode: "alias" needs the azure DNS provider`,
+		},
+		{
+			name:      "case6: the TTLs must be positive",
+			file:      strings.Replace(validConfigFile, "api: 60", "api: 0", 1),
+			expectErr: "invalid configuration: records.ttl.api: must be between 1 and 2147483647 seconds, got 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			loader := Loader{
+				Path:   path,
+				Getenv: func(key string) string { return tc.env[key] },
+				Flags:  tc.flags,
+			}
+			config, err := loader.Load()
+
+			switch {
+			case tc.expectErr == "" && err != nil:
+				t.Fatalf("unexpected error %v", err)
+			case tc.expectErr != "" && err == nil:
+				t.Fatalf("expected error %q", tc.expectErr)
+			case tc.expectErr != "":
+				if !errors.IsInvalidConfig(err) {
+					t.Errorf("expected invalid config error, got %#v", err)
+				}
+				if !strings.Contains(err.Error(), tc.expectErr) {
+					t.Errorf("error = %q, want %q", err.Error(), tc.expectErr)
+				}
+				return
+			}
+
+			tc.check(t, config)
+		})
+	}
+}
+
+func Test_FlagOverrides(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlags(fs, Default())
+	other := fs.String("config", "", "")
+	if err := fs.Parse([]string{"--config=/etc/config.yaml", "--watch-namespaces=org-a,org-b", "--sync-period=1m"}); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := FlagOverrides(fs)
+	expect := []string{"--sync-period=1m0s", "--watch-namespaces=org-a,org-b"}
+	if !reflect.DeepEqual(overrides, expect) {
+		t.Errorf("FlagOverrides() = %#v, want %#v", overrides, expect)
+	}
+	if *other != "/etc/config.yaml" {
+		t.Errorf("config = %q, want /etc/config.yaml", *other)
+	}
+}
+
+func Test_StaticChanges(t *testing.T) {
+	old := Default()
+
+	changed := Default()
+	changed.Records.TTL.API = 60
+	changed.Requeue.Reconciled.Duration = time.Minute
+	if changes := StaticChanges(old, changed); len(changes) != 0 {
+		t.Errorf("StaticChanges() = %v, want none for records and requeue", changes)
+	}
+
+	changed.ManagementLocks = true
+	changed.Manager.ClusterConcurrency = 10
+	if changes, expect := StaticChanges(old, changed), []string{"manager", "managementLocks"}; !reflect.DeepEqual(changes, expect) {
+		t.Errorf("StaticChanges() = %v, want %v", changes, expect)
+	}
+	if old.Checksum() == changed.Checksum() {
+		t.Errorf("checksums of different configurations are equal")
+	}
+}
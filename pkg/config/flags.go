@@ -0,0 +1,152 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	azurescope "github.com/giantswarm/dns-operator-azure/v3/azure/scope"
+)
+
+// BindFlags registers the command line flags overriding the configuration on
+// fs, with the current values of config as defaults.
+func BindFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.Manager.MetricsBindAddress, "metrics-addr", config.Manager.MetricsBindAddress, "The address the metric endpoint binds to.")
+	fs.StringVar(&config.Manager.HealthProbeBindAddress, "health-probe-bind-address", config.Manager.HealthProbeBindAddress, "The address the health and readiness probe endpoints bind to.")
+	fs.BoolVar(&config.Manager.LeaderElection, "enable-leader-election", config.Manager.LeaderElection,
+		"Enable leader election for controller manager. "+
+			"Enabling this will ensure there is only one active controller manager.")
+	fs.StringVar(&config.BaseDomain, "base-domain", config.BaseDomain,
+		"Domain for which to create the DNS entries, e.g. customer.gigantic.io.")
+	fs.StringVar(&config.BaseDomainResourceGroup, "base-domain-resource-group", config.BaseDomainResourceGroup,
+		"Resource Group where the base-domain is placed.")
+	fs.DurationVar(&config.Manager.SyncPeriod.Duration, "sync-period", config.Manager.SyncPeriod.Duration,
+		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
+	fs.IntVar(&config.Manager.ClusterConcurrency, "cluster-concurrency", config.Manager.ClusterConcurrency,
+		"Number of clusters to process simultaneously")
+	fs.StringVar(&config.ManagementCluster.Name, "management-cluster-name", config.ManagementCluster.Name,
+		"The name of the management cluster where this operator is running (also MC AzureCluster CR name)")
+	fs.StringVar(&config.ManagementCluster.Namespace, "management-cluster-namespace", config.ManagementCluster.Namespace,
+		"The namespace where the management cluster AzureCluster CR is deployed")
+	fs.StringVar(&config.Azure.IdentityRef.Name, "azure-identity-ref-name", config.Azure.IdentityRef.Name,
+		"The name of the Azure Cluster Identity reference to be used when reconciling non-Azure clusters")
+	fs.StringVar(&config.Azure.IdentityRef.Namespace, "azure-identity-ref-namespace", config.Azure.IdentityRef.Namespace,
+		"The namespace of the Azure Cluster Identity reference to be used when reconciling non-Azure clusters")
+	fs.Var(&listValue{list: &config.Records.Ingress.ServiceNamespaces, sep: ","}, "ingress-service-namespaces",
+		"Comma separated list of workload cluster namespaces to look for ingress controller services in (non-Azure clusters)")
+	fs.Var(&listValue{list: &config.Records.Ingress.ServiceSelectors, sep: ";"}, "ingress-service-selectors",
+		"Semicolon separated list of label selectors matching ingress controller services (non-Azure clusters)")
+	fs.Var(&zonesValue{zones: &config.AdditionalZones}, "additional-zones",
+		"Comma separated list of <zone>=<resource group> pairs, reachable with the base zone credentials, that service hostnames may be published in")
+	fs.StringVar(&config.Records.AdoptionPolicy, "adoption-policy", config.Records.AdoptionPolicy,
+		"How existing records in cluster zones are adopted: matching only adopts records pointing to the desired target, takeover also overwrites conflicting records")
+	fs.StringVar(&config.Records.APIServerHostnameMode, "api-server-hostname-mode", config.Records.APIServerHostnameMode,
+		"How api records of non-Azure clusters with a hostname control plane endpoint are published: cname or resolve")
+	fs.StringVar(&config.Records.APIServerRecordMode, "api-server-record-mode", config.Records.APIServerRecordMode,
+		"How api records of CAPZ clusters with a public API server are published: address copies the public IP address, alias points to the public IP resource")
+	fs.StringVar(&config.Records.PrivateRecordsMode, "private-records-mode", config.Records.PrivateRecordsMode,
+		"Where records with private IPs, e.g. the api records of private clusters, are published: public in the public cluster zone, split-horizon only in a private DNS zone of the same name")
+	fs.Var(&listValue{list: &config.Records.SplitHorizonVirtualNetworkIDs, sep: ","}, "split-horizon-virtual-network-ids",
+		"Comma separated list of virtual network IDs the private DNS zones of split-horizon clusters are linked to, besides the virtual network of CAPZ clusters")
+	fs.StringVar(&config.Records.IntermediateZoneMode, "intermediate-zone-mode", config.Records.IntermediateZoneMode,
+		"Which zone new cluster zones are delegated from: none from the base zone, organization or region from a <organization or region>.<base domain> zone created on demand")
+	fs.BoolVar(&config.ManagementLocks, "management-locks", config.ManagementLocks,
+		"Place CanNotDelete management locks on cluster zones and on the resource groups of non-Azure clusters, recreated if removed")
+	fs.Var(&listValue{list: &config.Manager.WatchNamespaces, sep: ","}, "watch-namespaces",
+		"Comma separated list of namespaces to watch Clusters in, all namespaces if empty")
+	fs.StringVar(&config.Manager.ClusterSelector, "cluster-selector", config.Manager.ClusterSelector,
+		"Label selector the watched Clusters must match, all Clusters if empty")
+	fs.DurationVar(&config.OrphanSweeper.Interval.Duration, "orphan-sweep-interval", config.OrphanSweeper.Interval.Duration,
+		"Interval at which NS delegations, zones and resource groups of clusters that no longer exist are looked for, disabled if 0")
+	fs.DurationVar(&config.OrphanSweeper.DeletionGracePeriod.Duration, "orphan-deletion-grace-period", config.OrphanSweeper.DeletionGracePeriod.Duration,
+		"Time orphaned DNS resources owned by the operator are kept before they are deleted, never deleted if 0")
+	fs.StringVar(&config.ZoneExport.Dir, "zone-export-dir", config.ZoneExport.Dir,
+		"Directory the base zone and the cluster zones are exported to as <zone>.zone files")
+	fs.BoolVar(&config.ZoneExport.ConfigMaps, "zone-export-configmaps", config.ZoneExport.ConfigMaps,
+		"Export each cluster zone to the <cluster>-dns-zone ConfigMap in the namespace of the Cluster, and the base zone to the management cluster namespace")
+	fs.DurationVar(&config.ZoneExport.Interval.Duration, "zone-export-interval", config.ZoneExport.Interval.Duration,
+		"Interval at which the operator exports the zones, disabled if 0")
+	fs.DurationVar(&config.Preflight.Interval.Duration, "preflight-interval", config.Preflight.Interval.Duration,
+		"Interval of the preflight checks of the base zone and the management cluster behind /readyz. They only run at startup if 0.")
+	fs.BoolVar(&config.PropagationCheck.Enabled, "propagation-check", config.PropagationCheck.Enabled,
+		"Ask the name servers of the base zone and the cluster zones for the NS delegation and the records after every reconciliation.")
+	fs.StringVar(&config.PropagationCheck.Resolver, "propagation-check-resolver", config.PropagationCheck.Resolver,
+		"host:port all propagation check queries are sent to instead of the name servers of the zones, e.g. a local DNS server.")
+	fs.StringVar(&config.DNSProvider.Name, "dns-provider", config.DNSProvider.Name,
+		"Where the base zone and the cluster zones are written to: azure for Azure DNS, rfc2136 for a name server accepting dynamic updates")
+	fs.StringVar(&config.DNSProvider.RFC2136.Server, "rfc2136-server", config.DNSProvider.RFC2136.Server,
+		"host:port of the primary name server the rfc2136 provider sends updates and zone transfers to")
+	fs.StringVar(&config.DNSProvider.RFC2136.TSIGKeyName, "rfc2136-tsig-key-name", config.DNSProvider.RFC2136.TSIGKeyName,
+		"Name of the TSIG key signing the messages of the rfc2136 provider, whose base64 encoded secret is read from RFC2136_TSIG_SECRET. Messages are unsigned if empty.")
+	fs.StringVar(&config.DNSProvider.RFC2136.TSIGAlgorithm, "rfc2136-tsig-algorithm", config.DNSProvider.RFC2136.TSIGAlgorithm,
+		"Algorithm of the TSIG key: hmac-sha1, hmac-sha256 or hmac-sha512")
+}
+
+// FlagOverrides returns the flags registered by BindFlags that were set on
+// the parsed fs, as arguments for Loader.Flags.
+func FlagOverrides(fs *flag.FlagSet) []string {
+	known := flag.NewFlagSet("config", flag.ContinueOnError)
+	BindFlags(known, Default())
+
+	var overrides []string
+	fs.Visit(func(f *flag.Flag) {
+		if known.Lookup(f.Name) != nil {
+			overrides = append(overrides, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+		}
+	})
+	return overrides
+}
+
+// listValue is a flag holding a list given as items separated by sep.
+type listValue struct {
+	list *[]string
+	sep  string
+}
+
+func (v *listValue) String() string {
+	if v.list == nil {
+		return ""
+	}
+	return strings.Join(*v.list, v.sep)
+}
+
+func (v *listValue) Set(value string) error {
+	var items []string
+	for _, item := range strings.Split(value, v.sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v.list = items
+	return nil
+}
+
+// zonesValue is a flag holding zones given as a comma separated list of
+// <zone>=<resource group> pairs.
+type zonesValue struct {
+	zones *[]Zone
+}
+
+func (v *zonesValue) String() string {
+	if v.zones == nil {
+		return ""
+	}
+	var pairs []string
+	for _, zone := range *v.zones {
+		pairs = append(pairs, zone.Name+"="+zone.ResourceGroup)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v *zonesValue) Set(value string) error {
+	parsed, err := azurescope.ParseZones(value)
+	if err != nil {
+		return err
+	}
+	var zones []Zone
+	for _, zone := range parsed {
+		zones = append(zones, Zone{Name: zone.Name, ResourceGroup: zone.ResourceGroup})
+	}
+	*v.zones = zones
+	return nil
+}
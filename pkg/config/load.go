@@ -0,0 +1,109 @@
+package config
+
+import (
+	"flag"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/dns-operator-azure/v3/pkg/errors"
+)
+
+// Environment variables overriding the configuration file. The client
+// secrets and the TSIG secret are only read from the environment and are not
+// part of the configuration.
+const (
+	EnvBaseZoneSubscriptionID = "AZURE_SUBSCRIPTION_ID"
+	EnvBaseZoneTenantID       = "AZURE_TENANT_ID"
+	EnvBaseZoneClientID       = "AZURE_CLIENT_ID"
+
+	EnvClusterZoneSubscriptionID = "CLUSTER_AZURE_SUBSCRIPTION_ID"
+	EnvClusterZoneTenantID       = "CLUSTER_AZURE_TENANT_ID"
+	EnvClusterZoneClientID       = "CLUSTER_AZURE_CLIENT_ID"
+	EnvClusterZoneLocation       = "CLUSTER_AZURE_LOCATION"
+)
+
+// Loader loads the configuration: the defaults, overridden by the
+// configuration file, overridden by the environment, overridden by the
+// command line flags.
+type Loader struct {
+	// Path of the configuration file, only the defaults are overridden if
+	// empty.
+	Path string
+	// Getenv looks up the environment variables, e.g. os.Getenv. The
+	// environment is ignored if nil.
+	Getenv func(string) string
+	// Flags are the command line flags set explicitly, see FlagOverrides.
+	Flags []string
+}
+
+// Load loads and validates the configuration. It returns an
+// InvalidConfigError if the configuration file can't be parsed or the
+// configuration is invalid.
+func (l Loader) Load() (*Config, error) {
+	config := Default()
+
+	if l.Path != "" {
+		data, err := os.ReadFile(l.Path)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		config, err = Parse(data)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if l.Getenv != nil {
+		for env, field := range map[string]*string{
+			EnvBaseZoneSubscriptionID:    &config.Azure.BaseZone.SubscriptionID,
+			EnvBaseZoneTenantID:          &config.Azure.BaseZone.TenantID,
+			EnvBaseZoneClientID:          &config.Azure.BaseZone.ClientID,
+			EnvClusterZoneSubscriptionID: &config.Azure.ClusterZone.SubscriptionID,
+			EnvClusterZoneTenantID:       &config.Azure.ClusterZone.TenantID,
+			EnvClusterZoneClientID:       &config.Azure.ClusterZone.ClientID,
+			EnvClusterZoneLocation:       &config.Azure.ClusterZone.Location,
+		} {
+			if value := l.Getenv(env); value != "" {
+				*field = value
+			}
+		}
+	}
+
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	BindFlags(flags, config)
+	if err := flags.Parse(l.Flags); err != nil {
+		return nil, microerror.Maskf(errors.InvalidConfigError, "%s", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return config, nil
+}
+
+// Parse parses a configuration file over the defaults. Unknown fields are an
+// error, so that typos don't go unnoticed.
+func Parse(data []byte) (*Config, error) {
+	// the version of the file is checked before its fields, which other
+	// versions may name differently
+	var header struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, microerror.Maskf(errors.InvalidConfigError, "invalid configuration file: %s", err)
+	}
+	if header.APIVersion != APIVersion || header.Kind != Kind {
+		return nil, microerror.Maskf(errors.InvalidConfigError, "unsupported configuration file: apiVersion must be %q and kind %q, got %q and %q", APIVersion, Kind, header.APIVersion, header.Kind)
+	}
+
+	config := Default()
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, microerror.Maskf(errors.InvalidConfigError, "invalid configuration file: %s", err)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,21 @@
+package dnsquery
+
+import "github.com/giantswarm/microerror"
+
+// IsInvalidQuery asserts invalidQueryError.
+func IsInvalidQuery(err error) bool {
+	return microerror.Cause(err) == invalidQueryError
+}
+
+var invalidQueryError = &microerror.Error{
+	Kind: "invalidQueryError",
+}
+
+// IsInvalidResponse asserts invalidResponseError.
+func IsInvalidResponse(err error) bool {
+	return microerror.Cause(err) == invalidResponseError
+}
+
+var invalidResponseError = &microerror.Error{
+	Kind: "invalidResponseError",
+}
@@ -0,0 +1,224 @@
+// Package dnsquery sends single, non-recursive DNS queries directly to a name
+// server, e.g. to check what the authoritative servers of a zone answer.
+package dnsquery
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultTimeout bounds a single query, including the TCP retry of
+	// truncated answers.
+	DefaultTimeout = 5 * time.Second
+
+	dnsPort = "53"
+)
+
+// Answer holds the records a name server returned for a query. NS records
+// are also collected from the authority section, where parent zones return
+// delegations. Names are lowercase and without trailing dot.
+type Answer struct {
+	A     []string
+	CNAME string
+	NS    []string
+}
+
+// Querier sends queries to the name server they are meant for, or to Resolver
+// if set.
+type Querier struct {
+	// Resolver is a "host:port" all queries are sent to instead of the
+	// queried name server, e.g. a local DNS server in tests.
+	Resolver string
+	Timeout  time.Duration
+}
+
+// New creates a querier sending all queries to resolver, or to the queried
+// name servers if it is empty.
+func New(resolver string) *Querier {
+	return &Querier{
+		Resolver: resolver,
+		Timeout:  DefaultTimeout,
+	}
+}
+
+// Query asks server, a host name or IP address optionally with port, for the
+// records of type qtype of name. An unknown name is no error but an empty
+// answer.
+func (q *Querier) Query(ctx context.Context, server, name string, qtype dnsmessage.Type) (Answer, error) {
+	address := q.Resolver
+	if address == "" {
+		address = server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			address = net.JoinHostPort(server, dnsPort)
+		}
+	}
+
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return Answer{}, microerror.Maskf(invalidQueryError, "%s: %s", name, err)
+	}
+
+	id := uint16(rand.Intn(1 << 16)) //nolint:gosec
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return Answer{}, microerror.Mask(err)
+	}
+
+	timeout := q.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := exchange(ctx, "udp", address, query)
+	if err != nil {
+		return Answer{}, microerror.Mask(err)
+	}
+
+	answer, truncated, err := parse(response, id, qname)
+	if err == nil && truncated {
+		response, err = exchange(ctx, "tcp", address, query)
+		if err != nil {
+			return Answer{}, microerror.Mask(err)
+		}
+		answer, _, err = parse(response, id, qname)
+	}
+	if err != nil {
+		return Answer{}, microerror.Maskf(invalidResponseError, "%s from %s: %s", name, address, err)
+	}
+
+	return answer, nil
+}
+
+func exchange(ctx context.Context, network, address string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, microerror.Mask(err)
+		}
+		response := make([]byte, 1232)
+		n, err := conn.Read(response)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return response[:n], nil
+	}
+
+	// DNS over TCP prefixes messages with their length
+	message := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(message, uint16(len(query)))
+	copy(message[2:], query)
+	if _, err := conn.Write(message); err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	length := make([]byte, 2)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return nil, microerror.Mask(err)
+	}
+	response := make([]byte, binary.BigEndian.Uint16(length))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, microerror.Mask(err)
+	}
+	return response, nil
+}
+
+// parse returns the records of response for qname and whether it was
+// truncated.
+func parse(response []byte, id uint16, qname dnsmessage.Name) (Answer, bool, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil {
+		return Answer{}, false, err
+	}
+	if header.ID != id || !header.Response {
+		return Answer{}, false, errors.New("response doesn't match the query")
+	}
+	if header.Truncated {
+		return Answer{}, true, nil
+	}
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return Answer{}, false, nil
+	default:
+		return Answer{}, false, fmt.Errorf("response code %s", header.RCode)
+	}
+
+	if err := parser.SkipAllQuestions(); err != nil {
+		return Answer{}, false, err
+	}
+
+	var answer Answer
+	answers, err := parser.AllAnswers()
+	if err != nil {
+		return Answer{}, false, err
+	}
+	authorities, err := parser.AllAuthorities()
+	if err != nil {
+		return Answer{}, false, err
+	}
+
+	for _, resource := range answers {
+		if !strings.EqualFold(resource.Header.Name.String(), qname.String()) {
+			continue
+		}
+		switch body := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			answer.A = append(answer.A, net.IP(body.A[:]).String())
+		case *dnsmessage.CNAMEResource:
+			answer.CNAME = normalize(body.CNAME.String())
+		case *dnsmessage.NSResource:
+			answer.NS = append(answer.NS, normalize(body.NS.String()))
+		}
+	}
+	for _, resource := range authorities {
+		if body, ok := resource.Body.(*dnsmessage.NSResource); ok && strings.EqualFold(resource.Header.Name.String(), qname.String()) {
+			answer.NS = append(answer.NS, normalize(body.NS.String()))
+		}
+	}
+
+	sort.Strings(answer.A)
+	sort.Strings(answer.NS)
+
+	return answer, false, nil
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,143 @@
+package dnsquery
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers the queries sent to the returned UDP address with the
+// resources built by answer, until the test ends.
+func serveDNS(t *testing.T, answer func(question dnsmessage.Question) dnsmessage.Message) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buffer[:n]); err != nil {
+				continue
+			}
+			response := answer(query.Questions[0])
+			response.ID = query.ID
+			response.Response = true
+			response.Questions = query.Questions
+
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestQuerier_Query(t *testing.T) {
+	name := func(name string) dnsmessage.Name {
+		return dnsmessage.MustNewName(name)
+	}
+	header := func(owner string, qtype dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name(owner), Type: qtype, Class: dnsmessage.ClassINET, TTL: 300}
+	}
+
+	resolver := serveDNS(t, func(question dnsmessage.Question) dnsmessage.Message {
+		switch question.Name.String() {
+		case "api.glippy.example.com.":
+			return dnsmessage.Message{
+				Header: dnsmessage.Header{Authoritative: true},
+				Answers: []dnsmessage.Resource{
+					{Header: header("api.glippy.example.com.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{5, 6, 7, 8}}},
+					{Header: header("api.glippy.example.com.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{1, 2, 3, 4}}},
+				},
+			}
+		case "*.glippy.example.com.":
+			return dnsmessage.Message{
+				Header: dnsmessage.Header{Authoritative: true},
+				Answers: []dnsmessage.Resource{
+					{Header: header("*.glippy.example.com.", dnsmessage.TypeCNAME), Body: &dnsmessage.CNAMEResource{CNAME: name("Ingress.glippy.example.com.")}},
+				},
+			}
+		case "glippy.example.com.":
+			// parent zones answer with a referral
+			return dnsmessage.Message{
+				Authorities: []dnsmessage.Resource{
+					{Header: header("glippy.example.com.", dnsmessage.TypeNS), Body: &dnsmessage.NSResource{NS: name("ns2-01.azure-dns.net.")}},
+					{Header: header("glippy.example.com.", dnsmessage.TypeNS), Body: &dnsmessage.NSResource{NS: name("ns1-01.azure-dns.com.")}},
+				},
+			}
+		case "broken.glippy.example.com.":
+			return dnsmessage.Message{Header: dnsmessage.Header{RCode: dnsmessage.RCodeServerFailure}}
+		}
+		return dnsmessage.Message{Header: dnsmessage.Header{Authoritative: true, RCode: dnsmessage.RCodeNameError}}
+	})
+
+	testCases := []struct {
+		name          string
+		queryName     string
+		qtype         dnsmessage.Type
+		expectAnswer  Answer
+		errorMatching func(error) bool
+	}{
+		{
+			name:         "case0: A records",
+			queryName:    "api.glippy.example.com",
+			qtype:        dnsmessage.TypeA,
+			expectAnswer: Answer{A: []string{"1.2.3.4", "5.6.7.8"}},
+		},
+		{
+			name:         "case1: CNAME record",
+			queryName:    "*.glippy.example.com",
+			qtype:        dnsmessage.TypeCNAME,
+			expectAnswer: Answer{CNAME: "ingress.glippy.example.com"},
+		},
+		{
+			name:         "case2: delegation in the authority section",
+			queryName:    "glippy.example.com.",
+			qtype:        dnsmessage.TypeNS,
+			expectAnswer: Answer{NS: []string{"ns1-01.azure-dns.com", "ns2-01.azure-dns.net"}},
+		},
+		{
+			name:      "case3: unknown name",
+			queryName: "missing.glippy.example.com",
+			qtype:     dnsmessage.TypeA,
+		},
+		{
+			name:          "case4: server failure",
+			queryName:     "broken.glippy.example.com",
+			qtype:         dnsmessage.TypeA,
+			errorMatching: IsInvalidResponse,
+		},
+	}
+
+	querier := New(resolver)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			answer, err := querier.Query(context.TODO(), "ns1-01.azure-dns.com", tc.queryName, tc.qtype)
+			switch {
+			case tc.errorMatching == nil && err != nil:
+				t.Fatalf("unexpected error %v", err)
+			case tc.errorMatching != nil && !tc.errorMatching(err):
+				t.Fatalf("unexpected error %#v", err)
+			}
+			if !reflect.DeepEqual(answer, tc.expectAnswer) {
+				t.Errorf("Query() = %+v, want %+v", answer, tc.expectAnswer)
+			}
+		})
+	}
+}
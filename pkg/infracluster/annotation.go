@@ -1,11 +1,86 @@
 package infracluster
 
-import "strings"
+import (
+	"net"
+	"strconv"
+	"strings"
+)
 
 const (
 	ResourceTagNamePrefix = "azure-resourcegroup-tag."
+
+	// AnnotationDNSDisabled is the annotation on the Cluster object that opts
+	// the cluster out of DNS management entirely: no finalizer, no zone, no NS
+	// delegation and no records.
+	AnnotationDNSDisabled = "dns-operator-azure.giantswarm.io/disabled"
+
+	// AnnotationManagedRecords is the annotation on the Cluster object that
+	// limits DNS management to a comma separated list of ManagedRecords values.
+	// The cluster zone and its NS delegation are always managed. Everything is
+	// managed if the annotation is missing.
+	AnnotationManagedRecords = "dns-operator-azure.giantswarm.io/managed-records"
+
+	// ManagedRecordsZone covers the cluster zone and its NS delegation in the
+	// base zone.
+	ManagedRecordsZone = "zone"
+	// ManagedRecordsRecords covers the A and CNAME records, e.g. api and
+	// ingress records.
+	ManagedRecordsRecords = "records"
+	// ManagedRecordsPrivate covers the private DNS zones and their records.
+	ManagedRecordsPrivate = "private"
+
+	// AnnotationImportZoneConfigMap is the annotation on the Cluster object
+	// naming a ConfigMap in the namespace of the Cluster that holds a zone
+	// file to import into the cluster zone.
+	AnnotationImportZoneConfigMap = "dns-operator-azure.giantswarm.io/import-zone-configmap"
+	// AnnotationImportedZoneChecksum is the annotation on the Cluster object
+	// recording the checksum of the last zone file imported from the
+	// ConfigMap named by AnnotationImportZoneConfigMap. Unchanged zone files
+	// aren't imported again.
+	AnnotationImportedZoneChecksum = "dns-operator-azure.giantswarm.io/imported-zone-checksum"
+
+	// AnnotationAPIServerAddresses is the annotation on the Cluster object that
+	// lists further IPv4 addresses of the control plane, comma separated, e.g.
+	// additional VIPs of vSphere clusters. They are published in the api and
+	// apiserver records besides the control plane endpoint of non-Azure
+	// clusters.
+	AnnotationAPIServerAddresses = "dns-operator-azure.giantswarm.io/api-server-addresses"
 )
 
+// IsDNSManagementDisabled reports whether the annotations opt a cluster out
+// of DNS management.
+func IsDNSManagementDisabled(annotations map[string]string) bool {
+	disabled, _ := strconv.ParseBool(annotations[AnnotationDNSDisabled])
+	return disabled
+}
+
+// ManagesRecords reports whether the annotations allow the operator to manage
+// the given ManagedRecords value.
+func ManagesRecords(annotations map[string]string, managedRecords string) bool {
+	value, ok := annotations[AnnotationManagedRecords]
+	if !ok || managedRecords == ManagedRecordsZone {
+		return true
+	}
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == managedRecords {
+			return true
+		}
+	}
+	return false
+}
+
+// APIServerAddresses returns the IPv4 addresses listed in the
+// AnnotationAPIServerAddresses annotation. Invalid addresses are skipped.
+func APIServerAddresses(annotations map[string]string) []net.IP {
+	var addresses []net.IP
+	for _, item := range strings.Split(annotations[AnnotationAPIServerAddresses], ",") {
+		if ip := net.ParseIP(strings.TrimSpace(item)).To4(); ip != nil {
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses
+}
+
 func GetResourceTagsFromInfraClusterAnnotations(annotations map[string]string) map[string]*string {
 	if annotations == nil {
 		return nil
@@ -114,3 +114,167 @@ func Test_GetResourceTagsFromInfraClusterAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func Test_AzureResourceTags(t *testing.T) {
+	testCases := []struct {
+		name         string
+		infraCluster runtime.Object
+		expectedTags map[string]string
+	}{
+		{
+			name: "case0: AdditionalTags of AzureClusters are used",
+			infraCluster: &infrav1.AzureCluster{
+				TypeMeta: metav1.TypeMeta{Kind: "AzureCluster"},
+				Spec: infrav1.AzureClusterSpec{
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						AdditionalTags: infrav1.Tags{"cost-center": "1234"},
+					},
+				},
+			},
+			expectedTags: map[string]string{"cost-center": "1234"},
+		},
+		{
+			name: "case1: annotations override AdditionalTags",
+			infraCluster: &infrav1.AzureCluster{
+				TypeMeta: metav1.TypeMeta{Kind: "AzureCluster"},
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"azure-resourcegroup-tag.cost-center": "5678",
+						"azure-resourcegroup-tag.team":        "rocket",
+					},
+				},
+				Spec: infrav1.AzureClusterSpec{
+					AzureClusterClassSpec: infrav1.AzureClusterClassSpec{
+						AdditionalTags: infrav1.Tags{"cost-center": "1234", "env": "prod"},
+					},
+				},
+			},
+			expectedTags: map[string]string{"cost-center": "5678", "team": "rocket", "env": "prod"},
+		},
+		{
+			name: "case2: other infrastructure clusters are tagged from annotations only",
+			infraCluster: &unstructured.Unstructured{Object: map[string]interface{}{
+				"kind": "VSphereCluster",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{"azure-resourcegroup-tag.team": "rocket"},
+				},
+			}},
+			expectedTags: map[string]string{"team": "rocket"},
+		},
+		{
+			name: "case3: no tags",
+			infraCluster: &infrav1.AzureCluster{
+				TypeMeta: metav1.TypeMeta{Kind: "AzureCluster"},
+			},
+			expectedTags: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			infraClusterObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tc.infraCluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scope := Scope{
+				InfraCluster: &unstructured.Unstructured{Object: infraClusterObj},
+			}
+			tags := scope.AzureResourceTags()
+			if len(tags) != len(tc.expectedTags) {
+				t.Fatalf("expected %d tags, got %d", len(tc.expectedTags), len(tags))
+			}
+			for key, value := range tc.expectedTags {
+				if tags[key] == nil {
+					t.Fatalf("expected tag %s not found", key)
+				}
+				if *tags[key] != value {
+					t.Fatalf("expected tag %s value %s, got %s", key, value, *tags[key])
+				}
+			}
+		})
+	}
+}
+
+func Test_IsDNSManagementDisabled(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:     "case0: no annotations",
+			expected: false,
+		},
+		{
+			name:        "case1: disabled",
+			annotations: map[string]string{AnnotationDNSDisabled: "true"},
+			expected:    true,
+		},
+		{
+			name:        "case2: explicitly enabled",
+			annotations: map[string]string{AnnotationDNSDisabled: "false"},
+			expected:    false,
+		},
+		{
+			name:        "case3: invalid value keeps DNS management enabled",
+			annotations: map[string]string{AnnotationDNSDisabled: "yes please"},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if disabled := IsDNSManagementDisabled(tc.annotations); disabled != tc.expected {
+				t.Errorf("IsDNSManagementDisabled() = %t, want %t", disabled, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_ManagesRecords(t *testing.T) {
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedZone    bool
+		expectedRecords bool
+		expectedPrivate bool
+	}{
+		{
+			name:            "case0: everything is managed without annotation",
+			expectedZone:    true,
+			expectedRecords: true,
+			expectedPrivate: true,
+		},
+		{
+			name:         "case1: zone and NS only",
+			annotations:  map[string]string{AnnotationManagedRecords: "zone"},
+			expectedZone: true,
+		},
+		{
+			name:            "case2: skip private DNS",
+			annotations:     map[string]string{AnnotationManagedRecords: "zone, records"},
+			expectedZone:    true,
+			expectedRecords: true,
+		},
+		{
+			name:            "case3: the zone is always managed",
+			annotations:     map[string]string{AnnotationManagedRecords: "private"},
+			expectedZone:    true,
+			expectedPrivate: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if managed := ManagesRecords(tc.annotations, ManagedRecordsZone); managed != tc.expectedZone {
+				t.Errorf("ManagesRecords(zone) = %t, want %t", managed, tc.expectedZone)
+			}
+			if managed := ManagesRecords(tc.annotations, ManagedRecordsRecords); managed != tc.expectedRecords {
+				t.Errorf("ManagesRecords(records) = %t, want %t", managed, tc.expectedRecords)
+			}
+			if managed := ManagesRecords(tc.annotations, ManagedRecordsPrivate); managed != tc.expectedPrivate {
+				t.Errorf("ManagesRecords(private) = %t, want %t", managed, tc.expectedPrivate)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ type CommonPatcherParams struct {
 	Cluster        *capi.Cluster
 	InfraCluster   *unstructured.Unstructured
 	K8sClient      client.Client
+	Provider       Provider
 }
 
 type CommonPatcher struct {
@@ -26,10 +27,21 @@ type CommonPatcher struct {
 	subscriptionID string
 	tenantID       string
 	k8sClient      client.Client
+	provider       Provider
+	host           string
 	ip             net.IP
+	// addresses are the further control plane addresses given by the
+	// AnnotationAPIServerAddresses annotation.
+	addresses []net.IP
 }
 
 func NewCommonPatcher(ctx context.Context, params CommonPatcherParams) (*CommonPatcher, error) {
+	provider := params.Provider
+	if provider == nil {
+		provider = genericProvider{}
+	}
+	host := provider.APIServerEndpoint(params.Cluster, params.InfraCluster)
+
 	return &CommonPatcher{
 		Cluster:        params.Cluster,
 		InfraCluster:   params.InfraCluster,
@@ -37,7 +49,10 @@ func NewCommonPatcher(ctx context.Context, params CommonPatcherParams) (*CommonP
 		subscriptionID: params.SubscriptionID,
 		tenantID:       params.TenantID,
 		k8sClient:      params.K8sClient,
-		ip:             net.ParseIP(params.Cluster.Spec.ControlPlaneEndpoint.Host),
+		provider:       provider,
+		host:           host,
+		ip:             net.ParseIP(host),
+		addresses:      APIServerAddresses(params.Cluster.GetAnnotations()),
 	}, nil
 }
 
@@ -73,18 +88,27 @@ func (s *CommonPatcher) PatchObject(ctx context.Context) error {
 }
 
 func (s *CommonPatcher) IsAPIServerPrivate() bool {
-	return s.ip != nil && s.ip.IsPrivate()
+	return s.ip != nil && s.provider.IsAPIServerPrivate(s.Cluster, s.InfraCluster)
 }
 
 func (s *CommonPatcher) APIServerPrivateIP() string {
-	if s.ip.IsPrivate() {
+	if s.IsAPIServerPrivate() {
 		return s.ip.String()
 	}
 	return ""
 }
 
+// APIServerPublicIP returns the public control plane endpoint. For hostname
+// endpoints, e.g. kube-vip or an external load balancer FQDN, it holds the
+// hostname as both name and DNS name.
 func (s *CommonPatcher) APIServerPublicIP() *infrav1.PublicIPSpec {
-	if s.ip.IsPrivate() {
+	if s.ip == nil {
+		return &infrav1.PublicIPSpec{
+			Name:    s.host,
+			DNSName: s.host,
+		}
+	}
+	if s.IsAPIServerPrivate() {
 		return nil
 	}
 	return &infrav1.PublicIPSpec{
@@ -92,6 +116,41 @@ func (s *CommonPatcher) APIServerPublicIP() *infrav1.PublicIPSpec {
 	}
 }
 
+// APIServerPrivateIPs returns the private control plane endpoint and the
+// further control plane addresses.
+func (s *CommonPatcher) APIServerPrivateIPs() []string {
+	if !s.IsAPIServerPrivate() {
+		return nil
+	}
+	return s.apiServerIPs()
+}
+
+// APIServerPublicIPs returns the public control plane endpoint and the
+// further control plane addresses. Hostname endpoints have no further
+// addresses.
+func (s *CommonPatcher) APIServerPublicIPs() []*infrav1.PublicIPSpec {
+	if s.ip == nil || s.IsAPIServerPrivate() {
+		if publicIP := s.APIServerPublicIP(); publicIP != nil {
+			return []*infrav1.PublicIPSpec{publicIP}
+		}
+		return nil
+	}
+
+	var publicIPs []*infrav1.PublicIPSpec
+	for _, ip := range s.apiServerIPs() {
+		publicIPs = append(publicIPs, &infrav1.PublicIPSpec{Name: ip})
+	}
+	return publicIPs
+}
+
+func (s *CommonPatcher) apiServerIPs() []string {
+	ips := []string{s.ip.String()}
+	for _, address := range s.addresses {
+		ips = append(ips, address.String())
+	}
+	return ips
+}
+
 func (s *CommonPatcher) Close(ctx context.Context) error {
 	return s.PatchObject(ctx)
 }
@@ -0,0 +1,12 @@
+package infracluster
+
+import "github.com/giantswarm/microerror"
+
+// IsManagementClusterCheckFailed asserts managementClusterCheckFailedError.
+func IsManagementClusterCheckFailed(err error) bool {
+	return microerror.Cause(err) == managementClusterCheckFailedError
+}
+
+var managementClusterCheckFailedError = &microerror.Error{
+	Kind: "managementClusterCheckFailedError",
+}
@@ -0,0 +1,40 @@
+package infracluster
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/util/record"
+)
+
+// Eventf records a Normal event about a DNS change on the Cluster and the
+// infrastructure cluster.
+func (s *Scope) Eventf(reason, messageFmt string, args ...interface{}) {
+	s.event(corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// Warnf records a Warning event, e.g. about a failed DNS change, on the
+// Cluster and the infrastructure cluster.
+func (s *Scope) Warnf(reason, messageFmt string, args ...interface{}) {
+	s.event(corev1.EventTypeWarning, reason, messageFmt, args...)
+}
+
+func (s *Scope) event(eventType, reason, messageFmt string, args ...interface{}) {
+	var objects []runtime.Object
+	if s.Cluster != nil {
+		objects = append(objects, s.Cluster)
+	}
+	if s.InfraCluster != nil {
+		objects = append(objects, s.InfraCluster)
+	}
+
+	for _, object := range objects {
+		switch {
+		case s.recorder != nil:
+			s.recorder.Eventf(object, eventType, reason, messageFmt, args...)
+		case eventType == corev1.EventTypeWarning:
+			record.Warnf(object, reason, messageFmt, args...)
+		default:
+			record.Eventf(object, reason, messageFmt, args...)
+		}
+	}
+}
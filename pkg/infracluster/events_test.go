@@ -0,0 +1,43 @@
+package infracluster
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+func Test_ScopeEvents(t *testing.T) {
+	infraCluster := &unstructured.Unstructured{}
+	infraCluster.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1beta1")
+	infraCluster.SetKind("VSphereCluster")
+	infraCluster.SetName("test-cluster")
+
+	recorder := record.NewFakeRecorder(10)
+	scope := &Scope{
+		Cluster:      &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}},
+		InfraCluster: infraCluster,
+		recorder:     recorder,
+	}
+
+	scope.Eventf("DNSZoneCreated", "Created DNS zone %s", "test-cluster.basedomain.io")
+	scope.Warnf("DNSZoneCreationFailed", "Failed to create DNS zone %s", "test-cluster.basedomain.io")
+	close(recorder.Events)
+
+	var got []string
+	for event := range recorder.Events {
+		got = append(got, event)
+	}
+	want := []string{
+		"Normal DNSZoneCreated Created DNS zone test-cluster.basedomain.io",
+		"Normal DNSZoneCreated Created DNS zone test-cluster.basedomain.io",
+		"Warning DNSZoneCreationFailed Failed to create DNS zone test-cluster.basedomain.io",
+		"Warning DNSZoneCreationFailed Failed to create DNS zone test-cluster.basedomain.io",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded events = %v, want %v", got, want)
+	}
+}
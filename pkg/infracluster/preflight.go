@@ -0,0 +1,40 @@
+package infracluster
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/types"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CheckManagementCluster returns a managementClusterCheckFailedError if the
+// AzureCluster of the management cluster or the AzureClusterIdentity it
+// references can't be read. They are needed for private DNS zones and as
+// fallback identity of workload clusters. Nothing is checked if no management
+// cluster is configured.
+func CheckManagementCluster(ctx context.Context, reader client.Reader, config ManagementClusterConfig) error {
+	if config.Name == "" || config.Namespace == "" {
+		return nil
+	}
+
+	azureCluster := &infrav1.AzureCluster{}
+	err := reader.Get(ctx, types.NamespacedName{Name: config.Name, Namespace: config.Namespace}, azureCluster)
+	if err != nil {
+		return microerror.Maskf(managementClusterCheckFailedError, "failed to get AzureCluster %s/%s of the management cluster: %s", config.Namespace, config.Name, err)
+	}
+
+	identityRef := azureCluster.Spec.IdentityRef
+	if identityRef == nil {
+		return microerror.Maskf(managementClusterCheckFailedError, "AzureCluster %s/%s of the management cluster has no identityRef", config.Namespace, config.Name)
+	}
+
+	identity := &infrav1.AzureClusterIdentity{}
+	err = reader.Get(ctx, types.NamespacedName{Name: identityRef.Name, Namespace: identityRef.Namespace}, identity)
+	if err != nil {
+		return microerror.Maskf(managementClusterCheckFailedError, "failed to get AzureClusterIdentity %s/%s of the management cluster: %s", identityRef.Namespace, identityRef.Name, err)
+	}
+
+	return nil
+}
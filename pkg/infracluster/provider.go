@@ -0,0 +1,208 @@
+package infracluster
+
+import (
+	"net"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+const infrastructureGroup = "infrastructure.cluster.x-k8s.io"
+
+// Provider knows how to derive DNS information from the infrastructure
+// cluster of one Cluster API infrastructure provider.
+type Provider interface {
+	// APIServerEndpoint returns the host of the control plane endpoint, an IP
+	// or a hostname.
+	APIServerEndpoint(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) string
+	// IsAPIServerPrivate reports whether the control plane endpoint is only
+	// reachable from private networks.
+	IsAPIServerPrivate(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) bool
+	// ExtraRecords returns A records to publish in the cluster zone besides
+	// api and apiserver, keyed by record name.
+	ExtraRecords(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) map[string]string
+	// IsReady reports whether the infrastructure cluster is provisioned far
+	// enough for its DNS records to be managed. If not, a reason is returned.
+	IsReady(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) (bool, string)
+}
+
+var (
+	providersMutex sync.RWMutex
+	providers      = map[schema.GroupKind]Provider{
+		{Group: infrastructureGroup, Kind: kindAzureCluster}:   azureClusterProvider{},
+		{Group: infrastructureGroup, Kind: "VSphereCluster"}:   readyStatusProvider{},
+		{Group: infrastructureGroup, Kind: "VCDCluster"}:       vcdClusterProvider{},
+		{Group: infrastructureGroup, Kind: "OpenStackCluster"}: openStackClusterProvider{},
+	}
+)
+
+// RegisterProvider registers provider for infrastructure clusters of the
+// given GroupKind, replacing any provider registered before.
+func RegisterProvider(groupKind schema.GroupKind, provider Provider) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+
+	providers[groupKind] = provider
+}
+
+// ProviderFor returns the provider registered for groupKind. Infrastructure
+// clusters without a registered provider are handled by a generic provider
+// that only relies on the Cluster's control plane endpoint.
+func ProviderFor(groupKind schema.GroupKind) Provider {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+
+	if provider, ok := providers[groupKind]; ok {
+		return provider
+	}
+	return genericProvider{}
+}
+
+// RegisteredProviders returns the GroupKinds of all registered providers,
+// sorted by kind.
+func RegisteredProviders() []schema.GroupKind {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+
+	var groupKinds []schema.GroupKind
+	for groupKind := range providers {
+		groupKinds = append(groupKinds, groupKind)
+	}
+	sort.Slice(groupKinds, func(i, j int) bool { return groupKinds[i].String() < groupKinds[j].String() })
+
+	return groupKinds
+}
+
+// genericProvider takes the control plane endpoint from the Cluster, treats
+// endpoints in private IP ranges as private and considers every
+// infrastructure cluster ready.
+type genericProvider struct{}
+
+func (genericProvider) APIServerEndpoint(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) string {
+	if cluster.Spec.ControlPlaneEndpoint.Host != "" {
+		return cluster.Spec.ControlPlaneEndpoint.Host
+	}
+	host, _, _ := unstructured.NestedString(infraCluster.Object, "spec", "controlPlaneEndpoint", "host")
+	return host
+}
+
+func (p genericProvider) IsAPIServerPrivate(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) bool {
+	ip := net.ParseIP(p.APIServerEndpoint(cluster, infraCluster))
+	return ip != nil && ip.IsPrivate()
+}
+
+func (genericProvider) ExtraRecords(*capi.Cluster, *unstructured.Unstructured) map[string]string {
+	return nil
+}
+
+func (genericProvider) IsReady(*capi.Cluster, *unstructured.Unstructured) (bool, string) {
+	return true, ""
+}
+
+// azureClusterProvider waits for the load balancers of CAPZ clusters.
+type azureClusterProvider struct {
+	genericProvider
+}
+
+func (azureClusterProvider) IsReady(_ *capi.Cluster, infraCluster *unstructured.Unstructured) (bool, string) {
+	// only act on Clusters where the LoadBalancersReady condition is set
+	if status := azureClusterStatus(infraCluster); status != nil {
+		for _, condition := range status.Conditions {
+			if condition.Type == infrav1.LoadBalancersReadyCondition {
+				return true, ""
+			}
+		}
+	}
+	return false, "Load Balancer is not ready."
+}
+
+// readyStatusProvider waits for the infrastructure cluster to be
+// provisioned, which CAPV and CAPVCD report once the control plane endpoint,
+// the load balancer VIP for CAPVCD, is available: status.ready for providers
+// on the v1beta1 contract, status.initialization.provisioned for providers on
+// the v1beta2 contract.
+type readyStatusProvider struct {
+	genericProvider
+}
+
+func (readyStatusProvider) IsReady(_ *capi.Cluster, infraCluster *unstructured.Unstructured) (bool, string) {
+	if ready, _, _ := unstructured.NestedBool(infraCluster.Object, "status", "ready"); ready {
+		return true, ""
+	}
+	if provisioned, _, _ := unstructured.NestedBool(infraCluster.Object, "status", "initialization", "provisioned"); provisioned {
+		return true, ""
+	}
+	return false, "Infrastructure cluster is not ready."
+}
+
+const (
+	// VCDLoadBalancerVIPRecordName is the record published for the load
+	// balancer VIP of VCD clusters.
+	VCDLoadBalancerVIPRecordName = "api-vip"
+
+	// OpenStackInternalAPIRecordName is the record published for the internal
+	// IP of the OpenStack API server load balancer.
+	OpenStackInternalAPIRecordName = "api-internal"
+	// OpenStackFloatingIPRecordName is the record published for the floating
+	// IP of the OpenStack API server.
+	OpenStackFloatingIPRecordName = "api-floating"
+)
+
+// vcdClusterProvider publishes the load balancer VIP of VCD clusters, the
+// control plane endpoint of the VCDCluster, which stays reachable by IP when
+// the Cluster endpoint is a hostname.
+type vcdClusterProvider struct {
+	readyStatusProvider
+}
+
+func (vcdClusterProvider) ExtraRecords(_ *capi.Cluster, infraCluster *unstructured.Unstructured) map[string]string {
+	vip, _, _ := unstructured.NestedString(infraCluster.Object, "spec", "controlPlaneEndpoint", "host")
+	if net.ParseIP(vip).To4() == nil {
+		return nil
+	}
+	return map[string]string{VCDLoadBalancerVIPRecordName: vip}
+}
+
+// openStackClusterProvider treats clusters without an API server floating IP
+// as private and publishes the internal IP of the API server load balancer
+// and the floating IP of the API server.
+type openStackClusterProvider struct {
+	readyStatusProvider
+}
+
+func (p openStackClusterProvider) IsAPIServerPrivate(cluster *capi.Cluster, infraCluster *unstructured.Unstructured) bool {
+	if disabled, _, _ := unstructured.NestedBool(infraCluster.Object, "spec", "disableAPIServerFloatingIP"); disabled {
+		return true
+	}
+	return p.genericProvider.IsAPIServerPrivate(cluster, infraCluster)
+}
+
+func (openStackClusterProvider) ExtraRecords(_ *capi.Cluster, infraCluster *unstructured.Unstructured) map[string]string {
+	records := map[string]string{}
+
+	internalIP, _, _ := unstructured.NestedString(infraCluster.Object, "status", "apiServerLoadBalancer", "internalIP")
+	if net.ParseIP(internalIP).To4() != nil {
+		records[OpenStackInternalAPIRecordName] = internalIP
+	}
+
+	// the floating IP is attached to the load balancer if there is one,
+	// otherwise the requested one is used
+	if disabled, _, _ := unstructured.NestedBool(infraCluster.Object, "spec", "disableAPIServerFloatingIP"); !disabled {
+		floatingIP, _, _ := unstructured.NestedString(infraCluster.Object, "status", "apiServerLoadBalancer", "ip")
+		if floatingIP == "" {
+			floatingIP, _, _ = unstructured.NestedString(infraCluster.Object, "spec", "apiServerFloatingIP")
+		}
+		if net.ParseIP(floatingIP).To4() != nil {
+			records[OpenStackFloatingIPRecordName] = floatingIP
+		}
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	return records
+}
@@ -0,0 +1,190 @@
+package infracluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+func newInfraCluster(kind string, object map[string]interface{}) *unstructured.Unstructured {
+	infraCluster := &unstructured.Unstructured{Object: object}
+	infraCluster.SetGroupVersionKind(schema.GroupVersionKind{Group: infrastructureGroup, Version: "v1beta1", Kind: kind})
+	infraCluster.SetName("test-cluster")
+	return infraCluster
+}
+
+func Test_Provider(t *testing.T) {
+	testCases := []struct {
+		name             string
+		endpoint         string
+		infraCluster     *unstructured.Unstructured
+		expectedEndpoint string
+		expectedReady    bool
+		expectedPrivate  bool
+		expectedRecords  map[string]string
+	}{
+		{
+			name:             "case0: unknown provider uses the Cluster endpoint",
+			endpoint:         "10.0.0.1",
+			infraCluster:     newInfraCluster("DockerCluster", map[string]interface{}{}),
+			expectedEndpoint: "10.0.0.1",
+			expectedReady:    true,
+			expectedPrivate:  true,
+		},
+		{
+			name:     "case1: unknown provider falls back to the infrastructure cluster endpoint",
+			endpoint: "",
+			infraCluster: newInfraCluster("DockerCluster", map[string]interface{}{
+				"spec": map[string]interface{}{"controlPlaneEndpoint": map[string]interface{}{"host": "1.2.3.4"}},
+			}),
+			expectedEndpoint: "1.2.3.4",
+			expectedReady:    true,
+		},
+		{
+			name:     "case2: vSphere cluster is not ready without status.ready",
+			endpoint: "1.2.3.4",
+			infraCluster: newInfraCluster("VSphereCluster", map[string]interface{}{
+				"status": map[string]interface{}{"ready": false},
+			}),
+			expectedEndpoint: "1.2.3.4",
+		},
+		{
+			name:     "case3: VCD cluster is ready with status.ready",
+			endpoint: "1.2.3.4",
+			infraCluster: newInfraCluster("VCDCluster", map[string]interface{}{
+				"status": map[string]interface{}{"ready": true},
+			}),
+			expectedEndpoint: "1.2.3.4",
+			expectedReady:    true,
+		},
+		{
+			name:     "case4: OpenStack cluster without floating IP is private and publishes the internal IP",
+			endpoint: "1.2.3.4",
+			infraCluster: newInfraCluster("OpenStackCluster", map[string]interface{}{
+				"spec": map[string]interface{}{"disableAPIServerFloatingIP": true},
+				"status": map[string]interface{}{
+					"ready":                 true,
+					"apiServerLoadBalancer": map[string]interface{}{"internalIP": "10.6.0.10"},
+				},
+			}),
+			expectedEndpoint: "1.2.3.4",
+			expectedReady:    true,
+			expectedPrivate:  true,
+			expectedRecords:  map[string]string{OpenStackInternalAPIRecordName: "10.6.0.10"},
+		},
+		{
+			name:     "case5: vSphere cluster on the v1beta2 contract is ready with status.initialization.provisioned",
+			endpoint: "1.2.3.4",
+			infraCluster: newInfraCluster("VSphereCluster", map[string]interface{}{
+				"status": map[string]interface{}{"initialization": map[string]interface{}{"provisioned": true}},
+			}),
+			expectedEndpoint: "1.2.3.4",
+			expectedReady:    true,
+		},
+		{
+			name:     "case6: VCD cluster publishes the load balancer VIP",
+			endpoint: "api.glippy.example.com",
+			infraCluster: newInfraCluster("VCDCluster", map[string]interface{}{
+				"spec":   map[string]interface{}{"controlPlaneEndpoint": map[string]interface{}{"host": "185.10.0.20"}},
+				"status": map[string]interface{}{"ready": true},
+			}),
+			expectedEndpoint: "api.glippy.example.com",
+			expectedReady:    true,
+			expectedRecords:  map[string]string{VCDLoadBalancerVIPRecordName: "185.10.0.20"},
+		},
+		{
+			name:     "case7: OpenStack cluster publishes the floating IP and the internal IP of its load balancer",
+			endpoint: "1.2.3.4",
+			infraCluster: newInfraCluster("OpenStackCluster", map[string]interface{}{
+				"status": map[string]interface{}{
+					"ready":                 true,
+					"apiServerLoadBalancer": map[string]interface{}{"ip": "1.2.3.4", "internalIP": "10.6.0.10"},
+				},
+			}),
+			expectedEndpoint: "1.2.3.4",
+			expectedReady:    true,
+			expectedRecords: map[string]string{
+				OpenStackInternalAPIRecordName: "10.6.0.10",
+				OpenStackFloatingIPRecordName:  "1.2.3.4",
+			},
+		},
+		{
+			name:     "case8: OpenStack cluster without load balancer publishes the requested floating IP",
+			endpoint: "1.2.3.4",
+			infraCluster: newInfraCluster("OpenStackCluster", map[string]interface{}{
+				"spec":   map[string]interface{}{"apiServerFloatingIP": "1.2.3.5"},
+				"status": map[string]interface{}{"ready": true},
+			}),
+			expectedEndpoint: "1.2.3.4",
+			expectedReady:    true,
+			expectedRecords:  map[string]string{OpenStackFloatingIPRecordName: "1.2.3.5"},
+		},
+		{
+			name:             "case9: Azure cluster is not ready without the LoadBalancersReady condition",
+			endpoint:         "1.2.3.4",
+			infraCluster:     newInfraCluster(kindAzureCluster, map[string]interface{}{}),
+			expectedEndpoint: "1.2.3.4",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &capi.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+				Spec: capi.ClusterSpec{
+					ControlPlaneEndpoint: capi.APIEndpoint{Host: tc.endpoint},
+				},
+			}
+
+			provider := ProviderFor(tc.infraCluster.GroupVersionKind().GroupKind())
+
+			if endpoint := provider.APIServerEndpoint(cluster, tc.infraCluster); endpoint != tc.expectedEndpoint {
+				t.Errorf("APIServerEndpoint() = %q, want %q", endpoint, tc.expectedEndpoint)
+			}
+			if ready, _ := provider.IsReady(cluster, tc.infraCluster); ready != tc.expectedReady {
+				t.Errorf("IsReady() = %t, want %t", ready, tc.expectedReady)
+			}
+			if private := provider.IsAPIServerPrivate(cluster, tc.infraCluster); private != tc.expectedPrivate {
+				t.Errorf("IsAPIServerPrivate() = %t, want %t", private, tc.expectedPrivate)
+			}
+			if records := provider.ExtraRecords(cluster, tc.infraCluster); !reflect.DeepEqual(records, tc.expectedRecords) {
+				t.Errorf("ExtraRecords() = %v, want %v", records, tc.expectedRecords)
+			}
+
+			patcher, err := NewCommonPatcher(context.TODO(), CommonPatcherParams{
+				Cluster:      cluster,
+				InfraCluster: tc.infraCluster,
+				Provider:     provider,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if patcher.IsAPIServerPrivate() != tc.expectedPrivate {
+				t.Errorf("CommonPatcher.IsAPIServerPrivate() = %t, want %t", patcher.IsAPIServerPrivate(), tc.expectedPrivate)
+			}
+		})
+	}
+}
+
+type fakeProvider struct {
+	genericProvider
+}
+
+func Test_RegisterProvider(t *testing.T) {
+	groupKind := schema.GroupKind{Group: "infrastructure.example.com", Kind: "ExampleCluster"}
+
+	if _, ok := ProviderFor(groupKind).(genericProvider); !ok {
+		t.Fatalf("expected generic provider for unregistered %s", groupKind)
+	}
+
+	RegisterProvider(groupKind, fakeProvider{})
+
+	if _, ok := ProviderFor(groupKind).(fakeProvider); !ok {
+		t.Errorf("expected registered provider for %s", groupKind)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	capzscope "sigs.k8s.io/cluster-api-provider-azure/azure/scope"
@@ -68,6 +69,9 @@ type ScopeParams struct {
 	ManagementClusterConfig ManagementClusterConfig
 	ClusterIdentityRef      *corev1.ObjectReference
 	ClusterZoneAzureConfig  ClusterZoneAzureConfig
+	// Recorder records the events about DNS changes, the CAPI default
+	// recorder is used if nil.
+	Recorder record.EventRecorder
 }
 
 type ManagementClusterConfig struct {
@@ -89,12 +93,14 @@ type Scope struct {
 	InfraCluster              *unstructured.Unstructured
 	cache                     *capzscope.ClusterCache
 	Patcher                   Patcher
+	Provider                  Provider
 	publicIPService           async.Getter
 	managementClusterConfig   ManagementClusterConfig
 	managementCluster         *infrav1.AzureCluster
 	managementClusterIdentity *infrav1.AzureClusterIdentity
 	clusterIdentityRef        *corev1.ObjectReference
 	clusterK8sClient          client.Client
+	recorder                  record.EventRecorder
 	AzureLocation             string
 }
 
@@ -106,6 +112,94 @@ func (s *Scope) AzureClusterStatus() *infrav1.AzureClusterStatus {
 	return azureClusterStatus(s.InfraCluster)
 }
 
+// ManagesRecords reports whether the Cluster allows the operator to manage
+// the given ManagedRecords value.
+func (s *Scope) ManagesRecords(managedRecords string) bool {
+	return ManagesRecords(s.Cluster.GetAnnotations(), managedRecords)
+}
+
+// IsInfraClusterReady reports whether the infrastructure provider considers
+// the cluster ready for DNS management. If not, a reason is returned.
+func (s *Scope) IsInfraClusterReady() (bool, string) {
+	return s.provider().IsReady(s.Cluster, s.InfraCluster)
+}
+
+// APIServerEndpoint returns the host of the control plane endpoint as given
+// by the infrastructure provider.
+func (s *Scope) APIServerEndpoint() string {
+	return s.provider().APIServerEndpoint(s.Cluster, s.InfraCluster)
+}
+
+// ExtraRecords returns the A records the infrastructure provider publishes in
+// the cluster zone besides api and apiserver, keyed by record name.
+func (s *Scope) ExtraRecords() map[string]string {
+	return s.provider().ExtraRecords(s.Cluster, s.InfraCluster)
+}
+
+// apiServerFrontends is implemented by patchers of clusters whose control
+// plane has more than one address.
+type apiServerFrontends interface {
+	APIServerPrivateIPs() []string
+	APIServerPublicIPs() []*infrav1.PublicIPSpec
+}
+
+// APIServerPrivateIPs returns the private IPs of all frontends of the API
+// server load balancer of CAPZ clusters, or the control plane addresses of
+// other clusters with a private API server.
+func (s *Scope) APIServerPrivateIPs() []string {
+	if patcher, ok := s.Patcher.(apiServerFrontends); ok {
+		return patcher.APIServerPrivateIPs()
+	}
+	if !s.Patcher.IsAPIServerPrivate() {
+		return nil
+	}
+
+	var privateIPs []string
+	for _, frontendIP := range s.apiServerFrontendIPs() {
+		if frontendIP.PrivateIPAddress != "" {
+			privateIPs = append(privateIPs, frontendIP.PrivateIPAddress)
+		}
+	}
+	return privateIPs
+}
+
+// APIServerPublicIPs returns the public IPs of all frontends of the API
+// server load balancer of CAPZ clusters, or the control plane addresses of
+// other clusters with a public API server.
+func (s *Scope) APIServerPublicIPs() []*infrav1.PublicIPSpec {
+	if patcher, ok := s.Patcher.(apiServerFrontends); ok {
+		return patcher.APIServerPublicIPs()
+	}
+	if s.Patcher.IsAPIServerPrivate() {
+		return nil
+	}
+
+	var publicIPs []*infrav1.PublicIPSpec
+	for _, frontendIP := range s.apiServerFrontendIPs() {
+		if frontendIP.PublicIP != nil {
+			publicIPs = append(publicIPs, frontendIP.PublicIP)
+		}
+	}
+	return publicIPs
+}
+
+// apiServerFrontendIPs returns the frontend IPs of the API server load
+// balancer of CAPZ clusters.
+func (s *Scope) apiServerFrontendIPs() []infrav1.FrontendIP {
+	clusterScope, ok := s.Patcher.(*capzscope.ClusterScope)
+	if !ok || clusterScope.APIServerLB() == nil {
+		return nil
+	}
+	return clusterScope.APIServerLB().FrontendIPs
+}
+
+func (s *Scope) provider() Provider {
+	if s.Provider == nil {
+		return genericProvider{}
+	}
+	return s.Provider
+}
+
 func (s *Scope) IsAzureCluster() bool {
 	return isAzureCluster(s.InfraCluster)
 }
@@ -178,6 +272,26 @@ func (s *Scope) InfraClusterAnnotations() map[string]string {
 	return s.InfraCluster.GetAnnotations()
 }
 
+// AzureResourceTags returns the tags of the Azure resources the operator
+// creates for the cluster: the AdditionalTags of CAPZ clusters, overridden by
+// the resource tag annotations of the infrastructure cluster.
+func (s *Scope) AzureResourceTags() map[string]*string {
+	tags := GetResourceTagsFromInfraClusterAnnotations(s.InfraClusterAnnotations())
+	if spec := s.AzureClusterSpec(); spec != nil {
+		for key, value := range spec.AdditionalTags {
+			if _, ok := tags[key]; ok {
+				continue
+			}
+			if tags == nil {
+				tags = make(map[string]*string)
+			}
+			tagValue := value
+			tags[key] = &tagValue
+		}
+	}
+	return tags
+}
+
 func (s *Scope) ClusterK8sClient(ctx context.Context) (client.Client, error) {
 	if s.clusterK8sClient == nil {
 		var err error
@@ -198,6 +312,8 @@ func (s *Scope) SetClusterK8sClient(c client.Client) {
 func NewScope(ctx context.Context, params ScopeParams) (*Scope, error) {
 	var err error
 
+	provider := ProviderFor(params.InfraCluster.GroupVersionKind().GroupKind())
+
 	if isAzureCluster(params.InfraCluster) {
 		azureCluster := &infrav1.AzureCluster{}
 		err = params.Client.Get(ctx, types.NamespacedName{
@@ -231,9 +347,11 @@ func NewScope(ctx context.Context, params ScopeParams) (*Scope, error) {
 			Cluster:                 params.Cluster,
 			InfraCluster:            params.InfraCluster,
 			Patcher:                 clusterScope,
+			Provider:                provider,
 			cache:                   params.Cache,
 			publicIPService:         publicips,
 			managementClusterConfig: params.ManagementClusterConfig,
+			recorder:                params.Recorder,
 		}, nil
 	}
 
@@ -286,6 +404,7 @@ func NewScope(ctx context.Context, params ScopeParams) (*Scope, error) {
 		K8sClient:      params.Client,
 		Cluster:        params.Cluster,
 		InfraCluster:   params.InfraCluster,
+		Provider:       provider,
 	})
 
 	if err != nil {
@@ -297,6 +416,7 @@ func NewScope(ctx context.Context, params ScopeParams) (*Scope, error) {
 		Cluster:                   params.Cluster,
 		InfraCluster:              params.InfraCluster,
 		Patcher:                   clusterScope,
+		Provider:                  provider,
 		AzureLocation:             params.ClusterZoneAzureConfig.Location,
 		cache:                     params.Cache,
 		publicIPService:           NewPublicIPService(params.Cluster),
@@ -304,6 +424,7 @@ func NewScope(ctx context.Context, params ScopeParams) (*Scope, error) {
 		managementCluster:         managementCluster,
 		managementClusterIdentity: managementClusterIdentity,
 		clusterIdentityRef:        params.ClusterIdentityRef,
+		recorder:                  params.Recorder,
 	}
 
 	return scope, nil
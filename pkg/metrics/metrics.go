@@ -14,6 +14,9 @@ const (
 	MetricZone      = "zone"
 	metricRecordSet = "record_set"
 	metricAzure     = "api_request"
+	metricOrphan    = "orphan"
+	metricPreflight = "preflight"
+	metricConfig    = "config"
 
 	ZoneType        = "type"
 	ZoneTypePrivate = "private"
@@ -52,6 +55,19 @@ var (
 			ZoneType,
 		})
 
+	ZonePropagationMismatches = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: MetricZone,
+			Name:      "propagation_mismatches",
+			Help:      "Number of answers of the authoritative name servers that differ from the records of the zone",
+			ConstLabels: prometheus.Labels{
+				metricControllerLabel: metricControllerValue,
+			},
+		}, []string{
+			MetricZone,
+		})
+
 	RecordInfo = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: metricNamespace,
@@ -70,6 +86,71 @@ var (
 			"ttl",
 		})
 
+	OrphanInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricOrphan,
+			Name:      "info",
+			Help:      "Info about DNS resources left behind by clusters that no longer exist",
+			ConstLabels: prometheus.Labels{
+				metricControllerLabel: metricControllerValue,
+			},
+		},
+		[]string{
+			"kind",
+			"name",
+			"resource_group",
+			"subscription_id",
+			"owner",
+			"owned",
+		})
+	OrphanDeleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricOrphan,
+			Name:      "deleted_total",
+			Help:      "Total number of deleted orphaned DNS resources",
+			ConstLabels: prometheus.Labels{
+				metricControllerLabel: metricControllerValue,
+			},
+		}, []string{"kind"})
+
+	PreflightCheckFailed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricPreflight,
+			Name:      "check_failed",
+			Help:      "Whether the last run of a preflight check failed",
+			ConstLabels: prometheus.Labels{
+				metricControllerLabel: metricControllerValue,
+			},
+		}, []string{"check"})
+
+	ConfigInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricConfig,
+			Name:      "info",
+			Help:      "Info about the configuration the operator runs with",
+			ConstLabels: prometheus.Labels{
+				metricControllerLabel: metricControllerValue,
+			},
+		}, []string{
+			"api_version",
+			"checksum",
+			"restart_required",
+		})
+	ConfigReloadFailed = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricNamespace,
+			Subsystem: metricConfig,
+			Name:      "reload_failed",
+			Help:      "Whether the last reload of the configuration file failed",
+			ConstLabels: prometheus.Labels{
+				metricControllerLabel: metricControllerValue,
+			},
+		})
+
 	AzureRequestError = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: metricNamespace,
@@ -93,7 +174,13 @@ func init() {
 	// Register custom metrics with the global prometheus registry
 	metrics.Registry.MustRegister(ZoneInfo)
 	metrics.Registry.MustRegister(ClusterZoneRecords)
+	metrics.Registry.MustRegister(ZonePropagationMismatches)
 	metrics.Registry.MustRegister(RecordInfo)
+	metrics.Registry.MustRegister(OrphanInfo)
+	metrics.Registry.MustRegister(OrphanDeleted)
+	metrics.Registry.MustRegister(PreflightCheckFailed)
+	metrics.Registry.MustRegister(ConfigInfo)
+	metrics.Registry.MustRegister(ConfigReloadFailed)
 
 	metrics.Registry.MustRegister(AzureRequestError)
 	metrics.Registry.MustRegister(AzureRequest)
@@ -0,0 +1,464 @@
+// Package rfc2136 manages the record sets of zones on name servers accepting
+// dynamic updates as described in RFC 2136, e.g. BIND or PowerDNS. Updates
+// are signed with TSIG, RFC 8945, and zones are read with zone transfers.
+//
+// The Client provides the zone and record set operations of the Azure DNS
+// API the dns service uses, so that it plans records the same way for both.
+// Record set metadata, which DNS has no place for, is stored in companion
+// TXT record sets, see metadataName, sealed with a key derived from the TSIG
+// secret, so that it can't be read from the zone. Zones have no tags.
+package rfc2136
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	AlgorithmHMACSHA1   = "hmac-sha1"
+	AlgorithmHMACSHA256 = "hmac-sha256"
+	AlgorithmHMACSHA512 = "hmac-sha512"
+
+	// DefaultTimeout bounds a single update or zone transfer.
+	DefaultTimeout = 30 * time.Second
+
+	dnsPort = "53"
+
+	// tsigFudge is the time difference in seconds tolerated between the
+	// clocks of the operator and the server.
+	tsigFudge = 300
+)
+
+var tsigAlgorithms = map[string]string{
+	AlgorithmHMACSHA1:   dns.HmacSHA1,
+	AlgorithmHMACSHA256: dns.HmacSHA256,
+	AlgorithmHMACSHA512: dns.HmacSHA512,
+}
+
+// signatureErrors are the errors of the dns package for responses failing
+// TSIG verification.
+var signatureErrors = []error{dns.ErrAuth, dns.ErrKey, dns.ErrKeyAlg, dns.ErrNoSig, dns.ErrSecret, dns.ErrSig, dns.ErrTime}
+
+type Config struct {
+	// Server is the host:port of the primary name server of the zones. Port
+	// 53 is used if it's missing.
+	Server string
+	// TSIGKeyName, TSIGAlgorithm and TSIGSecret sign all messages, which are
+	// sent unsigned if TSIGKeyName is empty. TSIGAlgorithm defaults to
+	// hmac-sha256. Record set metadata is only stored for signed messages.
+	TSIGKeyName   string
+	TSIGAlgorithm string
+	TSIGSecret    []byte
+	// Timeout defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Client updates zones on a name server. Zones are identified by name only,
+// the resource group arguments are ignored. Zones can't be created or deleted
+// with RFC 2136, they must be configured on the server.
+type Client struct {
+	server string
+	// keyName and algorithm are absolute and lowercase, keyName is empty if
+	// messages are sent unsigned.
+	keyName   string
+	algorithm string
+	secrets   map[string]string
+	// sealer is nil if messages are sent unsigned.
+	sealer  *sealer
+	timeout time.Duration
+}
+
+// New creates a new RFC 2136 client.
+func New(config Config) (*Client, error) {
+	if config.Server == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Server must not be empty", config)
+	}
+	server := config.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, dnsPort)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	client := &Client{
+		server:  server,
+		timeout: timeout,
+	}
+
+	if config.TSIGKeyName != "" {
+		algorithm := strings.ToLower(strings.TrimSuffix(config.TSIGAlgorithm, "."))
+		if algorithm == "" {
+			algorithm = AlgorithmHMACSHA256
+		}
+		tsigAlgorithm, ok := tsigAlgorithms[algorithm]
+		if !ok {
+			return nil, microerror.Maskf(invalidConfigError, "unsupported TSIG algorithm %q", algorithm)
+		}
+		if len(config.TSIGSecret) == 0 {
+			return nil, microerror.Maskf(invalidConfigError, "TSIG key %q has no secret", config.TSIGKeyName)
+		}
+
+		s, err := newSealer(config.TSIGSecret)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		client.keyName = dns.CanonicalName(config.TSIGKeyName)
+		client.algorithm = tsigAlgorithm
+		client.secrets = map[string]string{client.keyName: base64.StdEncoding.EncodeToString(config.TSIGSecret)}
+		client.sealer = s
+	}
+
+	return client, nil
+}
+
+// GetZone returns the zone with its apex name servers and the number of
+// record sets. A zoneNotFoundError is returned if the server isn't
+// authoritative for the zone.
+func (c *Client) GetZone(ctx context.Context, resourceGroupName string, zoneName string) (armdns.Zone, error) {
+	recordSets, err := c.read(ctx, zoneName)
+	if err != nil {
+		return armdns.Zone{}, microerror.Mask(err)
+	}
+
+	var nameServers []*string
+	for _, recordSet := range recordSets {
+		if *recordSet.Name == "@" && *recordSet.Type == recordSetTypePrefix+string(armdns.RecordTypeNS) {
+			for _, record := range recordSet.Properties.NsRecords {
+				nameServers = append(nameServers, record.Nsdname)
+			}
+		}
+	}
+
+	return armdns.Zone{
+		Name:     pointer.String(zoneName),
+		Location: pointer.String("global"),
+		Properties: &armdns.ZoneProperties{
+			NameServers:        nameServers,
+			NumberOfRecordSets: pointer.Int64(int64(len(recordSets))),
+		},
+	}, nil
+}
+
+// CreateOrUpdateZone returns the zone, which must exist on the server. The
+// tags of zone are ignored, they would be published in the zone.
+func (c *Client) CreateOrUpdateZone(ctx context.Context, resourceGroupName string, zoneName string, zone armdns.Zone) (armdns.Zone, error) {
+	current, err := c.GetZone(ctx, resourceGroupName, zoneName)
+	if err != nil {
+		return armdns.Zone{}, microerror.Mask(err)
+	}
+	return current, nil
+}
+
+// DeleteZone fails, zones can't be deleted with RFC 2136. Their record sets
+// must be deleted one by one, which leaves records of others alone.
+func (c *Client) DeleteZone(ctx context.Context, resourceGroupName string, zoneName string) error {
+	return microerror.Maskf(notSupportedError, "zone %s can't be deleted with RFC 2136", zoneName)
+}
+
+// CreateOrUpdateRecordSet replaces the record set name of type recordType and
+// its metadata in a single update.
+func (c *Client) CreateOrUpdateRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, name string, recordSet armdns.RecordSet) (armdns.RecordSet, error) {
+	if _, ok := recordTypes[recordType]; !ok || recordType == armdns.RecordTypeSOA {
+		return armdns.RecordSet{}, microerror.Maskf(unsupportedRecordSetError, "record type %q can't be written with RFC 2136", recordType)
+	}
+
+	owner, err := ownerName(name, zoneName)
+	if err != nil {
+		return armdns.RecordSet{}, microerror.Mask(err)
+	}
+	records, err := resources(owner, recordType, recordSet.Properties)
+	if err != nil {
+		return armdns.RecordSet{}, microerror.Mask(err)
+	}
+
+	updates, err := removeRecordSetWithMetadata(zoneName, recordType, name)
+	if err != nil {
+		return armdns.RecordSet{}, microerror.Mask(err)
+	}
+	updates = append(updates, records...)
+
+	var properties armdns.RecordSetProperties
+	if recordSet.Properties != nil {
+		properties = *recordSet.Properties
+	}
+	if len(properties.Metadata) > 0 && c.sealer != nil {
+		metadataRecordSetName, err := metadataName(name, recordType)
+		if err != nil {
+			return armdns.RecordSet{}, microerror.Mask(err)
+		}
+		metadataOwner, err := ownerName(metadataRecordSetName, zoneName)
+		if err != nil {
+			return armdns.RecordSet{}, microerror.Mask(err)
+		}
+		metadata, err := c.sealer.seal(metadataOwner, properties.Metadata)
+		if err != nil {
+			return armdns.RecordSet{}, microerror.Mask(err)
+		}
+		updates = append(updates, metadata)
+	} else {
+		properties.Metadata = nil
+	}
+
+	if err := c.update(ctx, zoneName, updates); err != nil {
+		return armdns.RecordSet{}, microerror.Mask(err)
+	}
+
+	properties.Fqdn = pointer.String(owner)
+	return armdns.RecordSet{
+		Name:       pointer.String(name),
+		Type:       pointer.String(recordSetTypePrefix + string(recordType)),
+		Properties: &properties,
+	}, nil
+}
+
+// DeleteRecordSet deletes the record set name of type recordType and its
+// metadata.
+func (c *Client) DeleteRecordSet(ctx context.Context, resourceGroupName string, zoneName string, recordType armdns.RecordType, recordSetName string) error {
+	updates, err := removeRecordSetWithMetadata(zoneName, recordType, recordSetName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return microerror.Mask(c.update(ctx, zoneName, updates))
+}
+
+// ListRecordSets lists the record sets of all supported types in the zone,
+// sorted by name and type. Record sets holding metadata are folded into the
+// record sets they describe, metadata that can't be opened is dropped.
+func (c *Client) ListRecordSets(ctx context.Context, resourceGroupName string, zoneName string) ([]*armdns.RecordSet, error) {
+	recordSets, err := c.read(ctx, zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	return recordSets, nil
+}
+
+type recordSetKey struct {
+	name       string
+	recordType armdns.RecordType
+}
+
+func (c *Client) read(ctx context.Context, zoneName string) ([]*armdns.RecordSet, error) {
+	records, err := c.transfer(ctx, zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var result []*armdns.RecordSet
+	recordSets := map[recordSetKey]*armdns.RecordSet{}
+	metadata := map[recordSetKey]map[string]*string{}
+	for _, record := range records {
+		name, ok := relativeName(record.Header().Name, zoneName)
+		if !ok {
+			continue
+		}
+		recordType, ok := recordType(record.Header().Rrtype)
+		if !ok {
+			continue
+		}
+
+		if txt, isTXT := record.(*dns.TXT); isTXT {
+			if recordSetName, metadataType, ok := parseMetadataName(name); ok {
+				if c.sealer != nil {
+					if values, ok := c.sealer.open(txt); ok {
+						metadata[recordSetKey{name: recordSetName, recordType: metadataType}] = values
+					}
+				}
+				continue
+			}
+		}
+
+		key := recordSetKey{name: name, recordType: recordType}
+		recordSet, ok := recordSets[key]
+		if !ok {
+			recordSet = &armdns.RecordSet{
+				Name: pointer.String(name),
+				Type: pointer.String(recordSetTypePrefix + string(recordType)),
+				Properties: &armdns.RecordSetProperties{
+					Fqdn: pointer.String(record.Header().Name),
+				},
+			}
+			recordSets[key] = recordSet
+			result = append(result, recordSet)
+		}
+		addRecord(recordSet.Properties, record)
+	}
+
+	for key, values := range metadata {
+		if recordSet, ok := recordSets[key]; ok {
+			recordSet.Properties.Metadata = values
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if *result[i].Name != *result[j].Name {
+			return *result[i].Name < *result[j].Name
+		}
+		return *result[i].Type < *result[j].Type
+	})
+
+	return result, nil
+}
+
+// transfer returns the records of the zone without the closing SOA record.
+// The SOA record of the zone is queried first, as servers answer transfers of
+// zones they aren't authoritative for with errors that don't tell so.
+func (c *Client) transfer(ctx context.Context, zoneName string) ([]dns.RR, error) {
+	zone, err := newName(zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	query := new(dns.Msg)
+	query.SetQuestion(zone, dns.TypeSOA)
+	response, err := c.exchange(ctx, query)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	switch {
+	case response.Rcode == dns.RcodeNotAuth, response.Rcode == dns.RcodeNotZone:
+		return nil, microerror.Maskf(zoneNotFoundError, "%s isn't authoritative for zone %s: %s", c.server, zoneName, dns.RcodeToString[response.Rcode])
+	case response.Rcode != dns.RcodeSuccess:
+		return nil, microerror.Maskf(requestFailedError, "transfer of zone %s failed: %s", zoneName, dns.RcodeToString[response.Rcode])
+	case !response.Authoritative:
+		return nil, microerror.Maskf(zoneNotFoundError, "%s isn't authoritative for zone %s", c.server, zoneName)
+	}
+
+	request := new(dns.Msg)
+	request.SetAxfr(zone)
+	c.sign(request)
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	transfer := &dns.Transfer{
+		DialTimeout:  c.timeout,
+		ReadTimeout:  c.timeout,
+		WriteTimeout: c.timeout,
+		TsigSecret:   c.secrets,
+	}
+	envelopes, err := transfer.In(request, c.server)
+	if err != nil {
+		return nil, maskExchangeError(err)
+	}
+	// the transfer doesn't take a context, closing the connection ends it
+	stop := context.AfterFunc(ctx, func() { _ = transfer.Close() })
+	defer stop()
+
+	var records []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			err = envelope.Error
+			continue
+		}
+		records = append(records, envelope.RR...)
+	}
+	if ctx.Err() != nil {
+		return nil, microerror.Mask(ctx.Err())
+	} else if err != nil {
+		return nil, maskExchangeError(err)
+	}
+
+	if len(records) > 0 {
+		records = records[:len(records)-1]
+	}
+	return records, nil
+}
+
+// update sends updates in the update section of a single UPDATE message,
+// which the server applies atomically.
+func (c *Client) update(ctx context.Context, zoneName string, updates []dns.RR) error {
+	zone, err := newName(zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	request := new(dns.Msg)
+	request.SetUpdate(zone)
+	request.Ns = updates
+
+	response, err := c.exchange(ctx, request)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if response.Rcode != dns.RcodeSuccess {
+		return microerror.Maskf(requestFailedError, "update of zone %s failed: %s", zoneName, dns.RcodeToString[response.Rcode])
+	}
+	return nil
+}
+
+// exchange sends request over TCP and returns the response. Responses are
+// verified if the client has a TSIG key, successful ones must be signed.
+func (c *Client) exchange(ctx context.Context, request *dns.Msg) (*dns.Msg, error) {
+	c.sign(request)
+
+	client := &dns.Client{Net: "tcp", Timeout: c.timeout, TsigSecret: c.secrets}
+	response, _, err := client.ExchangeContext(ctx, request, c.server)
+	if errors.Is(err, dns.ErrAuth) && response != nil {
+		// the dns package doesn't verify NOTAUTH responses, which servers
+		// send for rejected signatures and zones they aren't authoritative
+		// for alike, the TSIG error tells them apart
+		if tsig := response.IsTsig(); tsig != nil && tsig.Error != dns.RcodeSuccess {
+			return nil, microerror.Maskf(invalidSignatureError, "%s rejected the signature: %s", c.server, dns.RcodeToString[int(tsig.Error)])
+		}
+		return response, nil
+	} else if err != nil {
+		return nil, maskExchangeError(err)
+	}
+	if c.keyName != "" && response.IsTsig() == nil && response.Rcode == dns.RcodeSuccess {
+		return nil, microerror.Maskf(invalidSignatureError, "response isn't signed")
+	}
+	return response, nil
+}
+
+// sign adds a TSIG record to msg, which is signed when it is sent.
+func (c *Client) sign(msg *dns.Msg) {
+	if c.keyName != "" {
+		msg.SetTsig(c.keyName, c.algorithm, tsigFudge, time.Now().Unix())
+	}
+}
+
+// removeRecordSetWithMetadata returns the updates deleting the record set
+// name of type recordType and its metadata.
+func removeRecordSetWithMetadata(zoneName string, recordType armdns.RecordType, name string) ([]dns.RR, error) {
+	owner, err := ownerName(name, zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	metadataRecordSetName, err := metadataName(name, recordType)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	metadataOwner, err := ownerName(metadataRecordSetName, zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return []dns.RR{
+		removeRecordSet(owner, recordTypes[recordType]),
+		removeRecordSet(metadataOwner, dns.TypeTXT),
+	}, nil
+}
+
+func maskExchangeError(err error) error {
+	for _, signatureError := range signatureErrors {
+		if errors.Is(err, signatureError) {
+			return microerror.Maskf(invalidSignatureError, "%s", err)
+		}
+	}
+	return microerror.Mask(err)
+}
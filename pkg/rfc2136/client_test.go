@@ -0,0 +1,610 @@
+package rfc2136
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/miekg/dns"
+	"k8s.io/utils/pointer"
+)
+
+const testZone = "glippy.example.com"
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// testServer is an in-process name server for testZone accepting messages
+// signed with the TSIG key operator and testSecret.
+type testServer struct {
+	mu      sync.Mutex
+	records []dns.RR
+}
+
+// newTestServer starts a test server, whose signatures are corrupted if
+// tamper is set.
+func newTestServer(t *testing.T, tamper bool) (*testServer, string) {
+	t.Helper()
+
+	server := &testServer{}
+	for _, record := range []string{
+		testZone + ". 3600 IN SOA ns1.example.com. hostmaster.example.com. 1 3600 300 2419200 300",
+		testZone + ". 3600 IN NS ns1.example.com.",
+		testZone + ". 3600 IN NS ns2.example.com.",
+		"legacy." + testZone + ". 3600 IN A 10.0.0.1",
+	} {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.records = append(server.records, rr)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	dnsServer := &dns.Server{
+		Listener:          listener,
+		Handler:           server,
+		TsigProvider:      testTSIGProvider{tamper: tamper},
+		MsgAcceptFunc:     func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go dnsServer.ActivateAndServe() //nolint:errcheck
+	<-started
+	t.Cleanup(func() { dnsServer.Shutdown() }) //nolint:errcheck
+
+	return server, listener.Addr().String()
+}
+
+// ServeDNS answers SOA queries, zone transfers and updates of testZone.
+// Unsigned messages are refused, like BIND does for zones allowing updates
+// with a TSIG key only.
+func (s *testServer) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
+	defer w.Close() //nolint:errcheck
+
+	response := new(dns.Msg)
+	response.SetReply(request)
+	response.Authoritative = true
+
+	tsig := request.IsTsig()
+	if tsig == nil {
+		response.Rcode = dns.RcodeRefused
+		_ = w.WriteMsg(response)
+		return
+	}
+	if w.TsigStatus() != nil {
+		// answer with the TSIG error and without MAC like BIND does
+		response.Rcode = dns.RcodeNotAuth
+		response.Extra = append(response.Extra, &dns.TSIG{
+			Hdr:        dns.RR_Header{Name: tsig.Hdr.Name, Rrtype: dns.TypeTSIG, Class: dns.ClassANY},
+			Algorithm:  tsig.Algorithm,
+			TimeSigned: uint64(time.Now().Unix()),
+			Fudge:      tsig.Fudge,
+			OrigId:     request.Id,
+			Error:      dns.RcodeBadSig,
+		})
+		packed, _ := response.Pack()
+		_, _ = w.Write(packed)
+		return
+	}
+	if !strings.EqualFold(request.Question[0].Name, testZone+".") {
+		response.Rcode = dns.RcodeNotAuth
+	} else if request.Opcode == dns.OpcodeUpdate {
+		s.apply(request.Ns)
+	} else if request.Question[0].Qtype == dns.TypeAXFR {
+		s.mu.Lock()
+		records := append(append([]dns.RR{}, s.records...), s.records[0])
+		s.mu.Unlock()
+
+		envelopes := make(chan *dns.Envelope)
+		go func() {
+			defer close(envelopes)
+			for _, record := range records {
+				envelopes <- &dns.Envelope{RR: []dns.RR{record}}
+			}
+		}()
+		_ = new(dns.Transfer).Out(w, request, envelopes)
+		return
+	} else {
+		s.mu.Lock()
+		response.Answer = []dns.RR{s.records[0]}
+		s.mu.Unlock()
+	}
+
+	response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsig.Fudge, time.Now().Unix())
+	_ = w.WriteMsg(response)
+}
+
+// apply applies the updates of RFC 2136 section 2.5 the client sends. The SOA
+// and NS records of the zone apex are never deleted.
+func (s *testServer) apply(updates []dns.RR) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, update := range updates {
+		header := update.Header()
+		if header.Class == dns.ClassANY {
+			var kept []dns.RR
+			for _, record := range s.records {
+				apex := strings.EqualFold(record.Header().Name, testZone+".") &&
+					(record.Header().Rrtype == dns.TypeSOA || record.Header().Rrtype == dns.TypeNS)
+				if !apex && strings.EqualFold(record.Header().Name, header.Name) &&
+					(header.Rrtype == dns.TypeANY || header.Rrtype == record.Header().Rrtype) {
+					continue
+				}
+				kept = append(kept, record)
+			}
+			s.records = kept
+			continue
+		}
+		s.records = append(s.records, update)
+	}
+}
+
+// names returns the names and types of the records on the server.
+func (s *testServer) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for _, record := range s.records {
+		names = append(names, fmt.Sprintf("%s %s", record.Header().Name, dns.TypeToString[record.Header().Rrtype]))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// zoneFile returns the records on the server in zone file format.
+func (s *testServer) zoneFile() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lines []string
+	for _, record := range s.records {
+		lines = append(lines, record.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// testTSIGProvider signs with hmac-sha256 and testSecret, corrupting the
+// signatures if tamper is set.
+type testTSIGProvider struct {
+	tamper bool
+}
+
+func (p testTSIGProvider) Generate(msg []byte, t *dns.TSIG) ([]byte, error) {
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	if p.tamper {
+		sum[0] ^= 0xFF
+	}
+	return sum, nil
+}
+
+func (p testTSIGProvider) Verify(msg []byte, t *dns.TSIG) error {
+	expected, err := p.Generate(msg, t)
+	if err != nil {
+		return err
+	}
+	mac, err := hex.DecodeString(t.MAC)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, expected) {
+		return dns.ErrSig
+	}
+	return nil
+}
+
+// describe returns the record sets as "<name> <type> <ttl> <values> <metadata>".
+func describe(recordSets []*armdns.RecordSet) []string {
+	var descriptions []string
+	for _, recordSet := range recordSets {
+		properties := recordSet.Properties
+		var values []string
+		for _, record := range properties.ARecords {
+			values = append(values, *record.IPv4Address)
+		}
+		if properties.CnameRecord != nil {
+			values = append(values, *properties.CnameRecord.Cname)
+		}
+		for _, record := range properties.NsRecords {
+			values = append(values, *record.Nsdname)
+		}
+		if properties.SoaRecord != nil {
+			values = append(values, *properties.SoaRecord.Host)
+		}
+		for _, record := range properties.TxtRecords {
+			values = append(values, *record.Value[0])
+		}
+		var metadata []string
+		for key, value := range properties.Metadata {
+			metadata = append(metadata, key+"="+*value)
+		}
+		sort.Strings(metadata)
+		descriptions = append(descriptions, fmt.Sprintf("%s %s %d %s %s",
+			*recordSet.Name, strings.TrimPrefix(*recordSet.Type, recordSetTypePrefix), *properties.TTL, strings.Join(values, ","), strings.Join(metadata, ",")))
+	}
+	return descriptions
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	server, address := newTestServer(t, false)
+
+	client, err := New(Config{Server: address, TSIGKeyName: "operator", TSIGSecret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// write the records the dns service plans for a cluster
+	_, err = client.CreateOrUpdateRecordSet(ctx, "", testZone, armdns.RecordTypeA, "api", armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL:      pointer.Int64(300),
+			ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}, {IPv4Address: pointer.String("5.6.7.8")}},
+			Metadata: map[string]*string{"dns_operator_azure_cluster": pointer.String("org-giantswarm/glippy")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateOrUpdateRecordSet(ctx, "", testZone, armdns.RecordTypeCNAME, "*", armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL:         pointer.Int64(300),
+			CnameRecord: &armdns.CnameRecord{Cname: pointer.String("ingress.glippy.example.com")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateOrUpdateRecordSet(ctx, "", testZone, armdns.RecordTypeNS, "team", armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL:       pointer.Int64(3600),
+			NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1-01.azure-dns.com.")}},
+			Metadata:  map[string]*string{"dns_operator_azure_cluster": pointer.String("org-giantswarm/glippy")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordSets, err := client.ListRecordSets(ctx, "", testZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"* CNAME 300 ingress.glippy.example.com ",
+		"@ NS 3600 ns1.example.com,ns2.example.com ",
+		"@ SOA 3600 ns1.example.com ",
+		"api A 300 1.2.3.4,5.6.7.8 dns_operator_azure_cluster=org-giantswarm/glippy",
+		"legacy A 3600 10.0.0.1 ",
+		"team NS 3600 ns1-01.azure-dns.com dns_operator_azure_cluster=org-giantswarm/glippy",
+	}
+	if got := describe(recordSets); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("ListRecordSets() = %#v, want %#v", got, expected)
+	}
+
+	// the owners can't be read from the zone
+	if zoneFile := server.zoneFile(); strings.Contains(zoneFile, "glippy\"") || strings.Contains(zoneFile, "dns_operator_azure_cluster") {
+		t.Errorf("zone publishes metadata:\n%s", zoneFile)
+	}
+
+	// replace a record set, dropping its metadata
+	_, err = client.CreateOrUpdateRecordSet(ctx, "", testZone, armdns.RecordTypeA, "api", armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL:      pointer.Int64(60),
+			ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("9.9.9.9")}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteRecordSet(ctx, "", testZone, armdns.RecordTypeCNAME, "*"); err != nil {
+		t.Fatal(err)
+	}
+
+	// zone tags aren't written
+	_, err = client.CreateOrUpdateZone(ctx, "", testZone, armdns.Zone{
+		Tags: map[string]*string{"dns_operator_azure_cluster": pointer.String("org-giantswarm/glippy")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordSets, err = client.ListRecordSets(ctx, "", testZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{
+		"@ NS 3600 ns1.example.com,ns2.example.com ",
+		"@ SOA 3600 ns1.example.com ",
+		"api A 60 9.9.9.9 ",
+		"legacy A 3600 10.0.0.1 ",
+		"team NS 3600 ns1-01.azure-dns.com dns_operator_azure_cluster=org-giantswarm/glippy",
+	}
+	if got := describe(recordSets); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("ListRecordSets() = %#v, want %#v", got, expected)
+	}
+
+	zone, err := client.GetZone(ctx, "", testZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nameServers []string
+	for _, nameServer := range zone.Properties.NameServers {
+		nameServers = append(nameServers, *nameServer)
+	}
+	if !reflect.DeepEqual(nameServers, []string{"ns1.example.com", "ns2.example.com"}) {
+		t.Errorf("GetZone() name servers = %v", nameServers)
+	}
+	if *zone.Properties.NumberOfRecordSets != 5 {
+		t.Errorf("GetZone() number of record sets = %d, want 5", *zone.Properties.NumberOfRecordSets)
+	}
+	if len(zone.Tags) != 0 {
+		t.Errorf("GetZone() tags = %v", zone.Tags)
+	}
+
+	// deleting the zone would delete the records of others
+	if err := client.DeleteZone(ctx, "", testZone); !IsNotSupported(err) {
+		t.Fatalf("DeleteZone() error = %v", err)
+	}
+	expected = []string{
+		"_dns-operator-azure-ns-team.glippy.example.com. TXT",
+		"api.glippy.example.com. A",
+		"glippy.example.com. NS",
+		"glippy.example.com. NS",
+		"glippy.example.com. SOA",
+		"legacy.glippy.example.com. A",
+		"team.glippy.example.com. NS",
+	}
+	if got := server.names(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("records after DeleteZone() = %#v, want %#v", got, expected)
+	}
+}
+
+func TestClient_errors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name          string
+		tamper        bool
+		config        Config
+		call          func(client *Client) error
+		expectedError func(error) bool
+	}{
+		{
+			name:   "case0: unknown key secret",
+			config: Config{TSIGKeyName: "operator", TSIGSecret: []byte("wrong")},
+			call: func(client *Client) error {
+				_, err := client.ListRecordSets(ctx, "", testZone)
+				return err
+			},
+			expectedError: IsInvalidSignature,
+		},
+		{
+			name:   "case1: tampered response",
+			tamper: true,
+			config: Config{TSIGKeyName: "operator", TSIGSecret: testSecret},
+			call: func(client *Client) error {
+				_, err := client.ListRecordSets(ctx, "", testZone)
+				return err
+			},
+			expectedError: IsInvalidSignature,
+		},
+		{
+			name:   "case2: unsigned update",
+			config: Config{},
+			call: func(client *Client) error {
+				return client.DeleteRecordSet(ctx, "", testZone, armdns.RecordTypeA, "api")
+			},
+			expectedError: IsRequestFailed,
+		},
+		{
+			name:   "case3: unknown zone",
+			config: Config{TSIGKeyName: "operator", TSIGSecret: testSecret},
+			call: func(client *Client) error {
+				_, err := client.GetZone(ctx, "", "other.example.com")
+				return err
+			},
+			expectedError: IsZoneNotFound,
+		},
+		{
+			name:   "case4: alias record set",
+			config: Config{TSIGKeyName: "operator", TSIGSecret: testSecret},
+			call: func(client *Client) error {
+				_, err := client.CreateOrUpdateRecordSet(ctx, "", testZone, armdns.RecordTypeA, "api", armdns.RecordSet{
+					Properties: &armdns.RecordSetProperties{
+						TargetResource: &armdns.SubResource{ID: pointer.String("/subscriptions/1234/resourceGroups/glippy/providers/Microsoft.Network/publicIPAddresses/glippy-api")},
+					},
+				})
+				return err
+			},
+			expectedError: IsUnsupportedRecordSet,
+		},
+		{
+			name:   "case5: SOA record set",
+			config: Config{TSIGKeyName: "operator", TSIGSecret: testSecret},
+			call: func(client *Client) error {
+				_, err := client.CreateOrUpdateRecordSet(ctx, "", testZone, armdns.RecordTypeSOA, "@", armdns.RecordSet{})
+				return err
+			},
+			expectedError: IsUnsupportedRecordSet,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, address := newTestServer(t, tc.tamper)
+
+			tc.config.Server = address
+			client, err := New(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tc.call(client)
+			if !tc.expectedError(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func Test_sealer(t *testing.T) {
+	s, err := newSealer(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := newSealer([]byte("rotated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := map[string]*string{
+		"dns_operator_azure_cluster":  pointer.String("org-giantswarm/glippy"),
+		"dns_operator_azure_instance": pointer.String(strings.Repeat("management-cluster", 20)),
+	}
+	record, err := s.seal("_dns-operator-azure-a-api."+testZone+".", metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := record.(*dns.TXT)
+	if len(txt.Txt) < 2 {
+		t.Errorf("seal() = %d strings, want the metadata split into strings of 255 bytes", len(txt.Txt))
+	}
+
+	opened, ok := s.open(txt)
+	if !ok || !reflect.DeepEqual(opened, metadata) {
+		t.Errorf("open() = %v, %t", opened, ok)
+	}
+
+	if _, ok := other.open(txt); ok {
+		t.Errorf("open() with another key succeeded")
+	}
+
+	copied := dns.Copy(txt).(*dns.TXT)
+	copied.Hdr.Name = "_dns-operator-azure-a-www." + testZone + "."
+	if _, ok := s.open(copied); ok {
+		t.Errorf("open() of metadata copied to another record set succeeded")
+	}
+
+	unsealed := &dns.TXT{Hdr: txt.Hdr, Txt: []string{"dns_operator_azure_cluster=org-giantswarm/glippy"}}
+	if _, ok := s.open(unsealed); ok {
+		t.Errorf("open() of unsealed metadata succeeded")
+	}
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedError bool
+	}{
+		{
+			name:   "case0: server without port",
+			config: Config{Server: "ns1.example.com"},
+		},
+		{
+			name:          "case1: no server",
+			config:        Config{},
+			expectedError: true,
+		},
+		{
+			name:          "case2: unsupported algorithm",
+			config:        Config{Server: "ns1.example.com", TSIGKeyName: "operator", TSIGAlgorithm: "hmac-md5", TSIGSecret: testSecret},
+			expectedError: true,
+		},
+		{
+			name:          "case3: no secret",
+			config:        Config{Server: "ns1.example.com", TSIGKeyName: "operator"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.config)
+			if tc.expectedError != IsInvalidConfig(err) {
+				t.Errorf("New() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_metadataName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		recordSet    string
+		recordType   armdns.RecordType
+		expectedName string
+	}{
+		{
+			name:         "case0: apex",
+			recordSet:    "@",
+			recordType:   armdns.RecordTypeNS,
+			expectedName: "_dns-operator-azure-ns",
+		},
+		{
+			name:         "case1: single label",
+			recordSet:    "api",
+			recordType:   armdns.RecordTypeA,
+			expectedName: "_dns-operator-azure-a-api",
+		},
+		{
+			name:         "case2: wildcard",
+			recordSet:    "*",
+			recordType:   armdns.RecordTypeCNAME,
+			expectedName: "_dns-operator-azure-cname-*",
+		},
+		{
+			name:         "case3: nested name, e.g. in a shared zone",
+			recordSet:    "api.glippy",
+			recordType:   armdns.RecordTypeA,
+			expectedName: "_dns-operator-azure-a-api.glippy",
+		},
+		{
+			name:         "case4: label with dashes",
+			recordSet:    "my-app",
+			recordType:   armdns.RecordTypeTXT,
+			expectedName: "_dns-operator-azure-txt-my-app",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := metadataName(tc.recordSet, tc.recordType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tc.expectedName {
+				t.Errorf("metadataName() = %q, want %q", name, tc.expectedName)
+			}
+
+			recordSet, recordType, ok := parseMetadataName(name)
+			if !ok || recordSet != tc.recordSet || recordType != tc.recordType {
+				t.Errorf("parseMetadataName(%q) = %q, %q, %t", name, recordSet, recordType, ok)
+			}
+		})
+	}
+
+	for _, name := range []string{"api", "_dns-operator-azure-zone", "_dns-operator-azure-preflight", "_acme-challenge.api"} {
+		if _, _, ok := parseMetadataName(name); ok {
+			t.Errorf("parseMetadataName(%q) holds metadata", name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package rfc2136
+
+import "github.com/giantswarm/microerror"
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsUnsupportedRecordSet asserts unsupportedRecordSetError.
+func IsUnsupportedRecordSet(err error) bool {
+	return microerror.Cause(err) == unsupportedRecordSetError
+}
+
+var unsupportedRecordSetError = &microerror.Error{
+	Kind: "unsupportedRecordSetError",
+}
+
+// IsZoneNotFound asserts zoneNotFoundError.
+func IsZoneNotFound(err error) bool {
+	return microerror.Cause(err) == zoneNotFoundError
+}
+
+var zoneNotFoundError = &microerror.Error{
+	Kind: "zoneNotFoundError",
+}
+
+// IsRequestFailed asserts requestFailedError.
+func IsRequestFailed(err error) bool {
+	return microerror.Cause(err) == requestFailedError
+}
+
+var requestFailedError = &microerror.Error{
+	Kind: "requestFailedError",
+}
+
+// IsInvalidResponse asserts invalidResponseError.
+func IsInvalidResponse(err error) bool {
+	return microerror.Cause(err) == invalidResponseError
+}
+
+var invalidResponseError = &microerror.Error{
+	Kind: "invalidResponseError",
+}
+
+// IsInvalidSignature asserts invalidSignatureError.
+func IsInvalidSignature(err error) bool {
+	return microerror.Cause(err) == invalidSignatureError
+}
+
+var invalidSignatureError = &microerror.Error{
+	Kind: "invalidSignatureError",
+}
+
+// IsNotSupported asserts notSupportedError.
+func IsNotSupported(err error) bool {
+	return microerror.Cause(err) == notSupportedError
+}
+
+var notSupportedError = &microerror.Error{
+	Kind: "notSupportedError",
+}
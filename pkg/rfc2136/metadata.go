@@ -0,0 +1,109 @@
+package rfc2136
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// sealedPrefix starts the sealed metadata, it versions the format.
+	sealedPrefix = "v1:"
+	// sealKeyContext separates the metadata key from the TSIG secret it is
+	// derived from.
+	sealKeyContext = "dns-operator-azure record set metadata"
+
+	maxStringLength = 255
+)
+
+// sealer encrypts record set metadata with a key derived from the TSIG
+// secret, so that the owners of record sets can't be read from the zone,
+// which is usually public.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(secret []byte) (*sealer, error) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sealKeyContext))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal returns metadata as sealed TXT record named owner. The owner is
+// authenticated with the metadata, so that it can't be copied to other
+// record sets.
+func (s *sealer) seal(owner string, metadata map[string]*string) (dns.RR, error) {
+	values := map[string]string{}
+	for key, value := range metadata {
+		values[key] = pointer.StringDeref(value, "")
+	}
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, microerror.Mask(err)
+	}
+	sealed := sealedPrefix + base64.RawStdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plaintext, []byte(strings.ToLower(owner))))
+
+	// TXT strings hold 255 bytes at most
+	var txt []string
+	for len(sealed) > maxStringLength {
+		txt = append(txt, sealed[:maxStringLength])
+		sealed = sealed[maxStringLength:]
+	}
+	txt = append(txt, sealed)
+
+	return &dns.TXT{
+		Hdr: dns.RR_Header{Name: owner, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 3600},
+		Txt: txt,
+	}, nil
+}
+
+// open returns the metadata sealed in record, ok is false if it wasn't
+// sealed with the key of s, e.g. before the TSIG key was rotated.
+func (s *sealer) open(record *dns.TXT) (metadata map[string]*string, ok bool) {
+	encoded, found := strings.CutPrefix(strings.Join(record.Txt, ""), sealedPrefix)
+	if !found {
+		return nil, false
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, false
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(strings.ToLower(record.Hdr.Name)))
+	if err != nil {
+		return nil, false
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, false
+	}
+	metadata = map[string]*string{}
+	for key, value := range values {
+		metadata[key] = pointer.String(value)
+	}
+	return metadata, true
+}
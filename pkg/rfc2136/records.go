@@ -0,0 +1,282 @@
+package rfc2136
+
+import (
+	"net"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// recordSetTypePrefix prefixes the record set types the way the Azure DNS
+	// API does, record sets look the same for both providers.
+	recordSetTypePrefix = "Microsoft.Network/dnszones/"
+
+	// metadataPrefix starts the first label of the TXT record sets holding
+	// the sealed record set metadata, which DNS has no place for.
+	metadataPrefix = "_dns-operator-azure-"
+
+	maxLabelLength = 63
+)
+
+// recordTypes are the record types the provider reads and writes. SOA
+// records are only read.
+var recordTypes = map[armdns.RecordType]uint16{
+	armdns.RecordTypeA:     dns.TypeA,
+	armdns.RecordTypeAAAA:  dns.TypeAAAA,
+	armdns.RecordTypeCNAME: dns.TypeCNAME,
+	armdns.RecordTypeMX:    dns.TypeMX,
+	armdns.RecordTypeNS:    dns.TypeNS,
+	armdns.RecordTypePTR:   dns.TypePTR,
+	armdns.RecordTypeSOA:   dns.TypeSOA,
+	armdns.RecordTypeSRV:   dns.TypeSRV,
+	armdns.RecordTypeTXT:   dns.TypeTXT,
+}
+
+// recordType returns the record type of t, or false if it isn't supported.
+func recordType(t uint16) (armdns.RecordType, bool) {
+	for recordType, messageType := range recordTypes {
+		if messageType == t {
+			return recordType, true
+		}
+	}
+	return "", false
+}
+
+// resources returns the records of properties as resources owned by owner.
+func resources(owner string, recordType armdns.RecordType, properties *armdns.RecordSetProperties) ([]dns.RR, error) {
+	if properties == nil {
+		return nil, nil
+	}
+	if properties.TargetResource != nil && properties.TargetResource.ID != nil {
+		return nil, microerror.Maskf(unsupportedRecordSetError, "alias record sets can't be written with RFC 2136")
+	}
+
+	header := func() dns.RR_Header {
+		return dns.RR_Header{
+			Name:   owner,
+			Rrtype: recordTypes[recordType],
+			Class:  dns.ClassINET,
+			Ttl:    uint32(pointer.Int64Deref(properties.TTL, 3600)),
+		}
+	}
+
+	var result []dns.RR
+	switch recordType {
+	case armdns.RecordTypeA:
+		for _, record := range properties.ARecords {
+			ip := net.ParseIP(pointer.StringDeref(record.IPv4Address, "")).To4()
+			if ip == nil {
+				return nil, microerror.Maskf(unsupportedRecordSetError, "invalid IPv4 address %q", pointer.StringDeref(record.IPv4Address, ""))
+			}
+			result = append(result, &dns.A{Hdr: header(), A: ip})
+		}
+	case armdns.RecordTypeAAAA:
+		for _, record := range properties.AaaaRecords {
+			ip := net.ParseIP(pointer.StringDeref(record.IPv6Address, ""))
+			if ip == nil || ip.To4() != nil {
+				return nil, microerror.Maskf(unsupportedRecordSetError, "invalid IPv6 address %q", pointer.StringDeref(record.IPv6Address, ""))
+			}
+			result = append(result, &dns.AAAA{Hdr: header(), AAAA: ip})
+		}
+	case armdns.RecordTypeCNAME:
+		if properties.CnameRecord != nil {
+			name, err := newName(pointer.StringDeref(properties.CnameRecord.Cname, ""))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			result = append(result, &dns.CNAME{Hdr: header(), Target: name})
+		}
+	case armdns.RecordTypeMX:
+		for _, record := range properties.MxRecords {
+			name, err := newName(pointer.StringDeref(record.Exchange, ""))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			result = append(result, &dns.MX{Hdr: header(), Preference: uint16(pointer.Int32Deref(record.Preference, 0)), Mx: name})
+		}
+	case armdns.RecordTypeNS:
+		for _, record := range properties.NsRecords {
+			name, err := newName(pointer.StringDeref(record.Nsdname, ""))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			result = append(result, &dns.NS{Hdr: header(), Ns: name})
+		}
+	case armdns.RecordTypePTR:
+		for _, record := range properties.PtrRecords {
+			name, err := newName(pointer.StringDeref(record.Ptrdname, ""))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			result = append(result, &dns.PTR{Hdr: header(), Ptr: name})
+		}
+	case armdns.RecordTypeSRV:
+		for _, record := range properties.SrvRecords {
+			name, err := newName(pointer.StringDeref(record.Target, ""))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			result = append(result, &dns.SRV{
+				Hdr:      header(),
+				Priority: uint16(pointer.Int32Deref(record.Priority, 0)),
+				Weight:   uint16(pointer.Int32Deref(record.Weight, 0)),
+				Port:     uint16(pointer.Int32Deref(record.Port, 0)),
+				Target:   name,
+			})
+		}
+	case armdns.RecordTypeTXT:
+		for _, record := range properties.TxtRecords {
+			var txt []string
+			for _, value := range record.Value {
+				txt = append(txt, pointer.StringDeref(value, ""))
+			}
+			result = append(result, &dns.TXT{Hdr: header(), Txt: txt})
+		}
+	default:
+		return nil, microerror.Maskf(unsupportedRecordSetError, "record type %q can't be written with RFC 2136", recordType)
+	}
+
+	return result, nil
+}
+
+// addRecord adds the record rr to the record set properties. Names are
+// returned without trailing dot, like Azure DNS does.
+func addRecord(properties *armdns.RecordSetProperties, rr dns.RR) {
+	if properties.TTL == nil {
+		properties.TTL = pointer.Int64(int64(rr.Header().Ttl))
+	}
+
+	switch record := rr.(type) {
+	case *dns.A:
+		properties.ARecords = append(properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(record.A.String())})
+	case *dns.AAAA:
+		properties.AaaaRecords = append(properties.AaaaRecords, &armdns.AaaaRecord{IPv6Address: pointer.String(record.AAAA.String())})
+	case *dns.CNAME:
+		properties.CnameRecord = &armdns.CnameRecord{Cname: pointer.String(withoutDot(record.Target))}
+	case *dns.MX:
+		properties.MxRecords = append(properties.MxRecords, &armdns.MxRecord{Preference: pointer.Int32(int32(record.Preference)), Exchange: pointer.String(withoutDot(record.Mx))})
+	case *dns.NS:
+		properties.NsRecords = append(properties.NsRecords, &armdns.NsRecord{Nsdname: pointer.String(withoutDot(record.Ns))})
+	case *dns.PTR:
+		properties.PtrRecords = append(properties.PtrRecords, &armdns.PtrRecord{Ptrdname: pointer.String(withoutDot(record.Ptr))})
+	case *dns.SOA:
+		properties.SoaRecord = &armdns.SoaRecord{
+			Host:         pointer.String(withoutDot(record.Ns)),
+			Email:        pointer.String(withoutDot(record.Mbox)),
+			SerialNumber: pointer.Int64(int64(record.Serial)),
+			RefreshTime:  pointer.Int64(int64(record.Refresh)),
+			RetryTime:    pointer.Int64(int64(record.Retry)),
+			ExpireTime:   pointer.Int64(int64(record.Expire)),
+			MinimumTTL:   pointer.Int64(int64(record.Minttl)),
+		}
+	case *dns.SRV:
+		properties.SrvRecords = append(properties.SrvRecords, &armdns.SrvRecord{
+			Priority: pointer.Int32(int32(record.Priority)),
+			Weight:   pointer.Int32(int32(record.Weight)),
+			Port:     pointer.Int32(int32(record.Port)),
+			Target:   pointer.String(withoutDot(record.Target)),
+		})
+	case *dns.TXT:
+		var value []*string
+		for _, txt := range record.Txt {
+			value = append(value, pointer.String(txt))
+		}
+		properties.TxtRecords = append(properties.TxtRecords, &armdns.TxtRecord{Value: value})
+	}
+}
+
+// removeRecordSet returns the update deleting the records of type rrtype
+// named owner, see RFC 2136 section 2.5.2.
+func removeRecordSet(owner string, rrtype uint16) dns.RR {
+	return &dns.ANY{Hdr: dns.RR_Header{Name: owner, Rrtype: rrtype, Class: dns.ClassANY}}
+}
+
+// metadataName returns the relative name of the TXT record set holding the
+// metadata of the record set name of type recordType, its first label
+// prefixed with _dns-operator-azure-<type>-, e.g. _dns-operator-azure-a-api
+// for api. The metadata can't be stored below name, which may be a
+// delegation.
+func metadataName(name string, recordType armdns.RecordType) (string, error) {
+	prefix := metadataPrefix + strings.ToLower(string(recordType))
+	if name == "@" {
+		return prefix, nil
+	}
+
+	first, rest, found := strings.Cut(name, ".")
+	label := prefix + "-" + first
+	if len(label) > maxLabelLength {
+		return "", microerror.Maskf(unsupportedRecordSetError, "metadata of record set %s %s doesn't fit into a label", name, recordType)
+	}
+	if found {
+		return label + "." + rest, nil
+	}
+	return label, nil
+}
+
+// parseMetadataName returns the record set whose metadata the TXT record
+// set name holds, ok is false if name doesn't hold metadata.
+func parseMetadataName(name string) (recordSetName string, recordType armdns.RecordType, ok bool) {
+	first, rest, found := strings.Cut(name, ".")
+	if !strings.HasPrefix(first, metadataPrefix) {
+		return "", "", false
+	}
+
+	typeLabel, nameLabel, hasName := strings.Cut(strings.TrimPrefix(first, metadataPrefix), "-")
+	recordType = armdns.RecordType(strings.ToUpper(typeLabel))
+	if _, supported := recordTypes[recordType]; !supported {
+		return "", "", false
+	}
+
+	switch {
+	case !hasName && !found:
+		return "@", recordType, true
+	case !hasName:
+		return "", "", false
+	case found:
+		return nameLabel + "." + rest, recordType, true
+	default:
+		return nameLabel, recordType, true
+	}
+}
+
+// newName returns the absolute form of name.
+func newName(name string) (string, error) {
+	if _, ok := dns.IsDomainName(name); !ok || name == "" {
+		return "", microerror.Maskf(unsupportedRecordSetError, "invalid name %q", name)
+	}
+	return dns.Fqdn(name), nil
+}
+
+// ownerName returns the absolute name of the record set name, relative to
+// zone, "@" being the zone apex.
+func ownerName(name, zone string) (string, error) {
+	if name == "@" || name == "" {
+		return newName(zone)
+	}
+	return newName(name + "." + strings.TrimSuffix(zone, "."))
+}
+
+// relativeName returns the lowercase name of the record set owning records
+// named owner in zone, "@" for the zone apex. ok is false for names outside
+// of zone.
+func relativeName(owner string, zone string) (string, bool) {
+	name := strings.ToLower(withoutDot(owner))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	switch {
+	case name == zone:
+		return "@", true
+	case strings.HasSuffix(name, "."+zone):
+		return strings.TrimSuffix(name, "."+zone), true
+	default:
+		return "", false
+	}
+}
+
+// withoutDot returns name without trailing dot.
+func withoutDot(name string) string {
+	return strings.TrimSuffix(name, ".")
+}
@@ -0,0 +1,21 @@
+package zonefile
+
+import "github.com/giantswarm/microerror"
+
+var unsupportedRecordTypeError = &microerror.Error{
+	Kind: "unsupportedRecordTypeError",
+}
+
+// IsUnsupportedRecordType asserts unsupportedRecordTypeError.
+func IsUnsupportedRecordType(err error) bool {
+	return microerror.Cause(err) == unsupportedRecordTypeError
+}
+
+var invalidZoneFileError = &microerror.Error{
+	Kind: "invalidZoneFileError",
+}
+
+// IsInvalidZoneFile asserts invalidZoneFileError.
+func IsInvalidZoneFile(err error) bool {
+	return microerror.Cause(err) == invalidZoneFileError
+}
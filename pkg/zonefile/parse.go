@@ -0,0 +1,387 @@
+package zonefile
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+	"k8s.io/utils/pointer"
+)
+
+// defaultTTL is used for records without TTL if the zone file has no $TTL
+// directive.
+const defaultTTL = 3600
+
+// Parse parses the zone file of the zone origin read from r into record sets
+// with names relative to origin, "@" for the apex, and short types, e.g. "A".
+// Records of the same name and type are merged into one record set, taking
+// the TTL of the first record. Names outside of origin, $INCLUDE directives
+// and record types Azure DNS doesn't support are rejected.
+func Parse(r io.Reader, origin string) ([]*armdns.RecordSet, error) {
+	origin = normalizeName(origin)
+
+	lines, err := logicalLines(r)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	p := parser{
+		zone:       origin,
+		origin:     origin,
+		ttl:        defaultTTL,
+		recordSets: map[string]*armdns.RecordSet{},
+	}
+	for _, l := range lines {
+		if err := p.parseLine(l); err != nil {
+			return nil, microerror.Maskf(invalidZoneFileError, "line %d: %s", l.number, err)
+		}
+	}
+
+	var recordSets []*armdns.RecordSet
+	for _, key := range p.order {
+		recordSets = append(recordSets, p.recordSets[key])
+	}
+	return recordSets, nil
+}
+
+type line struct {
+	number int
+	tokens []string
+	// inheritsOwner is true if the line starts with whitespace, so the
+	// owner of the previous record applies.
+	inheritsOwner bool
+}
+
+type parser struct {
+	// zone is the origin of the zone record set names are relative to,
+	// origin the current $ORIGIN.
+	zone   string
+	origin string
+	ttl    int64
+	owner  string
+
+	recordSets map[string]*armdns.RecordSet
+	order      []string
+}
+
+func (p *parser) parseLine(l line) error {
+	tokens := l.tokens
+
+	switch strings.ToUpper(tokens[0]) {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return microerror.Maskf(invalidZoneFileError, "$ORIGIN needs one argument")
+		}
+		p.origin = p.absolute(tokens[1])
+		return nil
+	case "$TTL":
+		if len(tokens) != 2 {
+			return microerror.Maskf(invalidZoneFileError, "$TTL needs one argument")
+		}
+		ttl, ok := parseTTL(tokens[1])
+		if !ok {
+			return microerror.Maskf(invalidZoneFileError, "invalid TTL %q", tokens[1])
+		}
+		p.ttl = ttl
+		return nil
+	case "$INCLUDE", "$GENERATE":
+		return microerror.Maskf(invalidZoneFileError, "%s is not supported", tokens[0])
+	}
+
+	if !l.inheritsOwner {
+		p.owner = p.absolute(tokens[0])
+		tokens = tokens[1:]
+	} else if p.owner == "" {
+		return microerror.Maskf(invalidZoneFileError, "record without owner")
+	}
+
+	ttl := p.ttl
+	for i := 0; i < 2 && len(tokens) > 0; i++ {
+		if value, ok := parseTTL(tokens[0]); ok {
+			ttl = value
+			tokens = tokens[1:]
+		} else if strings.EqualFold(tokens[0], "IN") {
+			tokens = tokens[1:]
+		}
+	}
+	if len(tokens) == 0 {
+		return microerror.Maskf(invalidZoneFileError, "record without type")
+	}
+
+	name, err := p.relative(p.owner)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	recordType := armdns.RecordType(strings.ToUpper(tokens[0]))
+	key := name + " " + string(recordType)
+	recordSet, ok := p.recordSets[key]
+	if !ok {
+		recordSet = &armdns.RecordSet{
+			Name:       pointer.String(name),
+			Type:       pointer.String(string(recordType)),
+			Properties: &armdns.RecordSetProperties{TTL: pointer.Int64(ttl)},
+		}
+	}
+
+	if err := p.addRecord(recordSet.Properties, recordType, tokens[1:]); err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !ok {
+		p.recordSets[key] = recordSet
+		p.order = append(p.order, key)
+	}
+	return nil
+}
+
+// addRecord adds the record with rdata to properties.
+func (p *parser) addRecord(properties *armdns.RecordSetProperties, recordType armdns.RecordType, rdata []string) error {
+	expect := func(n int) error {
+		if len(rdata) != n {
+			return microerror.Maskf(invalidZoneFileError, "%s record needs %d fields, got %d", recordType, n, len(rdata))
+		}
+		return nil
+	}
+
+	switch recordType {
+	case armdns.RecordTypeA:
+		if err := expect(1); err != nil {
+			return microerror.Mask(err)
+		}
+		properties.ARecords = append(properties.ARecords, &armdns.ARecord{IPv4Address: pointer.String(rdata[0])})
+	case armdns.RecordTypeAAAA:
+		if err := expect(1); err != nil {
+			return microerror.Mask(err)
+		}
+		properties.AaaaRecords = append(properties.AaaaRecords, &armdns.AaaaRecord{IPv6Address: pointer.String(rdata[0])})
+	case armdns.RecordTypeCAA:
+		if err := expect(3); err != nil {
+			return microerror.Mask(err)
+		}
+		flags, err := parseInt32(rdata[0])
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		properties.CaaRecords = append(properties.CaaRecords, &armdns.CaaRecord{Flags: flags, Tag: pointer.String(rdata[1]), Value: pointer.String(rdata[2])})
+	case armdns.RecordTypeCNAME:
+		if err := expect(1); err != nil {
+			return microerror.Mask(err)
+		}
+		if properties.CnameRecord != nil {
+			return microerror.Maskf(invalidZoneFileError, "more than one CNAME record")
+		}
+		properties.CnameRecord = &armdns.CnameRecord{Cname: pointer.String(p.absolute(rdata[0]))}
+	case armdns.RecordTypeMX:
+		if err := expect(2); err != nil {
+			return microerror.Mask(err)
+		}
+		preference, err := parseInt32(rdata[0])
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		properties.MxRecords = append(properties.MxRecords, &armdns.MxRecord{Preference: preference, Exchange: pointer.String(p.absolute(rdata[1]))})
+	case armdns.RecordTypeNS:
+		if err := expect(1); err != nil {
+			return microerror.Mask(err)
+		}
+		properties.NsRecords = append(properties.NsRecords, &armdns.NsRecord{Nsdname: pointer.String(p.absolute(rdata[0]))})
+	case armdns.RecordTypePTR:
+		if err := expect(1); err != nil {
+			return microerror.Mask(err)
+		}
+		properties.PtrRecords = append(properties.PtrRecords, &armdns.PtrRecord{Ptrdname: pointer.String(p.absolute(rdata[0]))})
+	case armdns.RecordTypeSOA:
+		if err := expect(7); err != nil {
+			return microerror.Mask(err)
+		}
+		var numbers [5]int64
+		for i, field := range rdata[2:] {
+			value, ok := parseTTL(field)
+			if !ok {
+				return microerror.Maskf(invalidZoneFileError, "invalid SOA field %q", field)
+			}
+			numbers[i] = value
+		}
+		properties.SoaRecord = &armdns.SoaRecord{
+			Host:         pointer.String(p.absolute(rdata[0])),
+			Email:        pointer.String(p.absolute(rdata[1])),
+			SerialNumber: pointer.Int64(numbers[0]),
+			RefreshTime:  pointer.Int64(numbers[1]),
+			RetryTime:    pointer.Int64(numbers[2]),
+			ExpireTime:   pointer.Int64(numbers[3]),
+			MinimumTTL:   pointer.Int64(numbers[4]),
+		}
+	case armdns.RecordTypeSRV:
+		if err := expect(4); err != nil {
+			return microerror.Mask(err)
+		}
+		var numbers [3]*int32
+		for i, field := range rdata[:3] {
+			value, err := parseInt32(field)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			numbers[i] = value
+		}
+		properties.SrvRecords = append(properties.SrvRecords, &armdns.SrvRecord{
+			Priority: numbers[0],
+			Weight:   numbers[1],
+			Port:     numbers[2],
+			Target:   pointer.String(p.absolute(rdata[3])),
+		})
+	case armdns.RecordTypeTXT:
+		if len(rdata) == 0 {
+			return microerror.Maskf(invalidZoneFileError, "TXT record without value")
+		}
+		record := &armdns.TxtRecord{}
+		for _, chunk := range rdata {
+			record.Value = append(record.Value, pointer.String(chunk))
+		}
+		properties.TxtRecords = append(properties.TxtRecords, record)
+	default:
+		return microerror.Maskf(unsupportedRecordTypeError, "record type %q", recordType)
+	}
+
+	return nil
+}
+
+// absolute returns name as absolute name without trailing dot, the way the
+// operator writes record targets.
+func (p *parser) absolute(name string) string {
+	switch {
+	case name == "@":
+		return p.origin
+	case strings.HasSuffix(name, "."):
+		return normalizeName(name)
+	case p.origin == "":
+		return normalizeName(name)
+	}
+	return normalizeName(name + "." + p.origin)
+}
+
+// relative returns the absolute name relative to the zone.
+func (p *parser) relative(name string) (string, error) {
+	switch {
+	case name == p.zone:
+		return "@", nil
+	case strings.HasSuffix(name, "."+p.zone):
+		return strings.TrimSuffix(name, "."+p.zone), nil
+	}
+	return "", microerror.Maskf(invalidZoneFileError, "name %q is outside of zone %q", name, p.zone)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// parseTTL parses TTLs in seconds or with BIND units, e.g. "1h30m".
+func parseTTL(value string) (int64, bool) {
+	if value == "" || !unicode.IsDigit(rune(value[0])) {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, true
+	}
+
+	units := map[byte]int64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	var total, current int64
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9':
+			current = current*10 + int64(c-'0')
+		case units[c|0x20] > 0:
+			total += current * units[c|0x20]
+			current = 0
+		default:
+			return 0, false
+		}
+	}
+	return total + current, true
+}
+
+func parseInt32(value string) (*int32, error) {
+	number, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return nil, microerror.Maskf(invalidZoneFileError, "invalid number %q", value)
+	}
+	return pointer.Int32(int32(number)), nil
+}
+
+// logicalLines splits the zone file into lines of tokens, removing comments,
+// joining lines within parentheses and unquoting character strings.
+func logicalLines(r io.Reader) ([]line, error) {
+	var lines []line
+	var current *line
+	depth := 0
+
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+
+		if depth == 0 {
+			current = &line{
+				number:        number,
+				inheritsOwner: len(text) > 0 && (text[0] == ' ' || text[0] == '\t'),
+			}
+		}
+
+		for i := 0; i < len(text); {
+			c := text[i]
+			switch {
+			case c == ';':
+				i = len(text)
+			case c == ' ' || c == '\t' || c == '\r':
+				i++
+			case c == '(':
+				depth++
+				i++
+			case c == ')':
+				if depth == 0 {
+					return nil, microerror.Maskf(invalidZoneFileError, "line %d: unbalanced parentheses", number)
+				}
+				depth--
+				i++
+			case c == '"':
+				var token strings.Builder
+				i++
+				for ; i < len(text) && text[i] != '"'; i++ {
+					if text[i] == '\\' && i+1 < len(text) {
+						i++
+					}
+					token.WriteByte(text[i])
+				}
+				if i == len(text) {
+					return nil, microerror.Maskf(invalidZoneFileError, "line %d: unterminated string", number)
+				}
+				i++
+				current.tokens = append(current.tokens, token.String())
+			default:
+				start := i
+				for i < len(text) && !strings.ContainsRune(" \t\r;()\"", rune(text[i])) {
+					i++
+				}
+				current.tokens = append(current.tokens, text[start:i])
+			}
+		}
+
+		if depth == 0 && len(current.tokens) > 0 {
+			lines = append(lines, *current)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if depth != 0 {
+		return nil, microerror.Maskf(invalidZoneFileError, "unbalanced parentheses")
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,167 @@
+package zonefile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/utils/pointer"
+)
+
+func Test_Parse(t *testing.T) {
+	zoneFile := `$ORIGIN Test.Example.com.
+$TTL 1h
+@	IN	SOA	ns1.legacy.io. hostmaster.legacy.io. (
+		2024010101 ; serial
+		1h 10m 2w 300 )
+	IN	NS	ns1.legacy.io.
+	IN	NS	ns2.legacy.io.
+@	300	MX	10 mail
+	MX	20 mail.example.org.
+www	IN	300	CNAME	ingress
+_sip._tcp	SRV	10 5 5060 sip.example.com.
+verify.test.example.com.	TXT	"v=spf1 \"quoted\" -all" "second chunk"
+$ORIGIN sub.test.example.com.
+caa	CAA	0 issue "letsencrypt.org"
+v6	AAAA	2001:db8::1
+`
+
+	ttl := func(ttl int64, properties armdns.RecordSetProperties) *armdns.RecordSetProperties {
+		properties.TTL = pointer.Int64(ttl)
+		return &properties
+	}
+	recordSet := func(name string, recordType armdns.RecordType, properties *armdns.RecordSetProperties) *armdns.RecordSet {
+		return &armdns.RecordSet{Name: pointer.String(name), Type: pointer.String(string(recordType)), Properties: properties}
+	}
+
+	want := []*armdns.RecordSet{
+		recordSet("@", armdns.RecordTypeSOA, ttl(3600, armdns.RecordSetProperties{SoaRecord: &armdns.SoaRecord{
+			Host:         pointer.String("ns1.legacy.io"),
+			Email:        pointer.String("hostmaster.legacy.io"),
+			SerialNumber: pointer.Int64(2024010101),
+			RefreshTime:  pointer.Int64(3600),
+			RetryTime:    pointer.Int64(600),
+			ExpireTime:   pointer.Int64(1209600),
+			MinimumTTL:   pointer.Int64(300),
+		}})),
+		recordSet("@", armdns.RecordTypeNS, ttl(3600, armdns.RecordSetProperties{NsRecords: []*armdns.NsRecord{
+			{Nsdname: pointer.String("ns1.legacy.io")},
+			{Nsdname: pointer.String("ns2.legacy.io")},
+		}})),
+		recordSet("@", armdns.RecordTypeMX, ttl(300, armdns.RecordSetProperties{MxRecords: []*armdns.MxRecord{
+			{Preference: pointer.Int32(10), Exchange: pointer.String("mail.test.example.com")},
+			{Preference: pointer.Int32(20), Exchange: pointer.String("mail.example.org")},
+		}})),
+		recordSet("www", armdns.RecordTypeCNAME, ttl(300, armdns.RecordSetProperties{CnameRecord: &armdns.CnameRecord{
+			Cname: pointer.String("ingress.test.example.com"),
+		}})),
+		recordSet("_sip._tcp", armdns.RecordTypeSRV, ttl(3600, armdns.RecordSetProperties{SrvRecords: []*armdns.SrvRecord{
+			{Priority: pointer.Int32(10), Weight: pointer.Int32(5), Port: pointer.Int32(5060), Target: pointer.String("sip.example.com")},
+		}})),
+		recordSet("verify", armdns.RecordTypeTXT, ttl(3600, armdns.RecordSetProperties{TxtRecords: []*armdns.TxtRecord{
+			{Value: []*string{pointer.String(`v=spf1 "quoted" -all`), pointer.String("second chunk")}},
+		}})),
+		recordSet("caa.sub", armdns.RecordTypeCAA, ttl(3600, armdns.RecordSetProperties{CaaRecords: []*armdns.CaaRecord{
+			{Flags: pointer.Int32(0), Tag: pointer.String("issue"), Value: pointer.String("letsencrypt.org")},
+		}})),
+		recordSet("v6.sub", armdns.RecordTypeAAAA, ttl(3600, armdns.RecordSetProperties{AaaaRecords: []*armdns.AaaaRecord{
+			{IPv6Address: pointer.String("2001:db8::1")},
+		}})),
+	}
+
+	got, err := Parse(strings.NewReader(zoneFile), "test.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Parse() returned %d record sets, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("record set %d: got %s %s, want %s %s", i, *got[i].Name, *got[i].Type, *want[i].Name, *want[i].Type)
+		}
+	}
+}
+
+func Test_Parse_invalid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		zoneFile      string
+		errorMatching func(error) bool
+	}{
+		{
+			name:          "case0: name outside of the zone",
+			zoneFile:      "www.other.com. 300 IN A 1.2.3.4\n",
+			errorMatching: IsInvalidZoneFile,
+		},
+		{
+			name:          "case1: $INCLUDE",
+			zoneFile:      "$INCLUDE other.zone\n",
+			errorMatching: IsInvalidZoneFile,
+		},
+		{
+			name:          "case2: unbalanced parentheses",
+			zoneFile:      "@ SOA ns1 hostmaster ( 1 2 3 4 5\n",
+			errorMatching: IsInvalidZoneFile,
+		},
+		{
+			name:          "case3: missing fields",
+			zoneFile:      "www MX mail\n",
+			errorMatching: IsInvalidZoneFile,
+		},
+		{
+			name:          "case4: record without owner",
+			zoneFile:      "  A 1.2.3.4\n",
+			errorMatching: IsInvalidZoneFile,
+		},
+		{
+			name:          "case5: unsupported record type",
+			zoneFile:      "www DS 1 2 3 abc\n",
+			errorMatching: IsInvalidZoneFile,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.zoneFile), "test.example.com")
+			if !tc.errorMatching(err) {
+				t.Errorf("unexpected error %#v", err)
+			}
+		})
+	}
+}
+
+func Test_Parse_roundTrip(t *testing.T) {
+	recordSets := []*armdns.RecordSet{
+		recordSet("@", armdns.RecordTypeMX, armdns.RecordSetProperties{
+			MxRecords: []*armdns.MxRecord{{Preference: pointer.Int32(10), Exchange: pointer.String("mail.example.com")}},
+		}),
+		recordSet("@", armdns.RecordTypeTXT, armdns.RecordSetProperties{
+			TxtRecords: []*armdns.TxtRecord{{Value: []*string{pointer.String(`v=spf1 "quoted" -all`)}}},
+		}),
+		recordSet("api", armdns.RecordTypeA, armdns.RecordSetProperties{
+			ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}, {IPv4Address: pointer.String("5.6.7.8")}},
+		}),
+		recordSet("www", armdns.RecordTypeCNAME, armdns.RecordSetProperties{
+			CnameRecord: &armdns.CnameRecord{Cname: pointer.String("ingress.test.example.com")},
+		}),
+	}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, "test.example.com", recordSets); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(&buffer, "test.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, recordSet := range recordSets {
+		recordSet.Type = pointer.String(string(RecordType(recordSet)))
+	}
+	if !reflect.DeepEqual(got, recordSets) {
+		t.Errorf("Parse(Write()) differs from the written record sets")
+	}
+}
@@ -0,0 +1,188 @@
+// Package zonefile converts Azure DNS record sets to and from RFC 1035 zone
+// files as read and written by BIND.
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/giantswarm/microerror"
+)
+
+// typeOrder sorts SOA and NS records of the zone apex first, as BIND expects.
+var typeOrder = map[armdns.RecordType]int{
+	armdns.RecordTypeSOA: 0,
+	armdns.RecordTypeNS:  1,
+}
+
+// Write writes recordSets of the zone origin as zone file to w. Record sets
+// of all types Azure DNS supports are written. Alias record sets pointing to
+// an Azure resource can't be expressed in a zone file and are written as
+// comments.
+func Write(w io.Writer, origin string, recordSets []*armdns.RecordSet) error {
+	origin = strings.TrimSuffix(origin, ".")
+
+	sorted := make([]*armdns.RecordSet, 0, len(recordSets))
+	for _, recordSet := range recordSets {
+		if recordSet != nil && recordSet.Name != nil && recordSet.Properties != nil {
+			sorted = append(sorted, recordSet)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		nameI, nameJ := *sorted[i].Name, *sorted[j].Name
+		if (nameI == "@") != (nameJ == "@") {
+			return nameI == "@"
+		}
+		if nameI != nameJ {
+			return nameI < nameJ
+		}
+		typeI, typeJ := RecordType(sorted[i]), RecordType(sorted[j])
+		orderI, okI := typeOrder[typeI]
+		orderJ, okJ := typeOrder[typeJ]
+		switch {
+		case okI && okJ:
+			return orderI < orderJ
+		case okI != okJ:
+			return okI
+		}
+		return typeI < typeJ
+	})
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "$ORIGIN %s.\n", origin)
+
+	for _, recordSet := range sorted {
+		name := *recordSet.Name
+		recordType := RecordType(recordSet)
+		var ttl int64
+		if recordSet.Properties.TTL != nil {
+			ttl = *recordSet.Properties.TTL
+		}
+
+		values, err := recordValues(recordType, recordSet.Properties)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if len(values) == 0 && recordSet.Properties.TargetResource != nil && recordSet.Properties.TargetResource.ID != nil {
+			fmt.Fprintf(b, "; %s %d IN %s alias to %s\n", name, ttl, recordType, *recordSet.Properties.TargetResource.ID)
+			continue
+		}
+
+		for _, value := range values {
+			fmt.Fprintf(b, "%s\t%d\tIN\t%s\t%s\n", name, ttl, recordType, value)
+		}
+	}
+
+	return microerror.Mask(b.Flush())
+}
+
+// RecordType returns the short type, e.g. "A", of recordSet. Listed record
+// sets carry the resource type, e.g. "Microsoft.Network/dnszones/A".
+func RecordType(recordSet *armdns.RecordSet) armdns.RecordType {
+	if recordSet.Type == nil {
+		return ""
+	}
+	recordType := *recordSet.Type
+	if i := strings.LastIndex(recordType, "/"); i >= 0 {
+		recordType = recordType[i+1:]
+	}
+	return armdns.RecordType(recordType)
+}
+
+// recordValues returns the RDATA of every record in properties of type
+// recordType.
+func recordValues(recordType armdns.RecordType, properties *armdns.RecordSetProperties) ([]string, error) {
+	var values []string
+
+	switch recordType {
+	case armdns.RecordTypeA:
+		for _, record := range properties.ARecords {
+			values = append(values, str(record.IPv4Address))
+		}
+	case armdns.RecordTypeAAAA:
+		for _, record := range properties.AaaaRecords {
+			values = append(values, str(record.IPv6Address))
+		}
+	case armdns.RecordTypeCAA:
+		for _, record := range properties.CaaRecords {
+			values = append(values, fmt.Sprintf("%d %s %s", i32(record.Flags), str(record.Tag), quote(str(record.Value))))
+		}
+	case armdns.RecordTypeCNAME:
+		if properties.CnameRecord != nil {
+			values = append(values, fqdn(str(properties.CnameRecord.Cname)))
+		}
+	case armdns.RecordTypeMX:
+		for _, record := range properties.MxRecords {
+			values = append(values, fmt.Sprintf("%d %s", i32(record.Preference), fqdn(str(record.Exchange))))
+		}
+	case armdns.RecordTypeNS:
+		for _, record := range properties.NsRecords {
+			values = append(values, fqdn(str(record.Nsdname)))
+		}
+	case armdns.RecordTypePTR:
+		for _, record := range properties.PtrRecords {
+			values = append(values, fqdn(str(record.Ptrdname)))
+		}
+	case armdns.RecordTypeSOA:
+		if record := properties.SoaRecord; record != nil {
+			values = append(values, fmt.Sprintf("%s %s %d %d %d %d %d",
+				fqdn(str(record.Host)), fqdn(str(record.Email)),
+				i64(record.SerialNumber), i64(record.RefreshTime), i64(record.RetryTime), i64(record.ExpireTime), i64(record.MinimumTTL)))
+		}
+	case armdns.RecordTypeSRV:
+		for _, record := range properties.SrvRecords {
+			values = append(values, fmt.Sprintf("%d %d %d %s", i32(record.Priority), i32(record.Weight), i32(record.Port), fqdn(str(record.Target))))
+		}
+	case armdns.RecordTypeTXT:
+		for _, record := range properties.TxtRecords {
+			var chunks []string
+			for _, chunk := range record.Value {
+				chunks = append(chunks, quote(str(chunk)))
+			}
+			values = append(values, strings.Join(chunks, " "))
+		}
+	default:
+		return nil, microerror.Maskf(unsupportedRecordTypeError, "record type %q", recordType)
+	}
+
+	return values, nil
+}
+
+// fqdn makes name absolute. Azure DNS returns most names without the
+// trailing dot.
+func fqdn(name string) string {
+	if name == "" || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// quote returns s as quoted character string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func i32(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func i64(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
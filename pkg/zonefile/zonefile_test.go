@@ -0,0 +1,96 @@
+package zonefile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"k8s.io/utils/pointer"
+)
+
+func recordSet(name string, recordType armdns.RecordType, properties armdns.RecordSetProperties) *armdns.RecordSet {
+	properties.TTL = pointer.Int64(300)
+	return &armdns.RecordSet{
+		Name:       pointer.String(name),
+		Type:       pointer.String("Microsoft.Network/dnszones/" + string(recordType)),
+		Properties: &properties,
+	}
+}
+
+func Test_Write(t *testing.T) {
+	recordSets := []*armdns.RecordSet{
+		recordSet("www", armdns.RecordTypeCNAME, armdns.RecordSetProperties{
+			CnameRecord: &armdns.CnameRecord{Cname: pointer.String("ingress.test.example.com")},
+		}),
+		recordSet("@", armdns.RecordTypeNS, armdns.RecordSetProperties{
+			NsRecords: []*armdns.NsRecord{{Nsdname: pointer.String("ns1-01.azure-dns.com.")}, {Nsdname: pointer.String("ns2-01.azure-dns.net.")}},
+		}),
+		recordSet("@", armdns.RecordTypeSOA, armdns.RecordSetProperties{
+			SoaRecord: &armdns.SoaRecord{
+				Host:         pointer.String("ns1-01.azure-dns.com."),
+				Email:        pointer.String("azuredns-hostmaster.microsoft.com"),
+				SerialNumber: pointer.Int64(1),
+				RefreshTime:  pointer.Int64(3600),
+				RetryTime:    pointer.Int64(300),
+				ExpireTime:   pointer.Int64(2419200),
+				MinimumTTL:   pointer.Int64(300),
+			},
+		}),
+		recordSet("@", armdns.RecordTypeCAA, armdns.RecordSetProperties{
+			CaaRecords: []*armdns.CaaRecord{{Flags: pointer.Int32(0), Tag: pointer.String("issue"), Value: pointer.String("letsencrypt.org")}},
+		}),
+		recordSet("@", armdns.RecordTypeMX, armdns.RecordSetProperties{
+			MxRecords: []*armdns.MxRecord{{Preference: pointer.Int32(10), Exchange: pointer.String("mail.example.com")}},
+		}),
+		recordSet("@", armdns.RecordTypeTXT, armdns.RecordSetProperties{
+			TxtRecords: []*armdns.TxtRecord{{Value: []*string{pointer.String(`v=spf1 "quoted" -all`)}}},
+		}),
+		recordSet("api", armdns.RecordTypeA, armdns.RecordSetProperties{
+			ARecords: []*armdns.ARecord{{IPv4Address: pointer.String("1.2.3.4")}},
+		}),
+		recordSet("api", armdns.RecordTypeAAAA, armdns.RecordSetProperties{
+			AaaaRecords: []*armdns.AaaaRecord{{IPv6Address: pointer.String("2001:db8::1")}},
+		}),
+		recordSet("_sip._tcp", armdns.RecordTypeSRV, armdns.RecordSetProperties{
+			SrvRecords: []*armdns.SrvRecord{{Priority: pointer.Int32(10), Weight: pointer.Int32(5), Port: pointer.Int32(5060), Target: pointer.String("sip.example.com")}},
+		}),
+		recordSet("4", armdns.RecordTypePTR, armdns.RecordSetProperties{
+			PtrRecords: []*armdns.PtrRecord{{Ptrdname: pointer.String("host.example.com")}},
+		}),
+		recordSet("alias", armdns.RecordTypeA, armdns.RecordSetProperties{
+			TargetResource: &armdns.SubResource{ID: pointer.String("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/ip")},
+		}),
+	}
+
+	expected := `$ORIGIN test.example.com.
+@	300	IN	SOA	ns1-01.azure-dns.com. azuredns-hostmaster.microsoft.com. 1 3600 300 2419200 300
+@	300	IN	NS	ns1-01.azure-dns.com.
+@	300	IN	NS	ns2-01.azure-dns.net.
+@	300	IN	CAA	0 issue "letsencrypt.org"
+@	300	IN	MX	10 mail.example.com.
+@	300	IN	TXT	"v=spf1 \"quoted\" -all"
+4	300	IN	PTR	host.example.com.
+_sip._tcp	300	IN	SRV	10 5 5060 sip.example.com.
+; alias 300 IN A alias to /subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/ip
+api	300	IN	A	1.2.3.4
+api	300	IN	AAAA	2001:db8::1
+www	300	IN	CNAME	ingress.test.example.com.
+`
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, "test.example.com.", recordSets); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != expected {
+		t.Errorf("Write() =\n%s\nwant\n%s", buffer.String(), expected)
+	}
+}
+
+func Test_Write_unsupportedRecordType(t *testing.T) {
+	recordSets := []*armdns.RecordSet{recordSet("x", "DS", armdns.RecordSetProperties{})}
+
+	err := Write(&bytes.Buffer{}, "test.example.com", recordSets)
+	if !IsUnsupportedRecordType(err) {
+		t.Errorf("expected unsupportedRecordTypeError, got %v", err)
+	}
+}